@@ -0,0 +1,10 @@
+// Package glofox holds assets shared across the service packages.
+package glofox
+
+import _ "embed"
+
+// OpenAPISpec is the embedded openapi.yaml, the source of truth for the
+// shape of every request and response.
+//
+//go:embed openapi.yaml
+var OpenAPISpec []byte
@@ -0,0 +1,546 @@
+// Package backup keeps backups of the store in a local directory: snapshots
+// of the whole store, each with a SHA-256 checksum file that sha256sum -c
+// accepts, and the log of mutations written since, one NDJSON line per
+// mutation with a CRC-32C of its encoding. The store can be rebuilt as it
+// was at any time from the oldest snapshot onwards.
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+)
+
+// ErrNoBackups is returned when a directory holds no snapshot to restore.
+var ErrNoBackups = errors.New("no backups")
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotExt    = ".json"
+	checksumExt    = ".sha256"
+	segmentPrefix  = "wal-"
+	segmentExt     = ".ndjson"
+	// takenAtLayout stamps snapshot file names with when they were taken.
+	takenAtLayout = "20060102T150405.000000000Z"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Info describes a snapshot in the directory.
+type Info struct {
+	File    string    `json:"file"`
+	Seq     int64     `json:"seq"`
+	TakenAt time.Time `json:"taken_at"`
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+}
+
+// Report is the outcome of Verify. The directory can be restored to any
+// time from From to To when Problems is empty.
+type Report struct {
+	Snapshots int       `json:"snapshots"`
+	Segments  int       `json:"segments"`
+	Mutations int       `json:"mutations"`
+	From      time.Time `json:"from,omitempty"`
+	To        time.Time `json:"to,omitempty"`
+	Problems  []string  `json:"problems"`
+}
+
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *Report) problem(format string, args ...any) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// logEntry is a line of the mutation log. Sum is the CRC-32C of Mutation
+// exactly as written.
+type logEntry struct {
+	Sum      uint32          `json:"sum"`
+	Mutation json.RawMessage `json:"mutation"`
+}
+
+// segment is a file of the mutation log, named after the first mutation in
+// it. A new segment is started after every snapshot so that pruning can
+// remove whole files.
+type segment struct {
+	file  string
+	start int64
+}
+
+// Dir is a backup directory. It is safe for concurrent use, but only one
+// process should write to a directory at a time.
+type Dir struct {
+	path string
+
+	mu  sync.Mutex
+	log *os.File
+}
+
+// Open opens the backup directory at path, creating it if need be.
+func Open(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return nil, err
+	}
+	return &Dir{path: path}, nil
+}
+
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// Close closes the current log segment.
+func (d *Dir) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closeLog()
+}
+
+func (d *Dir) closeLog() error {
+	if d.log == nil {
+		return nil
+	}
+	err := d.log.Close()
+	d.log = nil
+	return err
+}
+
+// Append writes mutations to the log and syncs it to disk.
+func (d *Dir) Append(mutations []entities.Mutation) error {
+	if len(mutations) == 0 {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.log == nil {
+		name := fmt.Sprintf("%s%020d%s", segmentPrefix, mutations[0].Seq, segmentExt)
+		f, err := os.OpenFile(filepath.Join(d.path, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		d.log = f
+	}
+
+	var b bytes.Buffer
+	for _, m := range mutations {
+		encoded, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("encoding mutation %d: %w", m.Seq, err)
+		}
+		line, err := json.Marshal(logEntry{Sum: crc32.Checksum(encoded, castagnoli), Mutation: encoded})
+		if err != nil {
+			return fmt.Errorf("encoding mutation %d: %w", m.Seq, err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	if _, err := d.log.Write(b.Bytes()); err != nil {
+		return err
+	}
+	return d.log.Sync()
+}
+
+// WriteSnapshot stores s and starts a new log segment for the mutations
+// that follow it.
+func (d *Dir) WriteSnapshot(s *entities.Snapshot) (*Info, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("encoding snapshot: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	info := &Info{
+		File:    fmt.Sprintf("%s%020d-%s%s", snapshotPrefix, s.Seq, s.TakenAt.UTC().Format(takenAtLayout), snapshotExt),
+		Seq:     s.Seq,
+		TakenAt: s.TakenAt,
+		Size:    int64(len(data)),
+		SHA256:  hex.EncodeToString(sum[:]),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := os.Stat(filepath.Join(d.path, info.File)); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", info.File)
+	}
+	// The checksum goes first so that every snapshot in the directory has one
+	checksum := fmt.Sprintf("%s  %s\n", info.SHA256, info.File)
+	if err := d.writeFile(info.File+checksumExt, []byte(checksum)); err != nil {
+		return nil, err
+	}
+	if err := d.writeFile(info.File, data); err != nil {
+		return nil, err
+	}
+	if err := d.closeLog(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// writeFile writes data to name atomically: a crash leaves either the whole
+// file or none of it.
+func (d *Dir) writeFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(d.path, "."+name+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(d.path, name)); err != nil {
+		return err
+	}
+	dir, err := os.Open(d.path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Snapshots lists the snapshots in the directory, oldest first.
+func (d *Dir) Snapshots() ([]Info, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	var infos []Info
+	for _, e := range entries {
+		name := e.Name()
+		rest, ok := strings.CutPrefix(name, snapshotPrefix)
+		if !ok || !strings.HasSuffix(rest, snapshotExt) {
+			continue
+		}
+		seq, takenAt, ok := strings.Cut(strings.TrimSuffix(rest, snapshotExt), "-")
+		if !ok {
+			continue
+		}
+		info := Info{File: name}
+		if info.Seq, err = strconv.ParseInt(seq, 10, 64); err != nil {
+			continue
+		}
+		if info.TakenAt, err = time.Parse(takenAtLayout, takenAt); err != nil {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		info.Size = fi.Size()
+		if checksum, err := os.ReadFile(filepath.Join(d.path, name+checksumExt)); err == nil {
+			info.SHA256, _, _ = strings.Cut(string(checksum), " ")
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].TakenAt.Equal(infos[j].TakenAt) {
+			return infos[i].TakenAt.Before(infos[j].TakenAt)
+		}
+		return infos[i].Seq < infos[j].Seq
+	})
+	return infos, nil
+}
+
+// segments lists the log segments in the directory in mutation order.
+func (d *Dir) segments() ([]segment, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	var segments []segment
+	for _, e := range entries {
+		rest, ok := strings.CutPrefix(e.Name(), segmentPrefix)
+		if !ok || !strings.HasSuffix(rest, segmentExt) {
+			continue
+		}
+		start, err := strconv.ParseInt(strings.TrimSuffix(rest, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment{file: e.Name(), start: start})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].start < segments[j].start })
+	return segments, nil
+}
+
+// readSnapshot reads the snapshot described by info, checking it against
+// its checksum.
+func (d *Dir) readSnapshot(info Info) (*entities.Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(d.path, info.File))
+	if err != nil {
+		return nil, err
+	}
+	if info.SHA256 == "" {
+		return nil, fmt.Errorf("%s has no checksum", info.File)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != info.SHA256 {
+		return nil, fmt.Errorf("%s does not match its checksum", info.File)
+	}
+	var s entities.Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", info.File, err)
+	}
+	if s.Seq != info.Seq || !s.TakenAt.Equal(info.TakenAt) {
+		return nil, fmt.Errorf("%s holds snapshot %d taken at %s", info.File, s.Seq, s.TakenAt.Format(time.RFC3339))
+	}
+	return &s, nil
+}
+
+// readSegment calls fn with every mutation in the segment. A last line
+// without a newline is a write that did not complete and is skipped.
+func (d *Dir) readSegment(seg segment, fn func(entities.Mutation) error) error {
+	f, err := os.Open(filepath.Join(d.path, seg.file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s line %d: %w", seg.file, n, err)
+		}
+
+		var entry logEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("%s line %d: %w", seg.file, n, err)
+		}
+		if crc32.Checksum(entry.Mutation, castagnoli) != entry.Sum {
+			return fmt.Errorf("%s line %d does not match its checksum", seg.file, n)
+		}
+		var m entities.Mutation
+		if err := json.Unmarshal(entry.Mutation, &m); err != nil {
+			return fmt.Errorf("%s line %d: %w", seg.file, n, err)
+		}
+		if err := fn(m); err != nil {
+			return fmt.Errorf("%s line %d: %w", seg.file, n, err)
+		}
+	}
+}
+
+// readLog calls fn with every mutation in the log in order, checking that
+// none is missing.
+func (d *Dir) readLog(fn func(entities.Mutation) error) error {
+	segments, err := d.segments()
+	if err != nil {
+		return err
+	}
+	var last int64
+	for _, seg := range segments {
+		err := d.readSegment(seg, func(m entities.Mutation) error {
+			if last != 0 && m.Seq != last+1 {
+				return fmt.Errorf("mutation %d follows mutation %d", m.Seq, last)
+			}
+			last = m.Seq
+			return fn(m)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load returns what the store held at until: the latest snapshot taken by
+// then and the mutations made after it up to until. A zero until loads
+// the latest state.
+func (d *Dir) Load(until time.Time) (*entities.Snapshot, []entities.Mutation, error) {
+	infos, err := d.Snapshots()
+	if err != nil {
+		return nil, nil, err
+	}
+	var from *Info
+	for i := range infos {
+		if until.IsZero() || !infos[i].TakenAt.After(until) {
+			from = &infos[i]
+		}
+	}
+	if from == nil && len(infos) == 0 {
+		return nil, nil, ErrNoBackups
+	}
+	if from == nil {
+		return nil, nil, fmt.Errorf("the oldest backup was taken at %s", infos[0].TakenAt.Format(time.RFC3339))
+	}
+
+	s, err := d.readSnapshot(*from)
+	if err != nil {
+		return nil, nil, err
+	}
+	var mutations []entities.Mutation
+	next := s.Seq + 1
+	err = d.readLog(func(m entities.Mutation) error {
+		if m.Seq < next {
+			return nil
+		}
+		if m.Seq > next {
+			return fmt.Errorf("mutations %d to %d are missing", next, m.Seq-1)
+		}
+		next++
+		if until.IsZero() || !m.At.After(until) {
+			mutations = append(mutations, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, mutations, nil
+}
+
+// LastSeq returns the number of the last mutation backed up, by the log or
+// a snapshot.
+func (d *Dir) LastSeq() (int64, error) {
+	infos, err := d.Snapshots()
+	if err != nil {
+		return 0, err
+	}
+	var last int64
+	for _, info := range infos {
+		last = max(last, info.Seq)
+	}
+	segments, err := d.segments()
+	if err != nil || len(segments) == 0 {
+		return last, err
+	}
+	err = d.readSegment(segments[len(segments)-1], func(m entities.Mutation) error {
+		last = max(last, m.Seq)
+		return nil
+	})
+	return last, err
+}
+
+// Prune removes all but the keep latest snapshots, and the log segments
+// that only the removed snapshots needed. It returns the removed files.
+func (d *Dir) Prune(keep int) ([]string, error) {
+	infos, err := d.Snapshots()
+	if err != nil || len(infos) <= keep || keep < 1 {
+		return nil, err
+	}
+	segments, err := d.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	remove := func(name string) error {
+		if err := os.Remove(filepath.Join(d.path, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		removed = append(removed, name)
+		return nil
+	}
+	kept := infos[len(infos)-keep:]
+	oldest := kept[0].Seq
+	for _, info := range kept {
+		oldest = min(oldest, info.Seq)
+	}
+	for _, info := range infos[:len(infos)-keep] {
+		if err := remove(info.File); err != nil {
+			return removed, err
+		}
+		if err := remove(info.File + checksumExt); err != nil {
+			return removed, err
+		}
+	}
+	// A segment ends where the next begins, and the kept snapshots only
+	// need the mutations after the oldest of them
+	for i := 0; i+1 < len(segments) && segments[i+1].start <= oldest+1; i++ {
+		if err := remove(segments[i].file); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// Verify checks every snapshot against its checksum, and that the log is
+// intact and carries on from the oldest snapshot without gaps.
+func (d *Dir) Verify() (*Report, error) {
+	infos, err := d.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	segments, err := d.segments()
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Snapshots: len(infos), Segments: len(segments), Problems: []string{}}
+	if len(infos) == 0 {
+		report.problem("there are no snapshots")
+	}
+
+	var oldest int64 = -1
+	for _, info := range infos {
+		s, err := d.readSnapshot(info)
+		if err != nil {
+			report.problem("%v", err)
+			continue
+		}
+		if oldest < 0 || s.Seq < oldest {
+			oldest = s.Seq
+		}
+		if report.From.IsZero() {
+			report.From = s.TakenAt
+		}
+		if s.TakenAt.After(report.To) {
+			report.To = s.TakenAt
+		}
+	}
+
+	var first, last int64
+	for _, seg := range segments {
+		start := true
+		err := d.readSegment(seg, func(m entities.Mutation) error {
+			if start && m.Seq != seg.start {
+				return fmt.Errorf("mutation %d starts a segment named after mutation %d", m.Seq, seg.start)
+			}
+			if last != 0 && m.Seq != last+1 {
+				return fmt.Errorf("mutation %d follows mutation %d", m.Seq, last)
+			}
+			if first == 0 {
+				first = m.Seq
+			}
+			start, last = false, m.Seq
+			report.Mutations++
+			if m.At.After(report.To) {
+				report.To = m.At
+			}
+			return nil
+		})
+		if err != nil {
+			report.problem("%v", err)
+			break
+		}
+	}
+	if oldest >= 0 && first > oldest+1 {
+		report.problem("mutations %d to %d are missing", oldest+1, first-1)
+	}
+	return report, nil
+}
@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+
+func snapshot(seq int64, at time.Time, classes string) *entities.Snapshot {
+	return &entities.Snapshot{Seq: seq, TakenAt: at, Tables: map[string]json.RawMessage{"classes": json.RawMessage(classes)}}
+}
+
+func mutation(seq int64, at time.Time, index int, name string) entities.Mutation {
+	record, _ := json.Marshal(entities.Class{ID: index + 1, ClassName: name})
+	return entities.Mutation{Seq: seq, At: at, Table: "classes", Index: index, Record: record}
+}
+
+// writeHistory backs up a store that gains a class every minute, with a
+// snapshot after the second class.
+func writeHistory(t *testing.T) *Dir {
+	d, err := Open(filepath.Join(t.TempDir(), "backups"))
+	require.NoError(t, err)
+	_, err = d.WriteSnapshot(snapshot(0, start, `[]`))
+	require.NoError(t, err)
+	require.NoError(t, d.Append([]entities.Mutation{
+		mutation(1, start.Add(time.Minute), 0, "Yoga"),
+		mutation(2, start.Add(2*time.Minute), 1, "Spin"),
+	}))
+	_, err = d.WriteSnapshot(snapshot(2, start.Add(2*time.Minute), `[{"id":1,"class_name":"Yoga"},{"id":2,"class_name":"Spin"}]`))
+	require.NoError(t, err)
+	require.NoError(t, d.Append([]entities.Mutation{mutation(3, start.Add(3*time.Minute), 2, "Barre")}))
+	require.NoError(t, d.Append([]entities.Mutation{mutation(4, start.Add(4*time.Minute), 3, "Pilates")}))
+	require.NoError(t, d.Close())
+	return d
+}
+
+func TestDir_Load(t *testing.T) {
+	d := writeHistory(t)
+
+	tests := []struct {
+		name      string
+		until     time.Time
+		seq       int64
+		mutations []int64
+	}{
+		{name: "should load the latest state", until: time.Time{}, seq: 2, mutations: []int64{3, 4}},
+		{name: "should replay the log up to the time", until: start.Add(90 * time.Second), seq: 0, mutations: []int64{1}},
+		{name: "should start from the latest snapshot by then", until: start.Add(3 * time.Minute), seq: 2, mutations: []int64{3}},
+		{name: "should load the first snapshot alone", until: start, seq: 0, mutations: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mutations, err := d.Load(tt.until)
+			require.NoError(t, err)
+			assert.Equal(t, tt.seq, s.Seq)
+			var seqs []int64
+			for _, m := range mutations {
+				seqs = append(seqs, m.Seq)
+			}
+			assert.Equal(t, tt.mutations, seqs)
+		})
+	}
+
+	_, _, err := d.Load(start.Add(-time.Minute))
+	assert.ErrorContains(t, err, "the oldest backup was taken at 2025-05-20T12:00:00Z")
+	empty, err := Open(t.TempDir())
+	require.NoError(t, err)
+	_, _, err = empty.Load(time.Time{})
+	assert.ErrorIs(t, err, ErrNoBackups)
+}
+
+func TestDir_LastSeq(t *testing.T) {
+	d := writeHistory(t)
+	last, err := d.LastSeq()
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), last)
+}
+
+func TestDir_Prune(t *testing.T) {
+	d := writeHistory(t)
+
+	removed, err := d.Prune(1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"snapshot-00000000000000000000-20250520T120000.000000000Z.json",
+		"snapshot-00000000000000000000-20250520T120000.000000000Z.json.sha256",
+		"wal-00000000000000000001.ndjson",
+	}, removed)
+
+	infos, err := d.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, int64(2), infos[0].Seq)
+	s, mutations, err := d.Load(time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), s.Seq)
+	assert.Len(t, mutations, 2)
+	_, _, err = d.Load(start.Add(time.Minute))
+	assert.ErrorContains(t, err, "the oldest backup was taken at 2025-05-20T12:02:00Z")
+}
+
+func TestDir_Verify(t *testing.T) {
+	d := writeHistory(t)
+	report, err := d.Verify()
+	require.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, &Report{
+		Snapshots: 2, Segments: 2, Mutations: 4,
+		From: start, To: start.Add(4 * time.Minute), Problems: []string{},
+	}, report)
+
+	// A write cut short by a crash was never acknowledged, so it is skipped
+	log := filepath.Join(d.Path(), "wal-00000000000000000003.ndjson")
+	f, err := os.OpenFile(log, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"sum":1,"mutation":{"seq":5`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	report, err = d.Verify()
+	require.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+
+	// Flip a byte in a logged record and in a snapshot
+	data, err := os.ReadFile(log)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(log, []byte(string(data[:len(data)-60])+"X"+string(data[len(data)-59:])), 0o600))
+	snapshotFile := filepath.Join(d.Path(), "snapshot-00000000000000000002-20250520T120200.000000000Z.json")
+	data, err = os.ReadFile(snapshotFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(snapshotFile, []byte("x"+string(data[1:])), 0o600))
+
+	report, err = d.Verify()
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []string{
+		"snapshot-00000000000000000002-20250520T120200.000000000Z.json does not match its checksum",
+		"wal-00000000000000000003.ndjson line 2 does not match its checksum",
+	}, report.Problems)
+	_, _, err = d.Load(time.Time{})
+	assert.ErrorContains(t, err, "does not match its checksum")
+}
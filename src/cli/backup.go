@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/backup"
+	"github.com/Vidyuallatha/glofox/src/components"
+)
+
+func runBackup(ctx context.Context, env *Env, args []string) int {
+	return runSubcommand(ctx, env, "backup", "create|list|verify|restore", map[string]command{
+		"create":  createBackup,
+		"list":    listBackups,
+		"verify":  verifyBackups,
+		"restore": restoreCommand("backup restore"),
+	}, args)
+}
+
+// dirFlag registers the -dir flag of the commands that work on a backup
+// directory directly.
+func dirFlag(flags *flag.FlagSet) *string {
+	return flags.String("dir", "", "backup directory (default $BACKUP_DIR)")
+}
+
+// openBackupDir opens the directory given with -dir, falling back on
+// BACKUP_DIR.
+func openBackupDir(env *Env, flagValue string) (*backup.Dir, error) {
+	path := flagValue
+	if path == "" {
+		path = env.Getenv("BACKUP_DIR")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no backup directory, set -dir or $BACKUP_DIR")
+	}
+	return backup.Open(path)
+}
+
+// createBackup has the running server take a backup.
+func createBackup(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("backup create", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	opts := apiFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox backup create [flags]")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 0, opts) {
+		return 2
+	}
+
+	var info backup.Info
+	if err := newClient(env, opts).call(ctx, http.MethodPost, "/backups", nil, nil, &info); err != nil {
+		return fail(env, err)
+	}
+	if opts.output == outputJSON {
+		if err := writeJSON(env.Stdout, info); err != nil {
+			return fail(env, err)
+		}
+		return 0
+	}
+	fmt.Fprintf(env.Stdout, "backup %s taken at mutation %d\n", info.File, info.Seq)
+	return 0
+}
+
+// listBackups prints the snapshots the running server keeps.
+func listBackups(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("backup list", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	opts := apiFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox backup list [flags]")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 0, opts) {
+		return 2
+	}
+
+	var infos []backup.Info
+	if err := newClient(env, opts).call(ctx, http.MethodGet, "/backups", nil, nil, &infos); err != nil {
+		return fail(env, err)
+	}
+	if opts.output == outputJSON {
+		if err := writeJSON(env.Stdout, infos); err != nil {
+			return fail(env, err)
+		}
+		return 0
+	}
+	rows := make([][]string, 0, len(infos))
+	for _, info := range infos {
+		rows = append(rows, []string{
+			info.TakenAt.Format(time.RFC3339), strconv.FormatInt(info.Seq, 10), strconv.FormatInt(info.Size, 10), info.File,
+		})
+	}
+	if err := writeTable(env.Stdout, []string{"TAKEN", "SEQ", "SIZE", "FILE"}, rows); err != nil {
+		return fail(env, err)
+	}
+	return 0
+}
+
+// verifyBackups checks a backup directory directly, so it works whether or
+// not the server is running. It fails when any problem is found.
+func verifyBackups(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("backup verify", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	dir := dirFlag(flags)
+	output := flags.String("o", outputTable, "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox backup verify [flags]")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 0, nil) {
+		return 2
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintln(env.Stderr, "glofox:", err)
+		return 2
+	}
+
+	d, err := openBackupDir(env, *dir)
+	if err != nil {
+		return fail(env, err)
+	}
+	report, err := d.Verify()
+	if err != nil {
+		return fail(env, err)
+	}
+	if *output == outputJSON {
+		if err := writeJSON(env.Stdout, report); err != nil {
+			return fail(env, err)
+		}
+	} else {
+		fmt.Fprintf(env.Stdout, "%d snapshots, %d mutations in %d log files\n", report.Snapshots, report.Mutations, report.Segments)
+		if !report.From.IsZero() {
+			fmt.Fprintf(env.Stdout, "restorable from %s to %s\n", report.From.Format(time.RFC3339), report.To.Format(time.RFC3339))
+		}
+		for _, problem := range report.Problems {
+			fmt.Fprintln(env.Stdout, "problem:", problem)
+		}
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+// restoreCommand returns the command, run as name, that rewinds a backup
+// directory to the given time, so that the server starts from the store as
+// it was then. The server must be stopped while it runs.
+func restoreCommand(name string) command {
+	return func(ctx context.Context, env *Env, args []string) int {
+		flags := flag.NewFlagSet(name, flag.ContinueOnError)
+		flags.SetOutput(env.Stderr)
+		dir := dirFlag(flags)
+		to := flags.String("to", "", "time to restore to, as 2006-01-02T15:04:05Z07:00")
+		flags.Usage = func() {
+			fmt.Fprintf(env.Stderr, "usage: glofox %s -to TIME [flags]\n", name)
+			flags.PrintDefaults()
+		}
+		if !parseFlags(flags, args, 0, nil) {
+			return 2
+		}
+		until, err := time.Parse(time.RFC3339, *to)
+		if err != nil {
+			flags.Usage()
+			return 2
+		}
+
+		d, err := openBackupDir(env, *dir)
+		if err != nil {
+			return fail(env, err)
+		}
+		defer d.Close()
+		backups := components.InitBackupsComponent()
+		backups.Dir = d
+		info, err := backups.RestoreTo(ctx, until)
+		if err != nil {
+			return fail(env, err)
+		}
+		fmt.Fprintf(env.Stdout, "restored to %s as %s; the server starts from it next time\n", until.Format(time.RFC3339), info.File)
+		return 0
+	}
+}
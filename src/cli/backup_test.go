@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/backup"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup_Create(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"code":201,"data":{"file":"snapshot-00000000000000000042-20250520T120000.000000000Z.json","seq":42,"taken_at":"2025-05-20T12:00:00Z","size":512,"sha256":"ab"}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	code := Run(context.Background(), env, []string{"backup", "create"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, "/backups", gotPath)
+	assert.Equal(t, "backup snapshot-00000000000000000042-20250520T120000.000000000Z.json taken at mutation 42\n", stdout.String())
+}
+
+func TestBackup_VerifyAndRestore(t *testing.T) {
+	t.Cleanup(func() { entities.Classes = nil })
+	start := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	d, err := backup.Open(t.TempDir())
+	require.NoError(t, err)
+	_, err = d.WriteSnapshot(&entities.Snapshot{TakenAt: start, Tables: map[string]json.RawMessage{}})
+	require.NoError(t, err)
+	var mutations []entities.Mutation
+	for i, name := range []string{"Yoga", "Spin"} {
+		record, err := json.Marshal(entities.Class{ID: i + 1, ClassName: name})
+		require.NoError(t, err)
+		mutations = append(mutations, entities.Mutation{
+			Seq: int64(i + 1), At: start.Add(time.Duration(i+1) * time.Minute), Table: "classes", Index: i, Record: record,
+		})
+	}
+	require.NoError(t, d.Append(mutations))
+	require.NoError(t, d.Close())
+
+	env, stdout, _ := testEnv("")
+	code := Run(context.Background(), env, []string{"backup", "verify", "-dir", d.Path()})
+	assert.Equal(t, 0, code)
+	assert.Equal(t, ""+
+		"1 snapshots, 2 mutations in 1 log files\n"+
+		"restorable from 2025-05-20T12:00:00Z to 2025-05-20T12:02:00Z\n", stdout.String())
+
+	env, stdout, _ = testEnv("")
+	env.Getenv = func(key string) string {
+		return map[string]string{"BACKUP_DIR": d.Path()}[key]
+	}
+	code = Run(context.Background(), env, []string{"backup", "restore", "-to", "2025-05-20T12:01:30Z"})
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "restored to 2025-05-20T12:01:30Z as snapshot-00000000000000000002-")
+	s, rest, err := d.Load(time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.JSONEq(t, `[{"id":1,"class_name":"Yoga","start_date":"0001-01-01T00:00:00Z","end_date":"0001-01-01T00:00:00Z","capacity":0}]`,
+		string(s.Tables["classes"]))
+
+	env, _, stderr := testEnv("")
+	assert.Equal(t, 2, Run(context.Background(), env, []string{"backup", "restore", "-dir", d.Path()}))
+	assert.Contains(t, stderr.String(), "usage: glofox backup restore")
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+)
+
+func runBookings(ctx context.Context, env *Env, args []string) int {
+	return runSubcommand(ctx, env, "bookings", "list|cancel", map[string]command{
+		"list":   listBookings,
+		"cancel": cancelBooking,
+	}, args)
+}
+
+// listBookings prints the bookings matching its filter flags.
+func listBookings(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("bookings list", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	opts := apiFlags(flags)
+	name := flags.String("name", "", "member name")
+	classID := flags.Int("class", 0, "class ID")
+	status := flags.String("status", "", "pending, confirmed, cancelled, failed, attended or no_show")
+	from := flags.String("from", "", "first day, as 2006-01-02")
+	to := flags.String("to", "", "last day, as 2006-01-02")
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox bookings list [flags]")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 0, opts) {
+		return 2
+	}
+
+	query := url.Values{}
+	setIf(query, "name", *name)
+	if *classID != 0 {
+		query.Set("class_id", strconv.Itoa(*classID))
+	}
+	setIf(query, "status", *status)
+	setIf(query, "from", *from)
+	setIf(query, "to", *to)
+	bookings, err := list[entities.Booking](ctx, newClient(env, opts), "/bookings", query)
+	if err != nil {
+		return fail(env, err)
+	}
+	if opts.output == outputJSON {
+		if err := writeJSON(env.Stdout, bookings); err != nil {
+			return fail(env, err)
+		}
+		return 0
+	}
+	rows := make([][]string, 0, len(bookings))
+	for _, b := range bookings {
+		rows = append(rows, []string{
+			strconv.Itoa(b.ID), strconv.Itoa(b.ClassID), b.Name, b.Date.Format(tableTime), string(b.Status), strconv.Itoa(b.MembershipID),
+		})
+	}
+	if err := writeTable(env.Stdout, []string{"ID", "CLASS", "NAME", "DATE", "STATUS", "MEMBERSHIP"}, rows); err != nil {
+		return fail(env, err)
+	}
+	return 0
+}
+
+// cancelBooking cancels one booking as the member would.
+func cancelBooking(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("bookings cancel", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	opts := apiFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox bookings cancel [flags] ID")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 1, opts) {
+		return 2
+	}
+	id, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		flags.Usage()
+		return 2
+	}
+
+	var booking entities.Booking
+	if err := newClient(env, opts).call(ctx, http.MethodDelete, fmt.Sprintf("/bookings/%d", id), nil, nil, &booking); err != nil {
+		return fail(env, err)
+	}
+	if opts.output == outputJSON {
+		if err := writeJSON(env.Stdout, booking); err != nil {
+			return fail(env, err)
+		}
+		return 0
+	}
+	refund := "nothing refunded"
+	switch {
+	case booking.CreditRefunded:
+		refund = "credit refunded"
+	case booking.PaymentRefunded:
+		refund = "payment refunded"
+	}
+	fmt.Fprintf(env.Stdout, "cancelled booking %d; %s\n", booking.ID, refund)
+	return 0
+}
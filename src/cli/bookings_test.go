@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookings_List(t *testing.T) {
+	var gotQuery, gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery, gotKey = r.URL.RawQuery, r.Header.Get("X-Staff-Key")
+		io.WriteString(w, `{"code":200,"data":[{"id":4,"class_id":1,"name":"Ann","date":"2025-05-20T09:00:00Z","status":"confirmed","membership_id":2}]}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	env.Getenv = func(key string) string {
+		return map[string]string{"GLOFOX_URL": srv.URL, "GLOFOX_STAFF_KEY": "env-key"}[key]
+	}
+	code := Run(context.Background(), env, []string{"bookings", "list", "-class", "1", "-status", "confirmed"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "class_id=1&limit=200&status=confirmed", gotQuery)
+	assert.Equal(t, "env-key", gotKey)
+	assert.Equal(t, ""+
+		"ID  CLASS  NAME  DATE              STATUS     MEMBERSHIP\n"+
+		"4   1      Ann   2025-05-20 09:00  confirmed  2\n", stdout.String())
+}
+
+func TestBookings_Cancel(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		io.WriteString(w, `{"code":200,"data":{"id":4,"status":"cancelled","credit_refunded":true}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	code := Run(context.Background(), env, []string{"bookings", "cancel", "4"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, http.MethodDelete, gotMethod)
+	assert.Equal(t, "/bookings/4", gotPath)
+	assert.Equal(t, "cancelled booking 4; credit refunded\n", stdout.String())
+
+	env, _, stderr := testEnv(srv.URL)
+	assert.Equal(t, 2, Run(context.Background(), env, []string{"bookings", "cancel", "four"}))
+	assert.Contains(t, stderr.String(), "usage: glofox bookings cancel")
+}
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/entities"
+)
+
+// tableTime is how times are shown in tables.
+const tableTime = "2006-01-02 15:04"
+
+func runClasses(ctx context.Context, env *Env, args []string) int {
+	return runSubcommand(ctx, env, "classes", "list|search|create|cancel|import", map[string]command{
+		"list":   listClasses,
+		"search": searchClasses,
+		"create": createClass,
+		"cancel": cancelClass,
+		"import": func(ctx context.Context, env *Env, args []string) int {
+			return importRecords(ctx, env, "classes", args)
+		},
+	}, args)
+}
+
+// listClasses prints the classes running within -from and -to.
+func listClasses(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("classes list", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	opts := apiFlags(flags)
+	from := flags.String("from", "", "first day, as 2006-01-02")
+	to := flags.String("to", "", "last day, as 2006-01-02")
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox classes list [flags]")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 0, opts) {
+		return 2
+	}
+
+	query := url.Values{}
+	setIf(query, "from", *from)
+	setIf(query, "to", *to)
+	classes, err := list[entities.Class](ctx, newClient(env, opts), "/classes", query)
+	if err != nil {
+		return fail(env, err)
+	}
+	if err := writeClasses(env, opts.output, classes); err != nil {
+		return fail(env, err)
+	}
+	return 0
+}
+
+// searchClasses prints the classes to come matching -q and the filter
+// flags, best matches first.
+func searchClasses(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("classes search", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	opts := apiFlags(flags)
+	words := flags.String("q", "", "words to search for, such as \"yoga beginner evening\"")
+	category := flags.String("category", "", "category")
+	room := flags.String("room", "", "room")
+	instructor := flags.String("instructor", "", "instructor")
+	timeOfDay := flags.String("time", "", "morning, afternoon or evening")
+	weekday := flags.String("weekday", "", "day of the week, such as monday")
+	available := flags.Bool("available", false, "only classes with a spot left in their next session")
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox classes search [flags]")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 0, opts) {
+		return 2
+	}
+
+	query := url.Values{}
+	setIf(query, "q", *words)
+	setIf(query, "category", *category)
+	setIf(query, "room", *room)
+	setIf(query, "instructor", *instructor)
+	setIf(query, "time_of_day", *timeOfDay)
+	setIf(query, "weekday", *weekday)
+	if *available {
+		query.Set("available", "true")
+	}
+	var results components.ClassSearchResults
+	if err := newClient(env, opts).call(ctx, http.MethodGet, "/classes/search", query, nil, &results); err != nil {
+		return fail(env, err)
+	}
+	if opts.output == outputJSON {
+		if err := writeJSON(env.Stdout, results); err != nil {
+			return fail(env, err)
+		}
+		return 0
+	}
+	rows := make([][]string, 0, len(results.Classes))
+	for _, match := range results.Classes {
+		c := match.Class
+		rows = append(rows, []string{
+			strconv.Itoa(c.ID), c.ClassName, orDash(c.Instructor), orDash(c.Room),
+			match.NextSession.Start.Format(tableTime), strconv.Itoa(match.NextSession.Remaining),
+		})
+	}
+	if err := writeTable(env.Stdout, []string{"ID", "NAME", "INSTRUCTOR", "ROOM", "NEXT SESSION", "SPOTS"}, rows); err != nil {
+		return fail(env, err)
+	}
+	if len(results.Classes) < results.Total {
+		fmt.Fprintf(env.Stdout, "%d of %d classes found\n", len(results.Classes), results.Total)
+	}
+	return 0
+}
+
+// orDash returns s, or a dash when it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// createClass creates one class from its flags.
+func createClass(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("classes create", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	opts := apiFlags(flags)
+	class := entities.Class{}
+	flags.StringVar(&class.ClassName, "name", "", "class name")
+	start := flags.String("start", "", "first session start, as RFC 3339 (2006-01-02T15:04:05Z)")
+	end := flags.String("end", "", "last session end, as RFC 3339")
+	flags.IntVar(&class.Capacity, "capacity", 0, "spots per session")
+	flags.IntVar(&class.Price, "price", 0, "drop-in price in minor units of -currency")
+	flags.StringVar(&class.Currency, "currency", "", "ISO 4217 currency of -price")
+	flags.StringVar(&class.Studio, "studio", "", "studio (default main)")
+	flags.StringVar(&class.Description, "description", "", "what the class is about")
+	flags.StringVar(&class.Instructor, "instructor", "", "instructor")
+	flags.StringVar(&class.Category, "category", "", "category, such as yoga")
+	flags.StringVar(&class.Room, "room", "", "room")
+	tags := flags.String("tags", "", "comma-separated tags, such as beginner,low-impact")
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox classes create -name NAME -start TIME -end TIME -capacity N [flags]")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 0, opts) {
+		return 2
+	}
+	var err error
+	if class.StartDate, err = time.Parse(time.RFC3339, *start); err != nil {
+		fmt.Fprintln(env.Stderr, "glofox: -start must be an RFC 3339 time such as 2025-05-20T09:00:00Z")
+		return 2
+	}
+	if class.EndDate, err = time.Parse(time.RFC3339, *end); err != nil {
+		fmt.Fprintln(env.Stderr, "glofox: -end must be an RFC 3339 time such as 2025-05-20T10:00:00Z")
+		return 2
+	}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			class.Tags = append(class.Tags, tag)
+		}
+	}
+
+	var created entities.Class
+	if err := newClient(env, opts).call(ctx, http.MethodPost, "/classes", nil, class, &created); err != nil {
+		return fail(env, err)
+	}
+	if err := writeClasses(env, opts.output, []entities.Class{created}); err != nil {
+		return fail(env, err)
+	}
+	return 0
+}
+
+// cancelClass calls a class off and reports the bookings refunded.
+func cancelClass(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("classes cancel", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	opts := apiFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox classes cancel [flags] ID")
+		flags.PrintDefaults()
+	}
+	if !parseFlags(flags, args, 1, opts) {
+		return 2
+	}
+	id, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		flags.Usage()
+		return 2
+	}
+
+	var cancellation struct {
+		Class             entities.Class     `json:"class"`
+		CancelledBookings []entities.Booking `json:"cancelled_bookings"`
+	}
+	if err := newClient(env, opts).call(ctx, http.MethodDelete, fmt.Sprintf("/classes/%d", id), nil, nil, &cancellation); err != nil {
+		return fail(env, err)
+	}
+	if opts.output == outputJSON {
+		if err := writeJSON(env.Stdout, cancellation); err != nil {
+			return fail(env, err)
+		}
+		return 0
+	}
+	fmt.Fprintf(env.Stdout, "cancelled class %d (%s); %d bookings cancelled and refunded\n",
+		cancellation.Class.ID, cancellation.Class.ClassName, len(cancellation.CancelledBookings))
+	return 0
+}
+
+func writeClasses(env *Env, output string, classes []entities.Class) error {
+	if output == outputJSON {
+		return writeJSON(env.Stdout, classes)
+	}
+	rows := make([][]string, 0, len(classes))
+	for _, c := range classes {
+		status := "scheduled"
+		if c.CancelledAt != nil {
+			status = "cancelled"
+		}
+		price := "-"
+		if c.Price > 0 {
+			price = fmt.Sprintf("%d %s", c.Price, c.Currency)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(c.ID), c.ClassName, c.Studio, c.StartDate.Format(tableTime), c.EndDate.Format(tableTime),
+			strconv.Itoa(c.Capacity), price, status,
+		})
+	}
+	return writeTable(env.Stdout, []string{"ID", "NAME", "STUDIO", "START", "END", "CAPACITY", "PRICE", "STATUS"}, rows)
+}
+
+// setIf sets key in query unless value is empty.
+func setIf(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
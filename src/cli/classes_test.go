@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClasses_List(t *testing.T) {
+	var gotQueries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQueries = append(gotQueries, r.URL.RawQuery)
+		// Each class comes on a page of its own
+		if r.URL.Query().Get("cursor") == "" {
+			io.WriteString(w, `{"code":200,"data":[
+				{"id":1,"class_name":"Yoga","studio":"main","start_date":"2025-05-01T09:00:00Z","end_date":"2025-05-31T10:00:00Z","capacity":10}
+			],"next":"/classes?cursor=next"}`)
+			return
+		}
+		io.WriteString(w, `{"code":200,"data":[
+			{"id":2,"class_name":"Spin","studio":"annex","start_date":"2025-05-03T18:00:00Z","end_date":"2025-05-03T19:00:00Z","capacity":8,"price":1500,"currency":"EUR","cancelled_at":"2025-05-02T12:00:00Z"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	code := Run(context.Background(), env, []string{"classes", "list", "-from", "2025-05-01", "-to", "2025-05-31"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"from=2025-05-01&limit=200&to=2025-05-31", "cursor=next"}, gotQueries)
+	assert.Equal(t, ""+
+		"ID  NAME  STUDIO  START             END               CAPACITY  PRICE     STATUS\n"+
+		"1   Yoga  main    2025-05-01 09:00  2025-05-31 10:00  10        -         scheduled\n"+
+		"2   Spin  annex   2025-05-03 18:00  2025-05-03 19:00  8         1500 EUR  cancelled\n", stdout.String())
+}
+
+func TestClasses_Search(t *testing.T) {
+	var gotPath, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		io.WriteString(w, `{"code":200,"data":{"total":3,"classes":[
+			{"class":{"id":4,"class_name":"Vinyasa Yoga","instructor":"Ann Lee","room":"Studio A","start_date":"2025-05-05T18:00:00Z","end_date":"2025-05-05T19:00:00Z","capacity":10},
+			 "score":7,"next_session":{"class_id":4,"start":"2025-05-05T18:00:00Z","end":"2025-05-05T19:00:00Z","capacity":10,"booked":7,"remaining":3}},
+			{"class":{"id":6,"class_name":"Yin Yoga","start_date":"2025-05-06T19:00:00Z","end_date":"2025-05-06T20:00:00Z","capacity":8},
+			 "score":4,"next_session":{"class_id":6,"start":"2025-05-06T19:00:00Z","end":"2025-05-06T20:00:00Z","capacity":8,"booked":0,"remaining":8}}
+		],"facets":{}}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	code := Run(context.Background(), env, []string{"classes", "search", "-q", "yoga evening", "-weekday", "monday", "-available"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "/classes/search", gotPath)
+	assert.Equal(t, "available=true&q=yoga+evening&weekday=monday", gotQuery)
+	assert.Equal(t, ""+
+		"ID  NAME          INSTRUCTOR  ROOM      NEXT SESSION      SPOTS\n"+
+		"4   Vinyasa Yoga  Ann Lee     Studio A  2025-05-05 18:00  3\n"+
+		"6   Yin Yoga      -           -         2025-05-06 19:00  8\n"+
+		"2 of 3 classes found\n", stdout.String())
+}
+
+func TestClasses_Create(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"code":201,"data":{"id":3,"class_name":"Pilates","start_date":"2025-06-01T07:00:00Z","end_date":"2025-06-30T08:00:00Z","capacity":12}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	code := Run(context.Background(), env, []string{"classes", "create", "-o", "json",
+		"-name", "Pilates", "-start", "2025-06-01T07:00:00Z", "-end", "2025-06-30T08:00:00Z", "-capacity", "12",
+		"-tags", "core, beginner"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "Pilates", got["class_name"])
+	assert.Equal(t, "2025-06-01T07:00:00Z", got["start_date"])
+	assert.Equal(t, float64(12), got["capacity"])
+	assert.Equal(t, []any{"core", "beginner"}, got["tags"])
+	var printed []map[string]any
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &printed))
+	assert.Equal(t, float64(3), printed[0]["id"])
+}
+
+func TestClasses_Cancel(t *testing.T) {
+	var gotMethod, gotPath, gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath, gotKey = r.Method, r.URL.Path, r.Header.Get("X-Staff-Key")
+		io.WriteString(w, `{"code":200,"data":{"class":{"id":3,"class_name":"Pilates"},"cancelled_bookings":[{"id":7},{"id":8}]}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	code := Run(context.Background(), env, []string{"classes", "cancel", "-staff-key", "secret", "3"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, http.MethodDelete, gotMethod)
+	assert.Equal(t, "/classes/3", gotPath)
+	assert.Equal(t, "secret", gotKey)
+	assert.Equal(t, "cancelled class 3 (Pilates); 2 bookings cancelled and refunded\n", stdout.String())
+}
+
+func TestClasses_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"code":403,"data":null,"errors":["staff only"]}`)
+	}))
+	defer srv.Close()
+
+	env, _, stderr := testEnv(srv.URL)
+	assert.Equal(t, 1, Run(context.Background(), env, []string{"classes", "cancel", "3"}))
+	assert.Equal(t, "glofox: staff only\n", stderr.String())
+
+	env, _, stderr = testEnv(srv.URL)
+	assert.Equal(t, 2, Run(context.Background(), env, []string{"classes", "archive"}))
+	assert.Contains(t, stderr.String(), "usage: glofox classes list|search|create|cancel|import")
+
+	env, _, stderr = testEnv(srv.URL)
+	assert.Equal(t, 2, Run(context.Background(), env, []string{"classes", "list", "-o", "yaml"}))
+	assert.Contains(t, stderr.String(), `unknown output format "yaml"`)
+}
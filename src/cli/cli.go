@@ -0,0 +1,283 @@
+// Package cli implements the glofox command-line tool. It runs the server,
+// operates a running one over its REST API and works on a stopped one's
+// store directly.
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultServerURL is the server commands talk to unless -server or
+// GLOFOX_URL say otherwise.
+const DefaultServerURL = "http://localhost:9000"
+
+// command runs one subcommand with its arguments and returns the exit code.
+type command func(ctx context.Context, env *Env, args []string) int
+
+var commands = map[string]command{
+	"serve":    runServe,
+	"classes":  runClasses,
+	"bookings": runBookings,
+	"members":  runMembers,
+	"report":   runReport,
+	"import":   runImport,
+	"backup":   runBackup,
+	"store":    runStore,
+}
+
+// Env is what commands read from and write to.
+type Env struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Getenv looks up environment variables.
+	Getenv func(string) string
+	Client *http.Client
+}
+
+// DefaultEnv is the process's standard streams and environment.
+func DefaultEnv() *Env {
+	return &Env{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Getenv: os.Getenv,
+		Client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// Run runs the subcommand named by args[0] and returns the exit code.
+func Run(ctx context.Context, env *Env, args []string) int {
+	if len(args) == 0 {
+		usage(env.Stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(env.Stderr, "glofox: unknown command %q\n", args[0])
+		usage(env.Stderr)
+		return 2
+	}
+	return cmd(ctx, env, args[1:])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `usage: glofox <command> [flags] [args]
+
+commands:
+  serve                            run the booking service
+  classes list|search|create|cancel|import
+  bookings list|cancel
+  members import                   import members from a CSV or NDJSON file
+  report utilisation|peak-times|retention
+  import classes|members           import from a CSV or NDJSON file
+  backup create|list|verify|restore
+  store migrate|backup|restore     work on a stopped server's store
+
+Commands other than serve, backup verify|restore and store talk to the
+server at -server, $GLOFOX_URL or `+DefaultServerURL+`. Staff-only commands
+send -staff-key or $GLOFOX_STAFF_KEY. The store commands work on the backup
+directory at -dir or $BACKUP_DIR, which the server must not be using; the
+other commands have no direct mode.
+Run a command with -h for its flags.
+`)
+}
+
+// runSubcommand runs the subcommand of name named by args[0]. synopsis lists
+// the subcommands for the usage message.
+func runSubcommand(ctx context.Context, env *Env, name, synopsis string, subcommands map[string]command, args []string) int {
+	if len(args) > 0 {
+		if cmd, ok := subcommands[args[0]]; ok {
+			return cmd(ctx, env, args[1:])
+		}
+		fmt.Fprintf(env.Stderr, "glofox: unknown %s command %q\n", name, args[0])
+	}
+	fmt.Fprintf(env.Stderr, "usage: glofox %s %s [flags] [args]\n", name, synopsis)
+	return 2
+}
+
+// parseFlags parses args, printing usage on error, and checks that the
+// command was given want positional arguments and a known output format.
+// It returns false when the command should exit with status 2.
+func parseFlags(flags *flag.FlagSet, args []string, want int, opts *apiOptions) bool {
+	if err := flags.Parse(args); err != nil {
+		return false
+	}
+	if flags.NArg() != want {
+		flags.Usage()
+		return false
+	}
+	if opts != nil {
+		if err := checkOutput(opts.output); err != nil {
+			fmt.Fprintln(flags.Output(), "glofox:", err)
+			return false
+		}
+	}
+	return true
+}
+
+// fail reports err and returns the exit code of a failed command.
+func fail(env *Env, err error) int {
+	fmt.Fprintln(env.Stderr, "glofox:", err)
+	return 1
+}
+
+// serverURL returns the URL given with -server, falling back on GLOFOX_URL
+// and then DefaultServerURL.
+func serverURL(env *Env, flagValue string) string {
+	url := flagValue
+	if url == "" {
+		url = env.Getenv("GLOFOX_URL")
+	}
+	if url == "" {
+		url = DefaultServerURL
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// apiResponse is the envelope every API response comes in.
+type apiResponse struct {
+	Code      int             `json:"code"`
+	Data      json.RawMessage `json:"data"`
+	Errors    []string        `json:"errors"`
+	Next      string          `json:"next"`
+	RequestID string          `json:"request_id"`
+}
+
+// apiOptions are the flags every command that calls the API takes.
+type apiOptions struct {
+	server   string
+	staffKey string
+	output   string
+}
+
+// apiFlags registers the -server, -staff-key and -o flags on flags.
+func apiFlags(flags *flag.FlagSet) *apiOptions {
+	opts := &apiOptions{}
+	flags.StringVar(&opts.server, "server", "", "server URL (default $GLOFOX_URL or "+DefaultServerURL+")")
+	flags.StringVar(&opts.staffKey, "staff-key", "", "staff API key (default $GLOFOX_STAFF_KEY)")
+	flags.StringVar(&opts.output, "o", outputTable, "output format: table or json")
+	return opts
+}
+
+// client calls the API on behalf of a command.
+type client struct {
+	env      *Env
+	baseURL  string
+	staffKey string
+}
+
+func newClient(env *Env, opts *apiOptions) *client {
+	key := opts.staffKey
+	if key == "" {
+		key = env.Getenv("GLOFOX_STAFF_KEY")
+	}
+	return &client{env: env, baseURL: serverURL(env, opts.server), staffKey: key}
+}
+
+// call sends body, if any, as JSON to path and decodes the data of a
+// successful response into out. Error responses come back as an error
+// carrying the API's messages.
+func (c *client) call(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	target := path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	decoded, err := c.send(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(decoded.Data, out); err != nil {
+		return fmt.Errorf("unexpected response: %w", err)
+	}
+	return nil
+}
+
+// listPageSize is how many records list commands ask for at a time.
+const listPageSize = 200
+
+// list fetches every page of the list at path, following the next link of
+// each page, and returns the records of them all.
+func list[T any](ctx context.Context, c *client, path string, query url.Values) ([]T, error) {
+	query.Set("limit", strconv.Itoa(listPageSize))
+	target := path + "?" + query.Encode()
+	all := []T{}
+	for target != "" {
+		decoded, err := c.send(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page []T
+		if err := json.Unmarshal(decoded.Data, &page); err != nil {
+			return nil, fmt.Errorf("unexpected response: %w", err)
+		}
+		all = append(all, page...)
+		target = decoded.Next
+	}
+	return all, nil
+}
+
+// send sends body, if any, as JSON to target, a path with its query, and
+// returns the response when it is a success.
+func (c *client) send(ctx context.Context, method, target string, body any) (*apiResponse, error) {
+	target = c.baseURL + target
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.staffKey != "" {
+		req.Header.Set("X-Staff-Key", c.staffKey)
+	}
+	resp, err := c.env.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	decoded, err := decodeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		if len(decoded.Errors) == 0 {
+			return nil, fmt.Errorf("server answered %s", resp.Status)
+		}
+		return nil, errors.New(strings.Join(decoded.Errors, "; "))
+	}
+	return decoded, nil
+}
+
+// decodeResponse reads an API response, whatever its status.
+func decodeResponse(resp *http.Response) (*apiResponse, error) {
+	var body apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("unexpected response %s: %w", resp.Status, err)
+	}
+	return &body, nil
+}
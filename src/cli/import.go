@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Vidyuallatha/glofox/src/records"
+)
+
+// importReport is the part of an import report the CLI prints.
+type importReport struct {
+	DryRun bool `json:"dry_run"`
+	Rows   int  `json:"rows"`
+	Errors []struct {
+		Row    int      `json:"row"`
+		Errors []string `json:"errors"`
+	} `json:"errors"`
+	Imported []json.RawMessage `json:"imported"`
+}
+
+// runImport sends a CSV or NDJSON file to POST /classes/import or
+// /members/import and prints the per-row errors, if any.
+func runImport(ctx context.Context, env *Env, args []string) int {
+	return importRecords(ctx, env, "", args)
+}
+
+// importRecords imports a file of kind, or of the kind named by the first
+// argument when kind is empty.
+func importRecords(ctx context.Context, env *Env, kind string, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	server := flags.String("server", "", "server URL (default $GLOFOX_URL or "+DefaultServerURL+")")
+	staffKey := flags.String("staff-key", "", "staff API key (default $GLOFOX_STAFF_KEY)")
+	dryRun := flags.Bool("dry-run", false, "only check the rows")
+	formatName := flags.String("format", "", "csv or ndjson (default from the file extension)")
+	flags.Usage = func() {
+		if kind == "" {
+			fmt.Fprintln(env.Stderr, "usage: glofox import [flags] classes|members FILE")
+		} else {
+			fmt.Fprintf(env.Stderr, "usage: glofox %s import [flags] FILE\n", kind)
+		}
+		fmt.Fprintln(env.Stderr, "FILE may be - for standard input, with -format.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	positional := flags.Args()
+	if kind == "" && len(positional) == 2 && (positional[0] == "classes" || positional[0] == "members") {
+		kind, positional = positional[0], positional[1:]
+	}
+	if kind == "" || len(positional) != 1 {
+		flags.Usage()
+		return 2
+	}
+	path := positional[0]
+
+	format, err := importFormat(*formatName, path)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "glofox:", err)
+		return 2
+	}
+	var file io.Reader = env.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(env.Stderr, "glofox:", err)
+			return 1
+		}
+		defer f.Close()
+		file = f
+	}
+
+	c := newClient(env, &apiOptions{server: *server, staffKey: *staffKey})
+	url := fmt.Sprintf("%s/%s/import?dry_run=%t", c.baseURL, kind, *dryRun)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, file)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "glofox:", err)
+		return 1
+	}
+	req.Header.Set("Content-Type", format.ContentType())
+	if c.staffKey != "" {
+		req.Header.Set("X-Staff-Key", c.staffKey)
+	}
+	resp, err := env.Client.Do(req)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "glofox:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	body, err := decodeResponse(resp)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "glofox:", err)
+		return 1
+	}
+	var report importReport
+	if len(body.Data) > 0 && string(body.Data) != "null" {
+		if err := json.Unmarshal(body.Data, &report); err != nil {
+			fmt.Fprintln(env.Stderr, "glofox: unexpected response:", err)
+			return 1
+		}
+	}
+	for _, row := range report.Errors {
+		for _, msg := range row.Errors {
+			fmt.Fprintf(env.Stderr, "row %d: %s\n", row.Row, msg)
+		}
+	}
+	if resp.StatusCode >= 300 {
+		if len(report.Errors) == 0 {
+			for _, msg := range body.Errors {
+				fmt.Fprintln(env.Stderr, "glofox:", msg)
+			}
+		} else {
+			fmt.Fprintf(env.Stderr, "%d of %d rows are invalid; nothing was imported\n", len(report.Errors), report.Rows)
+		}
+		return 1
+	}
+
+	switch {
+	case report.DryRun && len(report.Errors) > 0:
+		fmt.Fprintf(env.Stdout, "checked %d rows: %d invalid\n", report.Rows, len(report.Errors))
+		return 1
+	case report.DryRun:
+		fmt.Fprintf(env.Stdout, "checked %d rows: all valid\n", report.Rows)
+	default:
+		fmt.Fprintf(env.Stdout, "imported %d %s\n", len(report.Imported), kind)
+	}
+	return 0
+}
+
+// importFormat returns the format named by the -format flag or, failing
+// that, by the file's extension.
+func importFormat(name, path string) (records.Format, error) {
+	if name != "" {
+		format, err := records.ParseFormat(name)
+		if err == nil && !format.Readable() {
+			err = fmt.Errorf("%s files cannot be imported", format)
+		}
+		return format, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return records.FormatCSV, nil
+	case ".ndjson", ".jsonl":
+		return records.FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %q, use -format", path)
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEnv(url string) (*Env, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	env := &Env{
+		Stdin:  strings.NewReader(""),
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Getenv: func(key string) string {
+			if key == "GLOFOX_URL" {
+				return url
+			}
+			return ""
+		},
+		Client: http.DefaultClient,
+	}
+	return env, &stdout, &stderr
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestImport_DryRun(t *testing.T) {
+	var gotPath, gotQuery, gotType, gotKey, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotType = r.URL.Path, r.URL.RawQuery, r.Header.Get("Content-Type")
+		gotKey = r.Header.Get("X-Staff-Key")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		io.WriteString(w, `{"code":200,"data":{"dry_run":true,"rows":2}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	path := writeFile(t, "classes.csv", "name,capacity\nYoga,10\nSpin,12\n")
+	code := Run(context.Background(), env, []string{"import", "-dry-run", "-staff-key", "secret", "classes", path})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "/classes/import", gotPath)
+	assert.Equal(t, "dry_run=true", gotQuery)
+	assert.Equal(t, "text/csv", gotType)
+	assert.Equal(t, "secret", gotKey)
+	assert.Equal(t, "name,capacity\nYoga,10\nSpin,12\n", gotBody)
+	assert.Equal(t, "checked 2 rows: all valid\n", stdout.String())
+}
+
+func TestImport_InvalidRows(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"code":400,"data":{"dry_run":false,"rows":3,"errors":[{"row":2,"errors":["name is required"]}]},"errors":["import has invalid rows"]}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, stderr := testEnv(srv.URL)
+	env.Stdin = strings.NewReader(`{"name":"jane"}`)
+	code := Run(context.Background(), env, []string{"import", "-format", "ndjson", "members", "-"})
+
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, "row 2: name is required\n1 of 3 rows are invalid; nothing was imported\n", stderr.String())
+}
+
+func TestImport_Usage(t *testing.T) {
+	env, _, stderr := testEnv("")
+	assert.Equal(t, 2, Run(context.Background(), env, []string{"import", "rooms", "rooms.csv"}))
+	assert.Contains(t, stderr.String(), "usage: glofox import")
+
+	env, _, stderr = testEnv("")
+	assert.Equal(t, 2, Run(context.Background(), env, []string{"import", "classes", "classes.txt"}))
+	assert.Contains(t, stderr.String(), "use -format")
+}
+
+func TestMembersImport(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		io.WriteString(w, `{"code":201,"data":{"dry_run":false,"rows":1,"imported":[{"name":"jane"}]}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	path := writeFile(t, "members.ndjson", `{"name":"jane"}`+"\n")
+	code := Run(context.Background(), env, []string{"members", "import", path})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "/members/import", gotPath)
+	assert.Equal(t, "imported 1 members\n", stdout.String())
+}
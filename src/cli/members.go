@@ -0,0 +1,11 @@
+package cli
+
+import "context"
+
+func runMembers(ctx context.Context, env *Env, args []string) int {
+	return runSubcommand(ctx, env, "members", "import", map[string]command{
+		"import": func(ctx context.Context, env *Env, args []string) int {
+			return importRecords(ctx, env, "members", args)
+		},
+	}, args)
+}
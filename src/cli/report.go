@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Vidyuallatha/glofox/src/components"
+)
+
+func runReport(ctx context.Context, env *Env, args []string) int {
+	return runSubcommand(ctx, env, "report", "utilisation|peak-times|retention", map[string]command{
+		"utilisation": reportCommand("utilisation", writeUtilisation),
+		"peak-times":  reportCommand("peak-times", writePeakTimes),
+		"retention":   reportCommand("retention", writeRetention),
+	}, args)
+}
+
+// reportCommand fetches GET /reports/{name} for -from and -to and prints it
+// as a table with write, or as JSON.
+func reportCommand[T any](name string, write func(env *Env, report *T) error) command {
+	return func(ctx context.Context, env *Env, args []string) int {
+		flags := flag.NewFlagSet("report "+name, flag.ContinueOnError)
+		flags.SetOutput(env.Stderr)
+		opts := apiFlags(flags)
+		from := flags.String("from", "", "first day, as 2006-01-02 (required)")
+		to := flags.String("to", "", "last day, as 2006-01-02 (required)")
+		flags.Usage = func() {
+			fmt.Fprintf(env.Stderr, "usage: glofox report %s -from DAY -to DAY [flags]\n", name)
+			flags.PrintDefaults()
+		}
+		if !parseFlags(flags, args, 0, opts) {
+			return 2
+		}
+		if *from == "" || *to == "" {
+			flags.Usage()
+			return 2
+		}
+
+		query := url.Values{"from": {*from}, "to": {*to}}
+		var report T
+		if err := newClient(env, opts).call(ctx, http.MethodGet, "/reports/"+name, query, nil, &report); err != nil {
+			return fail(env, err)
+		}
+		var err error
+		if opts.output == outputJSON {
+			err = writeJSON(env.Stdout, report)
+		} else {
+			err = write(env, &report)
+		}
+		if err != nil {
+			return fail(env, err)
+		}
+		return 0
+	}
+}
+
+var utilisationHeader = []string{"SESSIONS", "CAPACITY", "BOOKED", "ATTENDED", "NO-SHOWS", "CANCELLED", "FILL", "CANCEL RATE", "NO-SHOW RATE"}
+
+func utilisationCells(u components.Utilisation) []string {
+	return []string{
+		strconv.Itoa(u.Sessions), strconv.Itoa(u.Capacity), strconv.Itoa(u.Booked), strconv.Itoa(u.Attended),
+		strconv.Itoa(u.NoShows), strconv.Itoa(u.Cancelled),
+		percent(u.FillRate), percent(u.CancellationRate), percent(u.NoShowRate),
+	}
+}
+
+func writeUtilisation(env *Env, report *components.UtilisationReport) error {
+	rows := make([][]string, 0, len(report.Classes)+1)
+	for _, c := range report.Classes {
+		rows = append(rows, append([]string{strconv.Itoa(c.ClassID), c.ClassName}, utilisationCells(c.Utilisation)...))
+	}
+	rows = append(rows, append([]string{"", "TOTAL"}, utilisationCells(report.Total)...))
+	return writeTable(env.Stdout, append([]string{"ID", "CLASS"}, utilisationHeader...), rows)
+}
+
+func writePeakTimes(env *Env, report *components.PeakTimesReport) error {
+	rows := make([][]string, 0, len(report.Slots))
+	for _, s := range report.Slots {
+		rows = append(rows, append([]string{s.Weekday, fmt.Sprintf("%02d:00", s.Hour)}, utilisationCells(s.Utilisation)...))
+	}
+	return writeTable(env.Stdout, append([]string{"WEEKDAY", "HOUR"}, utilisationHeader...), rows)
+}
+
+// writeRetention writes one row per cohort with the share of it active in
+// each month after the first, as M+1, M+2 and so on.
+func writeRetention(env *Env, report *components.RetentionReport) error {
+	header := []string{"COHORT", "MEMBERS"}
+	rows := make([][]string, 0, len(report.Cohorts))
+	for _, c := range report.Cohorts {
+		row := []string{c.Month, strconv.Itoa(c.Members)}
+		for i, r := range c.Retention[min(1, len(c.Retention)):] {
+			if len(header) < i+3 {
+				header = append(header, fmt.Sprintf("M+%d", i+1))
+			}
+			row = append(row, percent(r))
+		}
+		rows = append(rows, row)
+	}
+	return writeTable(env.Stdout, header, rows)
+}
+
+func percent(rate float64) string {
+	return fmt.Sprintf("%.1f%%", rate*100)
+}
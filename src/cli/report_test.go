@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport_Utilisation(t *testing.T) {
+	var gotPath, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		io.WriteString(w, `{"code":200,"data":{"from":"2025-04-01","to":"2025-04-30",
+			"total":{"sessions":4,"capacity":14,"booked":5,"attended":3,"no_shows":1,"cancelled":1,"fill_rate":0.3571,"cancellation_rate":0.1667,"no_show_rate":0.25},
+			"classes":[{"class_id":1,"class_name":"Yoga","sessions":4,"capacity":14,"booked":5,"attended":3,"no_shows":1,"cancelled":1,"fill_rate":0.3571,"cancellation_rate":0.1667,"no_show_rate":0.25}]}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	code := Run(context.Background(), env, []string{"report", "utilisation", "-from", "2025-04-01", "-to", "2025-04-30"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "/reports/utilisation", gotPath)
+	assert.Equal(t, "from=2025-04-01&to=2025-04-30", gotQuery)
+	assert.Equal(t, ""+
+		"ID  CLASS  SESSIONS  CAPACITY  BOOKED  ATTENDED  NO-SHOWS  CANCELLED  FILL   CANCEL RATE  NO-SHOW RATE\n"+
+		"1   Yoga   4         14        5       3         1         1          35.7%  16.7%        25.0%\n"+
+		"    TOTAL  4         14        5       3         1         1          35.7%  16.7%        25.0%\n", stdout.String())
+}
+
+func TestReport_Retention(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"code":200,"data":{"from":"2025-03-01","to":"2025-04-30","cohorts":[
+			{"month":"2025-03","members":2,"active":[2,1],"retention":[1,0.5]},
+			{"month":"2025-04","members":1,"active":[1],"retention":[1]}]}}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, _ := testEnv(srv.URL)
+	code := Run(context.Background(), env, []string{"report", "retention", "-from", "2025-03-01", "-to", "2025-04-30"})
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, ""+
+		"COHORT   MEMBERS  M+1\n"+
+		"2025-03  2        50.0%\n"+
+		"2025-04  1\n", stdout.String())
+}
+
+func TestReport_RequiresRange(t *testing.T) {
+	env, _, stderr := testEnv("")
+	assert.Equal(t, 2, Run(context.Background(), env, []string{"report", "peak-times", "-from", "2025-04-01"}))
+	assert.Contains(t, stderr.String(), "usage: glofox report peak-times -from DAY -to DAY")
+}
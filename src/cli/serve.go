@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/backup"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/controllers"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/events"
+	"github.com/Vidyuallatha/glofox/src/notify"
+	"github.com/Vidyuallatha/glofox/src/payments"
+	"github.com/Vidyuallatha/glofox/src/scheduler"
+	"github.com/Vidyuallatha/glofox/src/server"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+)
+
+// DefaultAddr is the address serve listens on unless -addr says otherwise.
+const DefaultAddr = ":9000"
+
+// shutdownTimeout bounds how long in-flight requests and jobs get to finish
+// on shutdown.
+const shutdownTimeout = 30 * time.Second
+
+// runServe runs the booking service with its background jobs until it is
+// interrupted. It is configured from the environment.
+func runServe(ctx context.Context, env *Env, args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	addr := flags.String("addr", DefaultAddr, "address to listen on")
+	flags.Usage = func() {
+		fmt.Fprintln(env.Stderr, "usage: glofox serve [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(env.Stdout, nil)))
+
+	shutdownTracing, err := telemetry.Setup(ctx, env.Getenv(telemetry.ExporterEnv))
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		return 1
+	}
+	defer shutdownTracing(context.Background())
+
+	serverURL := "http://localhost" + *addr
+	fmt.Fprintln(env.Stdout, "==============================GLOFOX==============================")
+	fmt.Fprintln(env.Stdout, "Server listening on", serverURL)
+
+	cfg := server.Config{
+		ValidateResponses: env.Getenv("OPENAPI_VALIDATE_RESPONSES") == "true",
+		StaffAPIKey:       env.Getenv("STAFF_API_KEY"),
+	}
+	if url := env.Getenv("PAYMENT_GATEWAY_URL"); url != "" {
+		cfg.PaymentGateway = payments.NewHTTPGateway(url, env.Getenv("PAYMENT_GATEWAY_API_KEY"))
+	}
+	// SMS has no gateway yet, so it only ever goes to the dev notifiers:
+	// stdout by default, or NOTIFY_FILE
+	cfg.Notifiers = map[entities.NotificationChannel]notify.Notifier{}
+	if path := env.Getenv("NOTIFY_FILE"); path != "" {
+		file, err := notify.NewFileNotifier(path)
+		if err != nil {
+			slog.Error("failed to open notification file", "path", path, "error", err)
+			return 1
+		}
+		cfg.Notifiers[entities.ChannelEmail] = file
+		cfg.Notifiers[entities.ChannelSMS] = file
+	}
+	if addr := env.Getenv("SMTP_ADDR"); addr != "" {
+		cfg.Notifiers[entities.ChannelEmail] = notify.NewSMTPNotifier(addr, env.Getenv("SMTP_FROM"),
+			env.Getenv("SMTP_USERNAME"), env.Getenv("SMTP_PASSWORD"))
+	}
+
+	handler, err := server.NewHandler(cfg)
+	if err != nil {
+		slog.Error("failed to load openapi spec", "error", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The store is restored from its backups before anything writes to it
+	backups := controllers.BackupsComponent()
+	if path := env.Getenv("BACKUP_DIR"); path != "" {
+		dir, err := backup.Open(path)
+		if err != nil {
+			slog.Error("failed to open backup directory", "path", path, "error", err)
+			return 1
+		}
+		defer dir.Close()
+		backups.Dir = dir
+		if keep := env.Getenv("BACKUP_KEEP"); keep != "" {
+			if backups.Keep, err = strconv.Atoi(keep); err != nil || backups.Keep < 1 {
+				slog.Error("BACKUP_KEEP must be a positive number", "value", keep)
+				return 1
+			}
+		}
+		if err := backups.Start(ctx); err != nil {
+			slog.Error("failed to restore from backups", "path", path, "error", err)
+			return 1
+		}
+	}
+
+	// Class events recorded from here on keep the search index up to date
+	if err := controllers.SearchComponent().Rebuild(ctx); err != nil {
+		slog.Error("failed to build the search index", "error", err)
+		return 1
+	}
+
+	dispatcher := events.NewDispatcher(&entities.EventEntity{}, clock.Real{})
+	if env.Getenv("EVENTS_SINK") == "stdout" {
+		dispatcher.AddSink("stdout", events.NewWriterSink(env.Stdout))
+	}
+	dispatcher.AddSink("webhooks", controllers.WebhooksComponent())
+
+	jobs := scheduler.New(&entities.JobEntity{}, clock.Real{})
+	notifications := controllers.NotificationsComponent()
+	notifications.Reminders = jobs
+	dispatcher.Subscribe("notifications", notifications.HandleEvent,
+		entities.EventBookingCreated, entities.EventBookingCancelled, entities.EventWaitlistPromoted,
+		entities.EventClassCancelled)
+	dispatcher.Subscribe("availability", controllers.AvailabilityComponent().HandleEvent,
+		entities.EventBookingCreated, entities.EventBookingCancelled, entities.EventWaitlistPromoted)
+	dispatcher.Subscribe("search", controllers.SearchComponent().HandleEvent,
+		entities.EventClassCreated, entities.EventClassCancelled)
+	jobs.Handle(components.ReminderJob, notifications.SendReminderJob)
+	type recurringJob struct {
+		kind, spec string
+		handler    scheduler.Handler
+	}
+	recurring := []recurringJob{
+		{"mark-no-shows", "@every 1m", controllers.AttendanceComponent().MarkNoShowsJob},
+		{"release-waitlists", "@every 1m", controllers.BookingsComponent().ReleaseWaitlistsJob},
+		{"dispatch-events", "@every 1s", dispatcher.DispatchJob},
+		{"send-webhooks", "@every 1s", controllers.WebhooksComponent().SendDueJob},
+	}
+	if backups.Dir != nil {
+		schedule := env.Getenv("BACKUP_SCHEDULE")
+		if schedule == "" {
+			schedule = "@hourly"
+		}
+		recurring = append(recurring,
+			recurringJob{"flush-backup-log", "@every 1s", backups.FlushJob},
+			recurringJob{"take-backup", schedule, backups.TakeBackupJob})
+	}
+	for _, job := range recurring {
+		jobs.Handle(job.kind, job.handler)
+		if err := jobs.Every(ctx, job.kind, job.spec); err != nil {
+			slog.Error("failed to schedule job", "kind", job.kind, "error", err)
+			return 1
+		}
+	}
+	jobs.Start(ctx)
+
+	srv := &http.Server{Addr: *addr, Handler: handler}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+	slog.Info("server running", "url", serverURL)
+
+	select {
+	case err := <-serveErr:
+		slog.Error("server stopped", "error", err)
+		return 1
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("failed to shut down server", "error", err)
+	}
+	if err := jobs.Stop(shutdownCtx); err != nil {
+		slog.Error("failed to stop jobs", "error", err)
+	}
+	if backups.Dir != nil {
+		if _, err := backups.Flush(shutdownCtx); err != nil {
+			slog.Error("failed to flush backup log", "error", err)
+		}
+	}
+	return 0
+}
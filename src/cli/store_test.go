@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/backup"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_BackupAndMigrate(t *testing.T) {
+	t.Cleanup(func() { entities.Classes = nil })
+	start := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	d, err := backup.Open(t.TempDir())
+	require.NoError(t, err)
+	_, err = d.WriteSnapshot(&entities.Snapshot{TakenAt: start, Tables: map[string]json.RawMessage{}})
+	require.NoError(t, err)
+	var mutations []entities.Mutation
+	for i, name := range []string{"Yoga", "Spin"} {
+		record, err := json.Marshal(entities.Class{ID: i + 1, ClassName: name})
+		require.NoError(t, err)
+		mutations = append(mutations, entities.Mutation{
+			Seq: int64(i + 1), At: start.Add(time.Duration(i+1) * time.Minute), Table: "classes", Index: i, Record: record,
+		})
+	}
+	require.NoError(t, d.Append(mutations))
+	require.NoError(t, d.Close())
+
+	env, stdout, _ := testEnv("")
+	code := Run(context.Background(), env, []string{"store", "backup", "-dir", d.Path()})
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "backup snapshot-00000000000000000002-")
+	assert.Contains(t, stdout.String(), "taken at mutation 2\n")
+	s, rest, err := d.Load(time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, rest, "the log is folded into the snapshot")
+	var classes []entities.Class
+	require.NoError(t, json.Unmarshal(s.Tables["classes"], &classes))
+	assert.Len(t, classes, 2)
+
+	env, stdout, _ = testEnv("")
+	env.Getenv = func(key string) string {
+		return map[string]string{"BACKUP_DIR": d.Path()}[key]
+	}
+	code = Run(context.Background(), env, []string{"store", "migrate"})
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "store migrated to snapshot-00000000000000000002-")
+	infos, err := d.Snapshots()
+	require.NoError(t, err)
+	assert.Len(t, infos, 3, "migrating writes a new snapshot even when nothing changed")
+	s, _, err = d.Load(time.Time{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"id":1,"class_name":"Yoga","start_date":"0001-01-01T00:00:00Z","end_date":"0001-01-01T00:00:00Z","capacity":0},
+		{"id":2,"class_name":"Spin","start_date":"0001-01-01T00:00:00Z","end_date":"0001-01-01T00:00:00Z","capacity":0}
+	]`, string(s.Tables["classes"]))
+
+	env, _, stderr := testEnv("")
+	assert.Equal(t, 2, Run(context.Background(), env, []string{"store", "restore", "-dir", d.Path()}))
+	assert.Contains(t, stderr.String(), "usage: glofox store restore")
+
+	env, _, stderr = testEnv("")
+	assert.Equal(t, 1, Run(context.Background(), env, []string{"store", "migrate"}))
+	assert.Contains(t, stderr.String(), "no backup directory")
+}
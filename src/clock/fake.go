@@ -0,0 +1,157 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Timers and tickers fire
+// as Advance or Set moves the time past their deadline.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	// added is signalled whenever a timer or ticker is created.
+	added chan struct{}
+}
+
+type waiter struct {
+	deadline time.Time
+	// period is zero for one-shot timers.
+	period  time.Duration
+	c       chan time.Time
+	stopped bool
+}
+
+// NewFake returns a fake clock stopped at now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, added: make(chan struct{}, 1)}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.addWaiter(d, 0).c
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return &fakeTicker{clock: f, w: f.addWaiter(d, d)}
+}
+
+func (f *Fake) addWaiter(d, period time.Duration) *waiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Buffered like time.Timer and time.Ticker, so a slow reader misses
+	// ticks rather than blocking the clock.
+	w := &waiter{deadline: f.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	if d <= 0 && period == 0 {
+		w.c <- f.now
+		return w
+	}
+	f.waiters = append(f.waiters, w)
+	select {
+	case f.added <- struct{}{}:
+	default:
+	}
+	return w
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker whose
+// deadline is passed, in deadline order.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t. Moving it backwards fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		w := f.nextDue(t)
+		if w == nil {
+			break
+		}
+		f.now = w.deadline
+		select {
+		case w.c <- w.deadline:
+		default:
+		}
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			w.stopped = true
+		}
+	}
+	f.now = t
+	f.prune()
+}
+
+// nextDue returns the waiter with the earliest deadline not after t.
+// Callers must hold f.mu.
+func (f *Fake) nextDue(t time.Time) *waiter {
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].deadline.Before(f.waiters[j].deadline)
+	})
+	for _, w := range f.waiters {
+		if !w.stopped && !w.deadline.After(t) {
+			return w
+		}
+	}
+	return nil
+}
+
+// prune drops stopped waiters. Callers must hold f.mu.
+func (f *Fake) prune() {
+	active := f.waiters[:0]
+	for _, w := range f.waiters {
+		if !w.stopped {
+			active = append(active, w)
+		}
+	}
+	f.waiters = active
+}
+
+// Waiters returns how many timers and tickers are pending.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, w := range f.waiters {
+		if !w.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+// BlockUntil waits until at least n timers and tickers are pending, so a
+// test can advance the clock once the code under test is waiting on it.
+func (f *Fake) BlockUntil(n int) {
+	for f.Waiters() < n {
+		<-f.added
+	}
+}
+
+type fakeTicker struct {
+	clock *Fake
+	w     *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.w.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.w.stopped = true
+	t.clock.prune()
+}
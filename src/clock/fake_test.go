@@ -0,0 +1,79 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFake_Advance(t *testing.T) {
+	c := NewFake(start)
+
+	c.Advance(90 * time.Minute)
+
+	assert.Equal(t, start.Add(90*time.Minute), c.Now())
+}
+
+func TestFake_After(t *testing.T) {
+	c := NewFake(start)
+	fired := c.After(time.Hour)
+
+	c.Advance(59 * time.Minute)
+	assert.Empty(t, fired)
+
+	c.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Hour), <-fired)
+	assert.Equal(t, 0, c.Waiters())
+}
+
+func TestFake_After_NonPositive(t *testing.T) {
+	c := NewFake(start)
+
+	assert.Equal(t, start, <-c.After(0))
+}
+
+func TestFake_Ticker(t *testing.T) {
+	c := NewFake(start)
+	ticker := c.NewTicker(time.Minute)
+
+	c.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), <-ticker.C())
+
+	// Ticks missed by a slow reader are dropped, like time.Ticker
+	c.Advance(3 * time.Minute)
+	assert.Equal(t, start.Add(2*time.Minute), <-ticker.C())
+	assert.Empty(t, ticker.C())
+
+	ticker.Stop()
+	c.Advance(time.Minute)
+	assert.Empty(t, ticker.C())
+	assert.Equal(t, 0, c.Waiters())
+}
+
+func TestFake_FiresInDeadlineOrder(t *testing.T) {
+	c := NewFake(start)
+	late := c.After(2 * time.Hour)
+	early := c.After(time.Hour)
+
+	c.Advance(3 * time.Hour)
+
+	assert.Equal(t, start.Add(time.Hour), <-early)
+	assert.Equal(t, start.Add(2*time.Hour), <-late)
+	assert.Equal(t, start.Add(3*time.Hour), c.Now())
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(start)
+	done := make(chan time.Time)
+	go func() {
+		done <- <-c.After(time.Minute)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+
+	assert.Equal(t, start.Add(time.Minute), <-done)
+}
@@ -0,0 +1,161 @@
+package components
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+const (
+	// CheckInOpensBefore and CheckInClosesAfter bound the check-in window
+	// around the start of the booked session.
+	CheckInOpensBefore = 30 * time.Minute
+	CheckInClosesAfter = 15 * time.Minute
+
+	CheckedInByStaff = "staff"
+	CheckedInByQR    = "qr"
+)
+
+var (
+	ErrInvalidCheckInToken = errors.New("invalid check-in token")
+	ErrCheckInNotOpen      = errors.New("check-in is not open yet")
+	ErrCheckInClosed       = errors.New("check-in has closed")
+)
+
+type AttendanceComponent struct {
+	entities.BookingRepository
+	// Clock tells the time the check-in window is checked against and
+	// drives the no-show job.
+	Clock clock.Clock
+}
+
+func InitAttendanceComponent() *AttendanceComponent {
+	return &AttendanceComponent{
+		BookingRepository: &entities.BookingEntity{},
+		Clock:             clock.Real{},
+	}
+}
+
+// CheckIn marks a booking as attended. Members check in with the token from
+// their QR code; staff check members in without one.
+func (ac *AttendanceComponent) CheckIn(ctx context.Context, id int, token string, byStaff bool) (booking *entities.Booking, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "AttendanceComponent.CheckIn",
+		attribute.Int("booking.id", id), attribute.Bool("check_in.staff", byStaff))
+	outcome := "checked_in"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	booking, err = ac.FindBooking(ctx, id)
+	if errors.Is(err, entities.ErrNotFound) {
+		outcome = "not_found"
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if !byStaff && subtle.ConstantTimeCompare([]byte(token), []byte(booking.CheckInToken)) != 1 {
+		outcome = "invalid_token"
+		return nil, ErrInvalidCheckInToken
+	}
+	if booking.Status != entities.BookingConfirmed {
+		outcome = "not_confirmed"
+		return nil, fmt.Errorf("cannot check in a %s booking", booking.Status)
+	}
+
+	class, err := ac.FindClass(ctx, booking.ClassID)
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	now := ac.Clock.Now()
+	sessionStart, _ := class.Session(booking.Date)
+	if now.Before(sessionStart.Add(-CheckInOpensBefore)) {
+		outcome = "too_early"
+		return nil, ErrCheckInNotOpen
+	}
+	if now.After(sessionStart.Add(CheckInClosesAfter)) {
+		outcome = "too_late"
+		return nil, ErrCheckInClosed
+	}
+
+	booking.Status = entities.BookingAttended
+	booking.CheckedInAt = &now
+	booking.CheckedInBy = CheckedInByQR
+	if byStaff {
+		booking.CheckedInBy = CheckedInByStaff
+	}
+	if err := ac.UpdateBooking(ctx, booking); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	utils.Logger(ctx).Info("member checked in", "booking_id", booking.ID, "by", booking.CheckedInBy)
+	return booking, nil
+}
+
+// MarkNoShows flags confirmed bookings whose session ended before now
+// without a check-in, and counts them against the member.
+func (ac *AttendanceComponent) MarkNoShows(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := telemetry.StartSpan(ctx, "AttendanceComponent.MarkNoShows")
+	bookings, err := ac.ListBookings(ctx, entities.BookingFilter{Status: entities.BookingConfirmed, To: now})
+	if err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return 0, err
+	}
+
+	marked := 0
+	for i := range bookings {
+		booking := &bookings[i]
+		class, err := ac.FindClass(ctx, booking.ClassID)
+		if err != nil {
+			telemetry.EndSpan(span, "error", err)
+			return marked, err
+		}
+		if _, sessionEnd := class.Session(booking.Date); now.Before(sessionEnd) {
+			continue
+		}
+
+		// The booking and the member's counter change together, and a
+		// member who checked in meanwhile is left alone
+		ok, err := ac.MarkNoShow(ctx, booking.ID)
+		if err != nil {
+			telemetry.EndSpan(span, "error", err)
+			return marked, err
+		}
+		if ok {
+			marked++
+		}
+	}
+
+	span.SetAttributes(attribute.Int("bookings.no_show", marked))
+	telemetry.EndSpan(span, "marked", nil)
+	if marked > 0 {
+		utils.Logger(ctx).Info("marked no-shows", "count", marked)
+	}
+	return marked, nil
+}
+
+// MarkNoShowsJob is the scheduler handler that runs MarkNoShows. Marking
+// is idempotent, so the job is safe to run again after an interruption.
+func (ac *AttendanceComponent) MarkNoShowsJob(ctx context.Context, _ entities.Job) error {
+	_, err := ac.MarkNoShows(ctx, ac.Clock.Now())
+	return err
+}
+
+// newToken returns a random 128-bit token in hex, used for check-in and
+// calendar feed tokens.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
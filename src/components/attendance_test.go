@@ -0,0 +1,194 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttendanceComponent_CheckIn(t *testing.T) {
+	now := testNow
+	const token = "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name         string
+		status       entities.BookingStatus
+		sessionStart time.Time
+		token        string
+		byStaff      bool
+		expectErr    string
+		wantBy       string
+	}{
+		{
+			name:         "should check in with the booking's QR token",
+			status:       entities.BookingConfirmed,
+			sessionStart: now.Add(10 * time.Minute),
+			token:        token,
+			wantBy:       CheckedInByQR,
+		},
+		{
+			name:         "should let staff check in without a token",
+			status:       entities.BookingConfirmed,
+			sessionStart: now.Add(-10 * time.Minute),
+			byStaff:      true,
+			wantBy:       CheckedInByStaff,
+		},
+		{
+			name:         "should reject a wrong token",
+			status:       entities.BookingConfirmed,
+			sessionStart: now,
+			token:        "wrong",
+			expectErr:    ErrInvalidCheckInToken.Error(),
+		},
+		{
+			name:         "should reject a check-in before the window opens",
+			status:       entities.BookingConfirmed,
+			sessionStart: now.Add(CheckInOpensBefore + time.Minute),
+			token:        token,
+			expectErr:    ErrCheckInNotOpen.Error(),
+		},
+		{
+			name:         "should reject a check-in after the window closes",
+			status:       entities.BookingConfirmed,
+			sessionStart: now.Add(-CheckInClosesAfter - time.Minute),
+			token:        token,
+			expectErr:    ErrCheckInClosed.Error(),
+		},
+		{
+			name:         "should reject a cancelled booking",
+			status:       entities.BookingCancelled,
+			sessionStart: now,
+			token:        token,
+			expectErr:    "cannot check in a cancelled booking",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *entities.Booking
+			ac := &AttendanceComponent{
+				BookingRepository: &MockBookingRepository{
+					FindBookingFn: func(ctx context.Context, id int) (*entities.Booking, error) {
+						return &entities.Booking{ID: id, ClassID: 1, Date: tt.sessionStart, Status: tt.status, CheckInToken: token}, nil
+					},
+					FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+						return &entities.Class{ID: id, StartDate: tt.sessionStart, EndDate: tt.sessionStart.Add(time.Hour)}, nil
+					},
+					UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+						updated = b
+						return nil
+					},
+				},
+				Clock: clock.NewFake(now),
+			}
+
+			got, err := ac.CheckIn(context.Background(), 1, tt.token, tt.byStaff)
+
+			if tt.expectErr != "" {
+				assert.EqualError(t, err, tt.expectErr)
+				assert.Nil(t, updated)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, entities.BookingAttended, got.Status)
+			assert.Equal(t, tt.wantBy, got.CheckedInBy)
+			assert.NotNil(t, got.CheckedInAt)
+			assert.Equal(t, got, updated)
+		})
+	}
+}
+
+func TestAttendanceComponent_CheckIn_NotFound(t *testing.T) {
+	ac := &AttendanceComponent{BookingRepository: &MockBookingRepository{}}
+
+	_, err := ac.CheckIn(context.Background(), 1, "token", false)
+
+	assert.ErrorIs(t, err, ErrBookingNotFound)
+}
+
+func TestAttendanceComponent_MarkNoShows(t *testing.T) {
+	now := time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC)
+	class := &entities.Class{
+		ID:        1,
+		StartDate: time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC),
+	}
+	bookings := []entities.Booking{
+		{ID: 1, ClassID: 1, Name: "Ann", Date: time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC), Status: entities.BookingConfirmed},
+		{ID: 2, ClassID: 1, Name: "Bob", Date: time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC), Status: entities.BookingConfirmed},
+	}
+
+	var marked []int
+	var filter entities.BookingFilter
+	ac := &AttendanceComponent{
+		BookingRepository: &MockBookingRepository{
+			ListBookingsFn: func(ctx context.Context, f entities.BookingFilter) ([]entities.Booking, error) {
+				filter = f
+				return bookings, nil
+			},
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				return class, nil
+			},
+			MarkNoShowFn: func(ctx context.Context, id int) (bool, error) {
+				marked = append(marked, id)
+				// Bob checked in after the bookings were listed
+				return id != 2, nil
+			},
+		},
+	}
+
+	// Both sessions ended at 10:00, before now.
+	count, err := ac.MarkNoShows(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.BookingFilter{Status: entities.BookingConfirmed, To: now}, filter)
+	assert.Equal(t, []int{1, 2}, marked)
+	assert.Equal(t, 1, count)
+}
+
+func TestAttendanceComponent_MarkNoShows_SessionNotOver(t *testing.T) {
+	now := time.Date(2025, 5, 7, 9, 30, 0, 0, time.UTC)
+	ac := &AttendanceComponent{
+		BookingRepository: &MockBookingRepository{
+			ListBookingsFn: func(ctx context.Context, f entities.BookingFilter) ([]entities.Booking, error) {
+				return []entities.Booking{{ID: 1, ClassID: 1, Name: "Ann", Date: time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC), Status: entities.BookingConfirmed}}, nil
+			},
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				return &entities.Class{
+					ID:        1,
+					StartDate: time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC),
+					EndDate:   time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC),
+				}, nil
+			},
+			MarkNoShowFn: func(ctx context.Context, id int) (bool, error) {
+				t.Errorf("booking %d marked before its session ended", id)
+				return true, nil
+			},
+		},
+	}
+
+	marked, err := ac.MarkNoShows(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, marked)
+}
+
+func TestAttendanceComponent_MarkNoShowsJob(t *testing.T) {
+	var marked entities.BookingFilter
+	ac := &AttendanceComponent{
+		BookingRepository: &MockBookingRepository{
+			ListBookingsFn: func(ctx context.Context, f entities.BookingFilter) ([]entities.Booking, error) {
+				marked = f
+				return nil, nil
+			},
+		},
+		Clock: clock.NewFake(testNow),
+	}
+
+	assert.NoError(t, ac.MarkNoShowsJob(context.Background(), entities.Job{Kind: "mark-no-shows"}))
+	assert.Equal(t, testNow, marked.To)
+}
@@ -0,0 +1,256 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"sync"
+	"time"
+)
+
+const (
+	// HeartbeatInterval is how often an idle availability stream is sent a
+	// comment so that proxies and clients keep the connection open.
+	HeartbeatInterval = 15 * time.Second
+	// availabilityHistory is how many updates per class are kept for
+	// clients resuming with Last-Event-ID.
+	availabilityHistory = 100
+	// subscriberBuffer is how many updates a subscriber may fall behind by
+	// before it is dropped.
+	subscriberBuffer = 16
+)
+
+var ErrClassNotFound = errors.New("class not found")
+
+// Availability is how many spots are left in one session of a class and
+// how many members are waiting for one.
+type Availability struct {
+	ClassID  int       `json:"class_id"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Capacity int       `json:"capacity"`
+	Booked   int       `json:"booked"`
+	// Remaining is negative when a session holds more bookings than its
+	// capacity, e.g. after the capacity was lowered.
+	Remaining  int `json:"remaining"`
+	Waitlisted int `json:"waitlisted"`
+}
+
+// AvailabilityUpdate is one message on a class's availability stream. ID is
+// the ID of the booking event that caused it, so that a client resuming with
+// Last-Event-ID is sent the updates it missed.
+type AvailabilityUpdate struct {
+	ID           int
+	Availability Availability
+}
+
+// AvailabilitySubscription receives the availability updates of one class.
+type AvailabilitySubscription struct {
+	// Backlog is sent before any update: the updates missed since the
+	// Last-Event-ID the client resumed from or, when those are no longer
+	// kept, the availability of the class's next session.
+	Backlog []AvailabilityUpdate
+	// Updates is closed when the subscriber falls too far behind. The client
+	// is expected to reconnect with Last-Event-ID.
+	Updates <-chan AvailabilityUpdate
+
+	cancel func()
+}
+
+// Close stops the subscription.
+func (s *AvailabilitySubscription) Close() {
+	s.cancel()
+}
+
+// AvailabilityComponent streams the remaining capacity of classes as
+// bookings are made and cancelled.
+type AvailabilityComponent struct {
+	entities.BookingRepository
+	// Clock picks the session a new subscriber is first sent.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	streams map[int]*classStream
+}
+
+// classStream fans the updates of one class out to its subscribers.
+type classStream struct {
+	history []AvailabilityUpdate
+	// trimmed is the ID of the newest update dropped from history.
+	trimmed     int
+	subscribers map[chan AvailabilityUpdate]struct{}
+}
+
+func InitAvailabilityComponent() *AvailabilityComponent {
+	return &AvailabilityComponent{
+		BookingRepository: &entities.BookingEntity{},
+		Clock:             clock.Real{},
+	}
+}
+
+// Availability counts the bookings holding a spot in the session of class on
+// the day of date and those waiting for one.
+func (ac *AvailabilityComponent) Availability(ctx context.Context, class *entities.Class, date time.Time) (Availability, error) {
+	start, end := class.Session(date)
+	bookings, err := ac.ListBookings(ctx, entities.BookingFilter{ClassID: class.ID})
+	if err != nil {
+		return Availability{}, err
+	}
+	booked, waitlisted := 0, 0
+	for _, b := range bookings {
+		if s, _ := class.Session(b.Date); !s.Equal(start) {
+			continue
+		}
+		switch {
+		case b.HoldsSpot():
+			booked++
+		case b.Status == entities.BookingWaitlisted:
+			waitlisted++
+		}
+	}
+	return Availability{
+		ClassID:    class.ID,
+		Start:      start,
+		End:        end,
+		Capacity:   class.Capacity,
+		Booked:     booked,
+		Remaining:  class.Capacity - booked,
+		Waitlisted: waitlisted,
+	}, nil
+}
+
+// Subscribe starts streaming the availability of a class. lastEventID is the
+// Last-Event-ID a client resumes from, zero for a new client.
+func (ac *AvailabilityComponent) Subscribe(ctx context.Context, classID, lastEventID int) (sub *AvailabilitySubscription, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "AvailabilityComponent.Subscribe",
+		attribute.Int("class.id", classID), attribute.Int("last_event_id", lastEventID))
+	outcome := "subscribed"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	class, err := ac.FindClass(ctx, classID)
+	if errors.Is(err, entities.ErrNotFound) {
+		outcome = "not_found"
+		return nil, ErrClassNotFound
+	}
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+
+	updates := make(chan AvailabilityUpdate, subscriberBuffer)
+	ac.mu.Lock()
+	stream := ac.stream(classID)
+	stream.subscribers[updates] = struct{}{}
+	var backlog []AvailabilityUpdate
+	resumed := lastEventID > 0 && lastEventID >= stream.trimmed
+	if resumed {
+		for _, u := range stream.history {
+			if u.ID > lastEventID {
+				backlog = append(backlog, u)
+			}
+		}
+	}
+	latest := lastEventID
+	if n := len(stream.history); n > 0 {
+		latest = stream.history[n-1].ID
+	}
+	ac.mu.Unlock()
+
+	sub = &AvailabilitySubscription{
+		Backlog: backlog,
+		Updates: updates,
+		cancel:  func() { ac.unsubscribe(classID, updates) },
+	}
+	if resumed {
+		outcome = "resumed"
+		return sub, nil
+	}
+
+	// New clients, and clients that missed more than is kept, start from
+	// the current state
+	start, _, ok := class.NextSession(ac.Clock.Now())
+	if !ok {
+		return sub, nil
+	}
+	availability, err := ac.Availability(ctx, class, start)
+	if err != nil {
+		outcome = "error"
+		sub.Close()
+		return nil, err
+	}
+	sub.Backlog = []AvailabilityUpdate{{ID: latest, Availability: availability}}
+	return sub, nil
+}
+
+// HandleEvent publishes the availability of the session a booking event is
+// about. It is subscribed to the event dispatcher and never waits on
+// subscribers: one that is too far behind is dropped instead.
+func (ac *AvailabilityComponent) HandleEvent(ctx context.Context, event entities.Event) error {
+	// Created, cancelled and promoted events carry the booking the same way
+	var payload entities.BookingCreatedEvent
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	class, err := ac.FindClass(ctx, payload.Booking.ClassID)
+	if err != nil {
+		return err
+	}
+	availability, err := ac.Availability(ctx, class, payload.Booking.Date)
+	if err != nil {
+		return err
+	}
+	ac.publish(AvailabilityUpdate{ID: event.ID, Availability: availability})
+	return nil
+}
+
+func (ac *AvailabilityComponent) publish(update AvailabilityUpdate) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	stream := ac.stream(update.Availability.ClassID)
+	if n := len(stream.history); n > 0 && stream.history[n-1].ID >= update.ID {
+		// Already published: the dispatcher delivers at least once
+		return
+	}
+	stream.history = append(stream.history, update)
+	if len(stream.history) > availabilityHistory {
+		stream.trimmed = stream.history[0].ID
+		stream.history = stream.history[1:]
+	}
+	for updates := range stream.subscribers {
+		select {
+		case updates <- update:
+		default:
+			delete(stream.subscribers, updates)
+			close(updates)
+		}
+	}
+}
+
+func (ac *AvailabilityComponent) unsubscribe(classID int, updates chan AvailabilityUpdate) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	stream := ac.stream(classID)
+	if _, ok := stream.subscribers[updates]; ok {
+		delete(stream.subscribers, updates)
+		close(updates)
+	}
+}
+
+// stream returns the stream of classID, creating it if needed. ac.mu must be
+// held.
+func (ac *AvailabilityComponent) stream(classID int) *classStream {
+	if ac.streams == nil {
+		ac.streams = map[int]*classStream{}
+	}
+	stream, ok := ac.streams[classID]
+	if !ok {
+		stream = &classStream{subscribers: map[chan AvailabilityUpdate]struct{}{}}
+		ac.streams[classID] = stream
+	}
+	return stream
+}
@@ -0,0 +1,157 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAvailability returns a component over a daily class running from
+// 2 to 10 May 2025, 09:00 to 10:00, with bookings kept in memory.
+func newTestAvailability() (*AvailabilityComponent, *[]entities.Booking) {
+	class := &entities.Class{
+		ID:        1,
+		ClassName: "Yoga",
+		StartDate: time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 5, 10, 10, 0, 0, 0, time.UTC),
+		Capacity:  2,
+	}
+	bookings := &[]entities.Booking{}
+	ac := &AvailabilityComponent{
+		BookingRepository: &MockBookingRepository{
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				if id != class.ID {
+					return nil, entities.ErrNotFound
+				}
+				return class, nil
+			},
+			ListBookingsFn: func(ctx context.Context, filter entities.BookingFilter) ([]entities.Booking, error) {
+				return *bookings, nil
+			},
+		},
+		Clock: clock.NewFake(testNow),
+	}
+	return ac, bookings
+}
+
+// bookingEvent returns the outbox event of a booking being made.
+func bookingEvent(t *testing.T, id int, booking entities.Booking) entities.Event {
+	payload, err := json.Marshal(entities.BookingCreatedEvent{Booking: &booking})
+	require.NoError(t, err)
+	return entities.Event{ID: id, Type: entities.EventBookingCreated, ClassID: booking.ClassID, Payload: payload}
+}
+
+func TestAvailabilityComponent_Availability(t *testing.T) {
+	ac, bookings := newTestAvailability()
+	may3 := time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC)
+	*bookings = []entities.Booking{
+		{ID: 1, ClassID: 1, Date: may3, Status: entities.BookingConfirmed},
+		{ID: 2, ClassID: 1, Date: may3, Status: entities.BookingPending},
+		{ID: 3, ClassID: 1, Date: may3, Status: entities.BookingAttended},
+		{ID: 4, ClassID: 1, Date: may3, Status: entities.BookingCancelled},
+		{ID: 5, ClassID: 1, Date: may3.AddDate(0, 0, 1), Status: entities.BookingConfirmed},
+		{ID: 6, ClassID: 1, Date: may3, Status: entities.BookingWaitlisted},
+	}
+	class, _ := ac.FindClass(context.Background(), 1)
+
+	availability, err := ac.Availability(context.Background(), class, may3)
+	require.NoError(t, err)
+	assert.Equal(t, Availability{
+		ClassID:  1,
+		Start:    time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC),
+		End:      time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC),
+		Capacity: 2,
+		Booked:   3,
+		// Overbooked sessions show as such
+		Remaining:  -1,
+		Waitlisted: 1,
+	}, availability)
+}
+
+func TestAvailabilityComponent_Subscribe(t *testing.T) {
+	ac, bookings := newTestAvailability()
+	ctx := context.Background()
+
+	sub, err := ac.Subscribe(ctx, 1, 0)
+	require.NoError(t, err)
+	defer sub.Close()
+	// A new client is first sent the next session
+	require.Len(t, sub.Backlog, 1)
+	assert.Equal(t, 0, sub.Backlog[0].ID)
+	assert.Equal(t, time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC), sub.Backlog[0].Availability.Start)
+	assert.Equal(t, 2, sub.Backlog[0].Availability.Remaining)
+
+	booking := entities.Booking{ID: 1, ClassID: 1, Date: time.Date(2025, 5, 4, 0, 0, 0, 0, time.UTC), Status: entities.BookingConfirmed}
+	*bookings = append(*bookings, booking)
+	require.NoError(t, ac.HandleEvent(ctx, bookingEvent(t, 7, booking)))
+	// Redelivered events are not published again
+	require.NoError(t, ac.HandleEvent(ctx, bookingEvent(t, 7, booking)))
+
+	update := <-sub.Updates
+	assert.Equal(t, 7, update.ID)
+	assert.Equal(t, time.Date(2025, 5, 4, 9, 0, 0, 0, time.UTC), update.Availability.Start)
+	assert.Equal(t, 1, update.Availability.Remaining)
+	assert.Empty(t, sub.Updates)
+
+	_, err = ac.Subscribe(ctx, 2, 0)
+	assert.ErrorIs(t, err, ErrClassNotFound)
+}
+
+func TestAvailabilityComponent_Subscribe_Resume(t *testing.T) {
+	ac, _ := newTestAvailability()
+	ctx := context.Background()
+	booking := entities.Booking{ClassID: 1, Date: time.Date(2025, 5, 4, 0, 0, 0, 0, time.UTC)}
+	for id := 1; id <= 3; id++ {
+		require.NoError(t, ac.HandleEvent(ctx, bookingEvent(t, id, booking)))
+	}
+
+	sub, err := ac.Subscribe(ctx, 1, 1)
+	require.NoError(t, err)
+	defer sub.Close()
+	require.Len(t, sub.Backlog, 2)
+	assert.Equal(t, 2, sub.Backlog[0].ID)
+	assert.Equal(t, 3, sub.Backlog[1].ID)
+
+	// Once the missed updates are no longer kept, the client starts over
+	for id := 4; id <= availabilityHistory+3; id++ {
+		require.NoError(t, ac.HandleEvent(ctx, bookingEvent(t, id, booking)))
+	}
+	stale, err := ac.Subscribe(ctx, 1, 1)
+	require.NoError(t, err)
+	defer stale.Close()
+	require.Len(t, stale.Backlog, 1)
+	assert.Equal(t, availabilityHistory+3, stale.Backlog[0].ID)
+	assert.Equal(t, time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC), stale.Backlog[0].Availability.Start)
+}
+
+func TestAvailabilityComponent_HandleEvent_DropsSlowSubscribers(t *testing.T) {
+	ac, _ := newTestAvailability()
+	ctx := context.Background()
+	slow, err := ac.Subscribe(ctx, 1, 0)
+	require.NoError(t, err)
+	fast, err := ac.Subscribe(ctx, 1, 0)
+	require.NoError(t, err)
+	defer fast.Close()
+
+	booking := entities.Booking{ClassID: 1, Date: time.Date(2025, 5, 4, 0, 0, 0, 0, time.UTC)}
+	for id := 1; id <= subscriberBuffer+1; id++ {
+		require.NoError(t, ac.HandleEvent(ctx, bookingEvent(t, id, booking)))
+		if id <= subscriberBuffer {
+			<-fast.Updates
+		}
+	}
+
+	received := 0
+	for range slow.Updates {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received, "the slow subscriber is dropped once its buffer is full")
+	assert.Len(t, fast.Updates, 1)
+	slow.Close()
+}
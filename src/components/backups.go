@@ -0,0 +1,273 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/backup"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var ErrBackupsOff = errors.New("backups are not configured")
+
+// BackupsComponent keeps a backup directory up to date with the store: it
+// writes every mutation to the directory's log as it happens, takes
+// snapshots and prunes old ones, and rebuilds the store from them.
+type BackupsComponent struct {
+	entities.BackupRepository
+	// Dir is where backups are kept. Backups are off while it is nil.
+	Dir *backup.Dir
+	// Clock stamps mutations and snapshots.
+	Clock clock.Clock
+	// Keep is how many snapshots are kept. The store can be restored as it
+	// was at any time since the oldest of them.
+	Keep int
+
+	// mu keeps snapshots from interleaving with writes to the log.
+	mu sync.Mutex
+}
+
+func InitBackupsComponent() *BackupsComponent {
+	return &BackupsComponent{
+		BackupRepository: &entities.BackupEntity{},
+		Clock:            clock.Real{},
+		Keep:             24,
+	}
+}
+
+// Start loads the latest backup into the store and records every mutation
+// from then on. With no backups yet, it takes a first snapshot of the store
+// for the log to follow.
+func (bc *BackupsComponent) Start(ctx context.Context) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BackupsComponent.Start")
+	outcome := "restored"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+	if bc.Dir == nil {
+		outcome = "off"
+		return ErrBackupsOff
+	}
+
+	s, mutations, err := bc.Dir.Load(time.Time{})
+	if errors.Is(err, backup.ErrNoBackups) {
+		outcome = "empty"
+		if err := bc.RecordMutations(ctx, 0, bc.Clock.Now); err != nil {
+			return err
+		}
+		_, err = bc.TakeBackup(ctx)
+		return err
+	}
+	if err != nil {
+		outcome = "error"
+		return err
+	}
+	if err := bc.Restore(ctx, s, mutations); err != nil {
+		outcome = "error"
+		return err
+	}
+	last, err := bc.Dir.LastSeq()
+	if err != nil {
+		outcome = "error"
+		return err
+	}
+	span.SetAttributes(attribute.Int64("snapshot.seq", s.Seq), attribute.Int("mutations.count", len(mutations)))
+	utils.Logger(ctx).Info("store restored from backup", "snapshot_seq", s.Seq, "mutations", len(mutations))
+	return bc.RecordMutations(ctx, last, bc.Clock.Now)
+}
+
+// RestoreTo rebuilds the store as it was at until and saves that as the
+// latest snapshot, so that the server comes back up in that state. It must
+// not run while a server is writing to the directory.
+func (bc *BackupsComponent) RestoreTo(ctx context.Context, until time.Time) (info *backup.Info, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BackupsComponent.RestoreTo", attribute.String("until", until.Format(time.RFC3339)))
+	outcome := "restored"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+	if bc.Dir == nil {
+		outcome = "off"
+		return nil, ErrBackupsOff
+	}
+
+	s, mutations, err := bc.Dir.Load(until)
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if err := bc.Restore(ctx, s, mutations); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	// Later mutations keep their numbers, so the log carries on after them
+	last, err := bc.Dir.LastSeq()
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if err := bc.RecordMutations(ctx, last, bc.Clock.Now); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	restored, err := bc.Snapshot(ctx)
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	restored.TakenAt = bc.Clock.Now()
+	if info, err = bc.Dir.WriteSnapshot(restored); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	utils.Logger(ctx).Info("store restored", "until", until, "file", info.File)
+	return info, nil
+}
+
+// Flush writes the mutations recorded since the last flush to the log. It
+// returns how many were written. When writes could not be recorded, it
+// takes a backup instead, which covers them and lets the log carry on.
+func (bc *BackupsComponent) Flush(ctx context.Context) (int, error) {
+	if bc.Dir == nil {
+		return 0, ErrBackupsOff
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	n, err := bc.flush(ctx)
+	if errors.Is(err, entities.ErrUnrecordedWrites) {
+		utils.Logger(ctx).Error("backup log is missing writes, taking a backup", "error", err)
+		_, err = bc.takeBackup(ctx)
+	}
+	return n, err
+}
+
+// FlushJob flushes the log as a scheduled job.
+func (bc *BackupsComponent) FlushJob(ctx context.Context, _ entities.Job) error {
+	_, err := bc.Flush(ctx)
+	return err
+}
+
+func (bc *BackupsComponent) flush(ctx context.Context) (n int, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BackupsComponent.flush")
+	outcome := "flushed"
+	defer func() {
+		span.SetAttributes(attribute.Int("mutations.count", n))
+		telemetry.EndSpan(span, outcome, err)
+	}()
+
+	mutations, err := bc.Mutations(ctx)
+	if err != nil {
+		outcome = "error"
+		return 0, err
+	}
+	if len(mutations) == 0 {
+		outcome = "empty"
+		return 0, nil
+	}
+	if err := bc.Dir.Append(mutations); err != nil {
+		outcome = "error"
+		return 0, err
+	}
+	return len(mutations), bc.TrimMutations(ctx, mutations[len(mutations)-1].Seq)
+}
+
+// TakeBackup snapshots the store and prunes the snapshots beyond Keep. The
+// store keeps taking writes meanwhile. When nothing has changed since the
+// latest snapshot, that snapshot is returned instead.
+func (bc *BackupsComponent) TakeBackup(ctx context.Context) (*backup.Info, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return bc.takeBackup(ctx)
+}
+
+// takeBackup does the work of TakeBackup. Callers must hold mu.
+func (bc *BackupsComponent) takeBackup(ctx context.Context) (info *backup.Info, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BackupsComponent.TakeBackup")
+	outcome := "taken"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+	if bc.Dir == nil {
+		outcome = "off"
+		return nil, ErrBackupsOff
+	}
+
+	s, err := bc.Snapshot(ctx)
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	s.TakenAt = bc.Clock.Now()
+	span.SetAttributes(attribute.Int64("snapshot.seq", s.Seq))
+	// The log must hold everything up to the snapshot before it is written
+	if _, err := bc.flush(ctx); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+
+	infos, err := bc.Dir.Snapshots()
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if n := len(infos); n > 0 && infos[n-1].Seq == s.Seq {
+		outcome = "unchanged"
+		return &infos[n-1], nil
+	}
+	if info, err = bc.Dir.WriteSnapshot(s); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+
+	logger := utils.Logger(ctx)
+	logger.Info("backup taken", "file", info.File, "seq", info.Seq)
+	removed, err := bc.Dir.Prune(bc.Keep)
+	if err != nil {
+		// The backup itself is fine, so pruning is retried next time
+		logger.Error("failed to prune backups", "error", err)
+	} else if len(removed) > 0 {
+		logger.Info("backups pruned", "files", removed)
+	}
+	return info, nil
+}
+
+// TakeBackupJob takes a backup as a scheduled job.
+func (bc *BackupsComponent) TakeBackupJob(ctx context.Context, _ entities.Job) error {
+	_, err := bc.TakeBackup(ctx)
+	return err
+}
+
+// ListBackups returns the snapshots kept, oldest first.
+func (bc *BackupsComponent) ListBackups(ctx context.Context) (infos []backup.Info, err error) {
+	_, span := telemetry.StartSpan(ctx, "BackupsComponent.ListBackups")
+	outcome := "found"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+	if bc.Dir == nil {
+		outcome = "off"
+		return nil, ErrBackupsOff
+	}
+	if infos, err = bc.Dir.Snapshots(); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if infos == nil {
+		infos = []backup.Info{}
+	}
+	return infos, nil
+}
+
+// VerifyBackups checks the integrity of the snapshots and the log.
+func (bc *BackupsComponent) VerifyBackups(ctx context.Context) (report *backup.Report, err error) {
+	_, span := telemetry.StartSpan(ctx, "BackupsComponent.VerifyBackups")
+	outcome := "verified"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+	if bc.Dir == nil {
+		outcome = "off"
+		return nil, ErrBackupsOff
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if report, err = bc.Dir.Verify(); err == nil && !report.OK() {
+		outcome = "corrupt"
+	}
+	return report, err
+}
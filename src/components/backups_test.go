@@ -0,0 +1,189 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/backup"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockBackupRepository stands in for a store holding a list of class names,
+// each write appending one.
+type MockBackupRepository struct {
+	Names     []string
+	Seq       int64
+	Recording bool
+	Now       func() time.Time
+	Pending   []entities.Mutation
+	// Unrecorded counts the writes missing from Pending since the last
+	// snapshot.
+	Unrecorded int
+}
+
+func (m *MockBackupRepository) write(name string) {
+	m.Names = append(m.Names, name)
+	m.Seq++
+	record, _ := json.Marshal(name)
+	m.Pending = append(m.Pending, entities.Mutation{Seq: m.Seq, At: m.Now(), Table: "names", Index: len(m.Names) - 1, Record: record})
+}
+
+// writeUnrecorded makes a write that cannot be recorded.
+func (m *MockBackupRepository) writeUnrecorded(name string) {
+	m.Names = append(m.Names, name)
+	m.Unrecorded++
+}
+
+func (m *MockBackupRepository) Snapshot(ctx context.Context) (*entities.Snapshot, error) {
+	m.Unrecorded = 0
+	names, _ := json.Marshal(m.Names)
+	return &entities.Snapshot{Seq: m.Seq, Tables: map[string]json.RawMessage{"names": names}}, nil
+}
+
+func (m *MockBackupRepository) Restore(ctx context.Context, s *entities.Snapshot, mutations []entities.Mutation) error {
+	m.Names = nil
+	if err := json.Unmarshal(s.Tables["names"], &m.Names); err != nil {
+		return err
+	}
+	for _, mutation := range mutations {
+		var name string
+		if err := json.Unmarshal(mutation.Record, &name); err != nil {
+			return err
+		}
+		m.Names = append(m.Names, name)
+	}
+	return nil
+}
+
+func (m *MockBackupRepository) RecordMutations(ctx context.Context, seq int64, now func() time.Time) error {
+	m.Recording, m.Seq, m.Now, m.Pending = true, seq, now, nil
+	return nil
+}
+
+func (m *MockBackupRepository) Mutations(ctx context.Context) ([]entities.Mutation, error) {
+	if m.Unrecorded > 0 {
+		return nil, entities.ErrUnrecordedWrites
+	}
+	return append([]entities.Mutation(nil), m.Pending...), nil
+}
+
+func (m *MockBackupRepository) TrimMutations(ctx context.Context, seq int64) error {
+	for len(m.Pending) > 0 && m.Pending[0].Seq <= seq {
+		m.Pending = m.Pending[1:]
+	}
+	return nil
+}
+
+func TestBackupsComponent(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(testNow)
+	dir, err := backup.Open(t.TempDir())
+	require.NoError(t, err)
+	repo := &MockBackupRepository{}
+	bc := &BackupsComponent{BackupRepository: repo, Dir: dir, Clock: clk, Keep: 2}
+
+	// A new directory starts with a snapshot of the store as it is
+	require.NoError(t, bc.Start(ctx))
+	assert.True(t, repo.Recording)
+	infos, err := bc.ListBackups(ctx)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, int64(0), infos[0].Seq)
+
+	for i := 1; i <= 3; i++ {
+		clk.Advance(time.Minute)
+		repo.write(fmt.Sprintf("class %d", i))
+		n, err := bc.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		_, err = bc.TakeBackup(ctx)
+		require.NoError(t, err)
+	}
+	assert.Empty(t, repo.Pending)
+	clk.Advance(time.Minute)
+	latest, err := bc.TakeBackup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), latest.Seq)
+	assert.Equal(t, testNow.Add(3*time.Minute), latest.TakenAt, "an unchanged store keeps its latest snapshot")
+	infos, err = bc.ListBackups(ctx)
+	require.NoError(t, err)
+	assert.Len(t, infos, 2, "snapshots beyond Keep are pruned")
+
+	clk.Advance(time.Minute)
+	repo.write("class 4")
+	_, err = bc.Flush(ctx)
+	require.NoError(t, err)
+	report, err := bc.VerifyBackups(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, report.Problems)
+	assert.Equal(t, 2, report.Mutations)
+
+	// A restart comes back with everything flushed
+	restarted := &MockBackupRepository{}
+	bc.BackupRepository = restarted
+	require.NoError(t, bc.Start(ctx))
+	assert.Equal(t, []string{"class 1", "class 2", "class 3", "class 4"}, restarted.Names)
+	assert.Equal(t, int64(4), restarted.Seq)
+
+	// Rewinding to before the third class keeps numbering mutations after
+	// the latest one
+	rewound := &MockBackupRepository{}
+	bc.BackupRepository = rewound
+	info, err := bc.RestoreTo(ctx, testNow.Add(150*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"class 1", "class 2"}, rewound.Names)
+	assert.Equal(t, int64(4), info.Seq)
+	restarted = &MockBackupRepository{}
+	bc.BackupRepository = restarted
+	require.NoError(t, bc.Start(ctx))
+	assert.Equal(t, []string{"class 1", "class 2"}, restarted.Names)
+}
+
+func TestBackupsComponent_Flush_UnrecordedWrite(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(testNow)
+	dir, err := backup.Open(t.TempDir())
+	require.NoError(t, err)
+	repo := &MockBackupRepository{}
+	bc := &BackupsComponent{BackupRepository: repo, Dir: dir, Clock: clk, Keep: 2}
+	require.NoError(t, bc.Start(ctx))
+
+	clk.Advance(time.Minute)
+	repo.write("class 1")
+	repo.writeUnrecorded("class 2")
+	repo.write("class 3")
+	_, err = bc.Flush(ctx)
+	require.NoError(t, err)
+
+	// The backup taken in its place covers the write the log missed
+	infos, err := bc.ListBackups(ctx)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, int64(2), infos[1].Seq)
+
+	// and the log carries on from it
+	clk.Advance(time.Minute)
+	repo.write("class 4")
+	n, err := bc.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	restarted := &MockBackupRepository{}
+	bc.BackupRepository = restarted
+	require.NoError(t, bc.Start(ctx))
+	assert.Equal(t, []string{"class 1", "class 2", "class 3", "class 4"}, restarted.Names)
+}
+
+func TestBackupsComponent_Off(t *testing.T) {
+	bc := InitBackupsComponent()
+	_, err := bc.TakeBackup(context.Background())
+	assert.ErrorIs(t, err, ErrBackupsOff)
+	_, err = bc.ListBackups(context.Background())
+	assert.ErrorIs(t, err, ErrBackupsOff)
+	assert.ErrorIs(t, bc.Start(context.Background()), ErrBackupsOff)
+}
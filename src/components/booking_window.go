@@ -0,0 +1,123 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+var (
+	ErrClassInPast    = errors.New("cannot book a class that has already started")
+	ErrBookingNotOpen = errors.New("booking is not open yet")
+	ErrBookingClosed  = errors.New("booking has closed")
+	ErrNegativeWindow = errors.New("booking window values cannot be negative")
+)
+
+// BookingWindowsComponent decides when a session can be booked, from the
+// studio's booking window, the class's own overrides and the member's tier.
+type BookingWindowsComponent struct {
+	entities.BookingWindowRepository
+	entities.MemberRepository
+}
+
+func InitBookingWindowsComponent() *BookingWindowsComponent {
+	return &BookingWindowsComponent{
+		BookingWindowRepository: &entities.BookingWindowEntity{},
+		MemberRepository:        &entities.MemberEntity{},
+	}
+}
+
+func (wc *BookingWindowsComponent) GetWindowForm() *entities.BookingWindow {
+	return new(entities.BookingWindow)
+}
+
+func (wc *BookingWindowsComponent) ValidateWindow(form *entities.BookingWindow) []error {
+	if form.OpensDaysBefore < 0 || form.ClosesMinutesBefore < 0 || form.PremiumEarlyAccessDays < 0 {
+		return []error{ErrNegativeWindow}
+	}
+	return nil
+}
+
+// SetWindow replaces the studio's booking window.
+func (wc *BookingWindowsComponent) SetWindow(ctx context.Context, studio string, window *entities.BookingWindow) (*entities.BookingWindow, error) {
+	window.Studio = studio
+	stored, err := wc.SetBookingWindow(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	utils.Logger(ctx).Info("booking window set", "studio", studio)
+	return stored, nil
+}
+
+// Window returns the booking window for the class: the studio's, or the
+// default one, with the class's overrides applied.
+func (wc *BookingWindowsComponent) Window(ctx context.Context, class *entities.Class) (*entities.BookingWindow, error) {
+	window, err := wc.FindBookingWindow(ctx, class.Studio)
+	if errors.Is(err, entities.ErrNotFound) {
+		defaults := entities.DefaultBookingWindow
+		defaults.Studio = class.Studio
+		window, err = &defaults, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if class.BookingOpensDaysBefore != nil {
+		window.OpensDaysBefore = *class.BookingOpensDaysBefore
+	}
+	if class.BookingClosesMinutesBefore != nil {
+		window.ClosesMinutesBefore = *class.BookingClosesMinutesBefore
+	}
+	return window, nil
+}
+
+// CheckBooking returns an error unless name may book the session of class on
+// date at now.
+func (wc *BookingWindowsComponent) CheckBooking(ctx context.Context, name string, class *entities.Class, date, now time.Time) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BookingWindowsComponent.CheckBooking", attribute.Int("class.id", class.ID))
+	outcome := "open"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	sessionStart, _ := class.Session(date)
+	if !now.Before(sessionStart) {
+		outcome = "in_past"
+		return ErrClassInPast
+	}
+
+	window, err := wc.Window(ctx, class)
+	if err != nil {
+		outcome = "error"
+		return err
+	}
+
+	closes := sessionStart.Add(-time.Duration(window.ClosesMinutesBefore) * time.Minute)
+	if !now.Before(closes) {
+		outcome = "closed"
+		return fmt.Errorf("%w %d minutes before the class", ErrBookingClosed, window.ClosesMinutesBefore)
+	}
+	if window.OpensDaysBefore == 0 {
+		return nil
+	}
+
+	openDays := window.OpensDaysBefore
+	if window.PremiumEarlyAccessDays > 0 {
+		member, err := wc.FindMember(ctx, name)
+		if err != nil && !errors.Is(err, entities.ErrNotFound) {
+			outcome = "error"
+			return err
+		}
+		if member != nil && member.Tier == entities.TierPremium {
+			openDays += window.PremiumEarlyAccessDays
+		}
+	}
+	opens := sessionStart.AddDate(0, 0, -openDays)
+	if now.Before(opens) {
+		outcome = "not_open"
+		return fmt.Errorf("%w, it opens at %s", ErrBookingNotOpen, opens.Format(time.RFC3339))
+	}
+	return nil
+}
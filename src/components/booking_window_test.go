@@ -0,0 +1,127 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockBookingWindowRepository struct {
+	Windows []entities.BookingWindow
+}
+
+func (m *MockBookingWindowRepository) SetBookingWindow(ctx context.Context, w *entities.BookingWindow) (*entities.BookingWindow, error) {
+	m.Windows = append(m.Windows, *w)
+	return w, nil
+}
+
+func (m *MockBookingWindowRepository) FindBookingWindow(ctx context.Context, studio string) (*entities.BookingWindow, error) {
+	for _, w := range m.Windows {
+		if w.Studio == studio {
+			return &w, nil
+		}
+	}
+	return nil, entities.ErrNotFound
+}
+
+func TestBookingWindowsComponent_CheckBooking(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	days := func(n int) *int { return &n }
+	window := entities.BookingWindow{Studio: entities.DefaultStudio, OpensDaysBefore: 7, ClosesMinutesBefore: 60, PremiumEarlyAccessDays: 2}
+	members := []entities.Member{
+		{Name: "Ann", Tier: entities.TierPremium},
+		{Name: "Bob", Tier: entities.TierStandard},
+	}
+
+	tests := []struct {
+		name      string
+		member    string
+		windows   []entities.BookingWindow
+		class     entities.Class
+		expectErr error
+	}{
+		{
+			name:   "should allow any future session by default",
+			member: "Bob",
+			class:  entities.Class{StartDate: now.AddDate(1, 0, 0), EndDate: now.AddDate(1, 0, 0).Add(time.Hour)},
+		},
+		{
+			name:      "should reject a session in the past",
+			member:    "Bob",
+			class:     entities.Class{StartDate: now.AddDate(-1, 0, 0), EndDate: now.AddDate(-1, 0, 0).Add(time.Hour)},
+			expectErr: ErrClassInPast,
+		},
+		{
+			name:      "should reject a session that has started",
+			member:    "Bob",
+			class:     entities.Class{StartDate: now.Add(-time.Minute), EndDate: now.Add(time.Hour)},
+			expectErr: ErrClassInPast,
+		},
+		{
+			name:      "should reject once the studio window has closed",
+			member:    "Bob",
+			windows:   []entities.BookingWindow{window},
+			class:     entities.Class{StartDate: now.Add(30 * time.Minute), EndDate: now.Add(90 * time.Minute)},
+			expectErr: ErrBookingClosed,
+		},
+		{
+			name:      "should reject before the studio window opens",
+			member:    "Bob",
+			windows:   []entities.BookingWindow{window},
+			class:     entities.Class{StartDate: now.AddDate(0, 0, 8), EndDate: now.AddDate(0, 0, 8).Add(time.Hour)},
+			expectErr: ErrBookingNotOpen,
+		},
+		{
+			name:    "should open earlier for premium members",
+			member:  "Ann",
+			windows: []entities.BookingWindow{window},
+			class:   entities.Class{StartDate: now.AddDate(0, 0, 8), EndDate: now.AddDate(0, 0, 8).Add(time.Hour)},
+		},
+		{
+			name:      "should keep premium members to their early access",
+			member:    "Ann",
+			windows:   []entities.BookingWindow{window},
+			class:     entities.Class{StartDate: now.AddDate(0, 0, 10), EndDate: now.AddDate(0, 0, 10).Add(time.Hour)},
+			expectErr: ErrBookingNotOpen,
+		},
+		{
+			name:    "should let the class override the studio window",
+			member:  "Bob",
+			windows: []entities.BookingWindow{window},
+			class: entities.Class{
+				StartDate: now.Add(30 * time.Minute), EndDate: now.Add(90 * time.Minute),
+				BookingClosesMinutesBefore: days(0),
+			},
+		},
+		{
+			name:    "should let the class open bookings further ahead",
+			member:  "Bob",
+			windows: []entities.BookingWindow{window},
+			class: entities.Class{
+				StartDate: now.AddDate(0, 0, 8), EndDate: now.AddDate(0, 0, 8).Add(time.Hour),
+				BookingOpensDaysBefore: days(30),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wc := &BookingWindowsComponent{
+				BookingWindowRepository: &MockBookingWindowRepository{Windows: tt.windows},
+				MemberRepository:        &MockMemberRepository{Members: members},
+			}
+			tt.class.Studio = entities.DefaultStudio
+
+			err := wc.CheckBooking(context.Background(), tt.member, &tt.class, tt.class.StartDate, now)
+
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1,17 +1,50 @@
 package components
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/clock"
 	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// CancellationWindow is how long before the class starts a booking must be
+// cancelled for its credit to be refunded.
+const CancellationWindow = 12 * time.Hour
+
+var (
+	ErrBookingNotFound   = errors.New("booking not found")
+	ErrNoValidMembership = errors.New("no valid membership")
+	ErrPaymentFailed     = errors.New("payment failed")
 )
 
 type BookingsComponent struct {
 	entities.BookingRepository
+	entities.MembershipRepository
+	PaymentGateway PaymentGateway
+	Pricing        *PricingComponent
+	Penalties      *PenaltiesComponent
+	Windows        *BookingWindowsComponent
+	// Clock tells the time booking windows, cancellations and promo codes
+	// are checked against.
+	Clock clock.Clock
 }
 
 func InitBookingsComponent() *BookingsComponent {
+	// Penalty overrides refund payments, so both share one gateway
+	penalties := InitPenaltiesComponent()
 	return &BookingsComponent{
-		&entities.BookingEntity{},
+		BookingRepository:    &entities.BookingEntity{},
+		MembershipRepository: &entities.MembershipEntity{},
+		PaymentGateway:       penalties.PaymentGateway,
+		Pricing:              InitPricingComponent(),
+		Penalties:            penalties,
+		Windows:              InitBookingWindowsComponent(),
+		Clock:                clock.Real{},
 	}
 }
 
@@ -25,14 +58,464 @@ func (bc *BookingsComponent) Validate(form *entities.Booking) []error {
 		errs = append(errs, errors.New("name is required"))
 	}
 	if form.Date.IsZero() {
-		errs = append(errs, errors.New("invalid date format (expected YYYY-MM-DD)"))
+		errs = append(errs, errors.New("invalid date format (expected RFC 3339 date-time)"))
 	}
 	return errs
 }
 
-func (bc *BookingsComponent) CreateBooking(booking *entities.Booking) (*entities.Booking, error) {
-	if !bc.BookingRepository.CheckClassExistsOnDate(booking.Date) {
+func (bc *BookingsComponent) CreateBooking(ctx context.Context, booking *entities.Booking) (created *entities.Booking, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BookingsComponent.CreateBooking",
+		attribute.String("booking.date", booking.Date.String()))
+	outcome := "created"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	logger := utils.Logger(ctx).With("name", booking.Name, "date", booking.Date)
+	class, err := bc.BookingRepository.FindClassOnDate(ctx, booking.Date)
+	if err != nil {
+		outcome = "error"
+		logger.Error("failed to look up class", "error", err)
+		return nil, err
+	}
+	if class == nil {
+		outcome = "no_class"
+		logger.Info("booking rejected", "reason", "no class on date")
 		return nil, errors.New("no class exists on this date")
 	}
-	return bc.BookingRepository.AddBooking(booking)
+	span.SetAttributes(attribute.Int("class.id", class.ID))
+
+	now := bc.Clock.Now()
+	if err = bc.Windows.CheckBooking(ctx, booking.Name, class, booking.Date, now); err != nil {
+		outcome = "outside_window"
+		if !errors.Is(err, ErrClassInPast) && !errors.Is(err, ErrBookingNotOpen) && !errors.Is(err, ErrBookingClosed) {
+			outcome = "error"
+		}
+		logger.Info("booking rejected", "reason", err)
+		return nil, err
+	}
+
+	if err = bc.Penalties.CheckBooking(ctx, booking.Name, class.Studio, now); err != nil {
+		outcome = "suspended"
+		if !errors.Is(err, ErrBookingSuspended) {
+			outcome = "error"
+		}
+		logger.Info("booking rejected", "reason", err)
+		return nil, err
+	}
+
+	membership, err := bc.chargeMembership(ctx, booking.Name, class, booking.Date)
+	if err != nil {
+		outcome = "no_membership"
+		if !errors.Is(err, ErrNoValidMembership) {
+			outcome = "error"
+		}
+		logger.Info("booking rejected", "reason", err)
+		return nil, err
+	}
+
+	booking.Price = nil
+	if membership.Plan == entities.PlanDropIn && class.Price > 0 {
+		if booking.Price, err = bc.priceBooking(ctx, booking, class); err != nil {
+			outcome = "invalid_price"
+			logger.Info("booking rejected", "reason", err)
+			return nil, err
+		}
+	} else {
+		// Covered by the membership, so there is nothing to discount
+		booking.PromoCode = ""
+	}
+
+	paid := booking.Price != nil && booking.Price.Total > 0
+	if booking.CheckInToken, err = newToken(); err != nil {
+		outcome = "error"
+		bc.refundCredit(ctx, membership)
+		bc.releasePromoCode(ctx, booking)
+		return nil, err
+	}
+	booking.ClassID = class.ID
+	booking.MembershipID = membership.ID
+	booking.Status = entities.BookingConfirmed
+	if paid {
+		booking.Status = entities.BookingPending
+	}
+	var events []entities.Event
+	if !paid {
+		events = append(events, entities.NewEvent(entities.BookingCreatedEvent{Booking: booking}, now))
+	}
+	created, err = bc.BookingRepository.AddBooking(ctx, booking, events...)
+	if errors.Is(err, entities.ErrSessionFull) {
+		// Paid drop-ins are not charged for a spot they may never get
+		outcome = "full"
+		logger.Info("booking rejected", "reason", err)
+		bc.refundCredit(ctx, membership)
+		bc.releasePromoCode(ctx, booking)
+		return nil, err
+	}
+	if err != nil {
+		outcome = "error"
+		logger.Error("failed to add booking", "error", err)
+		bc.refundCredit(ctx, membership)
+		bc.releasePromoCode(ctx, booking)
+		return nil, err
+	}
+	if created.Status == entities.BookingWaitlisted {
+		outcome = "waitlisted"
+		logger.Info("booking waitlisted", "class_id", class.ID, "membership_id", membership.ID)
+		return created, nil
+	}
+
+	if paid {
+		if err = bc.takePayment(ctx, created); err != nil {
+			outcome = "payment_failed"
+			logger.Info("booking rolled back", "reason", err)
+			return nil, err
+		}
+	}
+	logger.Info("booking created", "class_id", class.ID, "membership_id", membership.ID)
+	return created, nil
+}
+
+// priceBooking quotes the drop-in price and redeems the promo code, if any.
+func (bc *BookingsComponent) priceBooking(ctx context.Context, booking *entities.Booking, class *entities.Class) (*entities.PriceBreakdown, error) {
+	price, err := bc.Pricing.Quote(ctx, class, booking.Name, booking.PromoCode)
+	if err != nil {
+		return nil, err
+	}
+	booking.PromoCode = price.PromoCode
+	if price.PromoCode != "" {
+		if err := bc.Pricing.RedeemPromoCode(ctx, price.PromoCode, bc.Clock.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return price, nil
+}
+
+// takePayment authorizes and captures the drop-in price of a pending
+// booking, then confirms it. On any failure the authorization is released
+// and the booking is marked failed, so it never holds a spot.
+func (bc *BookingsComponent) takePayment(ctx context.Context, booking *entities.Booking) error {
+	logger := utils.Logger(ctx).With("booking_id", booking.ID)
+	reference := fmt.Sprintf("booking-%d", booking.ID)
+
+	authID, err := bc.PaymentGateway.Authorize(ctx, booking.Price.Total, booking.Price.Currency, reference)
+	if err != nil {
+		logger.Info("payment authorization failed", "error", err)
+		bc.rollBack(ctx, booking, "")
+		return fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	if err := bc.PaymentGateway.Capture(ctx, authID); err != nil {
+		logger.Info("payment capture failed", "error", err)
+		bc.rollBack(ctx, booking, authID)
+		return fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+
+	booking.PaymentID = authID
+	booking.Status = entities.BookingConfirmed
+	created := entities.NewEvent(entities.BookingCreatedEvent{Booking: booking}, bc.Clock.Now())
+	if err := bc.BookingRepository.UpdateBooking(ctx, booking, created); err != nil {
+		logger.Error("failed to confirm paid booking", "error", err)
+		bc.rollBack(ctx, booking, authID)
+		return err
+	}
+	return nil
+}
+
+func (bc *BookingsComponent) rollBack(ctx context.Context, booking *entities.Booking, authID string) {
+	logger := utils.Logger(ctx).With("booking_id", booking.ID)
+	if authID != "" {
+		if err := bc.PaymentGateway.Refund(ctx, authID); err != nil {
+			logger.Error("failed to release payment", "payment_id", authID, "error", err)
+		}
+	}
+	bc.releasePromoCode(ctx, booking)
+	booking.Status = entities.BookingFailed
+	if err := bc.BookingRepository.UpdateBooking(ctx, booking); err != nil {
+		logger.Error("failed to roll back booking", "error", err)
+		return
+	}
+	if class, err := bc.BookingRepository.FindClass(ctx, booking.ClassID); err == nil {
+		bc.promoteWaitlisted(ctx, class, booking.Date)
+	}
+}
+
+// promoteWaitlisted gives a spot freed in the session of class on the day of
+// date to the member who has waited longest for it. The spot stays free
+// for the next booking when that fails, so it is only logged.
+func (bc *BookingsComponent) promoteWaitlisted(ctx context.Context, class *entities.Class, date time.Time) {
+	logger := utils.Logger(ctx).With("class_id", class.ID, "date", date)
+	promoted, err := bc.BookingRepository.PromoteWaitlisted(ctx, class, date, func(b *entities.Booking) []entities.Event {
+		return []entities.Event{entities.NewEvent(entities.WaitlistPromotedEvent{Booking: b}, bc.Clock.Now())}
+	})
+	if err != nil {
+		logger.Error("failed to promote waitlisted booking", "error", err)
+		return
+	}
+	if promoted != nil {
+		logger.Info("booking promoted from waitlist", "booking_id", promoted.ID)
+	}
+}
+
+func (bc *BookingsComponent) releasePromoCode(ctx context.Context, booking *entities.Booking) {
+	if booking.PromoCode == "" {
+		return
+	}
+	if err := bc.Pricing.ReleasePromoCode(ctx, booking.PromoCode); err != nil {
+		utils.Logger(ctx).Error("failed to release promo code", "code", booking.PromoCode, "error", err)
+	}
+}
+
+// CancelBooking cancels a booking. When the cancellation is made at least
+// CancellationWindow before the class starts, the class pack credit or the
+// drop-in payment is refunded; later cancellations are handled by the
+// studio's penalty policy. Leaving the waitlist is always refunded, and a
+// spot given up goes to the member who has waited longest for it.
+func (bc *BookingsComponent) CancelBooking(ctx context.Context, id int) (booking *entities.Booking, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BookingsComponent.CancelBooking", attribute.Int("booking.id", id))
+	outcome := "cancelled"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	// The booking is cancelled in the store before anything is refunded or
+	// charged, so concurrent cancellations cannot both refund it
+	booking, err = bc.BookingRepository.TransitionBooking(ctx, id, cancellable, entities.BookingCancelled)
+	if errors.Is(err, entities.ErrNotFound) {
+		outcome = "not_found"
+		return nil, ErrBookingNotFound
+	}
+	if errors.Is(err, entities.ErrBookingStatus) && booking.Status == entities.BookingCancelled {
+		outcome = "already_cancelled"
+		return nil, errors.New("booking is already cancelled")
+	}
+	if errors.Is(err, entities.ErrBookingStatus) {
+		outcome = "not_confirmed"
+		return nil, fmt.Errorf("cannot cancel a %s booking", booking.Status)
+	}
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	previous := booking.Status
+	booking.Status = entities.BookingCancelled
+
+	class, err := bc.BookingRepository.FindClass(ctx, booking.ClassID)
+	if err != nil {
+		outcome = "error"
+		bc.restoreBooking(ctx, booking, previous)
+		return nil, err
+	}
+
+	now := bc.Clock.Now()
+	heldSpot := previous == entities.BookingConfirmed
+	sessionStart, _ := class.Session(booking.Date)
+	refund := !heldSpot || now.Before(sessionStart.Add(-CancellationWindow))
+	if !refund {
+		if refund, err = bc.Penalties.LateCancellation(ctx, booking, class, now); err != nil {
+			outcome = "error"
+			bc.restoreBooking(ctx, booking, previous)
+			return nil, err
+		}
+	}
+	if refund {
+		if err := bc.refund(ctx, booking); err != nil {
+			outcome = "error"
+			bc.restoreBooking(ctx, booking, previous)
+			return nil, err
+		}
+	}
+
+	// The refund flags are stored with the booking.cancelled event
+	cancelled := entities.NewEvent(entities.BookingCancelledEvent{Booking: booking}, now)
+	if err := bc.BookingRepository.UpdateBooking(ctx, booking, cancelled); err != nil {
+		outcome = "error"
+		utils.Logger(ctx).Error("failed to record cancellation", "booking_id", booking.ID,
+			"credit_refunded", booking.CreditRefunded, "payment_refunded", booking.PaymentRefunded, "error", err)
+		return nil, err
+	}
+	utils.Logger(ctx).Info("booking cancelled", "booking_id", booking.ID, "credit_refunded", booking.CreditRefunded)
+	if heldSpot {
+		bc.promoteWaitlisted(ctx, class, booking.Date)
+	}
+	return booking, nil
+}
+
+// cancellable lists the statuses a booking can be cancelled from.
+var cancellable = []entities.BookingStatus{entities.BookingConfirmed, entities.BookingWaitlisted}
+
+// restoreBooking puts a booking whose cancellation could not be completed
+// back to its status before, so that it can be cancelled again.
+func (bc *BookingsComponent) restoreBooking(ctx context.Context, booking *entities.Booking, status entities.BookingStatus) {
+	_, err := bc.BookingRepository.TransitionBooking(ctx, booking.ID, []entities.BookingStatus{entities.BookingCancelled}, status)
+	if err != nil {
+		utils.Logger(ctx).Error("failed to restore booking", "booking_id", booking.ID, "status", status, "error", err)
+	}
+}
+
+// sweepCancel cancels a booking listed by a sweep and refunds it in full.
+// It reports false when the booking changed since it was listed, e.g. the
+// member cancelled it meanwhile, and was left alone.
+func (bc *BookingsComponent) sweepCancel(ctx context.Context, booking *entities.Booking, now time.Time) (bool, error) {
+	previous := booking.Status
+	_, err := bc.BookingRepository.TransitionBooking(ctx, booking.ID, []entities.BookingStatus{previous}, entities.BookingCancelled)
+	if errors.Is(err, entities.ErrBookingStatus) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	booking.Status = entities.BookingCancelled
+	if err := bc.refund(ctx, booking); err != nil {
+		bc.restoreBooking(ctx, booking, previous)
+		return false, err
+	}
+	event := entities.NewEvent(entities.BookingCancelledEvent{Booking: booking}, now)
+	if err := bc.BookingRepository.UpdateBooking(ctx, booking, event); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CancelClassBookings cancels the confirmed and waitlisted bookings of
+// class's sessions that have not started yet, refunding every one of them as
+// the studio called the class off. It returns the bookings it cancelled.
+func (bc *BookingsComponent) CancelClassBookings(ctx context.Context, class *entities.Class) (cancelled []entities.Booking, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BookingsComponent.CancelClassBookings", attribute.Int("class.id", class.ID))
+	outcome := "cancelled"
+	defer func() {
+		span.SetAttributes(attribute.Int("bookings.cancelled", len(cancelled)))
+		telemetry.EndSpan(span, outcome, err)
+	}()
+
+	bookings, err := bc.ListBookings(ctx, entities.BookingFilter{ClassID: class.ID})
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	now := bc.Clock.Now()
+	cancelled = []entities.Booking{}
+	for _, booking := range bookings {
+		if booking.Status != entities.BookingConfirmed && booking.Status != entities.BookingWaitlisted {
+			continue
+		}
+		if start, _ := class.Session(booking.Date); !start.After(now) {
+			continue
+		}
+		ok, err := bc.sweepCancel(ctx, &booking, now)
+		if err != nil {
+			outcome = "error"
+			return cancelled, err
+		}
+		if ok {
+			cancelled = append(cancelled, booking)
+		}
+	}
+	utils.Logger(ctx).Info("class bookings cancelled", "class_id", class.ID, "bookings", len(cancelled))
+	return cancelled, nil
+}
+
+// ReleaseWaitlists cancels the bookings still waiting for a spot in
+// sessions that have started, refunding them in full as they never got one.
+// It returns how many it cancelled.
+func (bc *BookingsComponent) ReleaseWaitlists(ctx context.Context, now time.Time) (released int, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "BookingsComponent.ReleaseWaitlists")
+	outcome := "released"
+	defer func() {
+		span.SetAttributes(attribute.Int("bookings.released", released))
+		telemetry.EndSpan(span, outcome, err)
+	}()
+
+	bookings, err := bc.ListBookings(ctx, entities.BookingFilter{Status: entities.BookingWaitlisted, To: now})
+	if err != nil {
+		outcome = "error"
+		return 0, err
+	}
+	for _, booking := range bookings {
+		class, err := bc.BookingRepository.FindClass(ctx, booking.ClassID)
+		if err != nil {
+			outcome = "error"
+			return released, err
+		}
+		if start, _ := class.Session(booking.Date); start.After(now) {
+			continue
+		}
+		ok, err := bc.sweepCancel(ctx, &booking, now)
+		if err != nil {
+			outcome = "error"
+			return released, err
+		}
+		if ok {
+			released++
+		}
+	}
+	if released > 0 {
+		utils.Logger(ctx).Info("released waitlists", "count", released)
+	}
+	return released, nil
+}
+
+// ReleaseWaitlistsJob is the scheduler handler that runs ReleaseWaitlists.
+func (bc *BookingsComponent) ReleaseWaitlistsJob(ctx context.Context, _ entities.Job) error {
+	_, err := bc.ReleaseWaitlists(ctx, bc.Clock.Now())
+	return err
+}
+
+// refund gives back what booking was paid with: the class pack credit or
+// the drop-in payment.
+func (bc *BookingsComponent) refund(ctx context.Context, booking *entities.Booking) error {
+	membership, err := bc.MembershipRepository.FindMembership(ctx, booking.MembershipID)
+	if err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return err
+	}
+	if err == nil && membership.Plan == entities.PlanClassPack {
+		if err := bc.MembershipRepository.RefundCredit(ctx, membership.ID); err != nil {
+			return err
+		}
+		booking.CreditRefunded = true
+	}
+	if booking.PaymentID != "" {
+		if err := bc.PaymentGateway.Refund(ctx, booking.PaymentID); err != nil {
+			return fmt.Errorf("refunding payment: %w", err)
+		}
+		booking.PaymentRefunded = true
+	}
+	return nil
+}
+
+// chargeMembership picks the membership that pays for the session of class
+// on date, preferring unlimited plans over class packs over drop-ins, and
+// debits a credit when a class pack is used.
+func (bc *BookingsComponent) chargeMembership(ctx context.Context, name string, class *entities.Class, date time.Time) (*entities.Membership, error) {
+	memberships, err := bc.MembershipRepository.FindMemberships(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	// Classes running over a date range have a session each day, and the
+	// membership must cover the one booked
+	start, _ := class.Session(date)
+
+	for _, plan := range []entities.PlanType{entities.PlanUnlimitedMonthly, entities.PlanClassPack, entities.PlanDropIn} {
+		for _, m := range memberships {
+			if m.Plan != plan || !m.ActiveAt(start) {
+				continue
+			}
+			if plan == entities.PlanClassPack {
+				err := bc.MembershipRepository.DebitCredit(ctx, m.ID)
+				if errors.Is(err, entities.ErrNoCredits) {
+					// Emptied by a concurrent booking, try the next one
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+			return &m, nil
+		}
+	}
+	return nil, ErrNoValidMembership
+}
+
+func (bc *BookingsComponent) refundCredit(ctx context.Context, membership *entities.Membership) {
+	if membership.Plan != entities.PlanClassPack {
+		return
+	}
+	if err := bc.MembershipRepository.RefundCredit(ctx, membership.ID); err != nil {
+		utils.Logger(ctx).Error("failed to refund credit", "membership_id", membership.ID, "error", err)
+	}
 }
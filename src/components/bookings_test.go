@@ -1,34 +1,162 @@
 package components
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/Vidyuallatha/glofox/src/clock"
 	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/payments"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // MockBookingRepository implements BookingRepository interface
 type MockBookingRepository struct {
-	CheckClassExistsOnDateFn func(time.Time) bool
-	AddBookingFn             func(*entities.Booking) (*entities.Booking, error)
+	CheckClassExistsOnDateFn func(context.Context, time.Time) (bool, error)
+	FindClassOnDateFn        func(context.Context, time.Time) (*entities.Class, error)
+	FindClassFn              func(context.Context, int) (*entities.Class, error)
+	AddBookingFn             func(context.Context, *entities.Booking) (*entities.Booking, error)
+	UpdateBookingFn          func(context.Context, *entities.Booking) error
+	FindBookingFn            func(context.Context, int) (*entities.Booking, error)
+	ListBookingsFn           func(context.Context, entities.BookingFilter) ([]entities.Booking, error)
+	PromoteWaitlistedFn      func(context.Context, *entities.Class, time.Time) (*entities.Booking, error)
+	MarkNoShowFn             func(context.Context, int) (bool, error)
+	TransitionBookingFn      func(context.Context, int, []entities.BookingStatus, entities.BookingStatus) (*entities.Booking, error)
+	// Events collects the events recorded by successful writes
+	Events []entities.Event
 }
 
-func (m *MockBookingRepository) CheckClassExistsOnDate(t time.Time) bool {
+func (m *MockBookingRepository) CheckClassExistsOnDate(ctx context.Context, t time.Time) (bool, error) {
 	if m.CheckClassExistsOnDateFn != nil {
-		return m.CheckClassExistsOnDateFn(t)
+		return m.CheckClassExistsOnDateFn(ctx, t)
 	}
-	return false
+	return false, nil
 }
 
-func (m *MockBookingRepository) AddBooking(b *entities.Booking) (*entities.Booking, error) {
+func (m *MockBookingRepository) FindClassOnDate(ctx context.Context, t time.Time) (*entities.Class, error) {
+	if m.FindClassOnDateFn != nil {
+		return m.FindClassOnDateFn(ctx, t)
+	}
+	return nil, nil
+}
+
+func (m *MockBookingRepository) FindClass(ctx context.Context, id int) (*entities.Class, error) {
+	if m.FindClassFn != nil {
+		return m.FindClassFn(ctx, id)
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockBookingRepository) AddBooking(ctx context.Context, b *entities.Booking, events ...entities.Event) (*entities.Booking, error) {
 	if m.AddBookingFn != nil {
-		return m.AddBookingFn(b)
+		created, err := m.AddBookingFn(ctx, b)
+		if err == nil {
+			m.Events = append(m.Events, events...)
+		}
+		return created, err
 	}
 	return nil, errors.New("not implemented")
 }
 
+func (m *MockBookingRepository) UpdateBooking(ctx context.Context, b *entities.Booking, events ...entities.Event) error {
+	if m.UpdateBookingFn != nil {
+		err := m.UpdateBookingFn(ctx, b)
+		if err == nil {
+			m.Events = append(m.Events, events...)
+		}
+		return err
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockBookingRepository) FindBooking(ctx context.Context, id int) (*entities.Booking, error) {
+	if m.FindBookingFn != nil {
+		return m.FindBookingFn(ctx, id)
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockBookingRepository) PromoteWaitlisted(ctx context.Context, class *entities.Class, date time.Time, events func(*entities.Booking) []entities.Event) (*entities.Booking, error) {
+	if m.PromoteWaitlistedFn != nil {
+		promoted, err := m.PromoteWaitlistedFn(ctx, class, date)
+		if err == nil && promoted != nil {
+			m.Events = append(m.Events, events(promoted)...)
+		}
+		return promoted, err
+	}
+	return nil, nil
+}
+
+func (m *MockBookingRepository) TransitionBooking(ctx context.Context, id int, from []entities.BookingStatus, to entities.BookingStatus) (*entities.Booking, error) {
+	if m.TransitionBookingFn != nil {
+		return m.TransitionBookingFn(ctx, id, from, to)
+	}
+	// Without a stub, the booking FindBookingFn returns is moved
+	b, err := m.FindBooking(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := *b
+	if !slices.Contains(from, b.Status) {
+		return &previous, entities.ErrBookingStatus
+	}
+	b.Status = to
+	return &previous, nil
+}
+
+func (m *MockBookingRepository) MarkNoShow(ctx context.Context, id int) (bool, error) {
+	if m.MarkNoShowFn != nil {
+		return m.MarkNoShowFn(ctx, id)
+	}
+	return false, errors.New("not implemented")
+}
+
+func (m *MockBookingRepository) ListBookings(ctx context.Context, filter entities.BookingFilter) ([]entities.Booking, error) {
+	if m.ListBookingsFn != nil {
+		return m.ListBookingsFn(ctx, filter)
+	}
+	return nil, nil
+}
+
+// testNow is the time the fake clocks in these tests start at.
+var testNow = time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// withTestDefaults gives bc a penalty engine with no penalties on record and
+// the default booking window, sharing bc's repositories. The clock is a fake
+// one stopped at testNow unless the test set its own.
+func withTestDefaults(bc *BookingsComponent) *BookingsComponent {
+	bc.Penalties = &PenaltiesComponent{
+		PenaltyRepository:    &MockPenaltyRepository{},
+		BookingRepository:    bc.BookingRepository,
+		MembershipRepository: bc.MembershipRepository,
+		PaymentGateway:       bc.PaymentGateway,
+	}
+	bc.Windows = &BookingWindowsComponent{
+		BookingWindowRepository: &MockBookingWindowRepository{},
+		MemberRepository:        &MockMemberRepository{},
+	}
+	if bc.Clock == nil {
+		bc.Clock = clock.NewFake(testNow)
+	}
+	return bc
+}
+
+func eventTypes(events []entities.Event) []entities.EventType {
+	var types []entities.EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
 func TestBookingsComponent_Valid(t *testing.T) {
 	bc := &BookingsComponent{}
 
@@ -59,7 +187,7 @@ func TestBookingsComponent_Valid(t *testing.T) {
 				Name: "Yoga",
 				Date: time.Time{},
 			},
-			expected: []string{"invalid date format (expected YYYY-MM-DD)"},
+			expected: []string{"invalid date format (expected RFC 3339 date-time)"},
 		},
 		{
 			name: "should add validation error for name and date if both are missing",
@@ -69,7 +197,7 @@ func TestBookingsComponent_Valid(t *testing.T) {
 			},
 			expected: []string{
 				"name is required",
-				"invalid date format (expected YYYY-MM-DD)",
+				"invalid date format (expected RFC 3339 date-time)",
 			},
 		},
 	}
@@ -91,15 +219,26 @@ func TestBookingsComponent_Valid(t *testing.T) {
 }
 
 func TestBookingsComponent_CreateBooking(t *testing.T) {
-	now := time.Now()
+	now := testNow
+	class := &entities.Class{ID: 3, ClassName: "Yoga", StartDate: now, EndDate: now.Add(time.Hour), Capacity: 10}
+	classOnDate := func(ctx context.Context, t time.Time) (*entities.Class, error) {
+		return class, nil
+	}
+	addBooking := func(ctx context.Context, b *entities.Booking) (*entities.Booking, error) {
+		return b, nil
+	}
+	monthEnd := now.AddDate(0, 1, 0)
 
 	tests := []struct {
-		name      string
-		booking   *entities.Booking
-		mockRepo  *MockBookingRepository
-		want      *entities.Booking
-		wantErr   bool
-		expectErr string
+		name        string
+		booking     *entities.Booking
+		mockRepo    *MockBookingRepository
+		memberships []entities.Membership
+		want        *entities.Booking
+		wantDebited []int
+		wantRefund  []int
+		wantErr     bool
+		expectErr   string
 	}{
 		{
 			name: "should successfully create a booking",
@@ -108,17 +247,113 @@ func TestBookingsComponent_CreateBooking(t *testing.T) {
 				Date: now,
 			},
 			mockRepo: &MockBookingRepository{
-				CheckClassExistsOnDateFn: func(t time.Time) bool {
-					return true
-				},
-				AddBookingFn: func(b *entities.Booking) (*entities.Booking, error) {
-					return b, nil
-				},
+				FindClassOnDateFn: classOnDate,
+				AddBookingFn:      addBooking,
+			},
+			memberships: []entities.Membership{
+				{ID: 7, Name: "Test booking", Plan: entities.PlanUnlimitedMonthly, StartDate: now.AddDate(0, 0, -1), EndDate: &monthEnd},
+			},
+			want: &entities.Booking{
+				ClassID:      3,
+				Name:         "Test booking",
+				Date:         now,
+				Status:       entities.BookingConfirmed,
+				MembershipID: 7,
+			},
+		},
+		{
+			name: "should debit a credit when booking with a class pack",
+			booking: &entities.Booking{
+				Name: "Test booking",
+				Date: now,
+			},
+			mockRepo: &MockBookingRepository{
+				FindClassOnDateFn: classOnDate,
+				AddBookingFn:      addBooking,
+			},
+			memberships: []entities.Membership{
+				{ID: 8, Name: "Test booking", Plan: entities.PlanClassPack, Credits: 2, StartDate: now.AddDate(0, 0, -1)},
+			},
+			want: &entities.Booking{
+				ClassID:      3,
+				Name:         "Test booking",
+				Date:         now,
+				Status:       entities.BookingConfirmed,
+				MembershipID: 8,
+			},
+			wantDebited: []int{8},
+		},
+		{
+			name: "should prefer an unlimited membership over a class pack",
+			booking: &entities.Booking{
+				Name: "Test booking",
+				Date: now,
+			},
+			mockRepo: &MockBookingRepository{
+				FindClassOnDateFn: classOnDate,
+				AddBookingFn:      addBooking,
+			},
+			memberships: []entities.Membership{
+				{ID: 8, Name: "Test booking", Plan: entities.PlanClassPack, Credits: 2, StartDate: now.AddDate(0, 0, -1)},
+				{ID: 7, Name: "Test booking", Plan: entities.PlanUnlimitedMonthly, StartDate: now.AddDate(0, 0, -1), EndDate: &monthEnd},
 			},
 			want: &entities.Booking{
+				ClassID:      3,
+				Name:         "Test booking",
+				Date:         now,
+				Status:       entities.BookingConfirmed,
+				MembershipID: 7,
+			},
+		},
+		{
+			name: "should reject the booking when the class pack is empty",
+			booking: &entities.Booking{
 				Name: "Test booking",
 				Date: now,
 			},
+			mockRepo: &MockBookingRepository{
+				FindClassOnDateFn: classOnDate,
+			},
+			memberships: []entities.Membership{
+				{ID: 8, Name: "Test booking", Plan: entities.PlanClassPack, Credits: 0, StartDate: now.AddDate(0, 0, -1)},
+			},
+			wantErr:   true,
+			expectErr: "no valid membership",
+		},
+		{
+			name: "should reject the booking when the membership has expired",
+			booking: &entities.Booking{
+				Name: "Test booking",
+				Date: now,
+			},
+			mockRepo: &MockBookingRepository{
+				FindClassOnDateFn: classOnDate,
+			},
+			memberships: []entities.Membership{
+				{ID: 7, Name: "Test booking", Plan: entities.PlanUnlimitedMonthly, StartDate: now.AddDate(0, -2, 0), EndDate: &now},
+			},
+			wantErr:   true,
+			expectErr: "no valid membership",
+		},
+		{
+			name: "should refund the credit when storing the booking fails",
+			booking: &entities.Booking{
+				Name: "Test booking",
+				Date: now,
+			},
+			mockRepo: &MockBookingRepository{
+				FindClassOnDateFn: classOnDate,
+				AddBookingFn: func(ctx context.Context, b *entities.Booking) (*entities.Booking, error) {
+					return nil, errors.New("storage unavailable")
+				},
+			},
+			memberships: []entities.Membership{
+				{ID: 8, Name: "Test booking", Plan: entities.PlanClassPack, Credits: 2, StartDate: now.AddDate(0, 0, -1)},
+			},
+			wantDebited: []int{8},
+			wantRefund:  []int{8},
+			wantErr:     true,
+			expectErr:   "storage unavailable",
 		},
 		{
 			name: "should return an error when class does not exist",
@@ -127,29 +362,687 @@ func TestBookingsComponent_CreateBooking(t *testing.T) {
 				Date: now,
 			},
 			mockRepo: &MockBookingRepository{
-				CheckClassExistsOnDateFn: func(t time.Time) bool {
-					return false
+				FindClassOnDateFn: func(ctx context.Context, t time.Time) (*entities.Class, error) {
+					return nil, nil
 				},
 			},
 			wantErr:   true,
 			expectErr: "no class exists on this date",
 		},
+		{
+			name: "should return the repository error when the class lookup fails",
+			booking: &entities.Booking{
+				Name: "Test booking",
+				Date: now,
+			},
+			mockRepo: &MockBookingRepository{
+				FindClassOnDateFn: func(ctx context.Context, t time.Time) (*entities.Class, error) {
+					return nil, context.Canceled
+				},
+			},
+			wantErr:   true,
+			expectErr: context.Canceled.Error(),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			bc := &BookingsComponent{
-				tt.mockRepo,
-			}
-			got, err := bc.CreateBooking(tt.booking)
+			memberships := &MockMembershipRepository{Memberships: tt.memberships}
+			bc := withTestDefaults(&BookingsComponent{
+				BookingRepository:    tt.mockRepo,
+				MembershipRepository: memberships,
+				Clock:                clock.NewFake(now.Add(-time.Hour)),
+			})
+			got, err := bc.CreateBooking(context.Background(), tt.booking)
 
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.EqualError(t, err, tt.expectErr)
 			} else {
 				assert.NoError(t, err)
+				// The check-in token is random, so it is checked on its own.
+				assert.Len(t, got.CheckInToken, 32)
+				got.CheckInToken = ""
 				assert.Equal(t, tt.want, got)
+				assert.Equal(t, []entities.EventType{entities.EventBookingCreated}, eventTypes(tt.mockRepo.Events))
+			}
+			if tt.wantErr {
+				assert.Empty(t, tt.mockRepo.Events)
+			}
+			assert.Equal(t, tt.wantDebited, memberships.Debited)
+			assert.Equal(t, tt.wantRefund, memberships.Refunded)
+		})
+	}
+}
+
+func TestBookingsComponent_CancelBooking(t *testing.T) {
+	now := testNow
+
+	tests := []struct {
+		name        string
+		classStart  time.Time
+		booking     *entities.Booking
+		membership  entities.Membership
+		findErr     error
+		wantRefund  []int
+		wantStatus  entities.BookingStatus
+		expectedErr error
+	}{
+		{
+			name:       "should refund the credit when cancelling inside the window",
+			classStart: now.Add(CancellationWindow + time.Hour),
+			booking:    &entities.Booking{ID: 1, ClassID: 3, Status: entities.BookingConfirmed, MembershipID: 8},
+			membership: entities.Membership{ID: 8, Plan: entities.PlanClassPack, Credits: 4},
+			wantRefund: []int{8},
+			wantStatus: entities.BookingCancelled,
+		},
+		{
+			name:       "should not refund the credit for a late cancellation",
+			classStart: now.Add(CancellationWindow - time.Hour),
+			booking:    &entities.Booking{ID: 1, ClassID: 3, Status: entities.BookingConfirmed, MembershipID: 8},
+			membership: entities.Membership{ID: 8, Plan: entities.PlanClassPack, Credits: 4},
+			wantStatus: entities.BookingCancelled,
+		},
+		{
+			name:       "should not refund anything for an unlimited membership",
+			classStart: now.Add(CancellationWindow + time.Hour),
+			booking:    &entities.Booking{ID: 1, ClassID: 3, Status: entities.BookingConfirmed, MembershipID: 7},
+			membership: entities.Membership{ID: 7, Plan: entities.PlanUnlimitedMonthly},
+			wantStatus: entities.BookingCancelled,
+		},
+		{
+			name:        "should return not found for an unknown booking",
+			expectedErr: ErrBookingNotFound,
+		},
+		{
+			name:        "should not cancel when the membership to refund cannot be read",
+			classStart:  now.Add(CancellationWindow + time.Hour),
+			booking:     &entities.Booking{ID: 1, ClassID: 3, Status: entities.BookingConfirmed, MembershipID: 8},
+			membership:  entities.Membership{ID: 8, Plan: entities.PlanClassPack, Credits: 4},
+			findErr:     context.DeadlineExceeded,
+			expectedErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memberships := &MockMembershipRepository{Memberships: []entities.Membership{tt.membership}, FindErr: tt.findErr}
+			var updated *entities.Booking
+			bookings := &MockBookingRepository{
+				FindBookingFn: func(ctx context.Context, id int) (*entities.Booking, error) {
+					if tt.booking == nil {
+						return nil, entities.ErrNotFound
+					}
+					return tt.booking, nil
+				},
+				FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+					return &entities.Class{ID: id, StartDate: tt.classStart, EndDate: tt.classStart.Add(time.Hour)}, nil
+				},
+				UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+					updated = b
+					return nil
+				},
+			}
+			bc := withTestDefaults(&BookingsComponent{
+				BookingRepository:    bookings,
+				MembershipRepository: memberships,
+			})
+
+			got, err := bc.CancelBooking(context.Background(), 1)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, updated, "the booking is left as it was")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.wantStatus, updated.Status)
+			assert.Equal(t, tt.wantRefund, memberships.Refunded)
+			assert.Equal(t, tt.wantRefund != nil, got.CreditRefunded)
+			assert.Equal(t, []entities.EventType{entities.EventBookingCancelled}, eventTypes(bookings.Events))
+		})
+	}
+}
+
+func TestBookingsComponent_CancelBooking_Twice(t *testing.T) {
+	start := testNow.Add(CancellationWindow + time.Hour)
+	booking := &entities.Booking{ID: 1, ClassID: 3, Status: entities.BookingConfirmed, MembershipID: 8}
+	memberships := &MockMembershipRepository{Memberships: []entities.Membership{{ID: 8, Plan: entities.PlanClassPack, Credits: 4}}}
+	memberships.FindErr = context.DeadlineExceeded
+	bc := withTestDefaults(&BookingsComponent{
+		BookingRepository: &MockBookingRepository{
+			// The mock moves this booking, as the store would
+			FindBookingFn: func(ctx context.Context, id int) (*entities.Booking, error) {
+				return booking, nil
+			},
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				return &entities.Class{ID: id, StartDate: start, EndDate: start.Add(time.Hour)}, nil
+			},
+			UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+				return nil
+			},
+		},
+		MembershipRepository: memberships,
+	})
+
+	// A failed refund puts the booking back so that it can be cancelled again
+	_, err := bc.CancelBooking(context.Background(), 1)
+	require.Error(t, err)
+	assert.Equal(t, entities.BookingConfirmed, booking.Status)
+
+	memberships.FindErr = nil
+	_, err = bc.CancelBooking(context.Background(), 1)
+	require.NoError(t, err)
+	_, err = bc.CancelBooking(context.Background(), 1)
+	assert.EqualError(t, err, "booking is already cancelled")
+	assert.Equal(t, []int{8}, memberships.Refunded, "the credit is refunded once")
+}
+
+func TestBookingsComponent_CreateBooking_DropInPayment(t *testing.T) {
+	now := testNow
+	class := &entities.Class{ID: 3, ClassName: "Yoga", StartDate: now.Add(48 * time.Hour), EndDate: now.Add(49 * time.Hour), Price: 1500, Currency: "EUR"}
+
+	tests := []struct {
+		name             string
+		declineAuthorize bool
+		declineCapture   bool
+		expectedStatuses []entities.BookingStatus
+		expectedAuth     payments.AuthorizationStatus
+		expectedErr      error
+	}{
+		{
+			name:             "should confirm the booking once the payment is captured",
+			expectedStatuses: []entities.BookingStatus{entities.BookingConfirmed},
+			expectedAuth:     payments.StatusCaptured,
+		},
+		{
+			name:             "should roll back the booking when authorization is declined",
+			declineAuthorize: true,
+			expectedStatuses: []entities.BookingStatus{entities.BookingFailed},
+			expectedErr:      ErrPaymentFailed,
+		},
+		{
+			name:             "should release the authorization when capture fails",
+			declineCapture:   true,
+			expectedStatuses: []entities.BookingStatus{entities.BookingFailed},
+			expectedAuth:     payments.StatusRefunded,
+			expectedErr:      ErrPaymentFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := payments.NewFakeGateway()
+			gateway.DeclineAuthorize = tt.declineAuthorize
+			gateway.DeclineCapture = tt.declineCapture
+			promoCodes := &MockPromoCodeRepository{PromoCodes: []entities.PromoCode{
+				{Code: "WELCOME", PercentOff: 20, ExpiresAt: now.AddDate(0, 1, 0)},
+			}}
+
+			var added entities.BookingStatus
+			var updates []entities.BookingStatus
+			bookings := &MockBookingRepository{
+				FindClassOnDateFn: func(ctx context.Context, t time.Time) (*entities.Class, error) {
+					return class, nil
+				},
+				AddBookingFn: func(ctx context.Context, b *entities.Booking) (*entities.Booking, error) {
+					added = b.Status
+					b.ID = 1
+					return b, nil
+				},
+				UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+					updates = append(updates, b.Status)
+					return nil
+				},
+			}
+			bc := withTestDefaults(&BookingsComponent{
+				BookingRepository: bookings,
+				MembershipRepository: &MockMembershipRepository{Memberships: []entities.Membership{
+					{ID: 9, Name: "John Doe", Plan: entities.PlanDropIn, StartDate: now.AddDate(0, -1, 0)},
+				}},
+				PaymentGateway: gateway,
+				Pricing:        &PricingComponent{MemberRepository: &MockMemberRepository{}, PromoCodeRepository: promoCodes, Clock: clock.NewFake(now)},
+			})
+
+			got, err := bc.CreateBooking(context.Background(), &entities.Booking{Name: "John Doe", Date: class.StartDate, PromoCode: "WELCOME"})
+
+			assert.Equal(t, entities.BookingPending, added)
+			assert.Equal(t, tt.expectedStatuses, updates)
+			assert.Equal(t, 1, promoCodes.PromoCodes[0].Uses)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				assert.Equal(t, []string{"WELCOME"}, promoCodes.Released)
+				// Rolled back bookings were never confirmed
+				assert.Empty(t, bookings.Events)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "auth_1", got.PaymentID)
+				assert.Equal(t, &entities.PriceBreakdown{
+					Currency: "EUR", BasePrice: 1500, Tier: "standard",
+					PromoCode: "WELCOME", PromoDiscount: 300, Total: 1200,
+				}, got.Price)
+				assert.Empty(t, promoCodes.Released)
+				// Created once the payment has gone through, not while pending
+				assert.Equal(t, []entities.EventType{entities.EventBookingCreated}, eventTypes(bookings.Events))
+			}
+			if tt.expectedAuth != "" {
+				auth, ok := gateway.Authorization("auth_1")
+				assert.True(t, ok)
+				assert.Equal(t, tt.expectedAuth, auth.Status)
+				assert.Equal(t, 1200, auth.Amount)
+				assert.Equal(t, "booking-1", auth.Reference)
 			}
 		})
 	}
 }
+
+func TestBookingsComponent_CancelBooking_RefundsPayment(t *testing.T) {
+	gateway := payments.NewFakeGateway()
+	authID, _ := gateway.Authorize(context.Background(), 1500, "EUR", "booking-1")
+	_ = gateway.Capture(context.Background(), authID)
+
+	start := testNow.Add(CancellationWindow + time.Hour)
+	bc := withTestDefaults(&BookingsComponent{
+		BookingRepository: &MockBookingRepository{
+			FindBookingFn: func(ctx context.Context, id int) (*entities.Booking, error) {
+				return &entities.Booking{ID: id, ClassID: 3, Status: entities.BookingConfirmed, MembershipID: 9, PaymentID: authID}, nil
+			},
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				return &entities.Class{ID: id, StartDate: start, EndDate: start.Add(time.Hour)}, nil
+			},
+			UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+				return nil
+			},
+		},
+		MembershipRepository: &MockMembershipRepository{Memberships: []entities.Membership{
+			{ID: 9, Plan: entities.PlanDropIn},
+		}},
+		PaymentGateway: gateway,
+	})
+
+	got, err := bc.CancelBooking(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.True(t, got.PaymentRefunded)
+	auth, _ := gateway.Authorization(authID)
+	assert.Equal(t, payments.StatusRefunded, auth.Status)
+}
+
+func TestBookingsComponent_CancelBooking_LateCancelFee(t *testing.T) {
+	gateway := payments.NewFakeGateway()
+	authID, _ := gateway.Authorize(context.Background(), 1500, "EUR", "booking-1")
+	_ = gateway.Capture(context.Background(), authID)
+
+	// Inside the cancellation window
+	start := testNow.Add(time.Hour)
+	bc := withTestDefaults(&BookingsComponent{
+		BookingRepository: &MockBookingRepository{
+			FindBookingFn: func(ctx context.Context, id int) (*entities.Booking, error) {
+				return &entities.Booking{ID: id, ClassID: 3, Status: entities.BookingConfirmed, MembershipID: 9, PaymentID: authID}, nil
+			},
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				return &entities.Class{ID: id, Studio: entities.DefaultStudio, StartDate: start, EndDate: start.Add(time.Hour)}, nil
+			},
+			UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+				return nil
+			},
+		},
+		MembershipRepository: &MockMembershipRepository{Memberships: []entities.Membership{
+			{ID: 9, Plan: entities.PlanDropIn},
+		}},
+		PaymentGateway: gateway,
+	})
+	penalties := &MockPenaltyRepository{Policies: []entities.PenaltyPolicy{{
+		Studio: entities.DefaultStudio, LateCancelAction: entities.LateCancelFee, LateCancelFee: 500, Currency: "EUR",
+	}}}
+	bc.Penalties.PenaltyRepository = penalties
+
+	got, err := bc.CancelBooking(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.True(t, got.PaymentRefunded)
+	paid, _ := gateway.Authorization(authID)
+	assert.Equal(t, payments.StatusRefunded, paid.Status)
+	require.Len(t, penalties.Penalties, 1)
+	fee, ok := gateway.Authorization(penalties.Penalties[0].PaymentID)
+	require.True(t, ok, "the fee is charged")
+	assert.Equal(t, payments.StatusCaptured, fee.Status)
+	assert.Equal(t, 1000, paid.Amount-fee.Amount, "the member gets the price back less the fee")
+}
+
+func TestBookingsComponent_CancelClassBookings(t *testing.T) {
+	gateway := payments.NewFakeGateway()
+	authID, _ := gateway.Authorize(context.Background(), 1500, "EUR", "booking-3")
+	_ = gateway.Capture(context.Background(), authID)
+
+	// A daily class at testNow's time of day, which started an hour ago today
+	start := testNow.Add(-time.Hour)
+	class := &entities.Class{ID: 3, StartDate: start, EndDate: start.AddDate(0, 0, 7).Add(time.Hour)}
+	tomorrow := start.AddDate(0, 0, 1)
+	var filter entities.BookingFilter
+	repo := &MockBookingRepository{
+		ListBookingsFn: func(ctx context.Context, f entities.BookingFilter) ([]entities.Booking, error) {
+			filter = f
+			return []entities.Booking{
+				{ID: 1, ClassID: 3, Date: start, Status: entities.BookingConfirmed, MembershipID: 8},
+				{ID: 2, ClassID: 3, Date: tomorrow, Status: entities.BookingConfirmed, MembershipID: 8},
+				{ID: 3, ClassID: 3, Date: tomorrow, Status: entities.BookingConfirmed, MembershipID: 9, PaymentID: authID},
+				{ID: 4, ClassID: 3, Date: tomorrow, Status: entities.BookingCancelled, MembershipID: 8},
+				{ID: 5, ClassID: 3, Date: tomorrow, Status: entities.BookingWaitlisted, MembershipID: 8},
+				{ID: 6, ClassID: 3, Date: tomorrow, Status: entities.BookingConfirmed, MembershipID: 8},
+			}, nil
+		},
+		TransitionBookingFn: func(ctx context.Context, id int, from []entities.BookingStatus, to entities.BookingStatus) (*entities.Booking, error) {
+			if id == 6 {
+				// The member cancelled it after it was listed
+				return &entities.Booking{ID: id, Status: entities.BookingCancelled}, entities.ErrBookingStatus
+			}
+			return &entities.Booking{ID: id, Status: from[0]}, nil
+		},
+		UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+			return nil
+		},
+	}
+	memberships := &MockMembershipRepository{Memberships: []entities.Membership{
+		{ID: 8, Plan: entities.PlanClassPack, Credits: 4},
+		{ID: 9, Plan: entities.PlanDropIn},
+	}}
+	bc := withTestDefaults(&BookingsComponent{
+		BookingRepository:    repo,
+		MembershipRepository: memberships,
+		PaymentGateway:       gateway,
+	})
+
+	cancelled, err := bc.CancelClassBookings(context.Background(), class)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.BookingFilter{ClassID: 3}, filter)
+	// The session already under way is left alone; later ones are refunded,
+	// waitlist included, unless they were cancelled meanwhile
+	require.Len(t, cancelled, 3)
+	assert.Equal(t, []int{2, 3, 5}, []int{cancelled[0].ID, cancelled[1].ID, cancelled[2].ID})
+	assert.True(t, cancelled[0].CreditRefunded)
+	assert.True(t, cancelled[1].PaymentRefunded)
+	assert.Equal(t, []int{8, 8}, memberships.Refunded)
+	auth, _ := gateway.Authorization(authID)
+	assert.Equal(t, payments.StatusRefunded, auth.Status)
+	assert.Equal(t, []entities.EventType{entities.EventBookingCancelled, entities.EventBookingCancelled, entities.EventBookingCancelled}, eventTypes(repo.Events))
+}
+
+func TestBookingsComponent_CreateBooking_OutsideWindow(t *testing.T) {
+	now := testNow
+	memberships := &MockMembershipRepository{Memberships: []entities.Membership{
+		{ID: 8, Name: "Ann", Plan: entities.PlanClassPack, Credits: 3, StartDate: now.AddDate(-1, 0, 0)},
+	}}
+	bc := withTestDefaults(&BookingsComponent{
+		BookingRepository: &MockBookingRepository{
+			FindClassOnDateFn: func(ctx context.Context, t time.Time) (*entities.Class, error) {
+				return &entities.Class{ID: 3, StartDate: t, EndDate: t.Add(time.Hour), Studio: entities.DefaultStudio}, nil
+			},
+		},
+		MembershipRepository: memberships,
+		Clock:                clock.NewFake(now),
+	})
+
+	_, err := bc.CreateBooking(context.Background(), &entities.Booking{Name: "Ann", Date: now.AddDate(-1, 0, 0)})
+
+	assert.ErrorIs(t, err, ErrClassInPast)
+	assert.Empty(t, memberships.Debited)
+}
+
+func TestBookingsComponent_CreateBooking_DateRangeClass(t *testing.T) {
+	now := testNow
+	// The class runs an hour a day for ten days; the session booked is on
+	// the sixth
+	class := &entities.Class{ID: 3, ClassName: "Yoga", StartDate: now, EndDate: now.AddDate(0, 0, 9).Add(time.Hour), Capacity: 10}
+	session := now.AddDate(0, 0, 5)
+	expired := now.AddDate(0, 0, 3)
+
+	tests := []struct {
+		name       string
+		membership entities.Membership
+		wantErr    error
+	}{
+		{
+			name:       "should reject a membership that has expired by the session",
+			membership: entities.Membership{ID: 7, Name: "Ann", Plan: entities.PlanUnlimitedMonthly, StartDate: now.AddDate(0, -1, 0), EndDate: &expired},
+			wantErr:    ErrNoValidMembership,
+		},
+		{
+			name:       "should accept a membership starting after the class but before the session",
+			membership: entities.Membership{ID: 8, Name: "Ann", Plan: entities.PlanClassPack, Credits: 2, StartDate: now.AddDate(0, 0, 2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := withTestDefaults(&BookingsComponent{
+				BookingRepository: &MockBookingRepository{
+					FindClassOnDateFn: func(ctx context.Context, t time.Time) (*entities.Class, error) {
+						return class, nil
+					},
+					AddBookingFn: func(ctx context.Context, b *entities.Booking) (*entities.Booking, error) {
+						return b, nil
+					},
+				},
+				MembershipRepository: &MockMembershipRepository{Memberships: []entities.Membership{tt.membership}},
+				Clock:                clock.NewFake(now.Add(-time.Hour)),
+			})
+
+			got, err := bc.CreateBooking(context.Background(), &entities.Booking{Name: "Ann", Date: session})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.membership.ID, got.MembershipID)
+		})
+	}
+}
+
+func TestBookingsComponent_CreateBooking_FullSession(t *testing.T) {
+	now := testNow
+	class := &entities.Class{ID: 3, ClassName: "Yoga", StartDate: now, EndDate: now.Add(time.Hour), Capacity: 1}
+
+	tests := []struct {
+		name         string
+		addErr       error
+		wantStatus   entities.BookingStatus
+		wantErr      error
+		wantRefunded []int
+	}{
+		{
+			name:       "should keep the credit of a booking put on the waitlist",
+			wantStatus: entities.BookingWaitlisted,
+		},
+		{
+			name:         "should refund the credit when the session is full",
+			addErr:       entities.ErrSessionFull,
+			wantErr:      entities.ErrSessionFull,
+			wantRefunded: []int{8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memberships := &MockMembershipRepository{Memberships: []entities.Membership{
+				{ID: 8, Name: "Ann", Plan: entities.PlanClassPack, Credits: 2, StartDate: now.AddDate(0, 0, -1)},
+			}}
+			repo := &MockBookingRepository{
+				FindClassOnDateFn: func(ctx context.Context, t time.Time) (*entities.Class, error) {
+					return class, nil
+				},
+				AddBookingFn: func(ctx context.Context, b *entities.Booking) (*entities.Booking, error) {
+					if tt.addErr != nil {
+						return nil, tt.addErr
+					}
+					b.Status = entities.BookingWaitlisted
+					return b, nil
+				},
+			}
+			bc := withTestDefaults(&BookingsComponent{
+				BookingRepository:    repo,
+				MembershipRepository: memberships,
+				Clock:                clock.NewFake(now.Add(-time.Hour)),
+			})
+
+			got, err := bc.CreateBooking(context.Background(), &entities.Booking{Name: "Ann", Date: now})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantStatus, got.Status)
+			}
+			assert.Equal(t, []int{8}, memberships.Debited)
+			assert.Equal(t, tt.wantRefunded, memberships.Refunded)
+		})
+	}
+}
+
+func TestBookingsComponent_CancelBooking_Waitlist(t *testing.T) {
+	now := testNow
+	// Inside the late cancellation window
+	class := &entities.Class{ID: 3, StartDate: now.Add(time.Hour), EndDate: now.Add(2 * time.Hour), Capacity: 1}
+
+	tests := []struct {
+		name         string
+		status       entities.BookingStatus
+		wantPromoted bool
+		wantRefunded []int
+	}{
+		{
+			name:         "should give the spot of a cancelled booking to the waitlist",
+			status:       entities.BookingConfirmed,
+			wantPromoted: true,
+		},
+		{
+			name:         "should refund leaving the waitlist, however late",
+			status:       entities.BookingWaitlisted,
+			wantRefunded: []int{8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var promotedFor *time.Time
+			repo := &MockBookingRepository{
+				FindBookingFn: func(ctx context.Context, id int) (*entities.Booking, error) {
+					return &entities.Booking{ID: id, ClassID: 3, Date: class.StartDate, Status: tt.status, MembershipID: 8}, nil
+				},
+				FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+					return class, nil
+				},
+				UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+					return nil
+				},
+				PromoteWaitlistedFn: func(ctx context.Context, c *entities.Class, date time.Time) (*entities.Booking, error) {
+					promotedFor = &date
+					return &entities.Booking{ID: 2, ClassID: 3, Date: date, Status: entities.BookingConfirmed}, nil
+				},
+			}
+			memberships := &MockMembershipRepository{Memberships: []entities.Membership{{ID: 8, Plan: entities.PlanClassPack, Credits: 4}}}
+			penalties := &MockPenaltyRepository{}
+			bc := withTestDefaults(&BookingsComponent{BookingRepository: repo, MembershipRepository: memberships})
+			bc.Penalties.PenaltyRepository = penalties
+
+			got, err := bc.CancelBooking(context.Background(), 1)
+
+			require.NoError(t, err)
+			assert.Equal(t, entities.BookingCancelled, got.Status)
+			assert.Equal(t, tt.wantRefunded, memberships.Refunded)
+			if tt.wantPromoted {
+				require.NotNil(t, promotedFor)
+				assert.Equal(t, class.StartDate, *promotedFor)
+				assert.Equal(t, []entities.EventType{entities.EventBookingCancelled, entities.EventWaitlistPromoted}, eventTypes(repo.Events))
+			} else {
+				assert.Nil(t, promotedFor, "a booking on the waitlist frees no spot")
+				assert.Empty(t, penalties.Penalties, "nor is it a late cancellation")
+			}
+		})
+	}
+}
+
+func TestBookingsComponent_ReleaseWaitlists(t *testing.T) {
+	now := testNow
+	class := &entities.Class{ID: 3, StartDate: now.Add(-time.Hour), EndDate: now.AddDate(0, 0, 7)}
+	var filter entities.BookingFilter
+	repo := &MockBookingRepository{
+		ListBookingsFn: func(ctx context.Context, f entities.BookingFilter) ([]entities.Booking, error) {
+			filter = f
+			return []entities.Booking{
+				{ID: 1, ClassID: 3, Date: class.StartDate, Status: entities.BookingWaitlisted, MembershipID: 8},
+				{ID: 2, ClassID: 3, Date: now.AddDate(0, 0, 1), Status: entities.BookingWaitlisted, MembershipID: 8},
+			}, nil
+		},
+		TransitionBookingFn: func(ctx context.Context, id int, from []entities.BookingStatus, to entities.BookingStatus) (*entities.Booking, error) {
+			return &entities.Booking{ID: id, Status: from[0]}, nil
+		},
+		FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+			return class, nil
+		},
+		UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+			return nil
+		},
+	}
+	memberships := &MockMembershipRepository{Memberships: []entities.Membership{{ID: 8, Plan: entities.PlanClassPack, Credits: 4}}}
+	bc := withTestDefaults(&BookingsComponent{BookingRepository: repo, MembershipRepository: memberships})
+
+	released, err := bc.ReleaseWaitlists(context.Background(), now)
+
+	require.NoError(t, err)
+	assert.Equal(t, entities.BookingFilter{Status: entities.BookingWaitlisted, To: now}, filter)
+	// Today's session started an hour ago; tomorrow's has not
+	assert.Equal(t, 1, released)
+	assert.Equal(t, []int{8}, memberships.Refunded)
+	assert.Equal(t, []entities.EventType{entities.EventBookingCancelled}, eventTypes(repo.Events))
+}
+
+func TestBookingsComponent_CreateBooking_RecordsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	bc := withTestDefaults(&BookingsComponent{
+		BookingRepository: &MockBookingRepository{
+			FindClassOnDateFn: func(ctx context.Context, t time.Time) (*entities.Class, error) {
+				return nil, nil
+			},
+		},
+		MembershipRepository: &MockMembershipRepository{},
+	})
+	_, err := bc.CreateBooking(context.Background(), &entities.Booking{Name: "Test booking", Date: testNow})
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "BookingsComponent.CreateBooking", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("outcome", "no_class"))
+}
+
+func TestBookingsComponent_CreateBooking_Suspended(t *testing.T) {
+	now := testNow
+	until := now.AddDate(0, 0, 3)
+	memberships := &MockMembershipRepository{Memberships: []entities.Membership{
+		{ID: 8, Name: "Ann", Plan: entities.PlanClassPack, Credits: 3, StartDate: now.AddDate(0, -1, 0)},
+	}}
+	bc := withTestDefaults(&BookingsComponent{
+		BookingRepository: &MockBookingRepository{
+			FindClassOnDateFn: func(ctx context.Context, t time.Time) (*entities.Class, error) {
+				return &entities.Class{ID: 3, StartDate: now, EndDate: now.Add(time.Hour), Studio: entities.DefaultStudio}, nil
+			},
+		},
+		MembershipRepository: memberships,
+		Clock:                clock.NewFake(now.Add(-time.Hour)),
+	})
+	bc.Penalties.PenaltyRepository = &MockPenaltyRepository{Penalties: []entities.Penalty{{
+		ID: 1, Name: "Ann", Studio: entities.DefaultStudio, Type: entities.PenaltySuspension, CreatedAt: now, ExpiresAt: &until,
+	}}}
+
+	_, err := bc.CreateBooking(context.Background(), &entities.Booking{Name: "Ann", Date: now})
+
+	assert.ErrorIs(t, err, ErrBookingSuspended)
+	assert.Empty(t, memberships.Debited)
+}
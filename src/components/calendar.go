@@ -0,0 +1,158 @@
+package components
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/ical"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"sort"
+	"time"
+)
+
+const (
+	// TimetablePast and TimetableAhead bound the sessions in the timetable
+	// feed, so that long-running classes do not make it grow without end.
+	TimetablePast  = 30 * 24 * time.Hour
+	TimetableAhead = 180 * 24 * time.Hour
+	// CalendarRefresh is how often calendar apps are asked to fetch feeds.
+	CalendarRefresh = time.Hour
+)
+
+// ErrCalendarNotFound is returned for unknown members and wrong tokens
+// alike, so that feeds cannot be used to find out who is a member.
+var ErrCalendarNotFound = errors.New("calendar not found")
+
+// CalendarComponent builds the iCalendar feeds of the timetable and of each
+// member's bookings.
+type CalendarComponent struct {
+	entities.ClassRepository
+	entities.BookingRepository
+	entities.MemberRepository
+	// Clock tells the time the timetable is centred on and events are
+	// stamped with.
+	Clock clock.Clock
+}
+
+func InitCalendarComponent() *CalendarComponent {
+	return &CalendarComponent{
+		ClassRepository:   &entities.ClassEntity{},
+		BookingRepository: &entities.BookingEntity{},
+		MemberRepository:  &entities.MemberEntity{},
+		Clock:             clock.Real{},
+	}
+}
+
+// Timetable returns every session of every class from TimetablePast ago to
+// TimetableAhead from now.
+func (cc *CalendarComponent) Timetable(ctx context.Context) (cal *ical.Calendar, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "CalendarComponent.Timetable")
+	outcome := "built"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	now := cc.Clock.Now()
+	from, to := now.Add(-TimetablePast), now.Add(TimetableAhead)
+	classes, err := cc.ListClasses(ctx, entities.ClassFilter{From: from, To: to})
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	cal = &ical.Calendar{Name: "Timetable", RefreshInterval: CalendarRefresh}
+	for _, class := range classes {
+		for start, end := range class.Sessions(from, to) {
+			status := ical.StatusConfirmed
+			if !class.Held(start) {
+				// Sessions called off stay in the feed so that calendar apps remove them
+				status = ical.StatusCancelled
+			}
+			cal.Events = append(cal.Events, ical.Event{
+				UID:      fmt.Sprintf("class-%d-%s@glofox", class.ID, start.UTC().Format("20060102T1504")),
+				Sequence: class.Revision,
+				Stamp:    now,
+				Start:    start,
+				End:      end,
+				Summary:  class.ClassName,
+				Location: class.Studio,
+				Status:   status,
+			})
+		}
+	}
+	sortEvents(cal.Events)
+	span.SetAttributes(attribute.Int("calendar.events", len(cal.Events)))
+	return cal, nil
+}
+
+// MemberCalendar returns the bookings of the named member, given their
+// calendar token. Cancelled bookings stay in the feed as cancelled events
+// so that calendar apps remove them.
+func (cc *CalendarComponent) MemberCalendar(ctx context.Context, name, token string) (cal *ical.Calendar, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "CalendarComponent.MemberCalendar")
+	outcome := "built"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	member, err := cc.FindMember(ctx, name)
+	if errors.Is(err, entities.ErrNotFound) {
+		outcome = "not_found"
+		return nil, ErrCalendarNotFound
+	}
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if member.CalendarToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(member.CalendarToken)) != 1 {
+		outcome = "invalid_token"
+		return nil, ErrCalendarNotFound
+	}
+
+	bookings, err := cc.ListBookings(ctx, entities.BookingFilter{Name: name})
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	now := cc.Clock.Now()
+	cal = &ical.Calendar{Name: "My bookings", RefreshInterval: CalendarRefresh}
+	for _, b := range bookings {
+		status, cancelled := ical.StatusConfirmed, 0
+		switch b.Status {
+		case entities.BookingConfirmed, entities.BookingAttended, entities.BookingNoShow:
+		case entities.BookingCancelled:
+			status, cancelled = ical.StatusCancelled, 1
+		default:
+			// Pending and failed bookings were never confirmed
+			continue
+		}
+		class, err := cc.BookingRepository.FindClass(ctx, b.ClassID)
+		if err != nil {
+			outcome = "error"
+			return nil, err
+		}
+		// The event changes with its class, and once more when the booking
+		// is cancelled, the one change a booking goes through
+		sequence := class.Revision + cancelled
+		start, end := class.Session(b.Date)
+		cal.Events = append(cal.Events, ical.Event{
+			UID:         fmt.Sprintf("booking-%d@glofox", b.ID),
+			Sequence:    sequence,
+			Stamp:       now,
+			Start:       start,
+			End:         end,
+			Summary:     class.ClassName,
+			Description: fmt.Sprintf("Booking #%d", b.ID),
+			Location:    class.Studio,
+			Status:      status,
+		})
+	}
+	sortEvents(cal.Events)
+	span.SetAttributes(attribute.Int("calendar.events", len(cal.Events)))
+	return cal, nil
+}
+
+func sortEvents(events []ical.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+}
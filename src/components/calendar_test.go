@@ -0,0 +1,157 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/ical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCalendar(bookings []entities.Booking) *CalendarComponent {
+	classes := []entities.Class{
+		{
+			ID:        1,
+			ClassName: "Yoga",
+			StartDate: time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2025, 5, 4, 10, 0, 0, 0, time.UTC),
+			Studio:    "main",
+		},
+		{
+			// Only the sessions within TimetableAhead are listed
+			ID:        2,
+			ClassName: "Spin",
+			StartDate: time.Date(2025, 5, 1, 7, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC),
+		},
+	}
+	return &CalendarComponent{
+		ClassRepository: &MockClassRepository{
+			ListClassesFn: func(ctx context.Context, filter entities.ClassFilter) ([]entities.Class, error) { return classes, nil },
+		},
+		BookingRepository: &MockBookingRepository{
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) { return &classes[id-1], nil },
+			ListBookingsFn: func(ctx context.Context, filter entities.BookingFilter) ([]entities.Booking, error) {
+				return bookings, nil
+			},
+		},
+		MemberRepository: &MockMemberRepository{Members: []entities.Member{
+			{Name: "Jane", CalendarToken: "secret"},
+			{Name: "Walk-in"},
+		}},
+		Clock: clock.NewFake(testNow),
+	}
+}
+
+func TestCalendarComponent_Timetable(t *testing.T) {
+	cc := newTestCalendar(nil)
+
+	cal, err := cc.Timetable(context.Background())
+	require.NoError(t, err)
+
+	var yoga, spin []ical.Event
+	for _, e := range cal.Events {
+		if e.Summary == "Yoga" {
+			yoga = append(yoga, e)
+		} else {
+			spin = append(spin, e)
+		}
+	}
+	require.Len(t, yoga, 3)
+	assert.Equal(t, ical.Event{
+		UID:      "class-1-20250502T0900@glofox",
+		Stamp:    testNow,
+		Start:    time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC),
+		End:      time.Date(2025, 5, 2, 10, 0, 0, 0, time.UTC),
+		Summary:  "Yoga",
+		Location: "main",
+		Status:   ical.StatusConfirmed,
+	}, yoga[0])
+	assert.Len(t, spin, int(TimetableAhead/(24*time.Hour))+1)
+	assert.True(t, cal.Events[0].Start.Before(cal.Events[1].Start), "events are in time order")
+}
+
+func TestCalendarComponent_Timetable_CancelledClass(t *testing.T) {
+	cc := newTestCalendar(nil)
+	classes := cc.ClassRepository.(*MockClassRepository)
+	list := classes.ListClassesFn
+	cancelledAt := time.Date(2025, 5, 2, 12, 0, 0, 0, time.UTC)
+	classes.ListClassesFn = func(ctx context.Context, filter entities.ClassFilter) ([]entities.Class, error) {
+		found, err := list(ctx, filter)
+		found[0].CancelledAt = &cancelledAt
+		found[0].Revision = 2
+		return found, err
+	}
+
+	cal, err := cc.Timetable(context.Background())
+	require.NoError(t, err)
+
+	var statuses []ical.Status
+	var sequences []int
+	for _, e := range cal.Events {
+		if e.Summary == "Yoga" {
+			statuses = append(statuses, e.Status)
+			sequences = append(sequences, e.Sequence)
+		}
+	}
+	assert.Equal(t, []ical.Status{ical.StatusConfirmed, ical.StatusCancelled, ical.StatusCancelled}, statuses)
+	assert.Equal(t, []int{2, 2, 2}, sequences, "events follow the class's revision")
+}
+
+func TestCalendarComponent_MemberCalendar(t *testing.T) {
+	cc := newTestCalendar([]entities.Booking{
+		{ID: 1, ClassID: 1, Name: "Jane", Date: time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC), Status: entities.BookingConfirmed},
+		{ID: 2, ClassID: 1, Name: "Jane", Date: time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC), Status: entities.BookingCancelled},
+		{ID: 3, ClassID: 1, Name: "Jane", Date: time.Date(2025, 5, 4, 0, 0, 0, 0, time.UTC), Status: entities.BookingPending},
+	})
+
+	cal, err := cc.MemberCalendar(context.Background(), "Jane", "secret")
+	require.NoError(t, err)
+	require.Len(t, cal.Events, 2)
+	assert.Equal(t, "booking-2@glofox", cal.Events[0].UID)
+	assert.Equal(t, ical.StatusCancelled, cal.Events[0].Status)
+	assert.Equal(t, 1, cal.Events[0].Sequence)
+	assert.Equal(t, "booking-1@glofox", cal.Events[1].UID)
+	assert.Equal(t, ical.StatusConfirmed, cal.Events[1].Status)
+	assert.Equal(t, 0, cal.Events[1].Sequence)
+	assert.Equal(t, time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC), cal.Events[1].Start)
+
+	// Changes to the class move its bookings' events on too
+	cc.BookingRepository.(*MockBookingRepository).FindClassFn = func(ctx context.Context, id int) (*entities.Class, error) {
+		return &entities.Class{ID: id, ClassName: "Yoga", StartDate: time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC),
+			EndDate: time.Date(2025, 5, 4, 10, 0, 0, 0, time.UTC), Revision: 3}, nil
+	}
+	cal, err = cc.MemberCalendar(context.Background(), "Jane", "secret")
+	require.NoError(t, err)
+	require.Len(t, cal.Events, 2)
+	assert.Equal(t, 4, cal.Events[0].Sequence)
+	assert.Equal(t, 3, cal.Events[1].Sequence)
+
+	for _, tc := range []struct{ name, token string }{{"Jane", "wrong"}, {"Jane", ""}, {"Walk-in", ""}, {"Bob", "secret"}} {
+		_, err := cc.MemberCalendar(context.Background(), tc.name, tc.token)
+		assert.ErrorIs(t, err, ErrCalendarNotFound, "%s with token %q", tc.name, tc.token)
+	}
+}
+
+func TestMembersComponent_RotateCalendarToken(t *testing.T) {
+	members := &MockMemberRepository{}
+	mc := &MembersComponent{MemberRepository: members}
+
+	created, err := mc.CreateMember(context.Background(), &entities.Member{Name: "Jane", CalendarToken: "chosen"})
+	require.NoError(t, err)
+	assert.Len(t, created.CalendarToken, 32)
+	assert.NotEqual(t, "chosen", created.CalendarToken)
+
+	rotated, err := mc.RotateCalendarToken(context.Background(), "Jane")
+	require.NoError(t, err)
+	assert.Len(t, rotated.CalendarToken, 32)
+	assert.NotEqual(t, created.CalendarToken, rotated.CalendarToken)
+	assert.Equal(t, rotated.CalendarToken, members.Members[0].CalendarToken)
+
+	_, err = mc.RotateCalendarToken(context.Background(), "Bob")
+	assert.ErrorIs(t, err, ErrMemberNotFound)
+}
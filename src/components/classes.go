@@ -1,17 +1,32 @@
 package components
 
 import (
+	"context"
 	"errors"
+	"github.com/Vidyuallatha/glofox/src/clock"
 	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrInvalidClassDates = errors.New("start and end dates are invalid")
+	ErrClassEnded        = errors.New("class has already ended")
+	ErrClassOverlaps     = errors.New("another class exists in this date range")
 )
 
 type ClassesComponent struct {
 	entities.ClassRepository
+	// Clock tells the time classes are checked against so that past classes
+	// are not created.
+	Clock clock.Clock
 }
 
 func InitClassesComponent() *ClassesComponent {
 	return &ClassesComponent{
-		&entities.ClassEntity{},
+		ClassRepository: &entities.ClassEntity{},
+		Clock:           clock.Real{},
 	}
 }
 func (cc *ClassesComponent) GetClassForm() *entities.Class {
@@ -24,25 +39,104 @@ func (cc *ClassesComponent) Validate(form *entities.Class) []error {
 		errs = append(errs, errors.New("class name is required"))
 	}
 	if form.StartDate.IsZero() {
-		errs = append(errs, errors.New("invalid start date format (expected YYYY-MM-DD)"))
+		errs = append(errs, errors.New("invalid start date format (expected RFC 3339 date-time)"))
 	}
 	if form.EndDate.IsZero() {
-		errs = append(errs, errors.New("invalid end date format (expected YYYY-MM-DD)"))
+		errs = append(errs, errors.New("invalid end date format (expected RFC 3339 date-time)"))
 	}
 	if form.Capacity <= 0 {
 		errs = append(errs, errors.New("capacity is required"))
 	}
+	if form.Price < 0 {
+		errs = append(errs, errors.New("price cannot be negative"))
+	}
+	if form.Price > 0 && len(form.Currency) != 3 {
+		errs = append(errs, errors.New("currency is required for priced classes (ISO 4217 code)"))
+	}
+	if (form.BookingOpensDaysBefore != nil && *form.BookingOpensDaysBefore < 0) ||
+		(form.BookingClosesMinutesBefore != nil && *form.BookingClosesMinutesBefore < 0) {
+		errs = append(errs, ErrNegativeWindow)
+	}
+	if form.CancelledAt != nil {
+		errs = append(errs, errors.New("cancelled_at cannot be set on a new class"))
+	}
+	if form.Revision != 0 {
+		errs = append(errs, errors.New("revision cannot be set on a new class"))
+	}
 	return errs
 }
 
-func (cc *ClassesComponent) CreateClass(class *entities.Class) (*entities.Class, error) {
+func (cc *ClassesComponent) CreateClass(ctx context.Context, class *entities.Class) (created *entities.Class, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "ClassesComponent.CreateClass",
+		attribute.String("class.name", class.ClassName))
+	outcome := "created"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	logger := utils.Logger(ctx).With("class_name", class.ClassName)
 	if class.StartDate.After(class.EndDate) || class.EndDate.Before(class.StartDate) {
-		return nil, errors.New("start and end dates are invalid")
+		outcome = "invalid_dates"
+		return nil, ErrInvalidClassDates
+	}
+	now := cc.Clock.Now()
+	if !class.EndDate.After(now) {
+		outcome = "in_past"
+		return nil, ErrClassEnded
 	}
 
-	if cc.CheckClassExists(class.StartDate, class.EndDate) {
-		return nil, errors.New("another class exists in this date range")
+	if class.Studio == "" {
+		class.Studio = entities.DefaultStudio
 	}
 
-	return cc.AddClass(class)
+	exists, err := cc.CheckClassExists(ctx, class.StartDate, class.EndDate)
+	if err != nil {
+		outcome = "error"
+		logger.Error("failed to look up overlapping classes", "error", err)
+		return nil, err
+	}
+	if exists {
+		outcome = "overlap"
+		logger.Info("class rejected", "reason", "overlapping class")
+		return nil, ErrClassOverlaps
+	}
+
+	created, err = cc.AddClass(ctx, class, entities.NewEvent(entities.ClassCreatedEvent{Class: class}, now))
+	if err != nil {
+		outcome = "error"
+		logger.Error("failed to add class", "error", err)
+		return nil, err
+	}
+	logger.Info("class created", "start_date", class.StartDate, "end_date", class.EndDate)
+	return created, nil
+}
+
+// CancelClass calls class id off from now on. Cancelling a class that is
+// already cancelled returns it unchanged, so a cancellation that failed part
+// way can be retried.
+func (cc *ClassesComponent) CancelClass(ctx context.Context, id int) (class *entities.Class, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "ClassesComponent.CancelClass", attribute.Int("class.id", id))
+	outcome := "cancelled"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	class, err = cc.FindClass(ctx, id)
+	if errors.Is(err, entities.ErrNotFound) {
+		outcome = "not_found"
+		return nil, ErrClassNotFound
+	}
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if class.CancelledAt != nil {
+		outcome = "already_cancelled"
+		return class, nil
+	}
+
+	now := cc.Clock.Now()
+	class.CancelledAt = &now
+	if err := cc.UpdateClass(ctx, class, entities.NewEvent(entities.ClassCancelledEvent{Class: class}, now)); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	utils.Logger(ctx).Info("class cancelled", "class_id", class.ID)
+	return class, nil
 }
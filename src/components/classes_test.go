@@ -1,37 +1,87 @@
 package components
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/Vidyuallatha/glofox/src/clock"
 	"github.com/Vidyuallatha/glofox/src/entities"
 	"github.com/stretchr/testify/assert"
 )
 
 type MockClassRepository struct {
-	CheckClassExistsFn func(start, end time.Time) bool
-	AddClassFn         func(class *entities.Class) (*entities.Class, error)
+	CheckClassExistsFn func(ctx context.Context, start, end time.Time) (bool, error)
+	AddClassFn         func(ctx context.Context, class *entities.Class) (*entities.Class, error)
+	ListClassesFn      func(ctx context.Context, filter entities.ClassFilter) ([]entities.Class, error)
+	FindClassFn        func(ctx context.Context, id int) (*entities.Class, error)
+	UpdateClassFn      func(ctx context.Context, class *entities.Class) error
+	// Events collects the events recorded by successful writes
+	Events []entities.Event
 }
 
-func (m *MockClassRepository) CheckClassExists(start, end time.Time) bool {
+func (m *MockClassRepository) CheckClassExists(ctx context.Context, start, end time.Time) (bool, error) {
 	if m.CheckClassExistsFn != nil {
-		return m.CheckClassExistsFn(start, end)
+		return m.CheckClassExistsFn(ctx, start, end)
 	}
-	return false
+	return false, nil
 }
 
-func (m *MockClassRepository) AddClass(class *entities.Class) (*entities.Class, error) {
+func (m *MockClassRepository) AddClass(ctx context.Context, class *entities.Class, events ...entities.Event) (*entities.Class, error) {
 	if m.AddClassFn != nil {
-		return m.AddClassFn(class)
+		created, err := m.AddClassFn(ctx, class)
+		if err == nil {
+			m.Events = append(m.Events, events...)
+		}
+		return created, err
 	}
 	return nil, errors.New("not implemented")
 }
 
+func (m *MockClassRepository) AddClasses(ctx context.Context, classes []*entities.Class, events ...entities.Event) error {
+	if m.AddClassFn == nil {
+		return errors.New("not implemented")
+	}
+	for _, class := range classes {
+		if _, err := m.AddClassFn(ctx, class); err != nil {
+			return err
+		}
+	}
+	m.Events = append(m.Events, events...)
+	return nil
+}
+
+func (m *MockClassRepository) UpdateClass(ctx context.Context, class *entities.Class, events ...entities.Event) error {
+	if m.UpdateClassFn != nil {
+		err := m.UpdateClassFn(ctx, class)
+		if err == nil {
+			m.Events = append(m.Events, events...)
+		}
+		return err
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockClassRepository) FindClass(ctx context.Context, id int) (*entities.Class, error) {
+	if m.FindClassFn != nil {
+		return m.FindClassFn(ctx, id)
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockClassRepository) ListClasses(ctx context.Context, filter entities.ClassFilter) ([]entities.Class, error) {
+	if m.ListClassesFn != nil {
+		return m.ListClassesFn(ctx, filter)
+	}
+	return nil, nil
+}
+
 func TestClassComponent_Valid(t *testing.T) {
 	cc := &ClassesComponent{}
 
 	now := time.Now()
+	negative := -30
 	tests := []struct {
 		name     string
 		form     *entities.Class
@@ -52,8 +102,8 @@ func TestClassComponent_Valid(t *testing.T) {
 			form: &entities.Class{},
 			expected: []string{
 				"class name is required",
-				"invalid start date format (expected YYYY-MM-DD)",
-				"invalid end date format (expected YYYY-MM-DD)",
+				"invalid start date format (expected RFC 3339 date-time)",
+				"invalid end date format (expected RFC 3339 date-time)",
 				"capacity is required",
 			},
 		},
@@ -76,6 +126,39 @@ func TestClassComponent_Valid(t *testing.T) {
 			},
 			expected: []string{"capacity is required"},
 		},
+		{
+			name: "should add validation errors for a negative price",
+			form: &entities.Class{
+				ClassName: "Yoga",
+				StartDate: now,
+				EndDate:   now.AddDate(0, 0, 1),
+				Capacity:  5,
+				Price:     -100,
+			},
+			expected: []string{"price cannot be negative"},
+		},
+		{
+			name: "should add validation error if a priced class has no currency",
+			form: &entities.Class{
+				ClassName: "Yoga",
+				StartDate: now,
+				EndDate:   now.AddDate(0, 0, 1),
+				Capacity:  5,
+				Price:     1500,
+			},
+			expected: []string{"currency is required for priced classes (ISO 4217 code)"},
+		},
+		{
+			name: "should add validation error for a negative booking window",
+			form: &entities.Class{
+				ClassName:                  "Yoga",
+				StartDate:                  now,
+				EndDate:                    now.AddDate(0, 0, 1),
+				Capacity:                   5,
+				BookingClosesMinutesBefore: &negative,
+			},
+			expected: []string{"booking window values cannot be negative"},
+		},
 		{
 			name: "should add validation error if start and end dates are missing",
 			form: &entities.Class{
@@ -83,8 +166,8 @@ func TestClassComponent_Valid(t *testing.T) {
 				Capacity:  5,
 			},
 			expected: []string{
-				"invalid start date format (expected YYYY-MM-DD)",
-				"invalid end date format (expected YYYY-MM-DD)",
+				"invalid start date format (expected RFC 3339 date-time)",
+				"invalid end date format (expected RFC 3339 date-time)",
 			},
 		},
 	}
@@ -107,7 +190,7 @@ func TestClassComponent_Valid(t *testing.T) {
 }
 
 func TestClassComponent_CreateClass(t *testing.T) {
-	now := time.Now()
+	now := testNow
 	nextWeek := now.AddDate(0, 0, 7)
 
 	tests := []struct {
@@ -127,10 +210,10 @@ func TestClassComponent_CreateClass(t *testing.T) {
 				Capacity:  15,
 			},
 			mockRepo: &MockClassRepository{
-				CheckClassExistsFn: func(start, end time.Time) bool {
-					return false
+				CheckClassExistsFn: func(ctx context.Context, start, end time.Time) (bool, error) {
+					return false, nil
 				},
-				AddClassFn: func(class *entities.Class) (*entities.Class, error) {
+				AddClassFn: func(ctx context.Context, class *entities.Class) (*entities.Class, error) {
 					return class, nil
 				},
 			},
@@ -139,6 +222,7 @@ func TestClassComponent_CreateClass(t *testing.T) {
 				StartDate: now,
 				EndDate:   nextWeek,
 				Capacity:  15,
+				Studio:    entities.DefaultStudio,
 			},
 		},
 		{
@@ -162,29 +246,79 @@ func TestClassComponent_CreateClass(t *testing.T) {
 				Capacity:  10,
 			},
 			mockRepo: &MockClassRepository{
-				CheckClassExistsFn: func(start, end time.Time) bool {
-					return true
+				CheckClassExistsFn: func(ctx context.Context, start, end time.Time) (bool, error) {
+					return true, nil
 				},
 			},
 			expectErr:   true,
 			expectedErr: "another class exists in this date range",
 		},
+		{
+			name: "should throw error if the class has already ended",
+			classInput: &entities.Class{
+				ClassName: "Spin",
+				StartDate: now.AddDate(0, 0, -7),
+				EndDate:   now.Add(-time.Hour),
+				Capacity:  10,
+			},
+			mockRepo:    &MockClassRepository{},
+			expectErr:   true,
+			expectedErr: "class has already ended",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cs := &ClassesComponent{
-				tt.mockRepo,
+				ClassRepository: tt.mockRepo,
+				Clock:           clock.NewFake(now),
 			}
-			got, err := cs.CreateClass(tt.classInput)
+			got, err := cs.CreateClass(context.Background(), tt.classInput)
 
 			if tt.expectErr {
 				assert.Error(t, err)
 				assert.EqualError(t, err, tt.expectedErr)
+				assert.Empty(t, tt.mockRepo.Events)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expected, got)
+				assert.Equal(t, []entities.EventType{entities.EventClassCreated}, eventTypes(tt.mockRepo.Events))
 			}
 		})
 	}
 }
+
+func TestClassComponent_CancelClass(t *testing.T) {
+	class := entities.Class{ID: 4, ClassName: "Yoga", StartDate: testNow, EndDate: testNow.AddDate(0, 0, 7)}
+	var updated []entities.Class
+	repo := &MockClassRepository{
+		FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+			if id != class.ID {
+				return nil, entities.ErrNotFound
+			}
+			found := class
+			return &found, nil
+		},
+		UpdateClassFn: func(ctx context.Context, c *entities.Class) error {
+			updated = append(updated, *c)
+			class = *c
+			return nil
+		},
+	}
+	cc := &ClassesComponent{ClassRepository: repo, Clock: clock.NewFake(testNow)}
+
+	got, err := cc.CancelClass(context.Background(), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, testNow, *got.CancelledAt)
+	assert.Len(t, updated, 1)
+	assert.Equal(t, []entities.EventType{entities.EventClassCancelled}, eventTypes(repo.Events))
+
+	// Cancelling again changes nothing
+	again, err := cc.CancelClass(context.Background(), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, got.CancelledAt, again.CancelledAt)
+	assert.Len(t, updated, 1)
+
+	_, err = cc.CancelClass(context.Background(), 5)
+	assert.ErrorIs(t, err, ErrClassNotFound)
+}
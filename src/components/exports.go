@@ -0,0 +1,291 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"iter"
+	"time"
+)
+
+// ListPageSize is how many records exports and reports read from a
+// repository at a time, so that memory use does not grow with the size of
+// the store.
+const ListPageSize = 500
+
+// ErrInvalidExportRange is returned when an export's range ends before it
+// starts.
+var ErrInvalidExportRange = errors.New("to must not be before from")
+
+// ExportFilter bounds an export by date, inclusive. Zero times leave that
+// end open.
+type ExportFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+func (f ExportFilter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return ErrInvalidExportRange
+	}
+	return nil
+}
+
+// ClassExportRow is a class as exported: one row per class running within
+// the range.
+type ClassExportRow struct {
+	ID        int       `json:"id"`
+	ClassName string    `json:"class_name"`
+	Studio    string    `json:"studio"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Capacity  int       `json:"capacity"`
+	// Price is the drop-in price in minor units of Currency.
+	Price    int    `json:"price"`
+	Currency string `json:"currency"`
+}
+
+// BookingExportRow is a booking as exported for accounting, with the price
+// breakdown flattened into columns. Amounts are in minor units of Currency.
+type BookingExportRow struct {
+	ID              int                    `json:"id"`
+	ClassID         int                    `json:"class_id"`
+	ClassName       string                 `json:"class_name"`
+	Name            string                 `json:"name"`
+	Date            time.Time              `json:"date"`
+	Status          entities.BookingStatus `json:"status"`
+	MembershipID    int                    `json:"membership_id"`
+	Currency        string                 `json:"currency"`
+	BasePrice       int                    `json:"base_price"`
+	TierDiscount    int                    `json:"tier_discount"`
+	PromoCode       string                 `json:"promo_code"`
+	PromoDiscount   int                    `json:"promo_discount"`
+	Total           int                    `json:"total"`
+	PaymentID       string                 `json:"payment_id"`
+	PaymentRefunded bool                   `json:"payment_refunded"`
+	CreditRefunded  bool                   `json:"credit_refunded"`
+}
+
+// AttendanceExportRow is a booking whose session has been settled, as
+// attended or as a no-show.
+type AttendanceExportRow struct {
+	BookingID   int                    `json:"booking_id"`
+	ClassID     int                    `json:"class_id"`
+	ClassName   string                 `json:"class_name"`
+	Name        string                 `json:"name"`
+	Date        time.Time              `json:"date"`
+	Status      entities.BookingStatus `json:"status"`
+	CheckedInAt *time.Time             `json:"checked_in_at"`
+	CheckedInBy string                 `json:"checked_in_by"`
+}
+
+// ExportsComponent reads classes, bookings and attendance out in bulk.
+// Exports are produced lazily, page by page, as they are iterated.
+type ExportsComponent struct {
+	entities.ClassRepository
+	entities.BookingRepository
+}
+
+func InitExportsComponent() *ExportsComponent {
+	return &ExportsComponent{
+		ClassRepository:   &entities.ClassEntity{},
+		BookingRepository: &entities.BookingEntity{},
+	}
+}
+
+// ExportClasses yields every class running at some point within filter.
+func (ec *ExportsComponent) ExportClasses(ctx context.Context, filter ExportFilter) iter.Seq2[ClassExportRow, error] {
+	return func(yield func(ClassExportRow, error) bool) {
+		ctx, span := telemetry.StartSpan(ctx, "ExportsComponent.ExportClasses")
+		rows := 0
+		outcome, err := "exported", error(nil)
+		defer func() {
+			span.SetAttributes(attribute.Int("export.rows", rows))
+			telemetry.EndSpan(span, outcome, err)
+		}()
+
+		for class, listErr := range pagedClasses(ctx, ec.ClassRepository, entities.ClassFilter{From: filter.From, To: filter.To}) {
+			if listErr != nil {
+				outcome, err = "error", listErr
+				yield(ClassExportRow{}, err)
+				return
+			}
+			rows++
+			if !yield(ClassExportRow{
+				ID:        class.ID,
+				ClassName: class.ClassName,
+				Studio:    class.Studio,
+				StartDate: class.StartDate,
+				EndDate:   class.EndDate,
+				Capacity:  class.Capacity,
+				Price:     class.Price,
+				Currency:  class.Currency,
+			}, nil) {
+				outcome = "stopped"
+				return
+			}
+		}
+	}
+}
+
+// ExportBookings yields every booking dated within filter, whatever its
+// status.
+func (ec *ExportsComponent) ExportBookings(ctx context.Context, filter ExportFilter) iter.Seq2[BookingExportRow, error] {
+	return func(yield func(BookingExportRow, error) bool) {
+		ctx, span := telemetry.StartSpan(ctx, "ExportsComponent.ExportBookings")
+		rows := 0
+		outcome, err := "exported", error(nil)
+		defer func() {
+			span.SetAttributes(attribute.Int("export.rows", rows))
+			telemetry.EndSpan(span, outcome, err)
+		}()
+
+		classNames := ec.classNames(ctx)
+		for booking, listErr := range pagedBookings(ctx, ec.BookingRepository, entities.BookingFilter{From: filter.From, To: filter.To}) {
+			var name string
+			if listErr == nil {
+				name, listErr = classNames(booking.ClassID)
+			}
+			if listErr != nil {
+				outcome, err = "error", listErr
+				yield(BookingExportRow{}, err)
+				return
+			}
+			row := BookingExportRow{
+				ID:              booking.ID,
+				ClassID:         booking.ClassID,
+				ClassName:       name,
+				Name:            booking.Name,
+				Date:            booking.Date,
+				Status:          booking.Status,
+				MembershipID:    booking.MembershipID,
+				PromoCode:       booking.PromoCode,
+				PaymentID:       booking.PaymentID,
+				PaymentRefunded: booking.PaymentRefunded,
+				CreditRefunded:  booking.CreditRefunded,
+			}
+			if price := booking.Price; price != nil {
+				row.Currency = price.Currency
+				row.BasePrice = price.BasePrice
+				row.TierDiscount = price.TierDiscount
+				row.PromoDiscount = price.PromoDiscount
+				row.Total = price.Total
+			}
+			rows++
+			if !yield(row, nil) {
+				outcome = "stopped"
+				return
+			}
+		}
+	}
+}
+
+// ExportAttendance yields every booking dated within filter that was
+// attended or missed.
+func (ec *ExportsComponent) ExportAttendance(ctx context.Context, filter ExportFilter) iter.Seq2[AttendanceExportRow, error] {
+	return func(yield func(AttendanceExportRow, error) bool) {
+		ctx, span := telemetry.StartSpan(ctx, "ExportsComponent.ExportAttendance")
+		rows := 0
+		outcome, err := "exported", error(nil)
+		defer func() {
+			span.SetAttributes(attribute.Int("export.rows", rows))
+			telemetry.EndSpan(span, outcome, err)
+		}()
+
+		classNames := ec.classNames(ctx)
+		for booking, listErr := range pagedBookings(ctx, ec.BookingRepository, entities.BookingFilter{From: filter.From, To: filter.To}) {
+			if listErr == nil && booking.Status != entities.BookingAttended && booking.Status != entities.BookingNoShow {
+				continue
+			}
+			var name string
+			if listErr == nil {
+				name, listErr = classNames(booking.ClassID)
+			}
+			if listErr != nil {
+				outcome, err = "error", listErr
+				yield(AttendanceExportRow{}, err)
+				return
+			}
+			rows++
+			if !yield(AttendanceExportRow{
+				BookingID:   booking.ID,
+				ClassID:     booking.ClassID,
+				ClassName:   name,
+				Name:        booking.Name,
+				Date:        booking.Date,
+				Status:      booking.Status,
+				CheckedInAt: booking.CheckedInAt,
+				CheckedInBy: booking.CheckedInBy,
+			}, nil) {
+				outcome = "stopped"
+				return
+			}
+		}
+	}
+}
+
+// classNames returns a lookup of class names that remembers the classes it
+// has found, as exports mention the same few classes over and over.
+func (ec *ExportsComponent) classNames(ctx context.Context) func(id int) (string, error) {
+	names := map[int]string{}
+	return func(id int) (string, error) {
+		if name, ok := names[id]; ok {
+			return name, nil
+		}
+		class, err := ec.BookingRepository.FindClass(ctx, id)
+		if errors.Is(err, entities.ErrNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		names[id] = class.ClassName
+		return class.ClassName, nil
+	}
+}
+
+// pagedClasses yields every class matching filter, reading ListPageSize at a
+// time.
+func pagedClasses(ctx context.Context, repo entities.ClassRepository, filter entities.ClassFilter) iter.Seq2[entities.Class, error] {
+	return paged(func(afterID int) ([]entities.Class, error) {
+		filter.After, filter.Limit = entities.Cursor{ID: afterID}, ListPageSize
+		return repo.ListClasses(ctx, filter)
+	}, func(c entities.Class) int { return c.ID })
+}
+
+// pagedBookings yields every booking matching filter, reading
+// ListPageSize at a time.
+func pagedBookings(ctx context.Context, repo entities.BookingRepository, filter entities.BookingFilter) iter.Seq2[entities.Booking, error] {
+	return paged(func(afterID int) ([]entities.Booking, error) {
+		filter.After, filter.Limit = entities.Cursor{ID: afterID}, ListPageSize
+		return repo.ListBookings(ctx, filter)
+	}, func(b entities.Booking) int { return b.ID })
+}
+
+// paged yields every record list returns, a page at a time. Each page is
+// listed after the ID of the last record of the page before, until a page
+// comes back short.
+func paged[T any](list func(afterID int) ([]T, error), id func(T) int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		afterID := 0
+		for {
+			page, err := list(afterID)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, record := range page {
+				if !yield(record, nil) {
+					return
+				}
+			}
+			if len(page) < ListPageSize {
+				return
+			}
+			afterID = id(page[len(page)-1])
+		}
+	}
+}
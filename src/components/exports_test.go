@@ -0,0 +1,172 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// servePages serves bookings through ListBookings the way BookingEntity
+// does, recording each filter it is called with.
+func servePages(bookings []entities.Booking, filters *[]entities.BookingFilter) func(context.Context, entities.BookingFilter) ([]entities.Booking, error) {
+	return func(ctx context.Context, f entities.BookingFilter) ([]entities.Booking, error) {
+		*filters = append(*filters, f)
+		var page []entities.Booking
+		for _, b := range bookings {
+			if b.ID > f.After.ID && len(page) < f.Limit {
+				page = append(page, b)
+			}
+		}
+		return page, nil
+	}
+}
+
+func TestExportsComponent_ExportBookings(t *testing.T) {
+	date := time.Date(2025, 5, 3, 18, 0, 0, 0, time.UTC)
+	bookings := make([]entities.Booking, 2*ListPageSize+1)
+	for i := range bookings {
+		bookings[i] = entities.Booking{ID: i + 1, ClassID: 1 + i%2, Name: "Ann", Date: date, Status: entities.BookingConfirmed}
+	}
+	bookings[0].Price = &entities.PriceBreakdown{Currency: "EUR", BasePrice: 1500, TierDiscount: 300, PromoCode: "SPRING", PromoDiscount: 200, Total: 1000}
+	bookings[0].PromoCode = "SPRING"
+	bookings[0].PaymentID = "pay_1"
+
+	var filters []entities.BookingFilter
+	lookups := 0
+	ec := &ExportsComponent{
+		BookingRepository: &MockBookingRepository{
+			ListBookingsFn: servePages(bookings, &filters),
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				lookups++
+				return &entities.Class{ID: id, ClassName: map[int]string{1: "Yoga", 2: "Spin"}[id]}, nil
+			},
+		},
+	}
+	filter := ExportFilter{From: date.AddDate(0, 0, -1), To: date}
+
+	var rows []BookingExportRow
+	for row, err := range ec.ExportBookings(context.Background(), filter) {
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+
+	require.Len(t, rows, len(bookings))
+	assert.Equal(t, BookingExportRow{
+		ID: 1, ClassID: 1, ClassName: "Yoga", Name: "Ann", Date: date, Status: entities.BookingConfirmed,
+		Currency: "EUR", BasePrice: 1500, TierDiscount: 300, PromoCode: "SPRING", PromoDiscount: 200, Total: 1000,
+		PaymentID: "pay_1",
+	}, rows[0])
+	assert.Equal(t, "Spin", rows[1].ClassName)
+	assert.Equal(t, 2, lookups, "class names should be looked up once per class")
+
+	require.Len(t, filters, 3)
+	for i, f := range filters {
+		assert.Equal(t, entities.BookingFilter{From: filter.From, To: filter.To, After: entities.Cursor{ID: i * ListPageSize}, Limit: ListPageSize}, f)
+	}
+}
+
+func TestExportsComponent_ExportBookings_Stop(t *testing.T) {
+	bookings := make([]entities.Booking, 2*ListPageSize)
+	for i := range bookings {
+		bookings[i] = entities.Booking{ID: i + 1, ClassID: 1}
+	}
+	var filters []entities.BookingFilter
+	ec := &ExportsComponent{
+		BookingRepository: &MockBookingRepository{
+			ListBookingsFn: servePages(bookings, &filters),
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				return nil, entities.ErrNotFound
+			},
+		},
+	}
+
+	for range ec.ExportBookings(context.Background(), ExportFilter{}) {
+		break
+	}
+	assert.Len(t, filters, 1, "no more pages should be read once the caller stops")
+}
+
+func TestExportsComponent_ExportAttendance(t *testing.T) {
+	checkedIn := time.Date(2025, 5, 3, 17, 55, 0, 0, time.UTC)
+	bookings := []entities.Booking{
+		{ID: 1, ClassID: 1, Name: "Ann", Status: entities.BookingAttended, CheckedInAt: &checkedIn, CheckedInBy: "staff"},
+		{ID: 2, ClassID: 1, Name: "Bob", Status: entities.BookingConfirmed},
+		{ID: 3, ClassID: 1, Name: "Cat", Status: entities.BookingNoShow},
+		{ID: 4, ClassID: 1, Name: "Dan", Status: entities.BookingCancelled},
+	}
+	var filters []entities.BookingFilter
+	ec := &ExportsComponent{
+		BookingRepository: &MockBookingRepository{
+			ListBookingsFn: servePages(bookings, &filters),
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+				return &entities.Class{ID: id, ClassName: "Yoga"}, nil
+			},
+		},
+	}
+
+	var rows []AttendanceExportRow
+	for row, err := range ec.ExportAttendance(context.Background(), ExportFilter{}) {
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+
+	assert.Equal(t, []AttendanceExportRow{
+		{BookingID: 1, ClassID: 1, ClassName: "Yoga", Name: "Ann", Status: entities.BookingAttended, CheckedInAt: &checkedIn, CheckedInBy: "staff"},
+		{BookingID: 3, ClassID: 1, ClassName: "Yoga", Name: "Cat", Status: entities.BookingNoShow},
+	}, rows)
+}
+
+func TestExportsComponent_ExportClasses(t *testing.T) {
+	start := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	var got entities.ClassFilter
+	ec := &ExportsComponent{
+		ClassRepository: &MockClassRepository{
+			ListClassesFn: func(ctx context.Context, f entities.ClassFilter) ([]entities.Class, error) {
+				got = f
+				return []entities.Class{{ID: 1, ClassName: "Yoga", Studio: "main", StartDate: start, EndDate: start.AddDate(0, 1, 0), Capacity: 10, Price: 1500, Currency: "EUR"}}, nil
+			},
+		},
+	}
+	filter := ExportFilter{From: start, To: start.AddDate(0, 0, 7)}
+
+	var rows []ClassExportRow
+	for row, err := range ec.ExportClasses(context.Background(), filter) {
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+
+	assert.Equal(t, entities.ClassFilter{From: filter.From, To: filter.To, Limit: ListPageSize}, got)
+	assert.Equal(t, []ClassExportRow{{
+		ID: 1, ClassName: "Yoga", Studio: "main", StartDate: start, EndDate: start.AddDate(0, 1, 0), Capacity: 10, Price: 1500, Currency: "EUR",
+	}}, rows)
+}
+
+func TestExportsComponent_ListError(t *testing.T) {
+	failure := errors.New("store unavailable")
+	ec := &ExportsComponent{
+		ClassRepository: &MockClassRepository{
+			ListClassesFn: func(ctx context.Context, f entities.ClassFilter) ([]entities.Class, error) {
+				return nil, failure
+			},
+		},
+	}
+
+	var errs []error
+	for _, err := range ec.ExportClasses(context.Background(), ExportFilter{}) {
+		errs = append(errs, err)
+	}
+	assert.Equal(t, []error{failure}, errs)
+}
+
+func TestExportFilter_Validate(t *testing.T) {
+	day := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, ExportFilter{}.Validate())
+	assert.NoError(t, ExportFilter{From: day, To: day}.Validate())
+	assert.NoError(t, ExportFilter{To: day}.Validate())
+	assert.ErrorIs(t, ExportFilter{From: day, To: day.AddDate(0, 0, -1)}.Validate(), ErrInvalidExportRange)
+}
@@ -0,0 +1,246 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/records"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"slices"
+)
+
+// MaxImportRows bounds the rows of one import, which is checked and applied
+// in memory.
+const MaxImportRows = 5000
+
+// ErrInvalidImport is returned, along with the report listing why, when
+// any row of an import is invalid. Nothing is imported.
+var ErrInvalidImport = errors.New("import has invalid rows")
+
+// ImportRowError lists what is wrong with one row of an import. Rows are
+// numbered from 1, not counting a CSV header.
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// ImportReport describes the outcome of an import.
+type ImportReport[T any] struct {
+	DryRun bool             `json:"dry_run"`
+	Rows   int              `json:"rows"`
+	Errors []ImportRowError `json:"errors,omitempty"`
+	// Imported holds the stored records. It is empty for dry runs and
+	// imports with errors.
+	Imported []T `json:"imported,omitempty"`
+}
+
+func (r *ImportReport[T]) addError(row int, errs ...error) {
+	e := ImportRowError{Row: row}
+	for _, err := range errs {
+		e.Errors = append(e.Errors, err.Error())
+	}
+	r.Errors = append(r.Errors, e)
+}
+
+// decodeRows reads every row of r, recording the rows that do not decode in
+// report. Rows that do not decode are returned as nil so that indexes match
+// row numbers minus one.
+func decodeRows[T any](r io.Reader, format records.Format, report *ImportReport[T]) ([]*T, error) {
+	dec := records.NewDecoder(r, format)
+	var rows []*T
+	for {
+		row := new(T)
+		err := dec.Decode(row)
+		if err == io.EOF {
+			return rows, nil
+		}
+		var rowErr *records.RowError
+		if errors.As(err, &rowErr) {
+			report.addError(rowErr.Row, rowErr.Err)
+			row = nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(rows) == MaxImportRows {
+			return nil, fmt.Errorf("imports are limited to %d rows", MaxImportRows)
+		}
+		rows = append(rows, row)
+		report.Rows++
+	}
+}
+
+// ImportClasses checks every class in r as CreateClass would, including
+// for overlaps with the other classes in r, then stores all of them or, if
+// any is invalid or dryRun is set, none. A report with errors comes with
+// ErrInvalidImport unless it is a dry run.
+func (cc *ClassesComponent) ImportClasses(ctx context.Context, r io.Reader, format records.Format, dryRun bool) (report *ImportReport[entities.Class], err error) {
+	ctx, span := telemetry.StartSpan(ctx, "ClassesComponent.ImportClasses",
+		attribute.String("import.format", string(format)), attribute.Bool("import.dry_run", dryRun))
+	outcome := "imported"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	report = &ImportReport[entities.Class]{DryRun: dryRun}
+	rows, err := decodeRows(r, format, report)
+	if err != nil {
+		outcome = "invalid_file"
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("import.rows", len(rows)))
+
+	now := cc.Clock.Now()
+	var classes []*entities.Class
+	var events []entities.Event
+	for i, class := range rows {
+		if class == nil {
+			continue
+		}
+		errs := cc.Validate(class)
+		if class.StartDate.After(class.EndDate) {
+			errs = append(errs, ErrInvalidClassDates)
+		} else if !class.EndDate.IsZero() && !class.EndDate.After(now) {
+			errs = append(errs, ErrClassEnded)
+		}
+		if class.Studio == "" {
+			class.Studio = entities.DefaultStudio
+		}
+
+		// Overlaps are only meaningful once the dates are valid.
+		if len(errs) == 0 {
+			exists, err := cc.CheckClassExists(ctx, class.StartDate, class.EndDate)
+			if err != nil {
+				outcome = "error"
+				return nil, err
+			}
+			if exists {
+				errs = append(errs, ErrClassOverlaps)
+			}
+			for j, other := range rows[:i] {
+				if other != nil && other.Overlaps(class.StartDate, class.EndDate) {
+					errs = append(errs, fmt.Errorf("overlaps the class in row %d", j+1))
+				}
+			}
+		}
+
+		if len(errs) > 0 {
+			report.addError(i+1, errs...)
+			continue
+		}
+		classes = append(classes, class)
+		events = append(events, entities.NewEvent(entities.ClassCreatedEvent{Class: class}, now))
+	}
+	span.SetAttributes(attribute.Int("import.invalid_rows", len(report.Errors)))
+
+	logger := utils.Logger(ctx).With("rows", report.Rows, "dry_run", dryRun)
+	if len(report.Errors) > 0 {
+		sortRowErrors(report.Errors)
+		logger.Info("class import rejected", "invalid_rows", len(report.Errors))
+	}
+	switch {
+	case dryRun:
+		outcome = "checked"
+		return report, nil
+	case len(report.Errors) > 0:
+		outcome = "invalid"
+		return report, ErrInvalidImport
+	}
+
+	if err = cc.AddClasses(ctx, classes, events...); err != nil {
+		outcome = "error"
+		logger.Error("failed to import classes", "error", err)
+		return nil, err
+	}
+	for _, class := range classes {
+		report.Imported = append(report.Imported, *class)
+	}
+	logger.Info("classes imported")
+	return report, nil
+}
+
+// ImportMembers checks every member in r as CreateMember would, including
+// for names repeated within r, then stores all of them or, if any is invalid
+// or dryRun is set, none.
+func (mc *MembersComponent) ImportMembers(ctx context.Context, r io.Reader, format records.Format, dryRun bool) (report *ImportReport[entities.Member], err error) {
+	ctx, span := telemetry.StartSpan(ctx, "MembersComponent.ImportMembers",
+		attribute.String("import.format", string(format)), attribute.Bool("import.dry_run", dryRun))
+	outcome := "imported"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	report = &ImportReport[entities.Member]{DryRun: dryRun}
+	rows, err := decodeRows(r, format, report)
+	if err != nil {
+		outcome = "invalid_file"
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("import.rows", len(rows)))
+
+	var members []entities.Member
+	seen := map[string]int{}
+	for i, member := range rows {
+		if member == nil {
+			continue
+		}
+		errs := mc.Validate(member)
+		if member.Name != "" {
+			_, err := mc.FindMember(ctx, member.Name)
+			if err == nil {
+				errs = append(errs, errors.New("a member with that name already exists"))
+			} else if !errors.Is(err, entities.ErrNotFound) {
+				outcome = "error"
+				return nil, err
+			}
+			if row, ok := seen[member.Name]; ok {
+				errs = append(errs, fmt.Errorf("repeats the name in row %d", row))
+			} else {
+				seen[member.Name] = i + 1
+			}
+		}
+		if len(errs) > 0 {
+			report.addError(i+1, errs...)
+			continue
+		}
+
+		if member.Tier == "" {
+			member.Tier = entities.TierStandard
+		}
+		member.NoShows = 0
+		if member.CalendarToken, err = newToken(); err != nil {
+			outcome = "error"
+			return nil, err
+		}
+		members = append(members, *member)
+	}
+	span.SetAttributes(attribute.Int("import.invalid_rows", len(report.Errors)))
+
+	logger := utils.Logger(ctx).With("rows", report.Rows, "dry_run", dryRun)
+	if len(report.Errors) > 0 {
+		sortRowErrors(report.Errors)
+		logger.Info("member import rejected", "invalid_rows", len(report.Errors))
+	}
+	switch {
+	case dryRun:
+		outcome = "checked"
+		return report, nil
+	case len(report.Errors) > 0:
+		outcome = "invalid"
+		return report, ErrInvalidImport
+	}
+
+	if err = mc.AddMembers(ctx, members); err != nil {
+		outcome = "error"
+		logger.Error("failed to import members", "error", err)
+		return nil, err
+	}
+	report.Imported = members
+	logger.Info("members imported")
+	return report, nil
+}
+
+// sortRowErrors orders errors by row. Rows that failed to decode are
+// reported before the checks of the other rows run.
+func sortRowErrors(errs []ImportRowError) {
+	slices.SortStableFunc(errs, func(a, b ImportRowError) int { return a.Row - b.Row })
+}
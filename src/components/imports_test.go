@@ -0,0 +1,121 @@
+package components
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/records"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClassImport(existing ...entities.Class) (*ClassesComponent, *[]entities.Class, *MockClassRepository) {
+	stored := &existing
+	repo := &MockClassRepository{
+		CheckClassExistsFn: func(ctx context.Context, start, end time.Time) (bool, error) {
+			for _, c := range *stored {
+				if c.Overlaps(start, end) {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		AddClassFn: func(ctx context.Context, class *entities.Class) (*entities.Class, error) {
+			class.ID = len(*stored) + 1
+			*stored = append(*stored, *class)
+			return class, nil
+		},
+	}
+	return &ClassesComponent{ClassRepository: repo, Clock: clock.NewFake(testNow)}, stored, repo
+}
+
+func TestClassesComponent_ImportClasses(t *testing.T) {
+	cc, stored, repo := newTestClassImport()
+	csv := `class_name,start_date,end_date,capacity,studio
+Yoga,2025-06-01T09:00:00Z,2025-06-30T10:00:00Z,20,
+Spin,2025-07-01T07:00:00Z,2025-07-01T08:00:00Z,12,north
+`
+
+	report, err := cc.ImportClasses(context.Background(), strings.NewReader(csv), records.FormatCSV, true)
+	require.NoError(t, err)
+	assert.Equal(t, &ImportReport[entities.Class]{DryRun: true, Rows: 2}, report)
+	assert.Empty(t, *stored, "a dry run stores nothing")
+
+	report, err = cc.ImportClasses(context.Background(), strings.NewReader(csv), records.FormatCSV, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	require.Len(t, report.Imported, 2)
+	assert.Equal(t, 1, report.Imported[0].ID)
+	assert.Equal(t, entities.DefaultStudio, report.Imported[0].Studio)
+	assert.Equal(t, "north", report.Imported[1].Studio)
+	assert.Len(t, *stored, 2)
+	assert.Equal(t, []entities.EventType{entities.EventClassCreated, entities.EventClassCreated}, eventTypes(repo.Events))
+}
+
+func TestClassesComponent_ImportClasses_InvalidRows(t *testing.T) {
+	cc, stored, _ := newTestClassImport(entities.Class{
+		ID: 1, ClassName: "Zumba",
+		StartDate: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+	})
+	ndjson := `{"class_name":"Yoga","start_date":"2025-07-01T09:00:00Z","end_date":"2025-07-31T10:00:00Z","capacity":20}
+{"class_name":"Clash","start_date":"2025-07-10T09:00:00Z","end_date":"2025-07-10T10:00:00Z","capacity":5}
+{"class_name":"Zumba again","start_date":"2025-06-01T09:00:00Z","end_date":"2025-06-01T10:00:00Z","capacity":5}
+{"class_name":"","start_date":"2025-04-01T09:00:00Z","end_date":"2025-04-01T10:00:00Z","capacity":5}
+{"class_name":"Broken","capacity":"ten"}
+`
+
+	for _, dryRun := range []bool{true, false} {
+		report, err := cc.ImportClasses(context.Background(), strings.NewReader(ndjson), records.FormatNDJSON, dryRun)
+		if dryRun {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, ErrInvalidImport)
+		}
+		assert.Equal(t, 5, report.Rows)
+		assert.Equal(t, []ImportRowError{
+			{Row: 2, Errors: []string{"overlaps the class in row 1"}},
+			{Row: 3, Errors: []string{"another class exists in this date range"}},
+			{Row: 4, Errors: []string{"class name is required", "class has already ended"}},
+			{Row: 5, Errors: []string{"json: cannot unmarshal string into Go struct field Class.capacity of type int"}},
+		}, report.Errors)
+		assert.Empty(t, report.Imported)
+		assert.Len(t, *stored, 1, "nothing is imported")
+	}
+}
+
+func TestMembersComponent_ImportMembers(t *testing.T) {
+	members := &MockMemberRepository{Members: []entities.Member{{Name: "Ann"}}}
+	mc := &MembersComponent{MemberRepository: members}
+	csv := `name,tier,email,phone,notification_channels
+Jane,student,jane@example.com,,email
+Sam,,,+353861234567,sms
+`
+
+	report, err := mc.ImportMembers(context.Background(), strings.NewReader(csv), records.FormatCSV, false)
+	require.NoError(t, err)
+	require.Len(t, report.Imported, 2)
+	assert.Equal(t, entities.TierStudent, report.Imported[0].Tier)
+	assert.Equal(t, entities.TierStandard, report.Imported[1].Tier)
+	assert.Equal(t, []entities.NotificationChannel{entities.ChannelSMS}, report.Imported[1].NotificationChannels)
+	assert.Len(t, report.Imported[1].CalendarToken, 32)
+	assert.Len(t, members.Members, 3)
+
+	invalid := `name,tier,phone
+Ann,,
+Lee,gold,
+Lee,,12345
+`
+	report, err = mc.ImportMembers(context.Background(), strings.NewReader(invalid), records.FormatCSV, false)
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	assert.Equal(t, []ImportRowError{
+		{Row: 1, Errors: []string{"a member with that name already exists"}},
+		{Row: 2, Errors: []string{"tier must be one of standard, student or premium"}},
+		{Row: 3, Errors: []string{"phone must be an E.164 number, e.g. +353861234567", "repeats the name in row 2"}},
+	}, report.Errors)
+	assert.Len(t, members.Members, 3)
+}
@@ -0,0 +1,154 @@
+package components
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/mail"
+	"regexp"
+)
+
+var (
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrWrongMemberToken is returned when a member's own token does not
+	// match.
+	ErrWrongMemberToken = errors.New("a valid member token or staff key is required")
+
+	// phonePattern matches E.164 numbers such as +353861234567.
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+)
+
+type MembersComponent struct {
+	entities.MemberRepository
+}
+
+func InitMembersComponent() *MembersComponent {
+	return &MembersComponent{
+		&entities.MemberEntity{},
+	}
+}
+
+func (mc *MembersComponent) GetMemberForm() *entities.Member {
+	return new(entities.Member)
+}
+
+func (mc *MembersComponent) Validate(form *entities.Member) []error {
+	var errs []error
+	if form.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	switch form.Tier {
+	case "", entities.TierStandard, entities.TierStudent, entities.TierPremium:
+	default:
+		errs = append(errs, errors.New("tier must be one of standard, student or premium"))
+	}
+	if form.Email != "" {
+		if addr, err := mail.ParseAddress(form.Email); err != nil || addr.Address != form.Email {
+			errs = append(errs, errors.New("email must be a plain email address"))
+		}
+	}
+	if form.Phone != "" && !phonePattern.MatchString(form.Phone) {
+		errs = append(errs, errors.New("phone must be an E.164 number, e.g. +353861234567"))
+	}
+	errs = append(errs, validateChannels(*form, form.NotificationChannels)...)
+	return errs
+}
+
+// validateChannels checks that channels are known and that member has the
+// contact details each of them needs.
+func validateChannels(member entities.Member, channels []entities.NotificationChannel) []error {
+	var errs []error
+	for _, c := range channels {
+		switch {
+		case c == entities.ChannelEmail && member.Email == "":
+			errs = append(errs, errors.New("email notifications need an email address"))
+		case c == entities.ChannelSMS && member.Phone == "":
+			errs = append(errs, errors.New("sms notifications need a phone number"))
+		case c != entities.ChannelEmail && c != entities.ChannelSMS:
+			errs = append(errs, fmt.Errorf("notification channel must be email or sms, got %q", c))
+		}
+	}
+	return errs
+}
+
+func (mc *MembersComponent) CreateMember(ctx context.Context, member *entities.Member) (*entities.Member, error) {
+	if member.Tier == "" {
+		member.Tier = entities.TierStandard
+	}
+	member.NoShows = 0
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	member.CalendarToken = token
+
+	created, err := mc.AddMember(ctx, member)
+	if err != nil {
+		utils.Logger(ctx).Info("member rejected", "reason", err)
+		return nil, err
+	}
+	utils.Logger(ctx).Info("member created", "name", created.Name, "tier", created.Tier)
+	return created, nil
+}
+
+// CheckMemberToken checks token against the named member's calendar token,
+// which also lets members change their own settings.
+func (mc *MembersComponent) CheckMemberToken(ctx context.Context, name, token string) error {
+	member, err := mc.FindMember(ctx, name)
+	if errors.Is(err, entities.ErrNotFound) {
+		return ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if member.CalendarToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(member.CalendarToken)) != 1 {
+		return ErrWrongMemberToken
+	}
+	return nil
+}
+
+// SetNotificationPreferences replaces the channels the member is notified
+// on and whether they opted out of notifications altogether.
+func (mc *MembersComponent) SetNotificationPreferences(ctx context.Context, name string, channels []entities.NotificationChannel, optOut bool) (*entities.Member, error) {
+	member, err := mc.FindMember(ctx, name)
+	if errors.Is(err, entities.ErrNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if errs := validateChannels(*member, channels); errs != nil {
+		return nil, errs[0]
+	}
+
+	member.NotificationChannels = channels
+	member.NotificationsOptOut = optOut
+	if err := mc.UpdateMember(ctx, member); err != nil {
+		return nil, err
+	}
+	utils.Logger(ctx).Info("notification preferences set", "name", name, "channels", channels, "opt_out", optOut)
+	return member, nil
+}
+
+// RotateCalendarToken gives the member a new calendar token, so that feed
+// URLs given out with the old one stop working.
+func (mc *MembersComponent) RotateCalendarToken(ctx context.Context, name string) (*entities.Member, error) {
+	member, err := mc.FindMember(ctx, name)
+	if errors.Is(err, entities.ErrNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if member.CalendarToken, err = newToken(); err != nil {
+		return nil, err
+	}
+	if err := mc.UpdateMember(ctx, member); err != nil {
+		return nil, err
+	}
+	utils.Logger(ctx).Info("calendar token rotated", "name", name)
+	return member, nil
+}
@@ -0,0 +1,60 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/utils"
+)
+
+type MembershipsComponent struct {
+	entities.MembershipRepository
+}
+
+func InitMembershipsComponent() *MembershipsComponent {
+	return &MembershipsComponent{
+		&entities.MembershipEntity{},
+	}
+}
+
+func (mc *MembershipsComponent) GetMembershipForm() *entities.Membership {
+	return new(entities.Membership)
+}
+
+func (mc *MembershipsComponent) Validate(form *entities.Membership) []error {
+	var errs []error
+	if form.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	switch form.Plan {
+	case entities.PlanUnlimitedMonthly, entities.PlanClassPack, entities.PlanDropIn:
+	default:
+		errs = append(errs, errors.New("plan must be one of unlimited_monthly, class_pack or drop_in"))
+	}
+	if form.StartDate.IsZero() {
+		errs = append(errs, errors.New("invalid start date format (expected RFC 3339 date-time)"))
+	}
+	return errs
+}
+
+// CreateMembership assigns a plan to a member, filling in the validity and
+// credits the plan comes with.
+func (mc *MembershipsComponent) CreateMembership(ctx context.Context, membership *entities.Membership) (*entities.Membership, error) {
+	membership.Credits = 0
+	membership.EndDate = nil
+	switch membership.Plan {
+	case entities.PlanUnlimitedMonthly:
+		end := membership.StartDate.AddDate(0, 1, 0)
+		membership.EndDate = &end
+	case entities.PlanClassPack:
+		membership.Credits = entities.ClassPackCredits
+	}
+
+	created, err := mc.AddMembership(ctx, membership)
+	if err != nil {
+		utils.Logger(ctx).Error("failed to add membership", "error", err)
+		return nil, err
+	}
+	utils.Logger(ctx).Info("membership created", "name", created.Name, "plan", created.Plan)
+	return created, nil
+}
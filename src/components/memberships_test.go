@@ -0,0 +1,147 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockMembershipRepository keeps memberships in memory and records credit
+// movements.
+type MockMembershipRepository struct {
+	Memberships []entities.Membership
+	Debited     []int
+	Refunded    []int
+	// FindErr, when set, fails every lookup of a single membership
+	FindErr error
+}
+
+func (m *MockMembershipRepository) AddMembership(ctx context.Context, membership *entities.Membership) (*entities.Membership, error) {
+	membership.ID = len(m.Memberships) + 1
+	m.Memberships = append(m.Memberships, *membership)
+	return membership, nil
+}
+
+func (m *MockMembershipRepository) DebitCredit(ctx context.Context, id int) error {
+	for i := range m.Memberships {
+		if m.Memberships[i].ID == id {
+			if m.Memberships[i].Credits <= 0 {
+				return entities.ErrNoCredits
+			}
+			m.Memberships[i].Credits--
+			m.Debited = append(m.Debited, id)
+			return nil
+		}
+	}
+	return entities.ErrNotFound
+}
+
+func (m *MockMembershipRepository) RefundCredit(ctx context.Context, id int) error {
+	m.Refunded = append(m.Refunded, id)
+	return nil
+}
+
+func (m *MockMembershipRepository) FindMembership(ctx context.Context, id int) (*entities.Membership, error) {
+	if m.FindErr != nil {
+		return nil, m.FindErr
+	}
+	for _, membership := range m.Memberships {
+		if membership.ID == id {
+			return &membership, nil
+		}
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockMembershipRepository) FindMemberships(ctx context.Context, name string) ([]entities.Membership, error) {
+	var found []entities.Membership
+	for _, membership := range m.Memberships {
+		if membership.Name == name {
+			found = append(found, membership)
+		}
+	}
+	return found, nil
+}
+
+func TestMembershipsComponent_Valid(t *testing.T) {
+	mc := &MembershipsComponent{}
+
+	tests := []struct {
+		name     string
+		form     *entities.Membership
+		expected []string
+	}{
+		{
+			name:     "should not have any validation errors if form is valid",
+			form:     &entities.Membership{Name: "John Doe", Plan: entities.PlanClassPack, StartDate: time.Now()},
+			expected: nil,
+		},
+		{
+			name: "should have validation errors if all the fields in form are missing",
+			form: &entities.Membership{},
+			expected: []string{
+				"name is required",
+				"plan must be one of unlimited_monthly, class_pack or drop_in",
+				"invalid start date format (expected RFC 3339 date-time)",
+			},
+		},
+		{
+			name:     "should add validation error for an unknown plan",
+			form:     &entities.Membership{Name: "John Doe", Plan: "annual", StartDate: time.Now()},
+			expected: []string{"plan must be one of unlimited_monthly, class_pack or drop_in"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := mc.Validate(tt.form)
+
+			if tt.expected == nil {
+				assert.Empty(t, errs)
+			} else {
+				var actual []string
+				for _, err := range errs {
+					actual = append(actual, err.Error())
+				}
+				assert.ElementsMatch(t, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMembershipsComponent_CreateMembership(t *testing.T) {
+	start := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := start.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name            string
+		plan            entities.PlanType
+		expectedCredits int
+		expectedEnd     *time.Time
+	}{
+		{name: "should make unlimited memberships valid for one month", plan: entities.PlanUnlimitedMonthly, expectedEnd: &monthEnd},
+		{name: "should load class packs with credits", plan: entities.PlanClassPack, expectedCredits: entities.ClassPackCredits},
+		{name: "should create drop-ins without credits or expiry", plan: entities.PlanDropIn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &MembershipsComponent{&MockMembershipRepository{}}
+
+			got, err := mc.CreateMembership(context.Background(), &entities.Membership{
+				Name:      "John Doe",
+				Plan:      tt.plan,
+				Credits:   99,
+				StartDate: start,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, got.ID)
+			assert.Equal(t, tt.expectedCredits, got.Credits)
+			assert.Equal(t, tt.expectedEnd, got.EndDate)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/notify"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"os"
+	"time"
+)
+
+// ReminderBefore is how long before a session members are reminded of it.
+const ReminderBefore = 2 * time.Hour
+
+// ReminderJob is the kind of the scheduler job that sends a reminder.
+const ReminderJob = "class-reminder"
+
+// JobScheduler schedules one-off background jobs.
+type JobScheduler interface {
+	At(ctx context.Context, kind, key string, runAt time.Time, payload any) (*entities.Job, error)
+}
+
+type reminderPayload struct {
+	BookingID int `json:"booking_id"`
+}
+
+// NotificationsComponent tells members about their bookings on the
+// channels they chose. Each notification is recorded and never sent twice,
+// so events and jobs delivered more than once are safe.
+type NotificationsComponent struct {
+	entities.NotificationRepository
+	entities.MemberRepository
+	entities.BookingRepository
+	// Notifiers send messages by channel. Channels without a notifier are
+	// skipped.
+	Notifiers map[entities.NotificationChannel]notify.Notifier
+	Templates *notify.Templates
+	// Reminders schedules the reminder sent ReminderBefore each booked
+	// session. No reminders are sent when nil.
+	Reminders JobScheduler
+	// Clock tells the time notifications are recorded at.
+	Clock clock.Clock
+}
+
+func InitNotificationsComponent() *NotificationsComponent {
+	templates, err := notify.LoadTemplates()
+	if err != nil {
+		// The templates are embedded, so this only fails on a broken build
+		panic(err)
+	}
+	console := notify.NewWriterNotifier(os.Stdout)
+	return &NotificationsComponent{
+		NotificationRepository: &entities.NotificationEntity{},
+		MemberRepository:       &entities.MemberEntity{},
+		BookingRepository:      &entities.BookingEntity{},
+		Notifiers: map[entities.NotificationChannel]notify.Notifier{
+			entities.ChannelEmail: console,
+			entities.ChannelSMS:   console,
+		},
+		Templates: templates,
+		Clock:     clock.Real{},
+	}
+}
+
+// HandleEvent notifies the members a booking or class event is about and
+// schedules the reminder for new bookings. It is subscribed to the event
+// dispatcher.
+func (nc *NotificationsComponent) HandleEvent(ctx context.Context, event entities.Event) error {
+	key := fmt.Sprintf("event-%d", event.ID)
+	switch event.Type {
+	case entities.EventBookingCreated:
+		var payload entities.BookingCreatedEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		if payload.Booking.Status == entities.BookingWaitlisted {
+			// Nothing is confirmed until a spot frees up
+			return nil
+		}
+		if err := nc.notifyBooking(ctx, entities.NotificationBookingConfirmed, key, payload.Booking); err != nil {
+			return err
+		}
+		return nc.scheduleReminder(ctx, payload.Booking)
+	case entities.EventWaitlistPromoted:
+		var payload entities.WaitlistPromotedEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		if err := nc.notifyBooking(ctx, entities.NotificationWaitlistPromoted, key, payload.Booking); err != nil {
+			return err
+		}
+		return nc.scheduleReminder(ctx, payload.Booking)
+	case entities.EventBookingCancelled:
+		var payload entities.BookingCancelledEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		class, err := nc.BookingRepository.FindClass(ctx, payload.Booking.ClassID)
+		if err != nil {
+			return err
+		}
+		if cancelledByClass(class, payload.Booking, event.OccurredAt) {
+			return nc.notifyBooking(ctx, entities.NotificationClassCancelled, classCancelledKey(payload.Booking), payload.Booking)
+		}
+		return nc.notifyBooking(ctx, entities.NotificationBookingCancelled, key, payload.Booking)
+	case entities.EventClassCancelled:
+		var payload entities.ClassCancelledEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return nc.notifyClassCancelled(ctx, payload.Class)
+	}
+	return nil
+}
+
+// notifyClassCancelled tells the members still booked into sessions of a
+// cancelled class that they will not go ahead. The class's own sweep cancels
+// those bookings afterwards; their booking.cancelled events share the
+// notification key, so members hear about the cancellation once whichever
+// event arrives first.
+func (nc *NotificationsComponent) notifyClassCancelled(ctx context.Context, class *entities.Class) error {
+	if class.CancelledAt == nil {
+		return nil
+	}
+	bookings, err := nc.ListBookings(ctx, entities.BookingFilter{ClassID: class.ID})
+	if err != nil {
+		return err
+	}
+	for _, booking := range bookings {
+		if booking.Status != entities.BookingConfirmed && booking.Status != entities.BookingWaitlisted {
+			continue
+		}
+		if start, _ := class.Session(booking.Date); class.Held(start) {
+			continue
+		}
+		if err := nc.notifyBooking(ctx, entities.NotificationClassCancelled, classCancelledKey(&booking), &booking); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelledByClass reports whether a booking cancelled at cancelledAt was
+// cancelled because the studio called its session off, rather than by the
+// member before that.
+func cancelledByClass(class *entities.Class, booking *entities.Booking, cancelledAt time.Time) bool {
+	if class.CancelledAt == nil || cancelledAt.Before(*class.CancelledAt) {
+		return false
+	}
+	start, _ := class.Session(booking.Date)
+	return !class.Held(start)
+}
+
+func classCancelledKey(booking *entities.Booking) string {
+	return fmt.Sprintf("%s-%d", entities.NotificationClassCancelled, booking.ID)
+}
+
+func (nc *NotificationsComponent) scheduleReminder(ctx context.Context, booking *entities.Booking) error {
+	if nc.Reminders == nil {
+		return nil
+	}
+	class, err := nc.BookingRepository.FindClass(ctx, booking.ClassID)
+	if err != nil {
+		return err
+	}
+	start, _ := class.Session(booking.Date)
+	runAt := start.Add(-ReminderBefore)
+	if !runAt.After(nc.Clock.Now()) {
+		return nil
+	}
+	key := fmt.Sprintf("%s-%d", ReminderJob, booking.ID)
+	_, err = nc.Reminders.At(ctx, ReminderJob, key, runAt, reminderPayload{BookingID: booking.ID})
+	return err
+}
+
+// SendReminderJob is the scheduler handler that reminds a member of their
+// booking, unless it has since been cancelled.
+func (nc *NotificationsComponent) SendReminderJob(ctx context.Context, job entities.Job) error {
+	var payload reminderPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	booking, err := nc.FindBooking(ctx, payload.BookingID)
+	if errors.Is(err, entities.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if booking.Status != entities.BookingConfirmed {
+		return nil
+	}
+	key := fmt.Sprintf("%s-%d", ReminderJob, booking.ID)
+	return nc.notifyBooking(ctx, entities.NotificationClassReminder, key, booking)
+}
+
+func (nc *NotificationsComponent) notifyBooking(ctx context.Context, kind entities.NotificationKind, key string, booking *entities.Booking) error {
+	class, err := nc.BookingRepository.FindClass(ctx, booking.ClassID)
+	if err != nil {
+		return err
+	}
+	start, end := class.Session(booking.Date)
+	return nc.Notify(ctx, booking.Name, kind, key, notify.TemplateData{
+		Booking: *booking,
+		Class:   *class,
+		Start:   start,
+		End:     end,
+	})
+}
+
+// Notify sends the notification of kind to the named member on each of
+// their channels, skipping channels it was already sent on under key.
+// Members who never signed up, and so have no contact details, and members
+// who opted out are not notified.
+func (nc *NotificationsComponent) Notify(ctx context.Context, name string, kind entities.NotificationKind, key string, data notify.TemplateData) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "NotificationsComponent.Notify",
+		attribute.String("notification.kind", string(kind)))
+	outcome := "sent"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	logger := utils.Logger(ctx).With("name", name, "kind", kind)
+	member, err := nc.FindMember(ctx, name)
+	if errors.Is(err, entities.ErrNotFound) {
+		outcome = "no_member"
+		return nil
+	}
+	if err != nil {
+		outcome = "error"
+		return err
+	}
+	data.Member = *member
+
+	channels := member.Channels()
+	if len(channels) == 0 {
+		outcome = "no_channels"
+		return nil
+	}
+	for _, channel := range channels {
+		sent, err := nc.NotificationSent(ctx, key, channel)
+		if err != nil {
+			outcome = "error"
+			return err
+		}
+		notifier := nc.Notifiers[channel]
+		if sent || notifier == nil {
+			continue
+		}
+
+		msg, err := nc.Templates.Render(kind, channel, data)
+		if err != nil {
+			outcome = "error"
+			return err
+		}
+		if err := notifier.Send(ctx, msg); err != nil {
+			outcome = "error"
+			logger.Warn("failed to send notification", "channel", channel, "error", err)
+			return fmt.Errorf("sending %s: %w", channel, err)
+		}
+		_, err = nc.AddNotification(ctx, &entities.Notification{
+			Name:    name,
+			Kind:    kind,
+			Channel: channel,
+			To:      msg.To,
+			Key:     key,
+			SentAt:  nc.Clock.Now(),
+		})
+		if err != nil {
+			outcome = "error"
+			return err
+		}
+		logger.Info("notification sent", "channel", channel)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockNotificationRepository keeps sent notifications in memory.
+type MockNotificationRepository struct {
+	Notifications []entities.Notification
+}
+
+func (m *MockNotificationRepository) AddNotification(ctx context.Context, n *entities.Notification) (*entities.Notification, error) {
+	n.ID = len(m.Notifications) + 1
+	m.Notifications = append(m.Notifications, *n)
+	return n, nil
+}
+
+func (m *MockNotificationRepository) NotificationSent(ctx context.Context, key string, channel entities.NotificationChannel) (bool, error) {
+	for _, n := range m.Notifications {
+		if n.Key == key && n.Channel == channel {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordingNotifier keeps the messages it is asked to send.
+type recordingNotifier struct {
+	Sent []notify.Message
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg notify.Message) error {
+	n.Sent = append(n.Sent, msg)
+	return nil
+}
+
+// recordingScheduler keeps the one-off jobs it is asked to schedule.
+type recordingScheduler struct {
+	Jobs []entities.Job
+}
+
+func (s *recordingScheduler) At(ctx context.Context, kind, key string, runAt time.Time, payload any) (*entities.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := entities.Job{ID: len(s.Jobs) + 1, Kind: kind, Key: key, RunAt: runAt, Payload: raw}
+	s.Jobs = append(s.Jobs, job)
+	return &job, nil
+}
+
+func newTestNotifications(t *testing.T, members []entities.Member, booking *entities.Booking) (*NotificationsComponent, *recordingNotifier, *recordingScheduler) {
+	templates, err := notify.LoadTemplates()
+	require.NoError(t, err)
+	class := &entities.Class{
+		ID:        1,
+		ClassName: "Yoga",
+		StartDate: time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC),
+		Capacity:  10,
+	}
+	notifier := &recordingNotifier{}
+	reminders := &recordingScheduler{}
+	nc := &NotificationsComponent{
+		NotificationRepository: &MockNotificationRepository{},
+		MemberRepository:       &MockMemberRepository{Members: members},
+		BookingRepository: &MockBookingRepository{
+			FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) { return class, nil },
+			FindBookingFn: func(ctx context.Context, id int) (*entities.Booking, error) {
+				if booking == nil || booking.ID != id {
+					return nil, entities.ErrNotFound
+				}
+				return booking, nil
+			},
+		},
+		Notifiers: map[entities.NotificationChannel]notify.Notifier{
+			entities.ChannelEmail: notifier,
+			entities.ChannelSMS:   notifier,
+		},
+		Templates: templates,
+		Reminders: reminders,
+		Clock:     clock.NewFake(testNow),
+	}
+	return nc, notifier, reminders
+}
+
+// storedEvent returns the event as the dispatcher reads it from the outbox.
+func storedEvent(t *testing.T, id int, payload entities.EventPayload) entities.Event {
+	raw, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return entities.Event{ID: id, Type: payload.EventType(), Payload: raw, OccurredAt: testNow}
+}
+
+var jane = entities.Member{Name: "Jane", Email: "jane@example.com", Phone: "+353861234567"}
+
+func TestNotificationsComponent_HandleEvent_BookingCreated(t *testing.T) {
+	booking := &entities.Booking{ID: 3, Name: "Jane", ClassID: 1, Date: time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC), Status: entities.BookingConfirmed}
+	nc, notifier, reminders := newTestNotifications(t, []entities.Member{jane}, booking)
+	event := storedEvent(t, 9, entities.BookingCreatedEvent{Booking: booking})
+
+	require.NoError(t, nc.HandleEvent(context.Background(), event))
+	require.Len(t, notifier.Sent, 1)
+	assert.Equal(t, entities.ChannelEmail, notifier.Sent[0].Channel)
+	assert.Equal(t, "jane@example.com", notifier.Sent[0].To)
+	assert.Contains(t, notifier.Sent[0].Subject, "Booking confirmed: Yoga")
+
+	require.Len(t, reminders.Jobs, 1)
+	assert.Equal(t, ReminderJob, reminders.Jobs[0].Kind)
+	assert.Equal(t, "class-reminder-3", reminders.Jobs[0].Key)
+	assert.Equal(t, time.Date(2025, 5, 20, 7, 0, 0, 0, time.UTC), reminders.Jobs[0].RunAt)
+
+	// A redelivered event is not sent twice
+	require.NoError(t, nc.HandleEvent(context.Background(), event))
+	assert.Len(t, notifier.Sent, 1)
+}
+
+func TestNotificationsComponent_HandleEvent_BookingCancelled(t *testing.T) {
+	member := jane
+	member.NotificationChannels = []entities.NotificationChannel{entities.ChannelEmail, entities.ChannelSMS}
+	booking := &entities.Booking{ID: 3, Name: "Jane", ClassID: 1, Date: time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC), Status: entities.BookingCancelled}
+	nc, notifier, reminders := newTestNotifications(t, []entities.Member{member}, booking)
+	event := storedEvent(t, 10, entities.BookingCancelledEvent{Booking: booking})
+
+	require.NoError(t, nc.HandleEvent(context.Background(), event))
+	require.Len(t, notifier.Sent, 2)
+	assert.Equal(t, entities.ChannelEmail, notifier.Sent[0].Channel)
+	assert.Equal(t, entities.ChannelSMS, notifier.Sent[1].Channel)
+	assert.Equal(t, "+353861234567", notifier.Sent[1].To)
+	assert.Empty(t, reminders.Jobs)
+}
+
+func TestNotificationsComponent_HandleEvent_WaitlistPromoted(t *testing.T) {
+	booking := &entities.Booking{ID: 3, Name: "Jane", ClassID: 1, Date: time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC), Status: entities.BookingConfirmed}
+	nc, notifier, reminders := newTestNotifications(t, []entities.Member{jane}, booking)
+	event := storedEvent(t, 11, entities.WaitlistPromotedEvent{Booking: booking})
+
+	require.NoError(t, nc.HandleEvent(context.Background(), event))
+	require.Len(t, notifier.Sent, 1)
+	assert.Contains(t, notifier.Sent[0].Subject, "You're off the waitlist: Yoga")
+	require.Len(t, reminders.Jobs, 1)
+	assert.Equal(t, "class-reminder-3", reminders.Jobs[0].Key)
+}
+
+func TestNotificationsComponent_HandleEvent_ClassCancelled(t *testing.T) {
+	cancelledAt := testNow
+	class := &entities.Class{
+		ID:          1,
+		ClassName:   "Yoga",
+		StartDate:   time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC),
+		EndDate:     time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC),
+		Capacity:    10,
+		CancelledAt: &cancelledAt,
+	}
+	date := time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC)
+	bookings := []entities.Booking{
+		{ID: 3, Name: "Jane", ClassID: 1, Date: date, Status: entities.BookingConfirmed},
+		{ID: 4, Name: "Joe", ClassID: 1, Date: date, Status: entities.BookingWaitlisted},
+		{ID: 5, Name: "Ann", ClassID: 1, Date: date, Status: entities.BookingCancelled},
+	}
+	members := []entities.Member{
+		jane,
+		{Name: "Joe", Email: "joe@example.com"},
+		{Name: "Ann", Email: "ann@example.com"},
+	}
+	nc, notifier, _ := newTestNotifications(t, members, nil)
+	nc.BookingRepository = &MockBookingRepository{
+		FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) { return class, nil },
+		ListBookingsFn: func(ctx context.Context, filter entities.BookingFilter) ([]entities.Booking, error) {
+			assert.Equal(t, 1, filter.ClassID)
+			return bookings, nil
+		},
+	}
+
+	require.NoError(t, nc.HandleEvent(context.Background(), storedEvent(t, 12, entities.ClassCancelledEvent{Class: class})))
+	require.Len(t, notifier.Sent, 2, "only the members still booked are told")
+	assert.Equal(t, "jane@example.com", notifier.Sent[0].To)
+	assert.Equal(t, "joe@example.com", notifier.Sent[1].To)
+	assert.Contains(t, notifier.Sent[0].Subject, "Class cancelled: Yoga")
+
+	// The sweep's booking.cancelled events do not tell them again
+	cancelled := bookings[0]
+	cancelled.Status = entities.BookingCancelled
+	require.NoError(t, nc.HandleEvent(context.Background(), storedEvent(t, 13, entities.BookingCancelledEvent{Booking: &cancelled})))
+	assert.Len(t, notifier.Sent, 2)
+
+	// Members who cancelled before the studio did got the usual notice
+	earlier := storedEvent(t, 14, entities.BookingCancelledEvent{Booking: &bookings[2]})
+	earlier.OccurredAt = testNow.Add(-time.Hour)
+	require.NoError(t, nc.HandleEvent(context.Background(), earlier))
+	require.Len(t, notifier.Sent, 3)
+	assert.Equal(t, "ann@example.com", notifier.Sent[2].To)
+	assert.Contains(t, notifier.Sent[2].Subject, "Booking cancelled: Yoga")
+}
+
+func TestNotificationsComponent_Notify_Skips(t *testing.T) {
+	optedOut := jane
+	optedOut.NotificationsOptOut = true
+	noContact := entities.Member{Name: "Walk-in"}
+
+	for _, members := range [][]entities.Member{nil, {optedOut}, {noContact}} {
+		nc, notifier, _ := newTestNotifications(t, members, nil)
+		name := "Jane"
+		if len(members) > 0 {
+			name = members[0].Name
+		}
+		err := nc.Notify(context.Background(), name, entities.NotificationBookingConfirmed, "event-1", notify.TemplateData{})
+		assert.NoError(t, err)
+		assert.Empty(t, notifier.Sent)
+	}
+}
+
+func TestNotificationsComponent_SendReminderJob(t *testing.T) {
+	booking := &entities.Booking{ID: 3, Name: "Jane", ClassID: 1, Date: time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC), Status: entities.BookingConfirmed}
+	nc, notifier, _ := newTestNotifications(t, []entities.Member{jane}, booking)
+	job := entities.Job{Kind: ReminderJob, Payload: json.RawMessage(`{"booking_id":3}`)}
+
+	require.NoError(t, nc.SendReminderJob(context.Background(), job))
+	require.Len(t, notifier.Sent, 1)
+	assert.Contains(t, notifier.Sent[0].Subject, "Reminder")
+
+	// Cancelled bookings and unknown bookings are not reminded
+	booking.Status = entities.BookingCancelled
+	booking.ID = 4
+	assert.NoError(t, nc.SendReminderJob(context.Background(), entities.Job{Payload: json.RawMessage(`{"booking_id":4}`)}))
+	assert.NoError(t, nc.SendReminderJob(context.Background(), entities.Job{Payload: json.RawMessage(`{"booking_id":5}`)}))
+	assert.Len(t, notifier.Sent, 1)
+}
+
+func TestMembersComponent_SetNotificationPreferences(t *testing.T) {
+	members := &MockMemberRepository{Members: []entities.Member{{Name: "Jane", Email: "jane@example.com"}}}
+	mc := &MembersComponent{MemberRepository: members}
+
+	member, err := mc.SetNotificationPreferences(context.Background(), "Jane", []entities.NotificationChannel{entities.ChannelEmail}, true)
+	require.NoError(t, err)
+	assert.True(t, member.NotificationsOptOut)
+	assert.Empty(t, members.Members[0].Channels())
+
+	_, err = mc.SetNotificationPreferences(context.Background(), "Jane", []entities.NotificationChannel{entities.ChannelSMS}, false)
+	assert.EqualError(t, err, "sms notifications need a phone number")
+
+	_, err = mc.SetNotificationPreferences(context.Background(), "Bob", nil, false)
+	assert.ErrorIs(t, err, ErrMemberNotFound)
+}
@@ -0,0 +1,12 @@
+package components
+
+import "context"
+
+// PaymentGateway charges members for drop-in bookings. An authorization
+// reserves the amount, capture takes it and refund returns it; refunding an
+// authorization that was never captured releases it.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, amount int, currency, reference string) (string, error)
+	Capture(ctx context.Context, authorizationID string) error
+	Refund(ctx context.Context, authorizationID string) error
+}
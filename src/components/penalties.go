@@ -0,0 +1,353 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/payments"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+var (
+	ErrBookingSuspended         = errors.New("booking is suspended")
+	ErrPenaltyNotFound          = errors.New("penalty not found")
+	ErrPenaltyAlreadyOverridden = errors.New("penalty has already been overridden")
+)
+
+// PenaltiesComponent applies each studio's penalty policy to bookings and
+// records every penalty it issues.
+type PenaltiesComponent struct {
+	entities.PenaltyRepository
+	entities.BookingRepository
+	entities.MembershipRepository
+	PaymentGateway PaymentGateway
+	// Clock tells the time overrides are recorded at.
+	Clock clock.Clock
+}
+
+func InitPenaltiesComponent() *PenaltiesComponent {
+	return &PenaltiesComponent{
+		PenaltyRepository:    &entities.PenaltyEntity{},
+		BookingRepository:    &entities.BookingEntity{},
+		MembershipRepository: &entities.MembershipEntity{},
+		PaymentGateway:       payments.NewFakeGateway(),
+		Clock:                clock.Real{},
+	}
+}
+
+func (pc *PenaltiesComponent) GetPolicyForm() *entities.PenaltyPolicy {
+	return new(entities.PenaltyPolicy)
+}
+
+func (pc *PenaltiesComponent) ValidatePolicy(form *entities.PenaltyPolicy) []error {
+	var errs []error
+	if form.NoShowLimit < 0 {
+		errs = append(errs, errors.New("no_show_limit cannot be negative"))
+	}
+	if form.NoShowLimit > 0 && (form.NoShowWindowDays <= 0 || form.SuspensionDays <= 0) {
+		errs = append(errs, errors.New("no_show_window_days and suspension_days are required when no_show_limit is set"))
+	}
+	switch form.LateCancelAction {
+	case entities.LateCancelNone, entities.LateCancelForfeit:
+	case entities.LateCancelFee:
+		if form.LateCancelFee <= 0 || len(form.Currency) != 3 {
+			errs = append(errs, errors.New("late_cancel_fee and currency are required for the fee action"))
+		}
+	default:
+		errs = append(errs, errors.New("late_cancel_action must be one of none, forfeit or fee"))
+	}
+	return errs
+}
+
+// SetPolicy replaces the studio's penalty policy.
+func (pc *PenaltiesComponent) SetPolicy(ctx context.Context, studio string, policy *entities.PenaltyPolicy) (*entities.PenaltyPolicy, error) {
+	policy.Studio = studio
+	if policy.LateCancelAction != entities.LateCancelFee {
+		policy.LateCancelFee, policy.Currency = 0, ""
+	}
+	stored, err := pc.SetPenaltyPolicy(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	utils.Logger(ctx).Info("penalty policy set", "studio", studio)
+	return stored, nil
+}
+
+// Policy returns the studio's penalty policy, or the default one.
+func (pc *PenaltiesComponent) Policy(ctx context.Context, studio string) (*entities.PenaltyPolicy, error) {
+	policy, err := pc.FindPenaltyPolicy(ctx, studio)
+	if errors.Is(err, entities.ErrNotFound) {
+		policy := entities.DefaultPenaltyPolicy
+		policy.Studio = studio
+		return &policy, nil
+	}
+	return policy, err
+}
+
+// CheckBooking returns ErrBookingSuspended when the member may not book at
+// the studio, suspending them first if their recent no-shows reach the
+// policy's limit.
+func (pc *PenaltiesComponent) CheckBooking(ctx context.Context, name, studio string, now time.Time) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PenaltiesComponent.CheckBooking", attribute.String("studio", studio))
+	outcome := "allowed"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	suspensions, err := pc.ListPenalties(ctx, entities.PenaltyFilter{Name: name, Studio: studio, Type: entities.PenaltySuspension})
+	if err != nil {
+		outcome = "error"
+		return err
+	}
+	// No-shows from before the latest suspension have already been dealt with,
+	// even if staff lifted it.
+	var penalisedUntil time.Time
+	for _, s := range suspensions {
+		if s.ActiveAt(now) {
+			outcome = "suspended"
+			return fmt.Errorf("%w until %s", ErrBookingSuspended, s.ExpiresAt.Format(time.RFC3339))
+		}
+		if s.CreatedAt.After(penalisedUntil) {
+			penalisedUntil = s.CreatedAt
+		}
+	}
+
+	policy, err := pc.Policy(ctx, studio)
+	if err != nil {
+		outcome = "error"
+		return err
+	}
+	if policy.NoShowLimit == 0 {
+		return nil
+	}
+
+	window := time.Duration(policy.NoShowWindowDays) * 24 * time.Hour
+	from := now.Add(-window)
+	if penalisedUntil.After(from) {
+		from = penalisedUntil
+	}
+	noShows, err := pc.countNoShows(ctx, name, studio, from, now)
+	if err != nil {
+		outcome = "error"
+		return err
+	}
+	if noShows < policy.NoShowLimit {
+		return nil
+	}
+
+	expires := now.AddDate(0, 0, policy.SuspensionDays)
+	suspension := &entities.Penalty{
+		Name:      name,
+		Studio:    studio,
+		Type:      entities.PenaltySuspension,
+		Reason:    fmt.Sprintf("%d no-shows in the last %d days", noShows, policy.NoShowWindowDays),
+		CreatedAt: now,
+		ExpiresAt: &expires,
+	}
+	if _, err := pc.AddPenalty(ctx, suspension); err != nil {
+		outcome = "error"
+		return err
+	}
+	outcome = "suspended"
+	utils.Logger(ctx).Info("member suspended", "name", name, "studio", studio, "until", expires)
+	return fmt.Errorf("%w until %s", ErrBookingSuspended, expires.Format(time.RFC3339))
+}
+
+func (pc *PenaltiesComponent) countNoShows(ctx context.Context, name, studio string, from, to time.Time) (int, error) {
+	bookings, err := pc.ListBookings(ctx, entities.BookingFilter{Name: name, Status: entities.BookingNoShow, From: from, To: to})
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, b := range bookings {
+		class, err := pc.FindClass(ctx, b.ClassID)
+		if err != nil {
+			return 0, err
+		}
+		if class.Studio == studio {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// LateCancellation applies the studio's late cancellation policy to a
+// booking cancelled inside the cancellation window, and reports whether the
+// booking should still be refunded.
+func (pc *PenaltiesComponent) LateCancellation(ctx context.Context, booking *entities.Booking, class *entities.Class, now time.Time) (refund bool, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PenaltiesComponent.LateCancellation", attribute.Int("booking.id", booking.ID))
+	outcome := "no_penalty"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	policy, err := pc.Policy(ctx, class.Studio)
+	if err != nil {
+		outcome = "error"
+		return false, err
+	}
+
+	penalty := &entities.Penalty{
+		Name:      booking.Name,
+		Studio:    class.Studio,
+		BookingID: booking.ID,
+		Reason:    fmt.Sprintf("cancelled less than %s before the class", CancellationWindow),
+		CreatedAt: now,
+	}
+	switch policy.LateCancelAction {
+	case entities.LateCancelNone:
+		return true, nil
+	case entities.LateCancelFee:
+		penalty.Type = entities.PenaltyLateCancelFee
+		penalty.Amount = policy.LateCancelFee
+		penalty.Currency = policy.Currency
+		if penalty.PaymentID, err = pc.chargeFee(ctx, penalty); err != nil {
+			outcome = "error"
+			return false, err
+		}
+		refund = true
+	default:
+		forfeits, err := pc.forfeits(ctx, booking)
+		if err != nil {
+			outcome = "error"
+			return false, err
+		}
+		if !forfeits {
+			return false, nil
+		}
+		penalty.Type = entities.PenaltyLateCancelForfeit
+	}
+
+	if _, err := pc.AddPenalty(ctx, penalty); err != nil {
+		outcome = "error"
+		if penalty.PaymentID != "" {
+			pc.refundFee(ctx, penalty)
+		}
+		return false, err
+	}
+	outcome = string(penalty.Type)
+	utils.Logger(ctx).Info("late cancellation penalised", "booking_id", booking.ID, "type", penalty.Type)
+	return refund, nil
+}
+
+// chargeFee charges the late cancellation fee of penalty and returns the
+// payment's ID. A declined fee is left owed rather than stopping the
+// cancellation, so it returns no ID and no error.
+func (pc *PenaltiesComponent) chargeFee(ctx context.Context, penalty *entities.Penalty) (string, error) {
+	logger := utils.Logger(ctx).With("booking_id", penalty.BookingID)
+	reference := fmt.Sprintf("late-cancel-fee-%d", penalty.BookingID)
+	authID, err := pc.PaymentGateway.Authorize(ctx, penalty.Amount, penalty.Currency, reference)
+	if errors.Is(err, payments.ErrDeclined) {
+		logger.Info("late cancellation fee declined, recorded as owed")
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("charging late cancellation fee: %w", err)
+	}
+	if err := pc.PaymentGateway.Capture(ctx, authID); err != nil {
+		if refundErr := pc.PaymentGateway.Refund(ctx, authID); refundErr != nil {
+			logger.Error("failed to release fee authorization", "payment_id", authID, "error", refundErr)
+		}
+		if errors.Is(err, payments.ErrDeclined) {
+			logger.Info("late cancellation fee declined, recorded as owed")
+			return "", nil
+		}
+		return "", fmt.Errorf("charging late cancellation fee: %w", err)
+	}
+	return authID, nil
+}
+
+// refundFee gives back a late cancellation fee that was charged.
+func (pc *PenaltiesComponent) refundFee(ctx context.Context, penalty *entities.Penalty) {
+	if err := pc.PaymentGateway.Refund(ctx, penalty.PaymentID); err != nil {
+		utils.Logger(ctx).Error("failed to refund late cancellation fee", "payment_id", penalty.PaymentID, "error", err)
+	}
+}
+
+// forfeits reports whether the booking holds a class pack credit or drop-in
+// payment that a late cancellation would lose.
+func (pc *PenaltiesComponent) forfeits(ctx context.Context, booking *entities.Booking) (bool, error) {
+	if booking.PaymentID != "" {
+		return true, nil
+	}
+	membership, err := pc.FindMembership(ctx, booking.MembershipID)
+	if errors.Is(err, entities.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return membership.Plan == entities.PlanClassPack, nil
+}
+
+// OverridePenalty lifts a suspension or waives a fee, refunding it if it was
+// charged, and gives back what a forfeited late cancellation kept.
+func (pc *PenaltiesComponent) OverridePenalty(ctx context.Context, id int, by, reason string) (penalty *entities.Penalty, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PenaltiesComponent.OverridePenalty", attribute.Int("penalty.id", id))
+	outcome := "overridden"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	penalty, err = pc.FindPenalty(ctx, id)
+	if errors.Is(err, entities.ErrNotFound) {
+		outcome = "not_found"
+		return nil, ErrPenaltyNotFound
+	}
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if penalty.OverriddenAt != nil {
+		outcome = "already_overridden"
+		return nil, ErrPenaltyAlreadyOverridden
+	}
+
+	if penalty.Type == entities.PenaltyLateCancelForfeit {
+		if err := pc.refundForfeit(ctx, penalty.BookingID); err != nil {
+			outcome = "error"
+			return nil, err
+		}
+	}
+	if penalty.Type == entities.PenaltyLateCancelFee && penalty.PaymentID != "" {
+		if err := pc.PaymentGateway.Refund(ctx, penalty.PaymentID); err != nil {
+			outcome = "error"
+			return nil, fmt.Errorf("refunding fee: %w", err)
+		}
+	}
+
+	now := pc.Clock.Now()
+	penalty.OverriddenAt = &now
+	penalty.OverriddenBy = by
+	penalty.OverrideReason = reason
+	if err := pc.UpdatePenalty(ctx, penalty); err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	utils.Logger(ctx).Info("penalty overridden", "penalty_id", penalty.ID, "by", by)
+	return penalty, nil
+}
+
+func (pc *PenaltiesComponent) refundForfeit(ctx context.Context, bookingID int) error {
+	booking, err := pc.FindBooking(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	if booking.PaymentID != "" && !booking.PaymentRefunded {
+		if err := pc.PaymentGateway.Refund(ctx, booking.PaymentID); err != nil {
+			return fmt.Errorf("refunding payment: %w", err)
+		}
+		booking.PaymentRefunded = true
+	} else if !booking.CreditRefunded {
+		membership, err := pc.FindMembership(ctx, booking.MembershipID)
+		if err != nil {
+			return err
+		}
+		if membership.Plan == entities.PlanClassPack {
+			if err := pc.RefundCredit(ctx, membership.ID); err != nil {
+				return err
+			}
+			booking.CreditRefunded = true
+		}
+	}
+	return pc.UpdateBooking(ctx, booking)
+}
@@ -0,0 +1,365 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/payments"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockPenaltyRepository keeps policies and penalties in memory.
+type MockPenaltyRepository struct {
+	Policies  []entities.PenaltyPolicy
+	Penalties []entities.Penalty
+}
+
+func (m *MockPenaltyRepository) SetPenaltyPolicy(ctx context.Context, p *entities.PenaltyPolicy) (*entities.PenaltyPolicy, error) {
+	m.Policies = append(m.Policies, *p)
+	return p, nil
+}
+
+func (m *MockPenaltyRepository) FindPenaltyPolicy(ctx context.Context, studio string) (*entities.PenaltyPolicy, error) {
+	for _, p := range m.Policies {
+		if p.Studio == studio {
+			return &p, nil
+		}
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockPenaltyRepository) AddPenalty(ctx context.Context, p *entities.Penalty) (*entities.Penalty, error) {
+	p.ID = len(m.Penalties) + 1
+	m.Penalties = append(m.Penalties, *p)
+	return p, nil
+}
+
+func (m *MockPenaltyRepository) UpdatePenalty(ctx context.Context, p *entities.Penalty) error {
+	for i := range m.Penalties {
+		if m.Penalties[i].ID == p.ID {
+			m.Penalties[i] = *p
+			return nil
+		}
+	}
+	return entities.ErrNotFound
+}
+
+func (m *MockPenaltyRepository) FindPenalty(ctx context.Context, id int) (*entities.Penalty, error) {
+	for _, p := range m.Penalties {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockPenaltyRepository) ListPenalties(ctx context.Context, filter entities.PenaltyFilter) ([]entities.Penalty, error) {
+	var found []entities.Penalty
+	for _, p := range m.Penalties {
+		if (filter.Name == "" || p.Name == filter.Name) &&
+			(filter.Studio == "" || p.Studio == filter.Studio) &&
+			(filter.Type == "" || p.Type == filter.Type) {
+			found = append(found, p)
+		}
+	}
+	return found, nil
+}
+
+func TestPenaltiesComponent_ValidatePolicy(t *testing.T) {
+	pc := &PenaltiesComponent{}
+
+	tests := []struct {
+		name     string
+		form     *entities.PenaltyPolicy
+		expected []string
+	}{
+		{
+			name:     "should accept the default policy",
+			form:     &entities.PenaltyPolicy{NoShowLimit: 3, NoShowWindowDays: 30, SuspensionDays: 7, LateCancelAction: entities.LateCancelForfeit},
+			expected: nil,
+		},
+		{
+			name:     "should accept a policy without suspensions",
+			form:     &entities.PenaltyPolicy{LateCancelAction: entities.LateCancelNone},
+			expected: nil,
+		},
+		{
+			name: "should require the window and suspension length with a no-show limit",
+			form: &entities.PenaltyPolicy{NoShowLimit: 3, LateCancelAction: entities.LateCancelNone},
+			expected: []string{
+				"no_show_window_days and suspension_days are required when no_show_limit is set",
+			},
+		},
+		{
+			name:     "should require a fee and currency for the fee action",
+			form:     &entities.PenaltyPolicy{LateCancelAction: entities.LateCancelFee},
+			expected: []string{"late_cancel_fee and currency are required for the fee action"},
+		},
+		{
+			name:     "should reject an unknown action",
+			form:     &entities.PenaltyPolicy{NoShowLimit: -1, LateCancelAction: "ban"},
+			expected: []string{"no_show_limit cannot be negative", "late_cancel_action must be one of none, forfeit or fee"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := pc.ValidatePolicy(tt.form)
+
+			var actual []string
+			for _, err := range errs {
+				actual = append(actual, err.Error())
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestPenaltiesComponent_CheckBooking(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	lifted := now.Add(-time.Hour)
+	weekAgo := now.AddDate(0, 0, -7)
+	activeUntil := now.AddDate(0, 0, 3)
+	noShow := func(id, classID, daysAgo int) entities.Booking {
+		return entities.Booking{ID: id, ClassID: classID, Name: "Ann", Date: now.AddDate(0, 0, -daysAgo), Status: entities.BookingNoShow}
+	}
+	classes := map[int]*entities.Class{
+		1: {ID: 1, Studio: entities.DefaultStudio},
+		2: {ID: 2, Studio: "riverside"},
+	}
+
+	tests := []struct {
+		name            string
+		noShows         []entities.Booking
+		penalties       []entities.Penalty
+		policies        []entities.PenaltyPolicy
+		expectSuspended bool
+		wantPenalties   int
+	}{
+		{
+			name:    "should allow a member under the no-show limit",
+			noShows: []entities.Booking{noShow(1, 1, 2), noShow(2, 1, 5)},
+		},
+		{
+			name:            "should suspend a member reaching the no-show limit",
+			noShows:         []entities.Booking{noShow(1, 1, 2), noShow(2, 1, 5), noShow(3, 1, 20)},
+			expectSuspended: true,
+			wantPenalties:   1,
+		},
+		{
+			name:    "should only count no-shows at the class's studio",
+			noShows: []entities.Booking{noShow(1, 1, 2), noShow(2, 1, 5), noShow(3, 2, 20)},
+		},
+		{
+			name:     "should apply the studio's own limit",
+			noShows:  []entities.Booking{noShow(1, 1, 2), noShow(2, 1, 5), noShow(3, 1, 20)},
+			policies: []entities.PenaltyPolicy{{Studio: entities.DefaultStudio, NoShowLimit: 5, NoShowWindowDays: 30, SuspensionDays: 7}},
+		},
+		{
+			name:            "should keep rejecting while a suspension is active",
+			penalties:       []entities.Penalty{{ID: 1, Name: "Ann", Studio: entities.DefaultStudio, Type: entities.PenaltySuspension, CreatedAt: weekAgo, ExpiresAt: &activeUntil}},
+			expectSuspended: true,
+			wantPenalties:   1,
+		},
+		{
+			name:    "should not suspend again for no-shows a lifted suspension covered",
+			noShows: []entities.Booking{noShow(1, 1, 8), noShow(2, 1, 9), noShow(3, 1, 10)},
+			penalties: []entities.Penalty{{
+				ID: 1, Name: "Ann", Studio: entities.DefaultStudio, Type: entities.PenaltySuspension,
+				CreatedAt: weekAgo, ExpiresAt: &activeUntil, OverriddenAt: &lifted,
+			}},
+			wantPenalties: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			penalties := &MockPenaltyRepository{Policies: tt.policies, Penalties: tt.penalties}
+			pc := &PenaltiesComponent{
+				PenaltyRepository: penalties,
+				BookingRepository: &MockBookingRepository{
+					ListBookingsFn: func(ctx context.Context, f entities.BookingFilter) ([]entities.Booking, error) {
+						var found []entities.Booking
+						for _, b := range tt.noShows {
+							if b.Status == f.Status && !b.Date.Before(f.From) && !b.Date.After(f.To) {
+								found = append(found, b)
+							}
+						}
+						return found, nil
+					},
+					FindClassFn: func(ctx context.Context, id int) (*entities.Class, error) {
+						return classes[id], nil
+					},
+				},
+			}
+
+			err := pc.CheckBooking(context.Background(), "Ann", entities.DefaultStudio, now)
+
+			if tt.expectSuspended {
+				assert.ErrorIs(t, err, ErrBookingSuspended)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, penalties.Penalties, tt.wantPenalties)
+		})
+	}
+}
+
+func TestPenaltiesComponent_LateCancellation(t *testing.T) {
+	now := testNow
+	class := &entities.Class{ID: 3, Studio: entities.DefaultStudio}
+	feePolicy := entities.PenaltyPolicy{Studio: entities.DefaultStudio, LateCancelAction: entities.LateCancelFee, LateCancelFee: 500, Currency: "EUR"}
+	nonePolicy := entities.PenaltyPolicy{Studio: entities.DefaultStudio, LateCancelAction: entities.LateCancelNone}
+
+	tests := []struct {
+		name        string
+		policies    []entities.PenaltyPolicy
+		booking     *entities.Booking
+		declineFee  bool
+		wantRefund  bool
+		wantPenalty *entities.Penalty
+	}{
+		{
+			name:    "should forfeit a class pack credit by default",
+			booking: &entities.Booking{ID: 1, Name: "Ann", MembershipID: 8},
+			wantPenalty: &entities.Penalty{
+				ID: 1, Name: "Ann", Studio: entities.DefaultStudio, Type: entities.PenaltyLateCancelForfeit,
+				Reason: "cancelled less than 12h0m0s before the class", BookingID: 1, CreatedAt: now,
+			},
+		},
+		{
+			name:    "should forfeit a drop-in payment by default",
+			booking: &entities.Booking{ID: 1, Name: "Ann", MembershipID: 9, PaymentID: "auth_1"},
+			wantPenalty: &entities.Penalty{
+				ID: 1, Name: "Ann", Studio: entities.DefaultStudio, Type: entities.PenaltyLateCancelForfeit,
+				Reason: "cancelled less than 12h0m0s before the class", BookingID: 1, CreatedAt: now,
+			},
+		},
+		{
+			name:    "should not record a forfeit when nothing is lost",
+			booking: &entities.Booking{ID: 1, Name: "Ann", MembershipID: 7},
+		},
+		{
+			name:       "should refund and charge a fee under the fee action",
+			policies:   []entities.PenaltyPolicy{feePolicy},
+			booking:    &entities.Booking{ID: 1, Name: "Ann", MembershipID: 8},
+			wantRefund: true,
+			wantPenalty: &entities.Penalty{
+				ID: 1, Name: "Ann", Studio: entities.DefaultStudio, Type: entities.PenaltyLateCancelFee,
+				Reason: "cancelled less than 12h0m0s before the class", BookingID: 1, Amount: 500, Currency: "EUR",
+				PaymentID: "auth_1", CreatedAt: now,
+			},
+		},
+		{
+			name:       "should record a declined fee as owed",
+			policies:   []entities.PenaltyPolicy{feePolicy},
+			booking:    &entities.Booking{ID: 1, Name: "Ann", MembershipID: 8},
+			declineFee: true,
+			wantRefund: true,
+			wantPenalty: &entities.Penalty{
+				ID: 1, Name: "Ann", Studio: entities.DefaultStudio, Type: entities.PenaltyLateCancelFee,
+				Reason: "cancelled less than 12h0m0s before the class", BookingID: 1, Amount: 500, Currency: "EUR", CreatedAt: now,
+			},
+		},
+		{
+			name:       "should refund without a penalty under the none action",
+			policies:   []entities.PenaltyPolicy{nonePolicy},
+			booking:    &entities.Booking{ID: 1, Name: "Ann", MembershipID: 8},
+			wantRefund: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			penalties := &MockPenaltyRepository{Policies: tt.policies}
+			gateway := payments.NewFakeGateway()
+			gateway.DeclineAuthorize = tt.declineFee
+			pc := &PenaltiesComponent{
+				PenaltyRepository: penalties,
+				MembershipRepository: &MockMembershipRepository{Memberships: []entities.Membership{
+					{ID: 7, Plan: entities.PlanUnlimitedMonthly},
+					{ID: 8, Plan: entities.PlanClassPack, Credits: 3},
+					{ID: 9, Plan: entities.PlanDropIn},
+				}},
+				PaymentGateway: gateway,
+			}
+
+			refund, err := pc.LateCancellation(context.Background(), tt.booking, class, now)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRefund, refund)
+			if tt.wantPenalty == nil {
+				assert.Empty(t, penalties.Penalties)
+			} else {
+				assert.Equal(t, []entities.Penalty{*tt.wantPenalty}, penalties.Penalties)
+			}
+			if fee, ok := gateway.Authorization("auth_1"); ok {
+				assert.Equal(t, payments.Authorization{
+					ID: "auth_1", Amount: 500, Currency: "EUR", Reference: "late-cancel-fee-1", Status: payments.StatusCaptured,
+				}, fee)
+			}
+		})
+	}
+}
+
+func TestPenaltiesComponent_OverridePenalty(t *testing.T) {
+	booking := &entities.Booking{ID: 4, Name: "Ann", MembershipID: 8, Status: entities.BookingCancelled}
+	memberships := &MockMembershipRepository{Memberships: []entities.Membership{{ID: 8, Plan: entities.PlanClassPack, Credits: 3}}}
+	penalties := &MockPenaltyRepository{Penalties: []entities.Penalty{
+		{ID: 1, Name: "Ann", Type: entities.PenaltyLateCancelForfeit, BookingID: 4},
+	}}
+	var updated *entities.Booking
+	pc := &PenaltiesComponent{
+		PenaltyRepository: penalties,
+		BookingRepository: &MockBookingRepository{
+			FindBookingFn: func(ctx context.Context, id int) (*entities.Booking, error) {
+				return booking, nil
+			},
+			UpdateBookingFn: func(ctx context.Context, b *entities.Booking) error {
+				updated = b
+				return nil
+			},
+		},
+		MembershipRepository: memberships,
+		Clock:                clock.NewFake(testNow),
+	}
+
+	got, err := pc.OverridePenalty(context.Background(), 1, "front desk", "car broke down")
+
+	assert.NoError(t, err)
+	assert.Equal(t, testNow, *got.OverriddenAt)
+	assert.Equal(t, "front desk", got.OverriddenBy)
+	assert.Equal(t, "car broke down", got.OverrideReason)
+	assert.Equal(t, *got, penalties.Penalties[0])
+	assert.Equal(t, []int{8}, memberships.Refunded)
+	assert.True(t, updated.CreditRefunded)
+
+	_, err = pc.OverridePenalty(context.Background(), 1, "front desk", "again")
+	assert.ErrorIs(t, err, ErrPenaltyAlreadyOverridden)
+
+	_, err = pc.OverridePenalty(context.Background(), 2, "front desk", "unknown")
+	assert.ErrorIs(t, err, ErrPenaltyNotFound)
+}
+
+func TestPenaltiesComponent_OverridePenalty_RefundsFee(t *testing.T) {
+	gateway := payments.NewFakeGateway()
+	feeID, _ := gateway.Authorize(context.Background(), 500, "EUR", "late-cancel-fee-4")
+	_ = gateway.Capture(context.Background(), feeID)
+	penalties := &MockPenaltyRepository{Penalties: []entities.Penalty{
+		{ID: 1, Name: "Ann", Type: entities.PenaltyLateCancelFee, BookingID: 4, Amount: 500, Currency: "EUR", PaymentID: feeID},
+		{ID: 2, Name: "Ann", Type: entities.PenaltyLateCancelFee, BookingID: 5, Amount: 500, Currency: "EUR"},
+	}}
+	pc := &PenaltiesComponent{PenaltyRepository: penalties, PaymentGateway: gateway, Clock: clock.NewFake(testNow)}
+
+	_, err := pc.OverridePenalty(context.Background(), 1, "front desk", "first offence")
+	assert.NoError(t, err)
+	fee, _ := gateway.Authorization(feeID)
+	assert.Equal(t, payments.StatusRefunded, fee.Status)
+
+	// A fee that was never paid is simply waived
+	_, err = pc.OverridePenalty(context.Background(), 2, "front desk", "first offence")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,87 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"strings"
+)
+
+// DefaultTierDiscounts is the percentage taken off drop-in prices for each
+// member tier.
+var DefaultTierDiscounts = map[entities.MemberTier]int{
+	entities.TierStudent: 20,
+	entities.TierPremium: 10,
+}
+
+var ErrPromoCodeNotFound = errors.New("promo code not found")
+
+// PricingComponent works out what a member pays for a class.
+type PricingComponent struct {
+	entities.MemberRepository
+	entities.PromoCodeRepository
+	TierDiscounts map[entities.MemberTier]int
+	// Clock tells the time promo code expiry is checked against.
+	Clock clock.Clock
+}
+
+func InitPricingComponent() *PricingComponent {
+	return &PricingComponent{
+		MemberRepository:    &entities.MemberEntity{},
+		PromoCodeRepository: &entities.PromoCodeEntity{},
+		TierDiscounts:       DefaultTierDiscounts,
+		Clock:               clock.Real{},
+	}
+}
+
+// NormalizePromoCode makes promo codes case and whitespace insensitive.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Quote prices a class for a member: the tier discount is applied to the
+// class price first and the promo code to what remains. The promo code is
+// checked but not redeemed.
+func (pc *PricingComponent) Quote(ctx context.Context, class *entities.Class, memberName, promoCode string) (*entities.PriceBreakdown, error) {
+	tier := entities.TierStandard
+	member, err := pc.FindMember(ctx, memberName)
+	if err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return nil, err
+	}
+	if member != nil {
+		tier = member.Tier
+	}
+
+	price := &entities.PriceBreakdown{
+		Currency:  class.Currency,
+		BasePrice: class.Price,
+		Tier:      string(tier),
+	}
+	price.TierDiscount = percentOf(class.Price, pc.TierDiscounts[tier])
+	remaining := class.Price - price.TierDiscount
+
+	if code := NormalizePromoCode(promoCode); code != "" {
+		promo, err := pc.FindPromoCode(ctx, code)
+		if errors.Is(err, entities.ErrNotFound) {
+			return nil, ErrPromoCodeNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := promo.Redeemable(pc.Clock.Now()); err != nil {
+			return nil, err
+		}
+		price.PromoCode = promo.Code
+		price.PromoDiscount = percentOf(remaining, promo.PercentOff)
+		remaining -= price.PromoDiscount
+	}
+
+	price.Total = remaining
+	return price, nil
+}
+
+// percentOf returns pct percent of amount, rounded down to a whole minor unit.
+func percentOf(amount, pct int) int {
+	return amount * pct / 100
+}
@@ -0,0 +1,177 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockMemberRepository struct {
+	Members []entities.Member
+}
+
+func (m *MockMemberRepository) AddMember(ctx context.Context, member *entities.Member) (*entities.Member, error) {
+	m.Members = append(m.Members, *member)
+	return member, nil
+}
+
+func (m *MockMemberRepository) AddMembers(ctx context.Context, members []entities.Member) error {
+	m.Members = append(m.Members, members...)
+	return nil
+}
+
+func (m *MockMemberRepository) FindMember(ctx context.Context, name string) (*entities.Member, error) {
+	for _, member := range m.Members {
+		if member.Name == name {
+			return &member, nil
+		}
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockMemberRepository) UpdateMember(ctx context.Context, member *entities.Member) error {
+	for i := range m.Members {
+		if m.Members[i].Name == member.Name {
+			m.Members[i] = *member
+			return nil
+		}
+	}
+	return entities.ErrNotFound
+}
+
+// MockPromoCodeRepository keeps promo codes in memory and records releases.
+type MockPromoCodeRepository struct {
+	PromoCodes []entities.PromoCode
+	Released   []string
+}
+
+func (m *MockPromoCodeRepository) AddPromoCode(ctx context.Context, p *entities.PromoCode) (*entities.PromoCode, error) {
+	m.PromoCodes = append(m.PromoCodes, *p)
+	return p, nil
+}
+
+func (m *MockPromoCodeRepository) FindPromoCode(ctx context.Context, code string) (*entities.PromoCode, error) {
+	for _, p := range m.PromoCodes {
+		if p.Code == code {
+			return &p, nil
+		}
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockPromoCodeRepository) RedeemPromoCode(ctx context.Context, code string, at time.Time) error {
+	for i := range m.PromoCodes {
+		if m.PromoCodes[i].Code == code {
+			if err := m.PromoCodes[i].Redeemable(at); err != nil {
+				return err
+			}
+			m.PromoCodes[i].Uses++
+			return nil
+		}
+	}
+	return entities.ErrNotFound
+}
+
+func (m *MockPromoCodeRepository) ReleasePromoCode(ctx context.Context, code string) error {
+	m.Released = append(m.Released, code)
+	return nil
+}
+
+func TestPricingComponent_Quote(t *testing.T) {
+	now := testNow
+	class := &entities.Class{ID: 1, Price: 2000, Currency: "EUR"}
+	members := []entities.Member{
+		{Name: "Student", Tier: entities.TierStudent},
+		{Name: "Premium", Tier: entities.TierPremium},
+	}
+	promoCodes := []entities.PromoCode{
+		{Code: "SPRING", PercentOff: 50, ExpiresAt: now.AddDate(0, 1, 0)},
+		{Code: "OLD", PercentOff: 50, ExpiresAt: now.AddDate(0, -1, 0)},
+		{Code: "USEDUP", PercentOff: 50, MaxUses: 2, Uses: 2, ExpiresAt: now.AddDate(0, 1, 0)},
+	}
+
+	tests := []struct {
+		name        string
+		member      string
+		promoCode   string
+		expected    *entities.PriceBreakdown
+		expectedErr error
+	}{
+		{
+			name:     "should charge the full price to standard members",
+			member:   "Walk-in",
+			expected: &entities.PriceBreakdown{Currency: "EUR", BasePrice: 2000, Tier: "standard", Total: 2000},
+		},
+		{
+			name:     "should apply the tier discount",
+			member:   "Student",
+			expected: &entities.PriceBreakdown{Currency: "EUR", BasePrice: 2000, Tier: "student", TierDiscount: 400, Total: 1600},
+		},
+		{
+			name:      "should apply the promo code after the tier discount",
+			member:    "Premium",
+			promoCode: " spring ",
+			expected: &entities.PriceBreakdown{
+				Currency: "EUR", BasePrice: 2000, Tier: "premium", TierDiscount: 200,
+				PromoCode: "SPRING", PromoDiscount: 900, Total: 900,
+			},
+		},
+		{
+			name:        "should reject an unknown promo code",
+			member:      "Walk-in",
+			promoCode:   "NOPE",
+			expectedErr: ErrPromoCodeNotFound,
+		},
+		{
+			name:        "should reject an expired promo code",
+			member:      "Walk-in",
+			promoCode:   "OLD",
+			expectedErr: entities.ErrPromoCodeExpired,
+		},
+		{
+			name:        "should reject a promo code that reached its usage limit",
+			member:      "Walk-in",
+			promoCode:   "USEDUP",
+			expectedErr: entities.ErrPromoCodeExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := &PricingComponent{
+				MemberRepository:    &MockMemberRepository{Members: members},
+				PromoCodeRepository: &MockPromoCodeRepository{PromoCodes: promoCodes},
+				TierDiscounts:       DefaultTierDiscounts,
+				Clock:               clock.NewFake(now),
+			}
+
+			got, err := pc.Quote(context.Background(), class, tt.member, tt.promoCode)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPromoCodesComponent_CreatePromoCode(t *testing.T) {
+	pc := &PromoCodesComponent{&MockPromoCodeRepository{}}
+
+	got, err := pc.CreatePromoCode(context.Background(), &entities.PromoCode{
+		Code:       " summer10 ",
+		PercentOff: 10,
+		Uses:       5,
+		ExpiresAt:  time.Now().AddDate(0, 1, 0),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SUMMER10", got.Code)
+	assert.Equal(t, 0, got.Uses)
+}
@@ -0,0 +1,52 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/utils"
+)
+
+type PromoCodesComponent struct {
+	entities.PromoCodeRepository
+}
+
+func InitPromoCodesComponent() *PromoCodesComponent {
+	return &PromoCodesComponent{
+		&entities.PromoCodeEntity{},
+	}
+}
+
+func (pc *PromoCodesComponent) GetPromoCodeForm() *entities.PromoCode {
+	return new(entities.PromoCode)
+}
+
+func (pc *PromoCodesComponent) Validate(form *entities.PromoCode) []error {
+	var errs []error
+	if NormalizePromoCode(form.Code) == "" {
+		errs = append(errs, errors.New("code is required"))
+	}
+	if form.PercentOff < 1 || form.PercentOff > 100 {
+		errs = append(errs, errors.New("percent off must be between 1 and 100"))
+	}
+	if form.MaxUses < 0 {
+		errs = append(errs, errors.New("max uses cannot be negative"))
+	}
+	if form.ExpiresAt.IsZero() {
+		errs = append(errs, errors.New("invalid expiry format (expected RFC 3339 date-time)"))
+	}
+	return errs
+}
+
+func (pc *PromoCodesComponent) CreatePromoCode(ctx context.Context, promo *entities.PromoCode) (*entities.PromoCode, error) {
+	promo.Code = NormalizePromoCode(promo.Code)
+	promo.Uses = 0
+
+	created, err := pc.AddPromoCode(ctx, promo)
+	if err != nil {
+		utils.Logger(ctx).Info("promo code rejected", "reason", err)
+		return nil, err
+	}
+	utils.Logger(ctx).Info("promo code created", "code", created.Code)
+	return created, nil
+}
@@ -0,0 +1,492 @@
+package components
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"iter"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// ReportSettleTime is how long after a day ends its figures can still
+	// change, while no-shows are marked. Days and months that ended longer
+	// ago than this are closed, and their figures are cached.
+	ReportSettleTime = 48 * time.Hour
+	// MaxReportDays bounds the days a report covers.
+	MaxReportDays = 3 * 366
+)
+
+var (
+	ErrInvalidReportRange = errors.New("to must not be before from")
+	ErrReportRangeTooLong = fmt.Errorf("reports cover at most %d days", MaxReportDays)
+)
+
+// ReportRange is the days a report covers, both inclusive. Days are UTC
+// days; From and To are their midnights.
+type ReportRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r ReportRange) Validate() error {
+	switch {
+	case r.To.Before(r.From):
+		return ErrInvalidReportRange
+	case r.end().Sub(r.From) > MaxReportDays*24*time.Hour:
+		return ErrReportRangeTooLong
+	}
+	return nil
+}
+
+// end returns the midnight after the last day.
+func (r ReportRange) end() time.Time {
+	return r.To.AddDate(0, 0, 1)
+}
+
+func (r ReportRange) days() iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		for day := r.From; day.Before(r.end()); day = day.AddDate(0, 0, 1) {
+			if !yield(day) {
+				return
+			}
+		}
+	}
+}
+
+// Utilisation sums up how full a set of sessions were.
+type Utilisation struct {
+	Sessions int `json:"sessions"`
+	// Capacity is the spots the sessions offered between them.
+	Capacity int `json:"capacity"`
+	// Booked counts the spots taken: bookings confirmed, attended or missed.
+	Booked    int `json:"booked"`
+	Attended  int `json:"attended"`
+	NoShows   int `json:"no_shows"`
+	Cancelled int `json:"cancelled"`
+	// FillRate is Booked over Capacity.
+	FillRate float64 `json:"fill_rate"`
+	// CancellationRate is the share of bookings that were cancelled.
+	CancellationRate float64 `json:"cancellation_rate"`
+	// NoShowRate is the share of settled bookings, attended or missed, that
+	// were missed.
+	NoShowRate float64 `json:"no_show_rate"`
+}
+
+func (u *Utilisation) add(s sessionStats) {
+	u.Sessions++
+	u.Capacity += s.capacity
+	u.Booked += s.booked
+	u.Attended += s.attended
+	u.NoShows += s.noShows
+	u.Cancelled += s.cancelled
+	u.FillRate = ratio(u.Booked, u.Capacity)
+	u.CancellationRate = ratio(u.Cancelled, u.Booked+u.Cancelled)
+	u.NoShowRate = ratio(u.NoShows, u.Attended+u.NoShows)
+}
+
+type ClassUtilisation struct {
+	ClassID   int    `json:"class_id"`
+	ClassName string `json:"class_name"`
+	Utilisation
+}
+
+// TimeSlotUtilisation covers the sessions starting in the same hour of the
+// same day of the week, in UTC.
+type TimeSlotUtilisation struct {
+	Weekday string `json:"weekday"`
+	Hour    int    `json:"hour"`
+	Utilisation
+}
+
+type UtilisationReport struct {
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	Total   Utilisation        `json:"total"`
+	Classes []ClassUtilisation `json:"classes"`
+}
+
+type PeakTimesReport struct {
+	From  string                `json:"from"`
+	To    string                `json:"to"`
+	Slots []TimeSlotUtilisation `json:"slots"`
+}
+
+// Cohort follows the members who first booked in the same month.
+type Cohort struct {
+	// Month is when the cohort first booked, as 2006-01.
+	Month   string `json:"month"`
+	Members int    `json:"members"`
+	// Active counts the members who booked in Month and each month after it,
+	// up to the end of the report, so Active[0] is Members.
+	Active []int `json:"active"`
+	// Retention is each of Active over Members.
+	Retention []float64 `json:"retention"`
+}
+
+type RetentionReport struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Cohorts []Cohort `json:"cohorts"`
+}
+
+// sessionStats counts the bookings of one session of a class.
+type sessionStats struct {
+	classID   int
+	className string
+	start     time.Time
+	capacity  int
+	booked    int
+	attended  int
+	noShows   int
+	cancelled int
+}
+
+func (s *sessionStats) count(status entities.BookingStatus) {
+	switch status {
+	case entities.BookingConfirmed:
+		s.booked++
+	case entities.BookingAttended:
+		s.booked++
+		s.attended++
+	case entities.BookingNoShow:
+		s.booked++
+		s.noShows++
+	case entities.BookingCancelled:
+		s.cancelled++
+	}
+}
+
+// ReportsComponent computes studio analytics from the classes and bookings.
+// Reports are built from per-day session figures and per-month booking
+// activity, which are cached once closed, so that only the days and months
+// still open are read from the repositories again.
+type ReportsComponent struct {
+	entities.ClassRepository
+	entities.BookingRepository
+	// Clock tells which days and months are closed.
+	Clock clock.Clock
+
+	mu sync.Mutex
+	// days holds the sessions of each closed day, by date.
+	days map[string][]sessionStats
+	// active holds the names of the members who booked in each closed
+	// month, by the month's first day. It covers every month before
+	// activeUntil.
+	active      map[time.Time]map[string]bool
+	activeUntil time.Time
+}
+
+func InitReportsComponent() *ReportsComponent {
+	return &ReportsComponent{
+		ClassRepository:   &entities.ClassEntity{},
+		BookingRepository: &entities.BookingEntity{},
+		Clock:             clock.Real{},
+	}
+}
+
+// Utilisation reports the fill, cancellation and no-show rates of every
+// class with sessions in rng.
+func (rc *ReportsComponent) Utilisation(ctx context.Context, rng ReportRange) (report *UtilisationReport, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "ReportsComponent.Utilisation")
+	outcome := "computed"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	sessions, computed, err := rc.sessions(ctx, rng)
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("reports.sessions", len(sessions)), attribute.Int("reports.computed_days", computed))
+	report = &UtilisationReport{From: rng.From.Format(time.DateOnly), To: rng.To.Format(time.DateOnly), Classes: []ClassUtilisation{}}
+	byClass := map[int]*ClassUtilisation{}
+	for _, s := range sessions {
+		report.Total.add(s)
+		c, ok := byClass[s.classID]
+		if !ok {
+			c = &ClassUtilisation{ClassID: s.classID, ClassName: s.className}
+			byClass[s.classID] = c
+		}
+		c.add(s)
+	}
+	for _, id := range slices.Sorted(maps.Keys(byClass)) {
+		report.Classes = append(report.Classes, *byClass[id])
+	}
+	return report, nil
+}
+
+// PeakTimes reports the utilisation of sessions in rng by the weekday and
+// hour they start, Monday first.
+func (rc *ReportsComponent) PeakTimes(ctx context.Context, rng ReportRange) (report *PeakTimesReport, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "ReportsComponent.PeakTimes")
+	outcome := "computed"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	sessions, computed, err := rc.sessions(ctx, rng)
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("reports.sessions", len(sessions)), attribute.Int("reports.computed_days", computed))
+	type slot struct{ weekday, hour int }
+	bySlot := map[slot]*TimeSlotUtilisation{}
+	for _, s := range sessions {
+		start := s.start.UTC()
+		// Weeks start on Monday
+		key := slot{(int(start.Weekday()) + 6) % 7, start.Hour()}
+		u, ok := bySlot[key]
+		if !ok {
+			u = &TimeSlotUtilisation{Weekday: start.Weekday().String(), Hour: start.Hour()}
+			bySlot[key] = u
+		}
+		u.add(s)
+	}
+	report = &PeakTimesReport{From: rng.From.Format(time.DateOnly), To: rng.To.Format(time.DateOnly), Slots: []TimeSlotUtilisation{}}
+	keys := slices.SortedFunc(maps.Keys(bySlot), func(a, b slot) int {
+		return cmp.Or(a.weekday-b.weekday, a.hour-b.hour)
+	})
+	for _, key := range keys {
+		report.Slots = append(report.Slots, *bySlot[key])
+	}
+	return report, nil
+}
+
+// Retention reports, for each month of rng, the members who first booked in
+// it and how many of them booked again in each month after, up to the end
+// of rng.
+func (rc *ReportsComponent) Retention(ctx context.Context, rng ReportRange) (report *RetentionReport, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "ReportsComponent.Retention")
+	outcome := "computed"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	firstMonth := monthOf(rng.From)
+	lastMonth := monthOf(rng.To)
+	active, err := rc.activeMembers(ctx, lastMonth.AddDate(0, 1, 0))
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+
+	// A member's cohort is the first month they booked in, ever
+	cohortOf := map[string]time.Time{}
+	for _, month := range slices.SortedFunc(maps.Keys(active), time.Time.Compare) {
+		for name := range active[month] {
+			if _, ok := cohortOf[name]; !ok {
+				cohortOf[name] = month
+			}
+		}
+	}
+
+	report = &RetentionReport{From: rng.From.Format(time.DateOnly), To: rng.To.Format(time.DateOnly), Cohorts: []Cohort{}}
+	for month := firstMonth; !month.After(lastMonth); month = month.AddDate(0, 1, 0) {
+		cohort := Cohort{Month: month.Format(monthFormat)}
+		var members []string
+		for name, first := range cohortOf {
+			if first.Equal(month) {
+				members = append(members, name)
+			}
+		}
+		cohort.Members = len(members)
+		for later := month; !later.After(lastMonth); later = later.AddDate(0, 1, 0) {
+			count := 0
+			for _, name := range members {
+				if active[later][name] {
+					count++
+				}
+			}
+			cohort.Active = append(cohort.Active, count)
+			cohort.Retention = append(cohort.Retention, ratio(count, cohort.Members))
+		}
+		report.Cohorts = append(report.Cohorts, cohort)
+	}
+	span.SetAttributes(attribute.Int("reports.members", len(cohortOf)))
+	return report, nil
+}
+
+// sessions returns every session held in rng with its bookings, in order of
+// start, and how many days were not cached. Closed days come from the
+// cache; the rest are read from the repositories in one pass.
+func (rc *ReportsComponent) sessions(ctx context.Context, rng ReportRange) ([]sessionStats, int, error) {
+	byDay := map[string][]sessionStats{}
+	var missing []time.Time
+	rc.mu.Lock()
+	for day := range rng.days() {
+		if cached, ok := rc.days[day.Format(time.DateOnly)]; ok {
+			byDay[day.Format(time.DateOnly)] = cached
+		} else {
+			missing = append(missing, day)
+		}
+	}
+	rc.mu.Unlock()
+
+	if len(missing) > 0 {
+		computed, err := rc.computeDays(ctx, ReportRange{From: missing[0], To: missing[len(missing)-1]})
+		if err != nil {
+			return nil, 0, err
+		}
+		closedBefore := rc.Clock.Now().Add(-ReportSettleTime)
+		rc.mu.Lock()
+		if rc.days == nil {
+			rc.days = map[string][]sessionStats{}
+		}
+		for _, day := range missing {
+			key := day.Format(time.DateOnly)
+			byDay[key] = computed[key]
+			if !day.AddDate(0, 0, 1).After(closedBefore) {
+				rc.days[key] = computed[key]
+			}
+		}
+		rc.mu.Unlock()
+	}
+
+	var sessions []sessionStats
+	for day := range rng.days() {
+		sessions = append(sessions, byDay[day.Format(time.DateOnly)]...)
+	}
+	return sessions, len(missing), nil
+}
+
+// computeDays reads the sessions of every day in rng, and their bookings,
+// from the repositories.
+func (rc *ReportsComponent) computeDays(ctx context.Context, rng ReportRange) (map[string][]sessionStats, error) {
+	type sessionKey struct {
+		classID int
+		start   time.Time
+	}
+	classes := map[int]entities.Class{}
+	stats := map[sessionKey]*sessionStats{}
+	var order []sessionKey
+	for class, err := range pagedClasses(ctx, rc.ClassRepository, entities.ClassFilter{From: rng.From, To: rng.end()}) {
+		if err != nil {
+			return nil, err
+		}
+		classes[class.ID] = class
+		for start := range class.Sessions(rng.From, rng.end()) {
+			// Sessions that began the day before belong to that day, and
+			// sessions called off were never held
+			if start.Before(rng.From) || !class.Held(start) {
+				continue
+			}
+			key := sessionKey{class.ID, start}
+			stats[key] = &sessionStats{classID: class.ID, className: class.ClassName, start: start, capacity: class.Capacity}
+			order = append(order, key)
+		}
+	}
+
+	// Bookings are dated on the day of their session in the class's time
+	// zone, and sessions may run past midnight
+	filter := entities.BookingFilter{From: rng.From.Add(-24 * time.Hour), To: rng.end().Add(24 * time.Hour)}
+	for booking, err := range pagedBookings(ctx, rc.BookingRepository, filter) {
+		if err != nil {
+			return nil, err
+		}
+		class, ok := classes[booking.ClassID]
+		if !ok {
+			continue
+		}
+		start, _ := class.Session(booking.Date)
+		if s, ok := stats[sessionKey{class.ID, start}]; ok {
+			s.count(booking.Status)
+		}
+	}
+
+	slices.SortFunc(order, func(a, b sessionKey) int {
+		return cmp.Or(a.start.Compare(b.start), a.classID-b.classID)
+	})
+	days := map[string][]sessionStats{}
+	for day := range rng.days() {
+		days[day.Format(time.DateOnly)] = []sessionStats{}
+	}
+	for _, key := range order {
+		day := key.start.UTC().Format(time.DateOnly)
+		days[day] = append(days[day], *stats[key])
+	}
+	return days, nil
+}
+
+const monthFormat = "2006-01"
+
+// monthOf returns the first day of t's month, in UTC.
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// activeMembers returns the names of the members who held a spot in each
+// month before until, by month. Closed months come from the cache; the rest
+// are read from the bookings in one pass.
+func (rc *ReportsComponent) activeMembers(ctx context.Context, until time.Time) (map[time.Time]map[string]bool, error) {
+	rc.mu.Lock()
+	active := map[time.Time]map[string]bool{}
+	for month, names := range rc.active {
+		if month.Before(until) {
+			active[month] = names
+		}
+	}
+	from := rc.activeUntil
+	rc.mu.Unlock()
+	if !from.Before(until) {
+		return active, nil
+	}
+
+	computed := map[time.Time]map[string]bool{}
+	filter := entities.BookingFilter{From: from, To: until.Add(-time.Nanosecond)}
+	for booking, err := range pagedBookings(ctx, rc.BookingRepository, filter) {
+		if err != nil {
+			return nil, err
+		}
+		switch booking.Status {
+		case entities.BookingConfirmed, entities.BookingAttended, entities.BookingNoShow:
+		default:
+			continue
+		}
+		month := monthOf(booking.Date)
+		if computed[month] == nil {
+			computed[month] = map[string]bool{}
+		}
+		computed[month][booking.Name] = true
+	}
+	maps.Copy(active, computed)
+
+	// Cache the closed months, unless another report has already moved on
+	closedBefore := monthOf(rc.Clock.Now().Add(-ReportSettleTime))
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if !rc.activeUntil.Equal(from) {
+		return active, nil
+	}
+	if rc.active == nil {
+		rc.active = map[time.Time]map[string]bool{}
+	}
+	for month, names := range computed {
+		if month.Before(closedBefore) {
+			rc.active[month] = names
+		}
+	}
+	rc.activeUntil = until
+	if closedBefore.Before(until) {
+		rc.activeUntil = closedBefore
+	}
+	if rc.activeUntil.Before(from) {
+		rc.activeUntil = from
+	}
+	return active, nil
+}
+
+// ratio returns part over whole to four decimal places, or 0 when whole is
+// 0.
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 10000
+}
@@ -0,0 +1,167 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReports serves classes and bookings the way the entities filter
+// and page them, counting the calls to ListBookings.
+func newTestReports(classes []entities.Class, bookings []entities.Booking, listed *int) *ReportsComponent {
+	return &ReportsComponent{
+		ClassRepository: &MockClassRepository{
+			ListClassesFn: func(ctx context.Context, f entities.ClassFilter) ([]entities.Class, error) {
+				var page []entities.Class
+				for _, c := range classes {
+					if c.ID > f.After.ID && !c.EndDate.Before(f.From) && !c.StartDate.After(f.To) && len(page) < f.Limit {
+						page = append(page, c)
+					}
+				}
+				return page, nil
+			},
+		},
+		BookingRepository: &MockBookingRepository{
+			ListBookingsFn: func(ctx context.Context, f entities.BookingFilter) ([]entities.Booking, error) {
+				*listed++
+				var page []entities.Booking
+				for _, b := range bookings {
+					if b.ID > f.After.ID && (f.From.IsZero() || !b.Date.Before(f.From)) && !b.Date.After(f.To) && len(page) < f.Limit {
+						page = append(page, b)
+					}
+				}
+				return page, nil
+			},
+		},
+		Clock: clock.NewFake(testNow),
+	}
+}
+
+func reportDay(month time.Month, d int) time.Time {
+	return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// reportClasses are a daily 9am Yoga class in April and a one-off Spin
+// session on Saturday 5 April at 6pm.
+var reportClasses = []entities.Class{
+	{ID: 1, ClassName: "Yoga", StartDate: reportDay(4, 1).Add(9 * time.Hour), EndDate: reportDay(4, 30).Add(10 * time.Hour), Capacity: 4},
+	{ID: 2, ClassName: "Spin", StartDate: reportDay(4, 5).Add(18 * time.Hour), EndDate: reportDay(4, 5).Add(19 * time.Hour), Capacity: 2},
+}
+
+var reportBookings = []entities.Booking{
+	{ID: 1, ClassID: 1, Name: "Ann", Date: reportDay(4, 4), Status: entities.BookingAttended},
+	{ID: 2, ClassID: 1, Name: "Bob", Date: reportDay(4, 4), Status: entities.BookingNoShow},
+	{ID: 3, ClassID: 1, Name: "Cat", Date: reportDay(4, 4), Status: entities.BookingCancelled},
+	{ID: 4, ClassID: 1, Name: "Ann", Date: reportDay(4, 5), Status: entities.BookingConfirmed},
+	{ID: 5, ClassID: 2, Name: "Bob", Date: reportDay(4, 5).Add(18 * time.Hour), Status: entities.BookingAttended},
+	{ID: 6, ClassID: 2, Name: "Cat", Date: reportDay(4, 5).Add(18 * time.Hour), Status: entities.BookingAttended},
+	{ID: 7, ClassID: 1, Name: "Dan", Date: reportDay(4, 6), Status: entities.BookingFailed},
+}
+
+func TestReportsComponent_Utilisation(t *testing.T) {
+	listed := 0
+	rc := newTestReports(reportClasses, reportBookings, &listed)
+
+	report, err := rc.Utilisation(context.Background(), ReportRange{From: reportDay(4, 4), To: reportDay(4, 6)})
+
+	require.NoError(t, err)
+	assert.Equal(t, "2025-04-04", report.From)
+	assert.Equal(t, "2025-04-06", report.To)
+	assert.Equal(t, Utilisation{
+		Sessions: 4, Capacity: 14, Booked: 5, Attended: 3, NoShows: 1, Cancelled: 1,
+		FillRate: 0.3571, CancellationRate: 0.1667, NoShowRate: 0.25,
+	}, report.Total)
+	assert.Equal(t, []ClassUtilisation{
+		{ClassID: 1, ClassName: "Yoga", Utilisation: Utilisation{
+			Sessions: 3, Capacity: 12, Booked: 3, Attended: 1, NoShows: 1, Cancelled: 1,
+			FillRate: 0.25, CancellationRate: 0.25, NoShowRate: 0.5,
+		}},
+		{ClassID: 2, ClassName: "Spin", Utilisation: Utilisation{
+			Sessions: 1, Capacity: 2, Booked: 2, Attended: 2, FillRate: 1,
+		}},
+	}, report.Classes)
+}
+
+func TestReportsComponent_CachesClosedDays(t *testing.T) {
+	listed := 0
+	rc := newTestReports(reportClasses, reportBookings, &listed)
+	ctx := context.Background()
+
+	// testNow is 1 May at noon, so April is closed up to the 28th
+	_, err := rc.Utilisation(ctx, ReportRange{From: reportDay(4, 1), To: reportDay(4, 30)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, listed)
+
+	report, err := rc.Utilisation(ctx, ReportRange{From: reportDay(4, 4), To: reportDay(4, 4)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, listed, "closed days should come from the cache")
+	assert.Equal(t, 3, report.Total.Booked+report.Total.Cancelled)
+
+	// Days still open are read again every time
+	for range 2 {
+		_, err = rc.Utilisation(ctx, ReportRange{From: reportDay(4, 29), To: reportDay(5, 1)})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, listed)
+}
+
+func TestReportsComponent_PeakTimes(t *testing.T) {
+	listed := 0
+	rc := newTestReports(reportClasses, reportBookings, &listed)
+
+	// 4 April 2025 is a Friday
+	report, err := rc.PeakTimes(context.Background(), ReportRange{From: reportDay(4, 4), To: reportDay(4, 7)})
+
+	require.NoError(t, err)
+	var slots []string
+	for _, s := range report.Slots {
+		slots = append(slots, fmt.Sprintf("%s %02d:00", s.Weekday, s.Hour))
+	}
+	assert.Equal(t, []string{"Monday 09:00", "Friday 09:00", "Saturday 09:00", "Saturday 18:00", "Sunday 09:00"}, slots)
+	assert.Equal(t, 1.0, report.Slots[3].FillRate)
+	assert.Equal(t, 0.5, report.Slots[1].FillRate)
+}
+
+func TestReportsComponent_Retention(t *testing.T) {
+	bookings := []entities.Booking{
+		{ID: 1, Name: "Old", Date: reportDay(2, 10), Status: entities.BookingAttended},
+		{ID: 2, Name: "Ann", Date: reportDay(3, 3), Status: entities.BookingAttended},
+		{ID: 3, Name: "Cat", Date: reportDay(3, 20), Status: entities.BookingNoShow},
+		{ID: 4, Name: "Old", Date: reportDay(3, 21), Status: entities.BookingAttended},
+		{ID: 5, Name: "Ann", Date: reportDay(4, 2), Status: entities.BookingConfirmed},
+		{ID: 6, Name: "Bob", Date: reportDay(4, 8), Status: entities.BookingAttended},
+		{ID: 7, Name: "Dan", Date: reportDay(4, 9), Status: entities.BookingCancelled},
+		{ID: 8, Name: "Cat", Date: reportDay(5, 1), Status: entities.BookingConfirmed},
+	}
+	listed := 0
+	rc := newTestReports(nil, bookings, &listed)
+	ctx := context.Background()
+
+	report, err := rc.Retention(ctx, ReportRange{From: reportDay(3, 15), To: reportDay(5, 31)})
+
+	require.NoError(t, err)
+	assert.Equal(t, []Cohort{
+		{Month: "2025-03", Members: 2, Active: []int{2, 1, 1}, Retention: []float64{1, 0.5, 0.5}},
+		{Month: "2025-04", Members: 1, Active: []int{1, 0}, Retention: []float64{1, 0}},
+		{Month: "2025-05", Members: 0, Active: []int{0}, Retention: []float64{0}},
+	}, report.Cohorts)
+
+	// Months up to March are closed; April and May are read again
+	listed = 0
+	again, err := rc.Retention(ctx, ReportRange{From: reportDay(3, 1), To: reportDay(5, 31)})
+	require.NoError(t, err)
+	assert.Equal(t, report.Cohorts, again.Cohorts)
+	assert.Equal(t, 1, listed)
+}
+
+func TestReportRange_Validate(t *testing.T) {
+	assert.NoError(t, ReportRange{From: reportDay(4, 1), To: reportDay(4, 1)}.Validate())
+	assert.ErrorIs(t, ReportRange{From: reportDay(4, 2), To: reportDay(4, 1)}.Validate(), ErrInvalidReportRange)
+	assert.ErrorIs(t, ReportRange{From: reportDay(4, 1), To: reportDay(4, 1).AddDate(4, 0, 0)}.Validate(), ErrReportRangeTooLong)
+}
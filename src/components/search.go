@@ -0,0 +1,144 @@
+package components
+
+import (
+	"context"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/search"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ClassSearch is what a member looks for in the timetable.
+type ClassSearch struct {
+	// Text holds the words every class found must match.
+	Text    string
+	Filters search.Filters
+	// Available leaves out classes whose next session is full.
+	Available bool
+	// Limit caps the classes returned. Total and Facets count every class
+	// found.
+	Limit int
+}
+
+// ClassMatch is a class found by a search, with the spots left in its next
+// session.
+type ClassMatch struct {
+	Class       entities.Class `json:"class"`
+	Score       float64        `json:"score"`
+	NextSession Availability   `json:"next_session"`
+}
+
+// ClassSearchResults are the classes found by a search, best matches first,
+// and how many of them have each value of every facet.
+type ClassSearchResults struct {
+	Total   int                                  `json:"total"`
+	Classes []ClassMatch                         `json:"classes"`
+	Facets  map[search.Facet][]search.FacetCount `json:"facets"`
+}
+
+// SearchComponent searches the classes that still have sessions to come.
+// Its index is filled by Rebuild and kept up to date by HandleEvent.
+type SearchComponent struct {
+	entities.ClassRepository
+	Index *search.Index
+	// Availability counts the spots left in sessions.
+	Availability *AvailabilityComponent
+	// Clock tells which classes have ended.
+	Clock clock.Clock
+}
+
+func InitSearchComponent() *SearchComponent {
+	return &SearchComponent{
+		ClassRepository: &entities.ClassEntity{},
+		Index:           search.NewIndex(),
+		Availability:    InitAvailabilityComponent(),
+		Clock:           clock.Real{},
+	}
+}
+
+// Rebuild indexes every class in the store afresh, as when the server
+// starts.
+func (sc *SearchComponent) Rebuild(ctx context.Context) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "SearchComponent.Rebuild")
+	outcome := "rebuilt"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	var classes []entities.Class
+	for class, err := range pagedClasses(ctx, sc.ClassRepository, entities.ClassFilter{}) {
+		if err != nil {
+			outcome = "error"
+			return err
+		}
+		classes = append(classes, class)
+	}
+	sc.Index.Replace(classes)
+	span.SetAttributes(attribute.Int("search.classes", sc.Index.Len()))
+	return nil
+}
+
+// HandleEvent indexes the class a class event is about. It is subscribed to
+// the event dispatcher; indexing a class again replaces it, so duplicate
+// events do no harm.
+func (sc *SearchComponent) HandleEvent(ctx context.Context, event entities.Event) error {
+	// Created and cancelled events carry the class the same way
+	var payload entities.ClassCreatedEvent
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	sc.Index.Put(*payload.Class)
+	return nil
+}
+
+// SearchClasses finds the classes matching q that have a session still to
+// come.
+func (sc *SearchComponent) SearchClasses(ctx context.Context, q ClassSearch) (results *ClassSearchResults, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "SearchComponent.SearchClasses",
+		attribute.Int("search.words", len(search.Words(q.Text))), attribute.Bool("search.available", q.Available))
+	outcome := "searched"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	now := sc.Clock.Now()
+	var hits []search.Hit
+	var matches []ClassMatch
+	for _, hit := range sc.Index.Search(q.Text, q.Filters) {
+		start, _, ok := hit.Class.NextSession(now)
+		if !ok {
+			continue
+		}
+		// Spots are only counted up front when they decide what is found;
+		// otherwise just the classes returned are counted below
+		if q.Available {
+			availability, err := sc.Availability.Availability(ctx, &hit.Class, start)
+			if err != nil {
+				outcome = "error"
+				return nil, err
+			}
+			if availability.Remaining == 0 {
+				continue
+			}
+			matches = append(matches, ClassMatch{Class: hit.Class, Score: hit.Score, NextSession: availability})
+		}
+		hits = append(hits, hit)
+	}
+
+	results = &ClassSearchResults{Total: len(hits), Classes: []ClassMatch{}, Facets: search.CountFacets(hits)}
+	span.SetAttributes(attribute.Int("search.found", len(hits)))
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	if q.Available {
+		results.Classes = matches[:len(hits)]
+		return results, nil
+	}
+	for _, hit := range hits {
+		start, _, _ := hit.Class.NextSession(now)
+		availability, err := sc.Availability.Availability(ctx, &hit.Class, start)
+		if err != nil {
+			outcome = "error"
+			return nil, err
+		}
+		results.Classes = append(results.Classes, ClassMatch{Class: hit.Class, Score: hit.Score, NextSession: availability})
+	}
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// classEvent returns the outbox event of a class being created or, once
+// cancelled, of it being cancelled.
+func classEvent(t *testing.T, id int, class entities.Class) entities.Event {
+	eventType := entities.EventClassCreated
+	if class.CancelledAt != nil {
+		eventType = entities.EventClassCancelled
+	}
+	payload, err := json.Marshal(entities.ClassCreatedEvent{Class: &class})
+	require.NoError(t, err)
+	return entities.Event{ID: id, Type: eventType, ClassID: class.ID, Payload: payload}
+}
+
+func TestSearchComponent(t *testing.T) {
+	ctx := context.Background()
+	day := testNow.Truncate(24*time.Hour).AddDate(0, 0, 1)
+	classes := []entities.Class{
+		{ID: 1, ClassName: "Evening Yoga", Category: "yoga", Tags: []string{"beginner"}, Capacity: 1,
+			StartDate: day.Add(18 * time.Hour), EndDate: day.Add(19 * time.Hour)},
+		{ID: 2, ClassName: "Sunrise Yoga", Category: "yoga", Capacity: 10,
+			StartDate: day.Add(6 * time.Hour), EndDate: day.AddDate(0, 0, 6).Add(7 * time.Hour)},
+		{ID: 3, ClassName: "Yesterday's Yoga", Category: "yoga", Capacity: 10,
+			StartDate: testNow.Add(-26 * time.Hour), EndDate: testNow.Add(-25 * time.Hour)},
+	}
+	bookings := []entities.Booking{{ID: 1, ClassID: 1, Date: classes[0].StartDate, Status: entities.BookingConfirmed}}
+	sc := &SearchComponent{
+		ClassRepository: &MockClassRepository{
+			ListClassesFn: func(ctx context.Context, filter entities.ClassFilter) ([]entities.Class, error) {
+				return classes, nil
+			},
+		},
+		Index: search.NewIndex(),
+		Availability: &AvailabilityComponent{BookingRepository: &MockBookingRepository{
+			ListBookingsFn: func(ctx context.Context, filter entities.BookingFilter) ([]entities.Booking, error) {
+				var found []entities.Booking
+				for _, b := range bookings {
+					if b.ClassID == filter.ClassID {
+						found = append(found, b)
+					}
+				}
+				return found, nil
+			},
+		}},
+		Clock: clock.NewFake(testNow),
+	}
+	require.NoError(t, sc.Rebuild(ctx))
+
+	results, err := sc.SearchClasses(ctx, ClassSearch{Text: "yoga"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.Total, "classes that have ended are left out")
+	require.Len(t, results.Classes, 2)
+	assert.Equal(t, 2, results.Classes[0].Class.ID, "equal matches come in start order")
+	assert.Equal(t, classes[1].StartDate, results.Classes[0].NextSession.Start)
+	assert.Equal(t, 0, results.Classes[1].NextSession.Remaining)
+	assert.Equal(t, []search.FacetCount{{Value: "yoga", Count: 2}}, results.Facets[search.FacetCategory])
+
+	results, err = sc.SearchClasses(ctx, ClassSearch{Text: "yoga", Available: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, results.Total, "full classes are left out")
+	assert.Equal(t, 2, results.Classes[0].Class.ID)
+	assert.Equal(t, 10, results.Classes[0].NextSession.Remaining)
+
+	results, err = sc.SearchClasses(ctx, ClassSearch{Filters: search.Filters{search.FacetTimeOfDay: "morning"}, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, results.Total)
+	assert.Len(t, results.Classes, 1)
+
+	// Class events keep the index up to date
+	spin := entities.Class{ID: 4, ClassName: "Spin", Capacity: 5, StartDate: day.Add(18 * time.Hour), EndDate: day.Add(19 * time.Hour)}
+	require.NoError(t, sc.HandleEvent(ctx, classEvent(t, 1, spin)))
+	results, err = sc.SearchClasses(ctx, ClassSearch{Text: "evening"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.Total)
+	cancelled := classes[0]
+	cancelledAt := testNow
+	cancelled.CancelledAt = &cancelledAt
+	require.NoError(t, sc.HandleEvent(ctx, classEvent(t, 2, cancelled)))
+	results, err = sc.SearchClasses(ctx, ClassSearch{Text: "evening"})
+	require.NoError(t, err)
+	require.Len(t, results.Classes, 1)
+	assert.Equal(t, 4, results.Classes[0].Class.ID)
+}
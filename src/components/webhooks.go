@@ -0,0 +1,322 @@
+package components
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/events"
+	"github.com/Vidyuallatha/glofox/src/retry"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every webhook delivery. The signature is the hex
+// HMAC-SHA256, keyed with the webhook's secret, of the timestamp, a dot and
+// the request body.
+const (
+	WebhookEventHeader     = "X-Glofox-Event"
+	WebhookDeliveryHeader  = "X-Glofox-Delivery"
+	WebhookTimestampHeader = "X-Glofox-Timestamp"
+	WebhookSignatureHeader = "X-Glofox-Signature"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// WebhooksComponent queues events for the webhooks subscribed to them and
+// sends the deliveries, retrying failures with exponential backoff.
+type WebhooksComponent struct {
+	entities.WebhookRepository
+	Client *http.Client
+	// Clock tells the time deliveries are signed with and retried at.
+	Clock clock.Clock
+	// Backoff spaces out the sends of a delivery, which is marked failed
+	// once its attempts are used up.
+	retry.Backoff
+	// BatchSize caps the deliveries sent per run of the delivery job.
+	BatchSize int
+}
+
+func InitWebhooksComponent() *WebhooksComponent {
+	return &WebhooksComponent{
+		WebhookRepository: &entities.WebhookEntity{},
+		Client:            &http.Client{Timeout: 10 * time.Second},
+		Clock:             clock.Real{},
+		Backoff:           retry.Backoff{MaxAttempts: 8, RetryDelay: 30 * time.Second},
+		BatchSize:         50,
+	}
+}
+
+func (wc *WebhooksComponent) GetWebhookForm() *entities.Webhook {
+	return new(entities.Webhook)
+}
+
+func (wc *WebhooksComponent) ValidateWebhook(form *entities.Webhook) []error {
+	var errs []error
+	if u, err := url.Parse(form.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, errors.New("url must be an absolute http or https URL"))
+	}
+	if len(form.EventTypes) == 0 {
+		errs = append(errs, errors.New("event_types is required"))
+	}
+	for _, t := range form.EventTypes {
+		if !slices.Contains(entities.EventTypes, t) {
+			errs = append(errs, fmt.Errorf("unknown event type %q", t))
+		}
+	}
+	if form.Secret != "" && len(form.Secret) < 16 {
+		errs = append(errs, errors.New("secret must be at least 16 characters"))
+	}
+	return errs
+}
+
+// RegisterWebhook stores a webhook, generating its signing secret when none
+// is given. The returned webhook is the only place the secret is shown.
+func (wc *WebhooksComponent) RegisterWebhook(ctx context.Context, webhook *entities.Webhook) (*entities.Webhook, error) {
+	if webhook.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		webhook.Secret = secret
+	}
+	webhook.CreatedAt = wc.Clock.Now()
+	created, err := wc.AddWebhook(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	utils.Logger(ctx).Info("webhook registered", "webhook_id", created.ID, "url", created.URL)
+	return created, nil
+}
+
+// Webhooks lists the registered webhooks without their secrets.
+func (wc *WebhooksComponent) Webhooks(ctx context.Context) ([]entities.Webhook, error) {
+	webhooks, err := wc.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	if webhooks == nil {
+		webhooks = []entities.Webhook{}
+	}
+	return webhooks, nil
+}
+
+// Deliveries returns the delivery history of a webhook.
+func (wc *WebhooksComponent) Deliveries(ctx context.Context, webhookID int) ([]entities.WebhookDelivery, error) {
+	if _, err := wc.FindWebhook(ctx, webhookID); err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return wc.ListWebhookDeliveries(ctx, webhookID)
+}
+
+// Deliver queues event for every webhook subscribed to its type. It is the
+// events.Sink the dispatcher hands events to; queueing an event twice
+// keeps the first delivery.
+func (wc *WebhooksComponent) Deliver(ctx context.Context, event entities.Event) error {
+	webhooks, err := wc.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(events.NewMessage(event))
+	if err != nil {
+		return err
+	}
+
+	now := wc.Clock.Now()
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		_, err := wc.AddWebhookDelivery(ctx, &entities.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        entities.WebhookDeliveryPending,
+			Attempts:      []entities.WebhookAttempt{},
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendDue sends the deliveries that are due and returns how many succeeded.
+func (wc *WebhooksComponent) SendDue(ctx context.Context) (sent int, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "WebhooksComponent.SendDue")
+	outcome := "sent"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	due, err := wc.DueWebhookDeliveries(ctx, wc.Clock.Now(), wc.BatchSize)
+	if err != nil {
+		outcome = "error"
+		return 0, err
+	}
+	for _, delivery := range due {
+		if err := ctx.Err(); err != nil {
+			outcome = "error"
+			return sent, err
+		}
+		if err := wc.send(ctx, &delivery); err != nil {
+			outcome = "error"
+			return sent, err
+		}
+		if delivery.Status == entities.WebhookDeliverySucceeded {
+			sent++
+		}
+	}
+	span.SetAttributes(attribute.Int("deliveries.sent", sent))
+	return sent, nil
+}
+
+// SendDueJob is the scheduler handler that runs SendDue.
+func (wc *WebhooksComponent) SendDueJob(ctx context.Context, _ entities.Job) error {
+	_, err := wc.SendDue(ctx)
+	return err
+}
+
+// Redeliver sends a delivery of the webhook again straight away, whatever
+// its status, and returns it with the attempt recorded.
+func (wc *WebhooksComponent) Redeliver(ctx context.Context, webhookID, deliveryID int) (*entities.WebhookDelivery, error) {
+	delivery, err := wc.FindWebhookDelivery(ctx, deliveryID)
+	if errors.Is(err, entities.ErrNotFound) || (err == nil && delivery.WebhookID != webhookID) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := wc.send(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// send makes one attempt at a delivery and records its outcome. Only
+// failing to record the attempt is returned as an error.
+func (wc *WebhooksComponent) send(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	logger := utils.Logger(ctx).With("delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "event_id", delivery.EventID)
+	webhook, err := wc.FindWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	start := wc.Clock.Now()
+	statusCode, postErr := wc.post(ctx, webhook, delivery, start)
+	attempt := entities.WebhookAttempt{
+		At:         start,
+		StatusCode: statusCode,
+		DurationMs: wc.Clock.Now().Sub(start).Milliseconds(),
+	}
+	if postErr != nil {
+		attempt.Error = postErr.Error()
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	now := wc.Clock.Now()
+	retryAt, retrying := wc.RetryAt(now, len(delivery.Attempts))
+	switch {
+	case postErr == nil:
+		delivery.Status = entities.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+	case retrying:
+		delivery.Status = entities.WebhookDeliveryPending
+		delivery.NextAttemptAt = retryAt
+		logger.Warn("webhook delivery failed, retrying", "error", postErr, "retry_at", delivery.NextAttemptAt)
+	default:
+		delivery.Status = entities.WebhookDeliveryFailed
+		logger.Error("webhook delivery failed", "attempts", len(delivery.Attempts), "error", postErr)
+	}
+	return wc.UpdateWebhookDelivery(context.WithoutCancel(ctx), delivery)
+}
+
+// post sends the signed delivery and returns the response status code.
+func (wc *WebhooksComponent) post(ctx context.Context, webhook *entities.Webhook, delivery *entities.WebhookDelivery, at time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	client := wc.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the X-Glofox-Signature value for a delivery body sent
+// with the given X-Glofox-Timestamp.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks a received delivery's signature, and that its
+// timestamp is within tolerance of now so that captured requests cannot be
+// replayed later.
+func VerifyWebhook(secret, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidWebhookSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, body))) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
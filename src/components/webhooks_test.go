@@ -0,0 +1,278 @@
+package components
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockWebhookRepository keeps webhooks and deliveries in memory.
+type MockWebhookRepository struct {
+	Webhooks   []entities.Webhook
+	Deliveries []entities.WebhookDelivery
+}
+
+func (m *MockWebhookRepository) AddWebhook(ctx context.Context, w *entities.Webhook) (*entities.Webhook, error) {
+	w.ID = len(m.Webhooks) + 1
+	m.Webhooks = append(m.Webhooks, *w)
+	return w, nil
+}
+
+func (m *MockWebhookRepository) FindWebhook(ctx context.Context, id int) (*entities.Webhook, error) {
+	for _, w := range m.Webhooks {
+		if w.ID == id {
+			return &w, nil
+		}
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockWebhookRepository) ListWebhooks(ctx context.Context) ([]entities.Webhook, error) {
+	return append([]entities.Webhook(nil), m.Webhooks...), nil
+}
+
+func (m *MockWebhookRepository) AddWebhookDelivery(ctx context.Context, d *entities.WebhookDelivery) (*entities.WebhookDelivery, error) {
+	for _, existing := range m.Deliveries {
+		if existing.WebhookID == d.WebhookID && existing.EventID == d.EventID {
+			return &existing, nil
+		}
+	}
+	d.ID = len(m.Deliveries) + 1
+	m.Deliveries = append(m.Deliveries, *d)
+	return d, nil
+}
+
+func (m *MockWebhookRepository) UpdateWebhookDelivery(ctx context.Context, d *entities.WebhookDelivery) error {
+	for i := range m.Deliveries {
+		if m.Deliveries[i].ID == d.ID {
+			m.Deliveries[i] = *d
+			return nil
+		}
+	}
+	return entities.ErrNotFound
+}
+
+func (m *MockWebhookRepository) FindWebhookDelivery(ctx context.Context, id int) (*entities.WebhookDelivery, error) {
+	for _, d := range m.Deliveries {
+		if d.ID == id {
+			return &d, nil
+		}
+	}
+	return nil, entities.ErrNotFound
+}
+
+func (m *MockWebhookRepository) ListWebhookDeliveries(ctx context.Context, webhookID int) ([]entities.WebhookDelivery, error) {
+	found := []entities.WebhookDelivery{}
+	for _, d := range m.Deliveries {
+		if d.WebhookID == webhookID {
+			found = append(found, d)
+		}
+	}
+	return found, nil
+}
+
+func (m *MockWebhookRepository) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	var due []entities.WebhookDelivery
+	for _, d := range m.Deliveries {
+		if d.Status == entities.WebhookDeliveryPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+// webhookReceiver records the requests it gets and answers with the next
+// queued status, or 200 once the queue is empty.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newTestWebhooks(t *testing.T, statuses ...int) (*WebhooksComponent, *webhookReceiver, *clock.Fake) {
+	receiver := &webhookReceiver{statuses: statuses}
+	srv := httptest.NewServer(receiver)
+	t.Cleanup(srv.Close)
+
+	clk := clock.NewFake(testNow)
+	wc := InitWebhooksComponent()
+	wc.WebhookRepository = &MockWebhookRepository{Webhooks: []entities.Webhook{
+		{ID: 1, URL: srv.URL, EventTypes: []entities.EventType{entities.EventBookingCreated}, Secret: "whsec_test_secret"},
+		{ID: 2, URL: srv.URL, EventTypes: []entities.EventType{entities.EventClassCreated}, Secret: "whsec_other_secret"},
+	}}
+	wc.Clock = clk
+	return wc, receiver, clk
+}
+
+var bookingCreated = entities.Event{
+	ID:         5,
+	Type:       entities.EventBookingCreated,
+	ClassID:    3,
+	Payload:    []byte(`{"booking":{"id":9}}`),
+	OccurredAt: testNow,
+}
+
+func TestWebhooksComponent_ValidateWebhook(t *testing.T) {
+	wc := InitWebhooksComponent()
+
+	tests := []struct {
+		name     string
+		form     *entities.Webhook
+		expected int
+	}{
+		{name: "should accept a valid webhook", form: &entities.Webhook{URL: "https://crm.example.com/hooks", EventTypes: []entities.EventType{entities.EventBookingCreated}}},
+		{name: "should reject a relative url", form: &entities.Webhook{URL: "/hooks", EventTypes: []entities.EventType{entities.EventBookingCreated}}, expected: 1},
+		{name: "should accept waitlist promotions", form: &entities.Webhook{URL: "https://crm.example.com/hooks", EventTypes: []entities.EventType{entities.EventWaitlistPromoted}}},
+		{name: "should reject an unknown event type", form: &entities.Webhook{URL: "https://crm.example.com", EventTypes: []entities.EventType{"booking.updated"}}, expected: 1},
+		{name: "should require event types", form: &entities.Webhook{URL: "ftp://crm.example.com"}, expected: 2},
+		{name: "should reject a short secret", form: &entities.Webhook{URL: "http://crm.example.com", EventTypes: entities.EventTypes, Secret: "short"}, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, wc.ValidateWebhook(tt.form), tt.expected)
+		})
+	}
+}
+
+func TestWebhooksComponent_RegisterWebhook(t *testing.T) {
+	wc := InitWebhooksComponent()
+	wc.WebhookRepository = &MockWebhookRepository{}
+	wc.Clock = clock.NewFake(testNow)
+
+	created, err := wc.RegisterWebhook(context.Background(), &entities.Webhook{URL: "https://crm.example.com", EventTypes: entities.EventTypes})
+	assert.NoError(t, err)
+	assert.Len(t, created.Secret, len("whsec_")+64)
+	assert.Equal(t, testNow, created.CreatedAt)
+
+	listed, err := wc.Webhooks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret)
+}
+
+func TestWebhooksComponent_DeliversSignedEvents(t *testing.T) {
+	wc, receiver, _ := newTestWebhooks(t)
+	ctx := context.Background()
+
+	assert.NoError(t, wc.Deliver(ctx, bookingCreated))
+	// Dispatching the event again does not queue a second delivery
+	assert.NoError(t, wc.Deliver(ctx, bookingCreated))
+	sent, err := wc.SendDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	assert.Len(t, receiver.requests, 1)
+	req, body := receiver.requests[0], receiver.bodies[0]
+	assert.JSONEq(t, `{"id":5,"type":"booking.created","class_id":3,"occurred_at":"2025-05-01T12:00:00Z","payload":{"booking":{"id":9}}}`, string(body))
+	assert.Equal(t, "booking.created", req.Header.Get(WebhookEventHeader))
+	assert.Equal(t, "1", req.Header.Get(WebhookDeliveryHeader))
+	assert.Equal(t, strconv.FormatInt(testNow.Unix(), 10), req.Header.Get(WebhookTimestampHeader))
+	assert.NoError(t, VerifyWebhook("whsec_test_secret", req.Header.Get(WebhookTimestampHeader), req.Header.Get(WebhookSignatureHeader), body, testNow, 5*time.Minute))
+
+	deliveries, err := wc.Deliveries(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, entities.WebhookDeliverySucceeded, deliveries[0].Status)
+	assert.Equal(t, http.StatusOK, deliveries[0].Attempts[0].StatusCode)
+
+	// Webhook 2 is not subscribed to bookings
+	deliveries, _ = wc.Deliveries(ctx, 2)
+	assert.Empty(t, deliveries)
+	_, err = wc.Deliveries(ctx, 9)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+}
+
+func TestWebhooksComponent_RetriesWithBackoff(t *testing.T) {
+	wc, receiver, clk := newTestWebhooks(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable)
+	wc.MaxAttempts = 3
+	ctx := context.Background()
+	assert.NoError(t, wc.Deliver(ctx, bookingCreated))
+
+	sent, _ := wc.SendDue(ctx)
+	assert.Equal(t, 0, sent)
+	delivery, _ := wc.FindWebhookDelivery(ctx, 1)
+	assert.Equal(t, entities.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, testNow.Add(wc.RetryDelay), delivery.NextAttemptAt)
+	assert.Equal(t, "unexpected response status 500 Internal Server Error", delivery.Attempts[0].Error)
+
+	// Not due yet
+	_, _ = wc.SendDue(ctx)
+	assert.Len(t, receiver.requests, 1)
+
+	clk.Advance(wc.RetryDelay)
+	_, _ = wc.SendDue(ctx)
+	delivery, _ = wc.FindWebhookDelivery(ctx, 1)
+	assert.Equal(t, clk.Now().Add(2*wc.RetryDelay), delivery.NextAttemptAt)
+
+	clk.Advance(2 * wc.RetryDelay)
+	_, _ = wc.SendDue(ctx)
+	delivery, _ = wc.FindWebhookDelivery(ctx, 1)
+	assert.Equal(t, entities.WebhookDeliveryFailed, delivery.Status)
+	assert.Len(t, delivery.Attempts, 3)
+
+	// Staff can send it again once the endpoint is fixed
+	redelivered, err := wc.Redeliver(ctx, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.WebhookDeliverySucceeded, redelivered.Status)
+	assert.Len(t, redelivered.Attempts, 4)
+	assert.Len(t, receiver.requests, 4)
+
+	_, err = wc.Redeliver(ctx, 2, 1)
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	timestamp := strconv.FormatInt(testNow.Unix(), 10)
+	signature := SignWebhook("whsec_test_secret", timestamp, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		now       time.Time
+		valid     bool
+	}{
+		{name: "should accept a fresh signed delivery", secret: "whsec_test_secret", timestamp: timestamp, body: body, now: testNow, valid: true},
+		{name: "should reject the wrong secret", secret: "whsec_other_secret", timestamp: timestamp, body: body, now: testNow},
+		{name: "should reject a tampered body", secret: "whsec_test_secret", timestamp: timestamp, body: []byte(`{"id":2}`), now: testNow},
+		{name: "should reject a replayed delivery", secret: "whsec_test_secret", timestamp: timestamp, body: body, now: testNow.Add(time.Hour)},
+		{name: "should reject a malformed timestamp", secret: "whsec_test_secret", timestamp: "yesterday", body: body, now: testNow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhook(tt.secret, tt.timestamp, signature, tt.body, tt.now, 5*time.Minute)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+			}
+		})
+	}
+}
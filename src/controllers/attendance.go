@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type AttendanceController struct {
+	Component components.AttendanceComponent
+}
+
+type CheckInForm struct {
+	Token string `json:"token"`
+}
+
+var attendanceComponent = components.InitAttendanceComponent()
+
+// AttendanceComponent returns the component behind the check-in routes so the
+// no-show job can share it.
+func AttendanceComponent() *components.AttendanceComponent {
+	return attendanceComponent
+}
+
+func HandleCheckIn(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := AttendanceController{}
+		controller.CheckIn(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (ac *AttendanceController) CheckIn(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid booking id")})
+		return
+	}
+
+	form := &CheckInForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil && !errors.Is(err, io.EOF) {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+
+	byStaff := isStaff(r)
+	if !byStaff && form.Token == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("token is required")})
+		return
+	}
+
+	booking, err := attendanceComponent.CheckIn(r.Context(), id, form.Token, byStaff)
+	switch {
+	case errors.Is(err, components.ErrBookingNotFound):
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	case errors.Is(err, components.ErrInvalidCheckInToken):
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{err})
+		return
+	case err != nil:
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, booking, nil)
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// streamRetry is how long clients wait before reconnecting to a dropped
+// stream.
+const streamRetry = 3 * time.Second
+
+type AvailabilityController struct {
+	Component components.AvailabilityComponent
+}
+
+var availabilityComponent = components.InitAvailabilityComponent()
+
+// AvailabilityComponent returns the component behind the availability
+// stream so the event dispatcher can publish to it.
+func AvailabilityComponent() *components.AvailabilityComponent {
+	return availabilityComponent
+}
+
+func HandleAvailabilityStream(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := AvailabilityController{}
+		controller.StreamAvailability(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// StreamAvailability sends the remaining capacity of a class as
+// Server-Sent Events until the client goes away.
+func (ac *AvailabilityController) StreamAvailability(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid class id")})
+		return
+	}
+	lastEventID := 0
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		if lastEventID, err = strconv.Atoi(v); err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid Last-Event-ID")})
+			return
+		}
+	}
+
+	sub, err := availabilityComponent.Subscribe(ctx, id, lastEventID)
+	if errors.Is(err, components.ErrClassNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Stop nginx and similar proxies from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	send := func(format string, args ...any) bool {
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	if !send("retry: %d\n\n", streamRetry.Milliseconds()) {
+		return
+	}
+	for _, update := range sub.Backlog {
+		if !sendAvailability(send, update) {
+			return
+		}
+	}
+
+	heartbeat := availabilityComponent.Clock.NewTicker(components.HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-sub.Updates:
+			if !ok {
+				// Too far behind; the client reconnects with Last-Event-ID
+				utils.Logger(ctx).Info("availability stream dropped", "class_id", id)
+				return
+			}
+			if !sendAvailability(send, update) {
+				return
+			}
+		case <-heartbeat.C():
+			if !send(": heartbeat\n\n") {
+				return
+			}
+		}
+	}
+}
+
+func sendAvailability(send func(string, ...any) bool, update components.AvailabilityUpdate) bool {
+	data, err := json.Marshal(update.Availability)
+	if err != nil {
+		return false
+	}
+	if update.ID > 0 {
+		return send("id: %d\nevent: availability\ndata: %s\n\n", update.ID, data)
+	}
+	return send("event: availability\ndata: %s\n\n", data)
+}
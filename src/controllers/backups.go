@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+)
+
+type BackupsController struct {
+	Component components.BackupsComponent
+}
+
+var backupsComponent = components.InitBackupsComponent()
+
+// BackupsComponent returns the component behind the backup routes so the
+// server can start it and schedule its jobs.
+func BackupsComponent() *components.BackupsComponent {
+	return backupsComponent
+}
+
+func HandleBackups(w http.ResponseWriter, r *http.Request) {
+	controller := BackupsController{}
+	switch r.Method {
+	case http.MethodPost:
+		controller.TakeBackup(w, r)
+	case http.MethodGet:
+		controller.ListBackups(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleBackupVerification(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := BackupsController{}
+		controller.VerifyBackups(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (bc *BackupsController) TakeBackup(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+	info, err := backupsComponent.TakeBackup(r.Context())
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, info, nil)
+}
+
+func (bc *BackupsController) ListBackups(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+	infos, err := backupsComponent.ListBackups(r.Context())
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, infos, nil)
+}
+
+func (bc *BackupsController) VerifyBackups(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+	report, err := backupsComponent.VerifyBackups(r.Context())
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, report, nil)
+}
+
+func writeBackupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, components.ErrBackupsOff) {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, nil, []error{err})
+		return
+	}
+	utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+)
+
+type BookingWindowsController struct {
+	Component components.BookingWindowsComponent
+}
+
+var bookingWindowsComponent = bookingsComponent.Windows
+
+func HandleBookingWindow(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		controller := BookingWindowsController{}
+		controller.SetWindow(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (wc *BookingWindowsController) SetWindow(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	windowForm := bookingWindowsComponent.GetWindowForm()
+	if err := json.NewDecoder(r.Body).Decode(windowForm); err != nil {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+
+	if errs := bookingWindowsComponent.ValidateWindow(windowForm); errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	window, err := bookingWindowsComponent.SetWindow(r.Context(), r.PathValue("studio"), windowForm)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, window, nil)
+}
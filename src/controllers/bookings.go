@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
 	"github.com/Vidyuallatha/glofox/src/utils"
-	"log"
 	"net/http"
+	"strconv"
 )
 
 type BookingsController struct {
@@ -15,8 +17,24 @@ type BookingsController struct {
 
 var bookingsComponent = components.InitBookingsComponent()
 
+// BookingsComponent returns the component behind the booking routes so the
+// waitlist job can share it.
+func BookingsComponent() *components.BookingsComponent {
+	return bookingsComponent
+}
+
+// UsePaymentGateway switches the gateway drop-in bookings and late
+// cancellation fees are charged and refunded through.
+func UsePaymentGateway(gateway components.PaymentGateway) {
+	bookingsComponent.PaymentGateway = gateway
+	penaltiesComponent.PaymentGateway = gateway
+}
+
 func HandleBookings(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
+	case http.MethodGet:
+		controller := BookingsController{}
+		controller.ListBookings(w, r)
 	case http.MethodPost:
 		controller := BookingsController{}
 		controller.CreateBooking(w, r)
@@ -25,20 +43,50 @@ func HandleBookings(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func HandleBooking(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		controller := BookingsController{}
+		controller.CancelBooking(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func (bc *BookingsController) CreateBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	bookingForm := bookingsComponent.GetBookingForm()
-	if err := json.NewDecoder(r.Body).Decode(bookingForm); err != nil {
-		log.Println("Error occurred while decoding json ", err)
+
+	_, span := telemetry.StartSpan(ctx, "BookingsController.decode")
+	err := json.NewDecoder(r.Body).Decode(bookingForm)
+	telemetry.EndSpan(span, outcome(err, "decoded", "invalid_body"), err)
+	if err != nil {
+		utils.Logger(ctx).Warn("failed to decode request body", "error", err)
 		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
 		return
 	}
 
-	if err := bookingsComponent.Validate(bookingForm); err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, nil, err)
+	_, span = telemetry.StartSpan(ctx, "BookingsController.validate")
+	errs := bookingsComponent.Validate(bookingForm)
+	telemetry.EndSpan(span, outcome(errors.Join(errs...), "valid", "invalid"), nil)
+	if errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
 		return
 	}
 
-	booking, err := bookingsComponent.CreateBooking(bookingForm)
+	booking, err := bookingsComponent.CreateBooking(ctx, bookingForm)
+	if errors.Is(err, components.ErrPaymentFailed) {
+		utils.WriteJSON(w, http.StatusPaymentRequired, nil, []error{err})
+		return
+	}
+	if errors.Is(err, components.ErrBookingSuspended) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{err})
+		return
+	}
+	if errors.Is(err, entities.ErrSessionFull) {
+		utils.WriteJSON(w, http.StatusConflict, nil, []error{err})
+		return
+	}
 	if err != nil {
 		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
 		return
@@ -46,3 +94,87 @@ func (bc *BookingsController) CreateBooking(w http.ResponseWriter, r *http.Reque
 
 	utils.WriteJSON(w, http.StatusCreated, booking, nil)
 }
+
+// ListBookings lists bookings for staff, narrowed by member name, class,
+// status and date. Check-in tokens are left out.
+func (bc *BookingsController) ListBookings(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := entities.BookingFilter{
+		Name:   query.Get("name"),
+		Status: entities.BookingStatus(query.Get("status")),
+	}
+	var errs []error
+	filter.From, filter.To, errs = dateRange(r)
+	if value := query.Get("class_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, errors.New("class_id must be a number"))
+		}
+		filter.ClassID = id
+	}
+	page, pageErrs := parsePage[entities.Booking](r)
+	filter.Sort, filter.After, filter.Limit = page.Sort, page.After, page.Limit+1
+	if errs = append(errs, pageErrs...); len(errs) > 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	bookings, err := bookingsComponent.ListBookings(r.Context(), filter)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	for i := range bookings {
+		bookings[i].CheckInToken = ""
+	}
+	writePage(w, r, page, bookings, entities.CursorOf[entities.Booking])
+}
+
+// CancelBooking is open to staff and to the member who made the booking,
+// who proves it with their calendar token.
+func (bc *BookingsController) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid booking id")})
+		return
+	}
+	if !isStaff(r) {
+		booking, err := bookingsComponent.FindBooking(r.Context(), id)
+		if errors.Is(err, entities.ErrNotFound) {
+			utils.WriteJSON(w, http.StatusNotFound, nil, []error{components.ErrBookingNotFound})
+			return
+		}
+		if err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+			return
+		}
+		// Members who booked without signing up have no token, so only staff
+		// can cancel for them
+		err = membersComponent.CheckMemberToken(r.Context(), booking.Name, r.URL.Query().Get("token"))
+		if errors.Is(err, components.ErrMemberNotFound) || errors.Is(err, components.ErrWrongMemberToken) {
+			utils.WriteJSON(w, http.StatusForbidden, nil, []error{components.ErrWrongMemberToken})
+			return
+		}
+		if err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+			return
+		}
+	}
+
+	booking, err := bookingsComponent.CancelBooking(r.Context(), id)
+	if errors.Is(err, components.ErrBookingNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, booking, nil)
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/ical"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+)
+
+type CalendarController struct {
+	Component components.CalendarComponent
+}
+
+var calendarComponent = components.InitCalendarComponent()
+
+func HandleTimetableCalendar(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := CalendarController{}
+		controller.Timetable(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleMemberCalendar(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := CalendarController{}
+		controller.MemberCalendar(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (cc *CalendarController) Timetable(w http.ResponseWriter, r *http.Request) {
+	cal, err := calendarComponent.Timetable(r.Context())
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	writeCalendar(w, r, cal)
+}
+
+func (cc *CalendarController) MemberCalendar(w http.ResponseWriter, r *http.Request) {
+	cal, err := calendarComponent.MemberCalendar(r.Context(), r.PathValue("name"), r.URL.Query().Get("token"))
+	if errors.Is(err, components.ErrCalendarNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	// The URL carries the member's token, so keep the feed out of shared caches
+	w.Header().Set("Cache-Control", "private")
+	writeCalendar(w, r, cal)
+}
+
+func writeCalendar(w http.ResponseWriter, r *http.Request, cal *ical.Calendar) {
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := cal.WriteTo(w); err != nil {
+		utils.Logger(r.Context()).Warn("failed to write calendar", "error", err)
+	}
+}
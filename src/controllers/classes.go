@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
 	"github.com/Vidyuallatha/glofox/src/utils"
-	"log"
 	"net/http"
+	"strconv"
 )
 
 type ClassesController struct {
@@ -17,6 +19,9 @@ var classesComponent = components.InitClassesComponent()
 
 func HandleClasses(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
+	case http.MethodGet:
+		controller := ClassesController{}
+		controller.ListClasses(w, r)
 	case http.MethodPost:
 		controller := ClassesController{}
 		controller.CreateClass(w, r)
@@ -25,20 +30,96 @@ func HandleClasses(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func HandleClass(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		controller := ClassesController{}
+		controller.CancelClass(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// classCancellation is the response to cancelling a class.
+type classCancellation struct {
+	Class *entities.Class `json:"class"`
+	// CancelledBookings are the bookings refunded because of the
+	// cancellation.
+	CancelledBookings []entities.Booking `json:"cancelled_bookings"`
+}
+
+func (cc *ClassesController) ListClasses(w http.ResponseWriter, r *http.Request) {
+	from, to, errs := dateRange(r)
+	page, pageErrs := parsePage[entities.Class](r)
+	if errs = append(errs, pageErrs...); len(errs) > 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+	classes, err := classesComponent.ListClasses(r.Context(), entities.ClassFilter{
+		From: from, To: to, Sort: page.Sort, After: page.After, Limit: page.Limit + 1,
+	})
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	writePage(w, r, page, classes, entities.CursorOf[entities.Class])
+}
+
+func (cc *ClassesController) CancelClass(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid class id")})
+		return
+	}
+
+	class, err := classesComponent.CancelClass(r.Context(), id)
+	if errors.Is(err, components.ErrClassNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	// The class is cancelled first so that no booking can slip in behind
+	// the sweep; a failed sweep is finished by cancelling again
+	bookings, err := bookingsComponent.CancelClassBookings(r.Context(), class)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	for i := range bookings {
+		bookings[i].CheckInToken = ""
+	}
+	utils.WriteJSON(w, http.StatusOK, classCancellation{Class: class, CancelledBookings: bookings}, nil)
+}
+
 func (cc *ClassesController) CreateClass(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	classForm := classesComponent.GetClassForm()
-	if err := json.NewDecoder(r.Body).Decode(classForm); err != nil {
-		log.Println("Error occurred while decoding json ", err)
+
+	_, span := telemetry.StartSpan(ctx, "ClassesController.decode")
+	err := json.NewDecoder(r.Body).Decode(classForm)
+	telemetry.EndSpan(span, outcome(err, "decoded", "invalid_body"), err)
+	if err != nil {
+		utils.Logger(ctx).Warn("failed to decode request body", "error", err)
 		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
 		return
 	}
 
-	if err := classesComponent.Validate(classForm); err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, nil, err)
+	_, span = telemetry.StartSpan(ctx, "ClassesController.validate")
+	errs := classesComponent.Validate(classForm)
+	telemetry.EndSpan(span, outcome(errors.Join(errs...), "valid", "invalid"), nil)
+	if errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
 		return
 	}
 
-	class, err := classesComponent.CreateClass(classForm)
+	class, err := classesComponent.CreateClass(ctx, classForm)
 	if err != nil {
 		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
 		return
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"net/http"
+)
+
+// StaffKeyHeader carries the staff API key on staff-only requests.
+const StaffKeyHeader = "X-Staff-Key"
+
+var (
+	staffAPIKey string
+
+	errStaffOnly = errors.New("a valid staff key is required")
+)
+
+// UseStaffAPIKey sets the key staff send in X-Staff-Key. Staff-only routes
+// are closed while the key is empty.
+func UseStaffAPIKey(key string) {
+	staffAPIKey = key
+}
+
+// UseClock switches the clock every component tells the time with.
+func UseClock(c clock.Clock) {
+	classesComponent.Clock = c
+	bookingsComponent.Clock = c
+	bookingsComponent.Pricing.Clock = c
+	penaltiesComponent.Clock = c
+	attendanceComponent.Clock = c
+	webhooksComponent.Clock = c
+	notificationsComponent.Clock = c
+	availabilityComponent.Clock = c
+	calendarComponent.Clock = c
+	reportsComponent.Clock = c
+	searchComponent.Clock = c
+}
+
+func isStaff(r *http.Request) bool {
+	key := r.Header.Get(StaffKeyHeader)
+	return staffAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(staffAPIKey)) == 1
+}
+
+// outcome picks the span outcome label for a step that may have failed.
+func outcome(err error, ok, failed string) string {
+	if err != nil {
+		return failed
+	}
+	return ok
+}
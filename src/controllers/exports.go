@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/records"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"iter"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// exportFlushRows is how many rows are written between flushes, so that
+// clients see an export arrive as it is produced.
+const exportFlushRows = 100
+
+var errNotAcceptable = errors.New("exports are available as text/csv, application/x-ndjson or " + records.FormatXLSX.ContentType())
+
+type ExportsController struct {
+	Component components.ExportsComponent
+}
+
+var exportsComponent = components.InitExportsComponent()
+
+func HandleClassExport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := ExportsController{}
+		controller.ExportClasses(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleBookingExport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := ExportsController{}
+		controller.ExportBookings(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleAttendanceExport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := ExportsController{}
+		controller.ExportAttendance(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (ec *ExportsController) ExportClasses(w http.ResponseWriter, r *http.Request) {
+	filter, format, ok := exportRequest(w, r)
+	if ok {
+		writeExport(w, r, "classes", format, exportsComponent.ExportClasses(r.Context(), filter))
+	}
+}
+
+func (ec *ExportsController) ExportBookings(w http.ResponseWriter, r *http.Request) {
+	filter, format, ok := exportRequest(w, r)
+	if ok {
+		writeExport(w, r, "bookings", format, exportsComponent.ExportBookings(r.Context(), filter))
+	}
+}
+
+func (ec *ExportsController) ExportAttendance(w http.ResponseWriter, r *http.Request) {
+	filter, format, ok := exportRequest(w, r)
+	if ok {
+		writeExport(w, r, "attendance", format, exportsComponent.ExportAttendance(r.Context(), filter))
+	}
+}
+
+// exportRequest checks the caller is staff and reads the date range and
+// format of an export. It writes the error response and returns false if
+// the request cannot be served.
+func exportRequest(w http.ResponseWriter, r *http.Request) (components.ExportFilter, records.Format, bool) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return components.ExportFilter{}, "", false
+	}
+
+	var filter components.ExportFilter
+	var errs []error
+	filter.From, filter.To, errs = dateRange(r)
+	if len(errs) == 0 {
+		if err := filter.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return filter, "", false
+	}
+
+	format, err := exportFormat(r)
+	if errors.Is(err, errNotAcceptable) {
+		utils.WriteJSON(w, http.StatusNotAcceptable, nil, []error{err})
+		return filter, "", false
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return filter, "", false
+	}
+	return filter, format, true
+}
+
+// dateRange reads the optional from and to dates of a listing. The range
+// takes in the whole of its last day; either end is zero when left out.
+func dateRange(r *http.Request) (from, to time.Time, errs []error) {
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			errs = append(errs, errors.New("from must be a date such as 2025-05-01"))
+		}
+		from = day
+	}
+	if value := query.Get("to"); value != "" {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			errs = append(errs, errors.New("to must be a date such as 2025-05-31"))
+		}
+		to = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return from, to, errs
+}
+
+// exportFormat returns the format named by the format query parameter or,
+// failing that, the first format Accept allows. CSV is the default.
+func exportFormat(r *http.Request) (records.Format, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		return records.ParseFormat(name)
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return records.FormatCSV, nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "text/*" {
+			return records.FormatCSV, nil
+		}
+		for _, f := range records.Formats {
+			if mediaType == f.ContentType() {
+				return f, nil
+			}
+		}
+	}
+	return "", errNotAcceptable
+}
+
+// writeExport streams rows to the client as they are produced. Once the
+// first row is out the status cannot change, so a failure part way aborts
+// the response rather than letting a truncated file pass for a whole one.
+func writeExport[T any](w http.ResponseWriter, r *http.Request, name string, format records.Format, rows iter.Seq2[T, error]) {
+	logger := utils.Logger(r.Context()).With("export", name, "format", format)
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	w.WriteHeader(http.StatusOK)
+
+	enc, err := records.NewEncoder[T](w, format, name)
+	if err != nil {
+		logger.Error("failed to start export", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+	flusher := http.NewResponseController(w)
+	written := 0
+	for row, err := range rows {
+		if err == nil {
+			err = enc.Encode(row)
+		}
+		if err != nil && r.Context().Err() != nil {
+			logger.Warn("client went away during export", "rows", written, "error", err)
+			return
+		}
+		if err != nil {
+			logger.Error("export failed", "rows", written, "error", err)
+			panic(http.ErrAbortHandler)
+		}
+		written++
+		if written%exportFlushRows == 0 {
+			if err := enc.Flush(); err != nil {
+				logger.Warn("client went away during export", "rows", written, "error", err)
+				return
+			}
+			_ = flusher.Flush()
+		}
+	}
+	if err := enc.Close(); err != nil {
+		logger.Warn("failed to finish export", "rows", written, "error", err)
+		return
+	}
+	logger.Info("export written", "rows", written)
+}
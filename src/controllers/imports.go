@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/records"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxImportBytes bounds the size of an import file.
+const maxImportBytes = 10 << 20
+
+func HandleClassImport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := ClassesController{}
+		controller.ImportClasses(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleMemberImport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := MembersController{}
+		controller.ImportMembers(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (cc *ClassesController) ImportClasses(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+	body, format, dryRun, err := importRequest(w, r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	report, err := classesComponent.ImportClasses(r.Context(), body, format, dryRun)
+	writeImportReport(w, report, err)
+}
+
+func (mc *MembersController) ImportMembers(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+	body, format, dryRun, err := importRequest(w, r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	report, err := membersComponent.ImportMembers(r.Context(), body, format, dryRun)
+	writeImportReport(w, report, err)
+}
+
+// importRequest returns the file, its format and whether the import is a
+// dry run.
+func importRequest(w http.ResponseWriter, r *http.Request) (io.Reader, records.Format, bool, error) {
+	format, err := records.FormatOf(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, "", false, err
+	}
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			return nil, "", false, errors.New("dry_run must be true or false")
+		}
+	}
+	return http.MaxBytesReader(w, r.Body, maxImportBytes), format, dryRun, nil
+}
+
+func writeImportReport[T any](w http.ResponseWriter, report *components.ImportReport[T], err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, components.ErrInvalidImport):
+		utils.WriteJSON(w, http.StatusBadRequest, report, []error{err})
+	case errors.As(err, &tooLarge):
+		utils.WriteJSON(w, http.StatusRequestEntityTooLarge, nil, []error{errors.New("import file is too large")})
+	case err != nil:
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+	case report.DryRun:
+		utils.WriteJSON(w, http.StatusOK, report, nil)
+	default:
+		utils.WriteJSON(w, http.StatusCreated, report, nil)
+	}
+}
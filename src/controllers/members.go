@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+)
+
+type MembersController struct {
+	Component components.MembersComponent
+}
+
+type NotificationPreferencesForm struct {
+	Channels []entities.NotificationChannel `json:"channels"`
+	OptOut   bool                           `json:"opt_out"`
+}
+
+var (
+	membersComponent = components.InitMembersComponent()
+
+	errTierStaffOnly = errors.New("only staff can register student or premium members")
+)
+
+func HandleMembers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := MembersController{}
+		controller.CreateMember(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		controller := MembersController{}
+		controller.SetNotificationPreferences(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleCalendarToken(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := MembersController{}
+		controller.RotateCalendarToken(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (mc *MembersController) CreateMember(w http.ResponseWriter, r *http.Request) {
+	memberForm := membersComponent.GetMemberForm()
+	if err := json.NewDecoder(r.Body).Decode(memberForm); err != nil {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+
+	if errs := membersComponent.Validate(memberForm); errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+	// Student and premium tiers earn discounts and early booking, so members
+	// cannot give them to themselves
+	if memberForm.Tier != "" && memberForm.Tier != entities.TierStandard && !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errTierStaffOnly})
+		return
+	}
+
+	member, err := membersComponent.CreateMember(r.Context(), memberForm)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, member, nil)
+}
+
+// SetNotificationPreferences is open to staff and to the member themselves,
+// who prove it with their calendar token.
+func (mc *MembersController) SetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		err := membersComponent.CheckMemberToken(r.Context(), r.PathValue("name"), r.URL.Query().Get("token"))
+		if errors.Is(err, components.ErrMemberNotFound) {
+			utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+			return
+		}
+		if errors.Is(err, components.ErrWrongMemberToken) {
+			utils.WriteJSON(w, http.StatusForbidden, nil, []error{err})
+			return
+		}
+		if err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+			return
+		}
+	}
+
+	form := &NotificationPreferencesForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+
+	member, err := membersComponent.SetNotificationPreferences(r.Context(), r.PathValue("name"), form.Channels, form.OptOut)
+	if errors.Is(err, components.ErrMemberNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, member, nil)
+}
+
+func (mc *MembersController) RotateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	member, err := membersComponent.RotateCalendarToken(r.Context(), r.PathValue("name"))
+	if errors.Is(err, components.ErrMemberNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, member, nil)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+)
+
+type MembershipsController struct {
+	Component components.MembershipsComponent
+}
+
+var membershipsComponent = components.InitMembershipsComponent()
+
+func HandleMemberships(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := MembershipsController{}
+		controller.CreateMembership(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CreateMembership assigns a plan to a member. Plans let members book
+// without paying, so only staff may assign them.
+func (mc *MembershipsController) CreateMembership(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+	membershipForm := membershipsComponent.GetMembershipForm()
+	if err := json.NewDecoder(r.Body).Decode(membershipForm); err != nil {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+
+	if errs := membershipsComponent.Validate(membershipForm); errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	membership, err := membershipsComponent.CreateMembership(r.Context(), membershipForm)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, membership, nil)
+}
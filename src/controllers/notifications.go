@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/notify"
+)
+
+var notificationsComponent = components.InitNotificationsComponent()
+
+// NotificationsComponent returns the component that notifies members so
+// the event dispatcher and reminder job can share it.
+func NotificationsComponent() *components.NotificationsComponent {
+	return notificationsComponent
+}
+
+// UseNotifier switches the notifier messages on channel are sent through.
+func UseNotifier(channel entities.NotificationChannel, notifier notify.Notifier) {
+	notificationsComponent.Notifiers[channel] = notifier
+}
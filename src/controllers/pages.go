@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is how many records a list returns when no limit is
+	// given, and MaxPageSize the most it returns at once.
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+var errCursorSort = errors.New("cursor was made for another sort order")
+
+// pageRequest is the page of a list asked for by the limit, sort, cursor and
+// fields query parameters.
+type pageRequest struct {
+	Sort  entities.Sort
+	After entities.Cursor
+	Limit int
+	// Fields are the JSON fields to return of each record, or nil for all
+	// of them.
+	Fields []string
+}
+
+// pageCursor is the content of the opaque cursor handed out in next links.
+// It carries the order it was made for, as its position means nothing in
+// any other.
+type pageCursor struct {
+	Sort entities.SortField `json:"s"`
+	Desc bool               `json:"d,omitempty"`
+	Key  string             `json:"k,omitempty"`
+	ID   int                `json:"i"`
+}
+
+func encodeCursor(s entities.Sort, c entities.Cursor) string {
+	data, _ := json.Marshal(pageCursor{Sort: s.Field, Desc: s.Desc, Key: c.Key, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, s entities.Sort) (entities.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	var c pageCursor
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.ID <= 0 {
+		return entities.Cursor{}, errors.New("invalid cursor")
+	}
+	if c.Sort != s.Field || c.Desc != s.Desc {
+		return entities.Cursor{}, errCursorSort
+	}
+	return entities.Cursor{Key: c.Key, ID: c.ID}, nil
+}
+
+// parsePage reads the page asked for of a list of T. sort is one of
+// created, date or name, prefixed with - for descending order.
+func parsePage[T any](r *http.Request) (pageRequest, []error) {
+	query := r.URL.Query()
+	p := pageRequest{Sort: entities.Sort{Field: entities.SortCreated}, Limit: DefaultPageSize}
+	var errs []error
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			errs = append(errs, fmt.Errorf("limit must be a number from 1 to %d", MaxPageSize))
+		}
+		p.Limit = limit
+	}
+	if value := query.Get("sort"); value != "" {
+		p.Sort.Desc = strings.HasPrefix(value, "-")
+		p.Sort.Field = entities.SortField(strings.TrimPrefix(value, "-"))
+		switch p.Sort.Field {
+		case entities.SortCreated, entities.SortDate, entities.SortName:
+		default:
+			errs = append(errs, errors.New("sort must be created, date or name, prefixed with - for descending order"))
+		}
+	}
+	if value := query.Get("cursor"); value != "" && len(errs) == 0 {
+		after, err := decodeCursor(value, p.Sort)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		p.After = after
+	}
+	if value := query.Get("fields"); value != "" {
+		known := jsonFields(reflect.TypeFor[T]())
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if !known[field] {
+				errs = append(errs, fmt.Errorf("fields: unknown field %q", field))
+				continue
+			}
+			p.Fields = append(p.Fields, field)
+		}
+	}
+	return p, errs
+}
+
+// jsonFields returns the names the fields of struct type t are encoded
+// under.
+func jsonFields(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.IsExported() && name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// writePage writes the records of page p. records are listed with a limit
+// one above p.Limit, so that an extra record tells that a next page follows.
+func writePage[T any](w http.ResponseWriter, r *http.Request, p pageRequest, records []T, cursor func(T, entities.Sort) entities.Cursor) {
+	next := ""
+	if len(records) > p.Limit {
+		records = records[:p.Limit]
+		query := r.URL.Query()
+		query.Set("cursor", encodeCursor(p.Sort, cursor(records[len(records)-1], p.Sort)))
+		next = r.URL.Path + "?" + query.Encode()
+	}
+	if len(p.Fields) == 0 {
+		utils.WritePage(w, records, next)
+		return
+	}
+
+	trimmed := make([]map[string]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		var all map[string]json.RawMessage
+		if err == nil {
+			err = json.Unmarshal(data, &all)
+		}
+		if err != nil {
+			utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+			return
+		}
+		fields := make(map[string]json.RawMessage, len(p.Fields))
+		for _, field := range p.Fields {
+			if value, ok := all[field]; ok {
+				fields[field] = value
+			}
+		}
+		trimmed = append(trimmed, fields)
+	}
+	utils.WritePage(w, trimmed, next)
+}
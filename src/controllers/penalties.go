@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+	"strconv"
+)
+
+type PenaltiesController struct {
+	Component components.PenaltiesComponent
+}
+
+type PenaltyOverrideForm struct {
+	By     string `json:"by"`
+	Reason string `json:"reason"`
+}
+
+// penaltiesComponent is the engine bookings are checked against, so that
+// both see the same payment gateway.
+var penaltiesComponent = bookingsComponent.Penalties
+
+func HandlePenaltyPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		controller := PenaltiesController{}
+		controller.SetPolicy(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandlePenalties(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := PenaltiesController{}
+		controller.ListPenalties(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandlePenaltyOverride(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := PenaltiesController{}
+		controller.OverridePenalty(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (pc *PenaltiesController) SetPolicy(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	policyForm := penaltiesComponent.GetPolicyForm()
+	if err := json.NewDecoder(r.Body).Decode(policyForm); err != nil {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+
+	if errs := penaltiesComponent.ValidatePolicy(policyForm); errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	policy, err := penaltiesComponent.SetPolicy(r.Context(), r.PathValue("studio"), policyForm)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, policy, nil)
+}
+
+func (pc *PenaltiesController) ListPenalties(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	page, errs := parsePage[entities.Penalty](r)
+	if len(errs) > 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	penalties, err := penaltiesComponent.ListPenalties(r.Context(), entities.PenaltyFilter{
+		Name: r.URL.Query().Get("name"), Sort: page.Sort, After: page.After, Limit: page.Limit + 1,
+	})
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+
+	writePage(w, r, page, penalties, entities.CursorOf[entities.Penalty])
+}
+
+func (pc *PenaltiesController) OverridePenalty(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid penalty id")})
+		return
+	}
+
+	form := &PenaltyOverrideForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+	var errs []error
+	if form.By == "" {
+		errs = append(errs, errors.New("by is required"))
+	}
+	if form.Reason == "" {
+		errs = append(errs, errors.New("reason is required"))
+	}
+	if errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	penalty, err := penaltiesComponent.OverridePenalty(r.Context(), id, form.By, form.Reason)
+	if errors.Is(err, components.ErrPenaltyNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, penalty, nil)
+}
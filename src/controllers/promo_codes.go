@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+)
+
+type PromoCodesController struct {
+	Component components.PromoCodesComponent
+}
+
+var promoCodesComponent = components.InitPromoCodesComponent()
+
+func HandlePromoCodes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := PromoCodesController{}
+		controller.CreatePromoCode(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CreatePromoCode creates a discount code. Codes take money off bookings,
+// so only staff may create them.
+func (pc *PromoCodesController) CreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+	promoCodeForm := promoCodesComponent.GetPromoCodeForm()
+	if err := json.NewDecoder(r.Body).Decode(promoCodeForm); err != nil {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+
+	if errs := promoCodesComponent.Validate(promoCodeForm); errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	promoCode, err := promoCodesComponent.CreatePromoCode(r.Context(), promoCodeForm)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, promoCode, nil)
+}
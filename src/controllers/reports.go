@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+	"time"
+)
+
+type ReportsController struct {
+	Component components.ReportsComponent
+}
+
+var reportsComponent = components.InitReportsComponent()
+
+func HandleUtilisationReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := ReportsController{}
+		controller.Utilisation(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandlePeakTimesReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := ReportsController{}
+		controller.PeakTimes(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleRetentionReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := ReportsController{}
+		controller.Retention(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (rc *ReportsController) Utilisation(w http.ResponseWriter, r *http.Request) {
+	rng, ok := reportRequest(w, r)
+	if !ok {
+		return
+	}
+	report, err := reportsComponent.Utilisation(r.Context(), rng)
+	writeReport(w, report, err)
+}
+
+func (rc *ReportsController) PeakTimes(w http.ResponseWriter, r *http.Request) {
+	rng, ok := reportRequest(w, r)
+	if !ok {
+		return
+	}
+	report, err := reportsComponent.PeakTimes(r.Context(), rng)
+	writeReport(w, report, err)
+}
+
+func (rc *ReportsController) Retention(w http.ResponseWriter, r *http.Request) {
+	rng, ok := reportRequest(w, r)
+	if !ok {
+		return
+	}
+	report, err := reportsComponent.Retention(r.Context(), rng)
+	writeReport(w, report, err)
+}
+
+// reportRequest checks the caller is staff and reads the range a report
+// covers. It writes the error response and returns false if the request
+// cannot be served.
+func reportRequest(w http.ResponseWriter, r *http.Request) (components.ReportRange, bool) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return components.ReportRange{}, false
+	}
+
+	var rng components.ReportRange
+	var errs []error
+	query := r.URL.Query()
+	from, err := time.Parse(time.DateOnly, query.Get("from"))
+	if err != nil {
+		errs = append(errs, errors.New("from must be a date such as 2025-05-01"))
+	}
+	to, err := time.Parse(time.DateOnly, query.Get("to"))
+	if err != nil {
+		errs = append(errs, errors.New("to must be a date such as 2025-05-31"))
+	}
+	rng.From, rng.To = from, to
+	if len(errs) == 0 {
+		if err := rng.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return rng, false
+	}
+	return rng, true
+}
+
+func writeReport(w http.ResponseWriter, report any, err error) {
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, report, nil)
+}
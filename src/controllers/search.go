@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/search"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type SearchController struct {
+	Component components.SearchComponent
+}
+
+var searchComponent = components.InitSearchComponent()
+
+// SearchComponent returns the component behind class search so that its
+// index can be built at startup and kept up to date from class events.
+func SearchComponent() *components.SearchComponent {
+	return searchComponent
+}
+
+func HandleClassSearch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := SearchController{}
+		controller.SearchClasses(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+var (
+	timesOfDay = []string{"morning", "afternoon", "evening"}
+	weekdays   = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+)
+
+// SearchClasses finds the classes to come matching the words in q, narrowed
+// by a value of any facet and, with available=true, to those with a spot
+// left in their next session.
+func (sc *SearchController) SearchClasses(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := components.ClassSearch{Text: query.Get("q"), Filters: search.Filters{}, Limit: DefaultPageSize}
+	var errs []error
+	for _, facet := range search.Facets {
+		if value := strings.TrimSpace(query.Get(string(facet))); value != "" {
+			q.Filters[facet] = value
+		}
+	}
+	if value, ok := q.Filters[search.FacetTimeOfDay]; ok && !slices.Contains(timesOfDay, strings.ToLower(value)) {
+		errs = append(errs, errors.New("time_of_day must be morning, afternoon or evening"))
+	}
+	if value, ok := q.Filters[search.FacetWeekday]; ok && !slices.Contains(weekdays, strings.ToLower(value)) {
+		errs = append(errs, errors.New("weekday must be a day of the week such as monday"))
+	}
+	if value := query.Get("available"); value != "" {
+		available, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, errors.New("available must be true or false"))
+		}
+		q.Available = available
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			errs = append(errs, fmt.Errorf("limit must be a number from 1 to %d", MaxPageSize))
+		}
+		q.Limit = limit
+	}
+	if len(errs) > 0 {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	results, err := searchComponent.SearchClasses(r.Context(), q)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, results, nil)
+}
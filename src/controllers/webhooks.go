@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"net/http"
+	"strconv"
+)
+
+type WebhooksController struct {
+	Component components.WebhooksComponent
+}
+
+var webhooksComponent = components.InitWebhooksComponent()
+
+// WebhooksComponent returns the component behind the webhook routes so the
+// event dispatcher and delivery job can share it.
+func WebhooksComponent() *components.WebhooksComponent {
+	return webhooksComponent
+}
+
+func HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	controller := WebhooksController{}
+	switch r.Method {
+	case http.MethodPost:
+		controller.RegisterWebhook(w, r)
+	case http.MethodGet:
+		controller.ListWebhooks(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller := WebhooksController{}
+		controller.ListDeliveries(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func HandleWebhookRedelivery(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		controller := WebhooksController{}
+		controller.Redeliver(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (wc *WebhooksController) RegisterWebhook(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	webhookForm := webhooksComponent.GetWebhookForm()
+	if err := json.NewDecoder(r.Body).Decode(webhookForm); err != nil {
+		utils.Logger(r.Context()).Warn("failed to decode request body", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid request body")})
+		return
+	}
+
+	if errs := webhooksComponent.ValidateWebhook(webhookForm); errs != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, errs)
+		return
+	}
+
+	webhook, err := webhooksComponent.RegisterWebhook(r.Context(), webhookForm)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, webhook, nil)
+}
+
+func (wc *WebhooksController) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	webhooks, err := webhooksComponent.Webhooks(r.Context())
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, webhooks, nil)
+}
+
+func (wc *WebhooksController) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid webhook id")})
+		return
+	}
+
+	deliveries, err := webhooksComponent.Deliveries(r.Context(), id)
+	if errors.Is(err, components.ErrWebhookNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, deliveries, nil)
+}
+
+func (wc *WebhooksController) Redeliver(w http.ResponseWriter, r *http.Request) {
+	if !isStaff(r) {
+		utils.WriteJSON(w, http.StatusForbidden, nil, []error{errStaffOnly})
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid webhook id")})
+		return
+	}
+	deliveryID, err := strconv.Atoi(r.PathValue("delivery_id"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, nil, []error{errors.New("invalid delivery id")})
+		return
+	}
+
+	delivery, err := webhooksComponent.Redeliver(r.Context(), id, deliveryID)
+	if errors.Is(err, components.ErrWebhookDeliveryNotFound) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, nil, []error{err})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, delivery, nil)
+}
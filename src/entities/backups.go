@@ -0,0 +1,273 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Snapshot is a consistent copy of every collection in the store, encoded
+// as JSON by collection name. Seq is the last mutation it includes.
+type Snapshot struct {
+	Seq     int64                      `json:"seq"`
+	TakenAt time.Time                  `json:"taken_at"`
+	Tables  map[string]json.RawMessage `json:"tables"`
+}
+
+// Mutation is one record written to the store: Record replaces the record
+// at Index in Table, or is appended when Index is the table's length.
+// Records are never removed, so replaying mutations in Seq order onto the
+// snapshot they follow rebuilds the store.
+type Mutation struct {
+	Seq    int64           `json:"seq"`
+	At     time.Time       `json:"at"`
+	Table  string          `json:"table"`
+	Index  int             `json:"index"`
+	Record json.RawMessage `json:"record"`
+}
+
+// ErrUnrecordedWrites is returned by Mutations while writes that could not
+// be recorded are missing from the log. A snapshot covers them; restoring
+// to a time between such a write and that snapshot goes without it.
+var ErrUnrecordedWrites = errors.New("writes could not be recorded")
+
+type BackupRepository interface {
+	// Snapshot copies the whole store as of the last recorded mutation,
+	// writes that could not be recorded included, and so clears
+	// ErrUnrecordedWrites.
+	Snapshot(ctx context.Context) (*Snapshot, error)
+	// Restore replaces the whole store with s and then mutations, or leaves
+	// it untouched if any of them does not apply.
+	Restore(ctx context.Context, s *Snapshot, mutations []Mutation) error
+	// RecordMutations starts recording every write to the store, numbering
+	// them from after seq and stamping them with now.
+	RecordMutations(ctx context.Context, seq int64, now func() time.Time) error
+	// Mutations returns the recorded mutations that have not been trimmed,
+	// or ErrUnrecordedWrites while a write is missing from them.
+	Mutations(ctx context.Context) ([]Mutation, error)
+	// TrimMutations forgets the recorded mutations up to and including seq.
+	TrimMutations(ctx context.Context, seq int64) error
+}
+
+type BackupEntity struct {
+	BackupRepository
+}
+
+// table is a collection of the store as seen by snapshots and the mutation
+// log.
+type table interface {
+	name() string
+	encode() (json.RawMessage, error)
+	// decode rebuilds the collection from data and mutations, returning a
+	// function that installs the result.
+	decode(data json.RawMessage, mutations []Mutation) (install func(), err error)
+}
+
+type tableOf[T any] struct {
+	table   string
+	records *[]T
+}
+
+func (t tableOf[T]) name() string { return t.table }
+
+func (t tableOf[T]) encode() (json.RawMessage, error) {
+	if *t.records == nil {
+		return json.RawMessage("[]"), nil
+	}
+	return json.Marshal(*t.records)
+}
+
+func (t tableOf[T]) decode(data json.RawMessage, mutations []Mutation) (func(), error) {
+	var records []T
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", t.table, err)
+		}
+	}
+	for _, m := range mutations {
+		var record T
+		if err := json.Unmarshal(m.Record, &record); err != nil {
+			return nil, fmt.Errorf("decoding mutation %d to %s: %w", m.Seq, t.table, err)
+		}
+		switch {
+		case m.Index == len(records):
+			records = append(records, record)
+		case m.Index >= 0 && m.Index < len(records):
+			records[m.Index] = record
+		default:
+			return nil, fmt.Errorf("mutation %d writes %s record %d of %d", m.Seq, t.table, m.Index, len(records))
+		}
+	}
+	return func() { *t.records = records }, nil
+}
+
+// tables lists every collection in the store. A collection missing here is
+// neither backed up nor restored.
+var tables = []table{
+	tableOf[Class]{"classes", &Classes},
+	tableOf[Booking]{"bookings", &Bookings},
+	tableOf[Membership]{"memberships", &Memberships},
+	tableOf[Member]{"members", &Members},
+	tableOf[PromoCode]{"promo_codes", &PromoCodes},
+	tableOf[PenaltyPolicy]{"penalty_policies", &PenaltyPolicies},
+	tableOf[Penalty]{"penalties", &Penalties},
+	tableOf[BookingWindow]{"booking_windows", &BookingWindows},
+	tableOf[Notification]{"notifications", &Notifications},
+	tableOf[Webhook]{"webhooks", &Webhooks},
+	tableOf[WebhookDelivery]{"webhook_deliveries", &WebhookDeliveries},
+	tableOf[Event]{"outbox", &Outbox},
+	tableOf[Job]{"jobs", &Jobs},
+}
+
+// The mutation log, recorded once RecordMutations is called. Mutations
+// holds what has not been trimmed yet. mutationErrors holds the writes that
+// could not be recorded since the last snapshot.
+var (
+	Mutations      []Mutation
+	mutationSeq    int64
+	mutationsOn    bool
+	mutationNow    func() time.Time
+	mutationErrors []error
+)
+
+// logWrite records that records[i] of table was written. Callers must hold
+// storeMu.
+func logWrite[T any](table string, records []T, i int) {
+	if !mutationsOn {
+		return
+	}
+	record, err := json.Marshal(records[i])
+	if err != nil {
+		mutationErrors = append(mutationErrors, fmt.Errorf("encoding %s record %d: %w", table, i, err))
+		return
+	}
+	mutationSeq++
+	Mutations = append(Mutations, Mutation{
+		Seq:    mutationSeq,
+		At:     mutationNow(),
+		Table:  table,
+		Index:  i,
+		Record: record,
+	})
+}
+
+// logAppends records the records appended to table since it had n.
+// Callers must hold storeMu.
+func logAppends[T any](table string, records []T, n int) {
+	for i := n; i < len(records); i++ {
+		logWrite(table, records, i)
+	}
+}
+
+// logRecord records a write to the record r points at in table. Callers
+// must hold storeMu.
+func logRecord[T any](table string, records []T, r *T) {
+	for i := range records {
+		if &records[i] == r {
+			logWrite(table, records, i)
+			return
+		}
+	}
+}
+
+func (e *BackupEntity) Snapshot(ctx context.Context) (*Snapshot, error) {
+	_, span := telemetry.StartSpan(ctx, "BackupEntity.Snapshot")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	s := &Snapshot{Seq: mutationSeq, Tables: make(map[string]json.RawMessage, len(tables))}
+	for _, t := range tables {
+		data, err := t.encode()
+		if err != nil {
+			telemetry.EndSpan(span, "error", err)
+			return nil, fmt.Errorf("encoding %s: %w", t.name(), err)
+		}
+		s.Tables[t.name()] = data
+	}
+	// The snapshot holds the writes the log is missing
+	mutationErrors = nil
+	span.SetAttributes(attribute.Int64("snapshot.seq", s.Seq))
+	telemetry.EndSpan(span, "copied", nil)
+	return s, nil
+}
+
+func (e *BackupEntity) Restore(ctx context.Context, s *Snapshot, mutations []Mutation) error {
+	_, span := telemetry.StartSpan(ctx, "BackupEntity.Restore",
+		attribute.Int64("snapshot.seq", s.Seq), attribute.Int("mutations.count", len(mutations)))
+
+	byTable := map[string][]Mutation{}
+	for _, m := range mutations {
+		byTable[m.Table] = append(byTable[m.Table], m)
+	}
+	installs := make([]func(), 0, len(tables))
+	for _, t := range tables {
+		install, err := t.decode(s.Tables[t.name()], byTable[t.name()])
+		if err != nil {
+			telemetry.EndSpan(span, "invalid", err)
+			return err
+		}
+		installs = append(installs, install)
+		delete(byTable, t.name())
+	}
+	for name := range byTable {
+		err := fmt.Errorf("mutation to unknown table %q", name)
+		telemetry.EndSpan(span, "invalid", err)
+		return err
+	}
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	for _, install := range installs {
+		install()
+	}
+	telemetry.EndSpan(span, "restored", nil)
+	return nil
+}
+
+func (e *BackupEntity) RecordMutations(ctx context.Context, seq int64, now func() time.Time) error {
+	_, span := telemetry.StartSpan(ctx, "BackupEntity.RecordMutations", attribute.Int64("mutation.seq", seq))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	mutationsOn = true
+	mutationSeq = seq
+	mutationNow = now
+	Mutations, mutationErrors = nil, nil
+	telemetry.EndSpan(span, "recording", nil)
+	return nil
+}
+
+func (e *BackupEntity) Mutations(ctx context.Context) ([]Mutation, error) {
+	_, span := telemetry.StartSpan(ctx, "BackupEntity.Mutations")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	if len(mutationErrors) > 0 {
+		err := fmt.Errorf("%w: %d since the last snapshot, the first: %w", ErrUnrecordedWrites, len(mutationErrors), mutationErrors[0])
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
+	mutations := append([]Mutation(nil), Mutations...)
+	span.SetAttributes(attribute.Int("mutations.count", len(mutations)))
+	telemetry.EndSpan(span, "found", nil)
+	return mutations, nil
+}
+
+func (e *BackupEntity) TrimMutations(ctx context.Context, seq int64) error {
+	_, span := telemetry.StartSpan(ctx, "BackupEntity.TrimMutations", attribute.Int64("mutation.seq", seq))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	i := 0
+	for i < len(Mutations) && Mutations[i].Seq <= seq {
+		i++
+	}
+	Mutations = append([]Mutation(nil), Mutations[i:]...)
+	telemetry.EndSpan(span, "trimmed", nil)
+	return nil
+}
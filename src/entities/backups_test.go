@@ -0,0 +1,122 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupEntity_SnapshotAndRestore(t *testing.T) {
+	t.Cleanup(func() { mutationsOn, Mutations = false, nil })
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	Classes, Bookings, Memberships, PromoCodes, Outbox = nil, nil, nil, nil, nil
+	entity := &BackupEntity{}
+	ctx := context.Background()
+
+	require.NoError(t, entity.RecordMutations(ctx, 10, func() time.Time { return now }))
+	_, err := (&MembershipEntity{}).AddMembership(ctx, &Membership{Name: "Ann", Plan: PlanClassPack, Credits: 2, StartDate: now})
+	require.NoError(t, err)
+	snapshot, err := entity.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), snapshot.Seq)
+	assert.JSONEq(t, `[]`, string(snapshot.Tables["classes"]))
+
+	class := &Class{ClassName: "Yoga", StartDate: now, EndDate: now.Add(time.Hour), Capacity: 10}
+	_, err = ClassEntity{}.AddClass(ctx, class, NewEvent(ClassCreatedEvent{Class: class}, now))
+	require.NoError(t, err)
+	require.NoError(t, (&MembershipEntity{}).DebitCredit(ctx, 1))
+	assert.ErrorIs(t, (&PromoCodeEntity{}).RedeemPromoCode(ctx, "NONE", now), ErrNotFound, "failed writes are not recorded")
+
+	mutations, err := entity.Mutations(ctx)
+	require.NoError(t, err)
+	require.Len(t, mutations, 4)
+	assert.Equal(t, Mutation{Seq: 12, At: now, Table: "classes", Index: 0, Record: mutations[1].Record}, mutations[1])
+	assert.Equal(t, "outbox", mutations[2].Table)
+	assert.Equal(t, Mutation{Seq: 14, At: now, Table: "memberships", Index: 0, Record: mutations[3].Record}, mutations[3])
+	var debited Membership
+	require.NoError(t, json.Unmarshal(mutations[3].Record, &debited))
+	assert.Equal(t, 1, debited.Credits)
+
+	require.NoError(t, entity.TrimMutations(ctx, 12))
+	remaining, err := entity.Mutations(ctx)
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+
+	// Replaying what followed the snapshot brings the store back
+	Classes, Memberships, Outbox = nil, nil, nil
+	require.NoError(t, entity.Restore(ctx, snapshot, mutations[1:]))
+	require.Len(t, Classes, 1)
+	assert.Equal(t, "Yoga", Classes[0].ClassName)
+	assert.Len(t, Outbox, 1)
+	require.Len(t, Memberships, 1)
+	assert.Equal(t, 1, Memberships[0].Credits)
+}
+
+func TestBackupEntity_Mutations_RecoverAfterSnapshot(t *testing.T) {
+	t.Cleanup(func() { mutationsOn, Mutations, mutationErrors = false, nil, nil })
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	Memberships = nil
+	entity := &BackupEntity{}
+	ctx := context.Background()
+	require.NoError(t, entity.RecordMutations(ctx, 0, func() time.Time { return now }))
+
+	storeMu.Lock()
+	logWrite("outbox", []Event{{ID: 1, Payload: json.RawMessage(`{`)}}, 0)
+	storeMu.Unlock()
+	_, err := (&MembershipEntity{}).AddMembership(ctx, &Membership{Name: "Ann", Plan: PlanUnlimitedMonthly, StartDate: now})
+	require.NoError(t, err)
+	_, err = entity.Mutations(ctx)
+	assert.ErrorIs(t, err, ErrUnrecordedWrites)
+
+	// The snapshot covers the write, so recording carries on
+	snapshot, err := entity.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), snapshot.Seq)
+	_, err = (&MembershipEntity{}).AddMembership(ctx, &Membership{Name: "Bob", Plan: PlanUnlimitedMonthly, StartDate: now})
+	require.NoError(t, err)
+	mutations, err := entity.Mutations(ctx)
+	require.NoError(t, err)
+	require.Len(t, mutations, 2)
+	assert.Equal(t, int64(1), mutations[0].Seq)
+	assert.Equal(t, int64(2), mutations[1].Seq)
+}
+
+func TestBackupEntity_Restore_Invalid(t *testing.T) {
+	Classes = []Class{{ID: 1, ClassName: "Yoga"}}
+	entity := &BackupEntity{}
+	snapshot := &Snapshot{Tables: map[string]json.RawMessage{"classes": json.RawMessage(`[]`)}}
+
+	tests := []struct {
+		name     string
+		mutation Mutation
+		expected string
+	}{
+		{
+			name:     "should refuse a write past the end of a table",
+			mutation: Mutation{Seq: 1, Table: "classes", Index: 1, Record: json.RawMessage(`{}`)},
+			expected: "mutation 1 writes classes record 1 of 0",
+		},
+		{
+			name:     "should refuse a write to an unknown table",
+			mutation: Mutation{Seq: 1, Table: "rooms", Record: json.RawMessage(`{}`)},
+			expected: `mutation to unknown table "rooms"`,
+		},
+		{
+			name:     "should refuse a record of the wrong shape",
+			mutation: Mutation{Seq: 1, Table: "classes", Record: json.RawMessage(`[]`)},
+			expected: "decoding mutation 1 to classes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := entity.Restore(context.Background(), snapshot, []Mutation{tt.mutation})
+			assert.ErrorContains(t, err, tt.expected)
+			assert.Equal(t, []Class{{ID: 1, ClassName: "Yoga"}}, Classes, "the store should be left as it was")
+		})
+	}
+}
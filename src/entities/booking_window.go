@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"context"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BookingWindow is when a studio's members may book a session.
+type BookingWindow struct {
+	Studio string `json:"studio"`
+	// OpensDaysBefore is how many days before the session bookings open.
+	// Zero means bookings are open as soon as the class is created.
+	OpensDaysBefore int `json:"opens_days_before"`
+	// ClosesMinutesBefore is how many minutes before the session bookings close.
+	ClosesMinutesBefore int `json:"closes_minutes_before"`
+	// PremiumEarlyAccessDays opens bookings this many days earlier for
+	// premium members.
+	PremiumEarlyAccessDays int `json:"premium_early_access_days"`
+}
+
+// DefaultBookingWindow applies to studios that have not set their own: any
+// session that has not started yet can be booked.
+var DefaultBookingWindow = BookingWindow{}
+
+// In-memory storage of booking windows
+var BookingWindows []BookingWindow
+
+type BookingWindowRepository interface {
+	SetBookingWindow(ctx context.Context, w *BookingWindow) (*BookingWindow, error)
+	FindBookingWindow(ctx context.Context, studio string) (*BookingWindow, error)
+}
+
+type BookingWindowEntity struct {
+	BookingWindowRepository
+}
+
+// SetBookingWindow stores the studio's window, replacing any previous one.
+func (e *BookingWindowEntity) SetBookingWindow(ctx context.Context, w *BookingWindow) (*BookingWindow, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingWindowEntity.SetBookingWindow", attribute.String("studio", w.Studio))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range BookingWindows {
+		if BookingWindows[i].Studio == w.Studio {
+			BookingWindows[i] = *w
+			logWrite("booking_windows", BookingWindows, i)
+			telemetry.EndSpan(span, "replaced", nil)
+			return w, nil
+		}
+	}
+	BookingWindows = append(BookingWindows, *w)
+	logWrite("booking_windows", BookingWindows, len(BookingWindows)-1)
+	telemetry.EndSpan(span, "stored", nil)
+	return w, nil
+}
+
+func (e *BookingWindowEntity) FindBookingWindow(ctx context.Context, studio string) (*BookingWindow, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingWindowEntity.FindBookingWindow", attribute.String("studio", studio))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, w := range BookingWindows {
+		if w.Studio == studio {
+			telemetry.EndSpan(span, "found", nil)
+			return &w, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
@@ -1,35 +1,356 @@
 package entities
 
-import "time"
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type BookingStatus string
+
+const (
+	// BookingPending holds the spot while a drop-in payment is taken.
+	BookingPending   BookingStatus = "pending"
+	BookingConfirmed BookingStatus = "confirmed"
+	BookingCancelled BookingStatus = "cancelled"
+	// BookingFailed is a pending booking rolled back after its payment failed.
+	BookingFailed   BookingStatus = "failed"
+	BookingAttended BookingStatus = "attended"
+	BookingNoShow   BookingStatus = "no_show"
+	// BookingWaitlisted waits for a spot in a full session and is confirmed
+	// when one frees up.
+	BookingWaitlisted BookingStatus = "waitlisted"
+)
+
+// ErrSessionFull is returned when a booking that must hold a spot straight
+// away is made for a session with none left.
+var ErrSessionFull = errors.New("the session is full")
+
+// ErrBookingStatus is returned by TransitionBooking when the booking is no
+// longer in a status it may move from.
+var ErrBookingStatus = errors.New("the booking status has changed")
 
 type Booking struct {
-	Name string    `json:"name"`
-	Date time.Time `json:"date"`
+	ID             int           `json:"id"`
+	ClassID        int           `json:"class_id"`
+	Name           string        `json:"name"`
+	Date           time.Time     `json:"date"`
+	Status         BookingStatus `json:"status"`
+	MembershipID   int           `json:"membership_id"`
+	CreditRefunded bool          `json:"credit_refunded,omitempty"`
+	// PaymentID is the gateway authorization for paid drop-in bookings.
+	PaymentID       string `json:"payment_id,omitempty"`
+	PaymentRefunded bool   `json:"payment_refunded,omitempty"`
+	// PromoCode is an optional code redeemed against a paid booking.
+	PromoCode string          `json:"promo_code,omitempty"`
+	Price     *PriceBreakdown `json:"price,omitempty"`
+	// CheckInToken is encoded in the member's QR code to check in.
+	CheckInToken string     `json:"check_in_token,omitempty"`
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`
+	CheckedInBy  string     `json:"checked_in_by,omitempty"`
+}
+
+// BookingFilter narrows ListBookings; zero fields match everything.
+type BookingFilter struct {
+	Status  BookingStatus
+	Name    string
+	ClassID int
+	// From and To bound the booking date, inclusive.
+	From time.Time
+	To   time.Time
+	// Sort, After and Limit page through bookings: a page starts after the
+	// cursor of the last booking of the one before. A zero Limit returns
+	// every match.
+	Sort  Sort
+	After Cursor
+	Limit int
+}
+
+func (f BookingFilter) matches(b Booking) bool {
+	return (f.Status == "" || b.Status == f.Status) &&
+		(f.Name == "" || b.Name == f.Name) &&
+		(f.ClassID == 0 || b.ClassID == f.ClassID) &&
+		(f.From.IsZero() || !b.Date.Before(f.From)) &&
+		(f.To.IsZero() || !b.Date.After(f.To))
+}
+
+// HoldsSpot reports whether b takes up one of its session's spots.
+func (b Booking) HoldsSpot() bool {
+	switch b.Status {
+	case BookingPending, BookingConfirmed, BookingAttended, BookingNoShow:
+		return true
+	}
+	return false
+}
+
+func (b Booking) recordID() int { return b.ID }
+
+func (b Booking) sortKey(field SortField) string {
+	if field == SortName {
+		return strings.ToLower(b.Name)
+	}
+	return sortTime(b.Date)
 }
 
 // In-memory storage of bookings
 var Bookings []Booking
 
 type BookingRepository interface {
-	AddBooking(b *Booking) (*Booking, error)
-	CheckClassExistsOnDate(date time.Time) bool
+	// AddBooking and UpdateBooking store b and, in the same change, record
+	// events in the outbox. AddBooking checks the capacity of b's session in
+	// that change too: when the session is full, or others are already
+	// waiting for a spot, a confirmed b joins the waitlist and a pending one
+	// fails with ErrSessionFull.
+	AddBooking(ctx context.Context, b *Booking, events ...Event) (*Booking, error)
+	UpdateBooking(ctx context.Context, b *Booking, events ...Event) error
+	// PromoteWaitlisted confirms the booking that has waited longest for a
+	// spot in the session of class on the day of date, if a spot is free,
+	// and records the events made for it in the same change. It returns nil
+	// when nobody is waiting or the session is still full.
+	PromoteWaitlisted(ctx context.Context, class *Class, date time.Time, events func(*Booking) []Event) (*Booking, error)
+	// TransitionBooking moves the booking with id to status to, provided it
+	// is in one of the statuses from, and returns the booking as it was
+	// before. Otherwise it changes nothing and returns the booking as it is
+	// with ErrBookingStatus, so that of concurrent changes only one goes
+	// ahead.
+	TransitionBooking(ctx context.Context, id int, from []BookingStatus, to BookingStatus) (*Booking, error)
+	// MarkNoShow flags the confirmed booking with id as a no-show and counts
+	// it against the member in the same change. It reports false, changing
+	// nothing, when the booking is no longer confirmed, so marking again is
+	// safe.
+	MarkNoShow(ctx context.Context, id int) (bool, error)
+	FindBooking(ctx context.Context, id int) (*Booking, error)
+	ListBookings(ctx context.Context, filter BookingFilter) ([]Booking, error)
+	CheckClassExistsOnDate(ctx context.Context, date time.Time) (bool, error)
+	FindClassOnDate(ctx context.Context, date time.Time) (*Class, error)
+	FindClass(ctx context.Context, id int) (*Class, error)
 }
 
 type BookingEntity struct {
 	BookingRepository
 }
 
-func (e *BookingEntity) AddBooking(b *Booking) (*Booking, error) {
+func (e *BookingEntity) AddBooking(ctx context.Context, b *Booking, events ...Event) (*Booking, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.AddBooking")
+	if err := ctx.Err(); err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	outcome := "stored"
+	if class := classByID(b.ClassID); class != nil && b.HoldsSpot() {
+		held, waiting := sessionBookings(class, b.Date)
+		if held >= class.Capacity || len(waiting) > 0 {
+			if b.Status != BookingConfirmed {
+				telemetry.EndSpan(span, "full", ErrSessionFull)
+				return nil, ErrSessionFull
+			}
+			b.Status, outcome = BookingWaitlisted, "waitlisted"
+		}
+	}
+	b.ID = len(Bookings) + 1
+	encoded, err := encodeEvents(events)
+	if err != nil {
+		b.ID = 0
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
 	Bookings = append(Bookings, *b)
+	logWrite("bookings", Bookings, len(Bookings)-1)
+	appendEvents(encoded)
+	span.SetAttributes(attribute.Int("booking.id", b.ID), attribute.Int("class.id", b.ClassID))
+	telemetry.EndSpan(span, outcome, nil)
 	return b, nil
 }
 
-func (e *BookingEntity) CheckClassExistsOnDate(date time.Time) bool {
-	for _, c := range Classes {
-		// Check if the date falls within the range of start and end date (inclusive)
-		if date.Equal(c.StartDate) || date.Equal(c.EndDate) || (date.After(c.StartDate) && date.Before(c.EndDate)) {
-			return true
+func (e *BookingEntity) PromoteWaitlisted(ctx context.Context, class *Class, date time.Time, events func(*Booking) []Event) (*Booking, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.PromoteWaitlisted", attribute.Int("class.id", class.ID))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	held, waiting := sessionBookings(class, date)
+	if len(waiting) == 0 || held >= class.Capacity {
+		telemetry.EndSpan(span, "none", nil)
+		return nil, nil
+	}
+	i := waiting[0]
+	promoted := Bookings[i]
+	promoted.Status = BookingConfirmed
+	encoded, err := encodeEvents(events(&promoted))
+	if err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
+	Bookings[i] = promoted
+	logWrite("bookings", Bookings, i)
+	appendEvents(encoded)
+	span.SetAttributes(attribute.Int("booking.id", promoted.ID))
+	telemetry.EndSpan(span, "promoted", nil)
+	return &promoted, nil
+}
+
+// sessionBookings counts the bookings holding a spot in the session of class
+// on the day of date and returns the indexes of those waiting for one, in
+// the order they were made. Callers must hold storeMu.
+func sessionBookings(class *Class, date time.Time) (held int, waiting []int) {
+	start, _ := class.Session(date)
+	for i, b := range Bookings {
+		if b.ClassID != class.ID {
+			continue
+		}
+		if s, _ := class.Session(b.Date); !s.Equal(start) {
+			continue
+		}
+		if b.HoldsSpot() {
+			held++
+		} else if b.Status == BookingWaitlisted {
+			waiting = append(waiting, i)
 		}
 	}
-	return false
+	return held, waiting
+}
+
+func (e *BookingEntity) UpdateBooking(ctx context.Context, b *Booking, events ...Event) error {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.UpdateBooking", attribute.Int("booking.id", b.ID))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range Bookings {
+		if Bookings[i].ID == b.ID {
+			encoded, err := encodeEvents(events)
+			if err != nil {
+				telemetry.EndSpan(span, "error", err)
+				return err
+			}
+			Bookings[i] = *b
+			logWrite("bookings", Bookings, i)
+			appendEvents(encoded)
+			telemetry.EndSpan(span, "stored", nil)
+			return nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return ErrNotFound
+}
+
+func (e *BookingEntity) TransitionBooking(ctx context.Context, id int, from []BookingStatus, to BookingStatus) (*Booking, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.TransitionBooking",
+		attribute.Int("booking.id", id), attribute.String("booking.status", string(to)))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range Bookings {
+		if Bookings[i].ID != id {
+			continue
+		}
+		previous := Bookings[i]
+		if !slices.Contains(from, previous.Status) {
+			telemetry.EndSpan(span, "conflict", nil)
+			return &previous, ErrBookingStatus
+		}
+		Bookings[i].Status = to
+		logWrite("bookings", Bookings, i)
+		telemetry.EndSpan(span, "stored", nil)
+		return &previous, nil
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return nil, ErrNotFound
+}
+
+func (e *BookingEntity) MarkNoShow(ctx context.Context, id int) (bool, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.MarkNoShow", attribute.Int("booking.id", id))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range Bookings {
+		if Bookings[i].ID != id {
+			continue
+		}
+		if Bookings[i].Status != BookingConfirmed {
+			telemetry.EndSpan(span, "skipped", nil)
+			return false, nil
+		}
+		Bookings[i].Status = BookingNoShow
+		logWrite("bookings", Bookings, i)
+		recordNoShow(Bookings[i].Name)
+		telemetry.EndSpan(span, "stored", nil)
+		return true, nil
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return false, ErrNotFound
+}
+
+func (e *BookingEntity) FindBooking(ctx context.Context, id int) (*Booking, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.FindBooking", attribute.Int("booking.id", id))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, b := range Bookings {
+		if b.ID == id {
+			telemetry.EndSpan(span, "found", nil)
+			return &b, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
+
+func (e *BookingEntity) ListBookings(ctx context.Context, filter BookingFilter) ([]Booking, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.ListBookings")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	found, err := listPage(ctx, Bookings, filter.matches, filter.Sort, filter.After, filter.Limit)
+	if err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("bookings.count", len(found)))
+	telemetry.EndSpan(span, "found", nil)
+	return found, nil
+}
+
+func (e *BookingEntity) CheckClassExistsOnDate(ctx context.Context, date time.Time) (bool, error) {
+	class, err := e.FindClassOnDate(ctx, date)
+	return class != nil, err
+}
+
+func (e *BookingEntity) FindClassOnDate(ctx context.Context, date time.Time) (*Class, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.FindClassOnDate")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	class, err := classOnDate(ctx, date)
+	if err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
+	if class == nil {
+		telemetry.EndSpan(span, "not_found", nil)
+		return nil, nil
+	}
+	span.SetAttributes(attribute.Int("class.id", class.ID))
+	telemetry.EndSpan(span, "found", nil)
+	return class, nil
+}
+
+func (e *BookingEntity) FindClass(ctx context.Context, id int) (*Class, error) {
+	_, span := telemetry.StartSpan(ctx, "BookingEntity.FindClass", attribute.Int("class.id", id))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	class := classByID(id)
+	if class == nil {
+		telemetry.EndSpan(span, "not_found", nil)
+		return nil, ErrNotFound
+	}
+	telemetry.EndSpan(span, "found", nil)
+	return class, nil
 }
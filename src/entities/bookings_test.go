@@ -1,6 +1,9 @@
 package entities
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -17,7 +20,7 @@ func TestBookingEntity_AddBooking(t *testing.T) {
 		Date: time.Now(),
 	}
 
-	result, err := entity.AddBooking(booking)
+	result, err := entity.AddBooking(context.Background(), booking)
 
 	assert.NoError(t, err)
 	assert.Equal(t, booking, result)
@@ -25,6 +28,125 @@ func TestBookingEntity_AddBooking(t *testing.T) {
 	assert.Equal(t, "John Doe", Bookings[0].Name)
 }
 
+func TestBookingEntity_AddBooking_Capacity(t *testing.T) {
+	start := time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC)
+	Classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: start, EndDate: start.AddDate(0, 0, 7).Add(time.Hour), Capacity: 2}}
+	Bookings = nil
+	defer func() { Classes, Bookings = nil, nil }()
+	entity := &BookingEntity{}
+	add := func(status BookingStatus, date time.Time) (*Booking, error) {
+		return entity.AddBooking(context.Background(), &Booking{ClassID: 1, Name: "jane", Date: date, Status: status})
+	}
+
+	for range 2 {
+		b, err := add(BookingConfirmed, start)
+		assert.NoError(t, err)
+		assert.Equal(t, BookingConfirmed, b.Status)
+	}
+	b, err := add(BookingConfirmed, start)
+	assert.NoError(t, err)
+	assert.Equal(t, BookingWaitlisted, b.Status, "a full session puts confirmed bookings on its waitlist")
+	_, err = add(BookingPending, start)
+	assert.ErrorIs(t, err, ErrSessionFull, "bookings waiting on payment are refused")
+	b, err = add(BookingConfirmed, start.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, BookingConfirmed, b.Status, "other sessions of the class have their own spots")
+
+	// A freed spot goes to the waitlist before anyone new
+	Bookings[0].Status = BookingCancelled
+	b, err = add(BookingConfirmed, start)
+	assert.NoError(t, err)
+	assert.Equal(t, BookingWaitlisted, b.Status)
+	assert.Len(t, Bookings, 5)
+}
+
+func TestBookingEntity_PromoteWaitlisted(t *testing.T) {
+	start := time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC)
+	class := Class{ID: 1, ClassName: "Yoga", StartDate: start, EndDate: start.Add(time.Hour), Capacity: 1}
+	Classes = []Class{class}
+	Bookings = []Booking{
+		{ID: 1, ClassID: 1, Date: start, Status: BookingConfirmed},
+		{ID: 2, ClassID: 1, Date: start, Status: BookingWaitlisted},
+		{ID: 3, ClassID: 1, Date: start, Status: BookingWaitlisted},
+	}
+	Outbox = nil
+	defer func() { Classes, Bookings, Outbox = nil, nil, nil }()
+	entity := &BookingEntity{}
+	events := func(b *Booking) []Event {
+		return []Event{NewEvent(BookingCreatedEvent{Booking: b}, start)}
+	}
+
+	promoted, err := entity.PromoteWaitlisted(context.Background(), &class, start, events)
+	assert.NoError(t, err)
+	assert.Nil(t, promoted, "nobody is promoted while the session is full")
+
+	Bookings[0].Status = BookingCancelled
+	promoted, err = entity.PromoteWaitlisted(context.Background(), &class, start, events)
+	assert.NoError(t, err)
+	if assert.NotNil(t, promoted) {
+		assert.Equal(t, 2, promoted.ID, "the longest waiting booking goes first")
+	}
+	assert.Equal(t, BookingConfirmed, Bookings[1].Status)
+	assert.Equal(t, BookingWaitlisted, Bookings[2].Status)
+	assert.Len(t, Outbox, 1)
+}
+
+func TestBookingEntity_TransitionBooking(t *testing.T) {
+	Bookings = []Booking{{ID: 1, Name: "Ann", Status: BookingConfirmed}}
+	defer func() { Bookings = nil }()
+	entity := &BookingEntity{}
+	from := []BookingStatus{BookingConfirmed, BookingWaitlisted}
+
+	var wg sync.WaitGroup
+	var moved atomic.Int32
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := entity.TransitionBooking(context.Background(), 1, from, BookingCancelled); err == nil {
+				moved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), moved.Load(), "only one of concurrent transitions goes ahead")
+	assert.Equal(t, BookingCancelled, Bookings[0].Status)
+	current, err := entity.TransitionBooking(context.Background(), 1, from, BookingCancelled)
+	assert.ErrorIs(t, err, ErrBookingStatus)
+	assert.Equal(t, BookingCancelled, current.Status)
+	_, err = entity.TransitionBooking(context.Background(), 2, from, BookingCancelled)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestBookingEntity_MarkNoShow(t *testing.T) {
+	date := time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC)
+	Bookings = []Booking{
+		{ID: 1, ClassID: 1, Name: "Ann", Date: date, Status: BookingConfirmed},
+		{ID: 2, ClassID: 1, Name: "Bob", Date: date, Status: BookingConfirmed},
+		{ID: 3, ClassID: 1, Name: "Cat", Date: date, Status: BookingAttended},
+	}
+	Members = []Member{{Name: "Ann", Tier: TierStudent, NoShows: 2}}
+	defer func() { Bookings, Members = nil, nil }()
+	entity := &BookingEntity{}
+
+	for _, id := range []int{1, 2, 1, 3} {
+		_, err := entity.MarkNoShow(context.Background(), id)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, BookingNoShow, Bookings[0].Status)
+	assert.Equal(t, BookingNoShow, Bookings[1].Status)
+	assert.Equal(t, BookingAttended, Bookings[2].Status, "attended bookings are left alone")
+	assert.Equal(t, []Member{
+		{Name: "Ann", Tier: TierStudent, NoShows: 3},
+		{Name: "Bob", Tier: TierStandard, NoShows: 1},
+	}, Members, "marking again does not count twice")
+
+	_, err := entity.MarkNoShow(context.Background(), 4)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
 func TestBookingEntity_CheckClassExistsOnDate(t *testing.T) {
 	entity := &BookingEntity{}
 
@@ -94,8 +216,62 @@ func TestBookingEntity_CheckClassExistsOnDate(t *testing.T) {
 			// Reset.
 			Classes = tt.classes
 
-			result := entity.CheckClassExistsOnDate(tt.checkDate)
+			result, err := entity.CheckClassExistsOnDate(context.Background(), tt.checkDate)
+			assert.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
+
+func TestBookingEntity_CheckClassExistsOnDate_Cancelled(t *testing.T) {
+	entity := &BookingEntity{}
+	start := time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC)
+	Classes = []Class{{ClassName: "Yoga", StartDate: start, EndDate: start.AddDate(0, 0, 7)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := entity.CheckClassExistsOnDate(ctx, start)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, result)
+}
+
+func TestBookingEntity_ListBookings(t *testing.T) {
+	day := time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC)
+	Bookings = []Booking{
+		{ID: 1, ClassID: 1, Name: "Ann", Date: day, Status: BookingConfirmed},
+		{ID: 2, ClassID: 1, Name: "Bob", Date: day.AddDate(0, 0, 1), Status: BookingConfirmed},
+		{ID: 3, ClassID: 2, Name: "Ann", Date: day.AddDate(0, 0, 2), Status: BookingCancelled},
+	}
+	entity := &BookingEntity{}
+
+	tests := []struct {
+		name     string
+		filter   BookingFilter
+		expected []int
+	}{
+		{name: "should list every booking with an empty filter", filter: BookingFilter{}, expected: []int{1, 2, 3}},
+		{name: "should filter by status", filter: BookingFilter{Status: BookingConfirmed}, expected: []int{1, 2}},
+		{name: "should filter by name", filter: BookingFilter{Name: "Ann"}, expected: []int{1, 3}},
+		{name: "should filter by class", filter: BookingFilter{ClassID: 2}, expected: []int{3}},
+		{name: "should filter by an inclusive date range", filter: BookingFilter{From: day.AddDate(0, 0, 1), To: day.AddDate(0, 0, 2)}, expected: []int{2, 3}},
+		{name: "should return a page after the cursor", filter: BookingFilter{After: Cursor{ID: 1}, Limit: 1}, expected: []int{2}},
+		{name: "should sort by name then ID", filter: BookingFilter{Sort: Sort{Field: SortName}}, expected: []int{1, 3, 2}},
+		{name: "should sort by date descending", filter: BookingFilter{Sort: Sort{Field: SortDate, Desc: true}}, expected: []int{3, 2, 1}},
+		{name: "should page after a sorted cursor", filter: BookingFilter{Sort: Sort{Field: SortName}, After: Cursor{Key: "ann", ID: 3}}, expected: []int{2}},
+		{name: "should apply the limit to matches only", filter: BookingFilter{Name: "Ann", Limit: 2}, expected: []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := entity.ListBookings(context.Background(), tt.filter)
+
+			assert.NoError(t, err)
+			var ids []int
+			for _, b := range found {
+				ids = append(ids, b.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
@@ -1,20 +1,154 @@
 package entities
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"iter"
+	"strings"
 	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// DefaultStudio is the studio classes belong to when none is given.
+const DefaultStudio = "main"
+
 type Class struct {
+	ID        int       `json:"id"`
 	ClassName string    `json:"class_name"`
 	StartDate time.Time `json:"start_date"`
 	EndDate   time.Time `json:"end_date"`
 	Capacity  int       `json:"capacity"`
+	// Price is the drop-in price in minor units of Currency, e.g. cents.
+	Price    int    `json:"price,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	// Studio selects the penalty policy and booking window applied to the
+	// class's bookings.
+	Studio string `json:"studio,omitempty"`
+	// Description, Instructor, Category, Room and Tags tell members what the
+	// class is, and are what they search the timetable by.
+	Description string   `json:"description,omitempty"`
+	Instructor  string   `json:"instructor,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Room        string   `json:"room,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// BookingOpensDaysBefore and BookingClosesMinutesBefore override the
+	// studio's booking window for this class when set.
+	BookingOpensDaysBefore     *int `json:"booking_opens_days_before,omitempty"`
+	BookingClosesMinutesBefore *int `json:"booking_closes_minutes_before,omitempty"`
+	// CancelledAt is when the studio called the class off. A cancelled class
+	// cannot be booked and no longer blocks its dates for other classes.
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	// Revision counts the changes made to the class since it was created,
+	// so that calendar apps can tell its events have changed.
+	Revision int `json:"revision,omitempty"`
+}
+
+// Session returns the occurrence of the class on the day of t. A class whose
+// start and end fall on different days runs every day of its date range,
+// from the time of day of StartDate to that of EndDate, or all day when the
+// two times of day match.
+func (c Class) Session(t time.Time) (start, end time.Time) {
+	sy, sm, sd := c.StartDate.Date()
+	ey, em, ed := c.EndDate.Date()
+	if sy == ey && sm == em && sd == ed {
+		return c.StartDate, c.EndDate
+	}
+
+	loc := c.StartDate.Location()
+	y, m, d := t.In(loc).Date()
+	start = time.Date(y, m, d, c.StartDate.Hour(), c.StartDate.Minute(), c.StartDate.Second(), 0, loc)
+	end = time.Date(y, m, d, c.EndDate.Hour(), c.EndDate.Minute(), c.EndDate.Second(), 0, loc)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+// NextSession returns the first occurrence of the class that has not ended
+// by t. ok is false once the last session is over.
+func (c Class) NextSession(t time.Time) (start, end time.Time, ok bool) {
+	lastStart, _ := c.Session(c.EndDate)
+	if start, end = c.Session(c.StartDate); end.After(t) {
+		return start, end, true
+	}
+	if start, end = c.Session(t); !end.After(t) {
+		start, end = c.Session(t.AddDate(0, 0, 1))
+	}
+	if start.After(lastStart) || !end.After(t) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// Held reports whether the session starting at start goes ahead: sessions
+// starting after the class was cancelled do not.
+func (c Class) Held(start time.Time) bool {
+	return c.CancelledAt == nil || !start.After(*c.CancelledAt)
+}
+
+// Overlaps reports whether a class from start to end would clash with c.
+func (c Class) Overlaps(start, end time.Time) bool {
+	return (start.Before(c.EndDate) && end.After(c.StartDate)) ||
+		start.Equal(c.StartDate) || end.Equal(c.EndDate)
+}
+
+// Sessions yields the start and end of every occurrence of the class that
+// overlaps [from, to).
+func (c Class) Sessions(from, to time.Time) iter.Seq2[time.Time, time.Time] {
+	return func(yield func(time.Time, time.Time) bool) {
+		lastStart, _ := c.Session(c.EndDate)
+		start, end, ok := c.NextSession(from)
+		for ok && start.Before(to) {
+			if !yield(start, end) || !start.Before(lastStart) {
+				return
+			}
+			start, end = c.Session(start.AddDate(0, 0, 1))
+		}
+	}
+}
+
+func (c Class) recordID() int { return c.ID }
+
+func (c Class) sortKey(field SortField) string {
+	if field == SortName {
+		return strings.ToLower(c.ClassName)
+	}
+	return sortTime(c.StartDate)
+}
+
+// ClassFilter narrows ListClasses; zero fields match everything.
+type ClassFilter struct {
+	// From and To select the classes running at some point between them,
+	// inclusive.
+	From time.Time
+	To   time.Time
+	// Sort, After and Limit page through classes, as in BookingFilter.
+	Sort  Sort
+	After Cursor
+	Limit int
+}
+
+func (f ClassFilter) matches(c Class) bool {
+	return (f.From.IsZero() || !c.EndDate.Before(f.From)) &&
+		(f.To.IsZero() || !c.StartDate.After(f.To))
 }
 
 type ClassRepository interface {
-	AddClass(c *Class) (*Class, error)
-	CheckClassExists(start, end time.Time) bool
+	// AddClass stores c and, in the same change, records events in the
+	// outbox.
+	AddClass(ctx context.Context, c *Class, events ...Event) (*Class, error)
+	// AddClasses stores all of classes, or none of them if any overlaps
+	// another class, and records events in the same change.
+	AddClasses(ctx context.Context, classes []*Class, events ...Event) error
+	// UpdateClass stores c as the class's next revision and, in the same
+	// change, records events in the outbox.
+	UpdateClass(ctx context.Context, c *Class, events ...Event) error
+	CheckClassExists(ctx context.Context, start, end time.Time) (bool, error)
+	FindClass(ctx context.Context, id int) (*Class, error)
+	ListClasses(ctx context.Context, filter ClassFilter) ([]Class, error)
 }
 
 type ClassEntity struct {
@@ -24,24 +158,190 @@ type ClassEntity struct {
 // In-memory storage of classes
 var Classes []Class
 
-func (e ClassEntity) AddClass(c *Class) (*Class, error) {
-	for _, existing := range Classes {
-		if (c.StartDate.Before(existing.EndDate) && c.EndDate.After(existing.StartDate)) ||
-			c.StartDate.Equal(existing.StartDate) || c.EndDate.Equal(existing.EndDate) {
-			return nil, errors.New("another class already exists in that date range")
-		}
+func (e ClassEntity) AddClass(ctx context.Context, c *Class, events ...Event) (*Class, error) {
+	ctx, span := telemetry.StartSpan(ctx, "ClassEntity.AddClass")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	exists, err := classExists(ctx, c.StartDate, c.EndDate)
+	if err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
+	if exists {
+		err = errors.New("another class already exists in that date range")
+		telemetry.EndSpan(span, "overlap", err)
+		return nil, err
 	}
 
+	c.ID = len(Classes) + 1
+	encoded, err := encodeEvents(events)
+	if err != nil {
+		c.ID = 0
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
 	Classes = append(Classes, *c)
+	logWrite("classes", Classes, len(Classes)-1)
+	appendEvents(encoded)
+	span.SetAttributes(attribute.Int("class.id", c.ID))
+	telemetry.EndSpan(span, "stored", nil)
 	return c, nil
 }
 
-func (e ClassEntity) CheckClassExists(start, end time.Time) bool {
+func (e ClassEntity) AddClasses(ctx context.Context, classes []*Class, events ...Event) error {
+	ctx, span := telemetry.StartSpan(ctx, "ClassEntity.AddClasses", attribute.Int("classes.count", len(classes)))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i, c := range classes {
+		exists, err := classExists(ctx, c.StartDate, c.EndDate)
+		if err != nil {
+			telemetry.EndSpan(span, "error", err)
+			return err
+		}
+		for _, other := range classes[:i] {
+			exists = exists || other.Overlaps(c.StartDate, c.EndDate)
+		}
+		if exists {
+			err = fmt.Errorf("class %q overlaps another class", c.ClassName)
+			telemetry.EndSpan(span, "overlap", err)
+			return err
+		}
+	}
+
+	for i, c := range classes {
+		c.ID = len(Classes) + i + 1
+	}
+	encoded, err := encodeEvents(events)
+	if err != nil {
+		for _, c := range classes {
+			c.ID = 0
+		}
+		telemetry.EndSpan(span, "error", err)
+		return err
+	}
+	n := len(Classes)
+	for _, c := range classes {
+		Classes = append(Classes, *c)
+	}
+	logAppends("classes", Classes, n)
+	appendEvents(encoded)
+	telemetry.EndSpan(span, "stored", nil)
+	return nil
+}
+
+func (e ClassEntity) UpdateClass(ctx context.Context, c *Class, events ...Event) error {
+	_, span := telemetry.StartSpan(ctx, "ClassEntity.UpdateClass", attribute.Int("class.id", c.ID))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range Classes {
+		if Classes[i].ID == c.ID {
+			encoded, err := encodeEvents(events)
+			if err != nil {
+				telemetry.EndSpan(span, "error", err)
+				return err
+			}
+			c.Revision = Classes[i].Revision + 1
+			Classes[i] = *c
+			logWrite("classes", Classes, i)
+			appendEvents(encoded)
+			telemetry.EndSpan(span, "stored", nil)
+			return nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return ErrNotFound
+}
+
+func (e ClassEntity) CheckClassExists(ctx context.Context, start, end time.Time) (bool, error) {
+	_, span := telemetry.StartSpan(ctx, "ClassEntity.CheckClassExists")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	exists, err := classExists(ctx, start, end)
+	if err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return false, err
+	}
+	if exists {
+		telemetry.EndSpan(span, "found", nil)
+		return true, nil
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return false, nil
+}
+
+func (e ClassEntity) FindClass(ctx context.Context, id int) (*Class, error) {
+	_, span := telemetry.StartSpan(ctx, "ClassEntity.FindClass", attribute.Int("class.id", id))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	class := classByID(id)
+	if class == nil {
+		telemetry.EndSpan(span, "not_found", nil)
+		return nil, ErrNotFound
+	}
+	telemetry.EndSpan(span, "found", nil)
+	return class, nil
+}
+
+func (e ClassEntity) ListClasses(ctx context.Context, filter ClassFilter) ([]Class, error) {
+	_, span := telemetry.StartSpan(ctx, "ClassEntity.ListClasses")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	classes, err := listPage(ctx, Classes, filter.matches, filter.Sort, filter.After, filter.Limit)
+	if err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("classes.count", len(classes)))
+	telemetry.EndSpan(span, "listed", nil)
+	return classes, nil
+}
+
+// classByID returns the class with id, or nil. Callers must hold storeMu.
+func classByID(id int) *Class {
+	for _, c := range Classes {
+		if c.ID == id {
+			return &c
+		}
+	}
+	return nil
+}
+
+// classExists reports whether any class that has not been cancelled overlaps
+// [start, end]. Callers must hold storeMu.
+func classExists(ctx context.Context, start, end time.Time) (bool, error) {
 	for _, c := range Classes {
-		if (start.Before(c.EndDate) && end.After(c.StartDate)) ||
-			start.Equal(c.StartDate) || end.Equal(c.EndDate) {
-			return true
+		// Scans can be long once history grows, so stop as soon as the caller gives up
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if c.CancelledAt == nil && c.Overlaps(start, end) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// classOnDate returns the class running on date, if any, leaving out
+// cancelled classes. Callers must hold storeMu.
+func classOnDate(ctx context.Context, date time.Time) (*Class, error) {
+	for i := range Classes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		c := Classes[i]
+		if c.CancelledAt != nil {
+			continue
+		}
+		// Check if the date falls within the range of start and end date (inclusive)
+		if date.Equal(c.StartDate) || date.Equal(c.EndDate) || (date.After(c.StartDate) && date.Before(c.EndDate)) {
+			return &c, nil
 		}
 	}
-	return false
+	return nil, nil
 }
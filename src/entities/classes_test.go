@@ -1,10 +1,12 @@
 package entities
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestClassEntity_AddClass(t *testing.T) {
@@ -85,7 +87,7 @@ func TestClassEntity_AddClass(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			Classes = tt.existing
 
-			result, err := entity.AddClass(tt.newClass)
+			result, err := entity.AddClass(context.Background(), tt.newClass)
 
 			if tt.expectErr {
 				assert.Nil(t, result)
@@ -100,6 +102,31 @@ func TestClassEntity_AddClass(t *testing.T) {
 	}
 }
 
+func TestClassEntity_AddClasses(t *testing.T) {
+	entity := ClassEntity{}
+	start := time.Date(2025, 5, 10, 9, 0, 0, 0, time.UTC)
+	Classes = []Class{{ID: 1, ClassName: "Zumba", StartDate: start, EndDate: start.Add(time.Hour)}}
+	Outbox = nil
+
+	yoga := &Class{ClassName: "Yoga", StartDate: start.AddDate(0, 0, 1), EndDate: start.AddDate(0, 0, 1).Add(time.Hour)}
+	spin := &Class{ClassName: "Spin", StartDate: start.AddDate(0, 0, 2), EndDate: start.AddDate(0, 0, 2).Add(time.Hour)}
+	err := entity.AddClasses(context.Background(), []*Class{yoga, spin},
+		NewEvent(ClassCreatedEvent{Class: yoga}, start), NewEvent(ClassCreatedEvent{Class: spin}, start))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, yoga.ID)
+	assert.Equal(t, 3, spin.ID)
+	assert.Len(t, Classes, 3)
+	assert.Equal(t, []int{2, 3}, []int{Outbox[0].ClassID, Outbox[1].ClassID})
+
+	// Nothing is stored when any class overlaps, including one in the batch
+	pilates := &Class{ClassName: "Pilates", StartDate: start.AddDate(0, 0, 5), EndDate: start.AddDate(0, 0, 5).Add(time.Hour)}
+	clash := &Class{ClassName: "HIIT", StartDate: pilates.StartDate.Add(30 * time.Minute), EndDate: pilates.EndDate.Add(30 * time.Minute)}
+	err = entity.AddClasses(context.Background(), []*Class{pilates, clash})
+	assert.EqualError(t, err, `class "HIIT" overlaps another class`)
+	assert.Len(t, Classes, 3)
+	assert.Zero(t, pilates.ID)
+}
+
 func TestClassEntity_CheckClassExists(t *testing.T) {
 	entity := ClassEntity{}
 
@@ -145,6 +172,18 @@ func TestClassEntity_CheckClassExists(t *testing.T) {
 			checkEnd:   end,
 			expected:   true,
 		},
+		{
+			name: "should return false if the overlapping class was cancelled",
+			existing: []Class{{
+				ClassName:   "Barre",
+				StartDate:   start,
+				EndDate:     end,
+				CancelledAt: &start,
+			}},
+			checkStart: start,
+			checkEnd:   end,
+			expected:   false,
+		},
 		{
 			name: "should return false if no class overlaps with date range",
 			existing: []Class{{
@@ -161,8 +200,208 @@ func TestClassEntity_CheckClassExists(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			Classes = tt.existing
-			result := entity.CheckClassExists(tt.checkStart, tt.checkEnd)
+			result, err := entity.CheckClassExists(context.Background(), tt.checkStart, tt.checkEnd)
+			assert.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
+
+func TestClassEntity_UpdateClass(t *testing.T) {
+	start := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	Classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: start, EndDate: start.Add(time.Hour)}}
+	Outbox = nil
+	entity := ClassEntity{}
+	ctx := context.Background()
+
+	class, err := entity.FindClass(ctx, 1)
+	require.NoError(t, err)
+	class.CancelledAt = &start
+	err = entity.UpdateClass(ctx, class, NewEvent(ClassCancelledEvent{Class: class}, start))
+
+	require.NoError(t, err)
+	assert.Equal(t, &start, Classes[0].CancelledAt)
+	assert.Equal(t, 1, Classes[0].Revision)
+	assert.Equal(t, 1, class.Revision)
+	require.NoError(t, entity.UpdateClass(ctx, class))
+	assert.Equal(t, 2, Classes[0].Revision, "every change counts")
+	require.Len(t, Outbox, 1)
+	assert.Equal(t, EventClassCancelled, Outbox[0].Type)
+
+	_, err = entity.FindClass(ctx, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, entity.UpdateClass(ctx, &Class{ID: 2}), ErrNotFound)
+}
+
+func TestClassEntity_ListClasses(t *testing.T) {
+	start := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	Classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: start, EndDate: start.AddDate(0, 0, 6)},
+		{ID: 2, ClassName: "Spin", StartDate: start.AddDate(0, 0, 7), EndDate: start.AddDate(0, 0, 13)},
+		{ID: 3, ClassName: "Pilates", StartDate: start.AddDate(0, 0, 14), EndDate: start.AddDate(0, 0, 20)},
+	}
+	entity := ClassEntity{}
+
+	tests := []struct {
+		name     string
+		filter   ClassFilter
+		expected []int
+	}{
+		{name: "should list every class with an empty filter", filter: ClassFilter{}, expected: []int{1, 2, 3}},
+		{name: "should list classes running within the range", filter: ClassFilter{From: start.AddDate(0, 0, 6), To: start.AddDate(0, 0, 7)}, expected: []int{1, 2}},
+		{name: "should return a page after the cursor", filter: ClassFilter{After: Cursor{ID: 1}, Limit: 1}, expected: []int{2}},
+		{name: "should return an empty page past the last class", filter: ClassFilter{After: Cursor{ID: 3}}, expected: []int{}},
+		{name: "should sort by name", filter: ClassFilter{Sort: Sort{Field: SortName}, Limit: 2}, expected: []int{3, 2}},
+		{name: "should sort by created time descending", filter: ClassFilter{Sort: Sort{Field: SortCreated, Desc: true}}, expected: []int{3, 2, 1}},
+		{name: "should page after a sorted cursor", filter: ClassFilter{Sort: Sort{Field: SortDate, Desc: true}, After: CursorOf(Classes[1], Sort{Field: SortDate})}, expected: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := entity.ListClasses(context.Background(), tt.filter)
+
+			assert.NoError(t, err)
+			ids := []int{}
+			for _, c := range found {
+				ids = append(ids, c.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestClass_Session(t *testing.T) {
+	tests := []struct {
+		name          string
+		class         Class
+		date          time.Time
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{
+			name: "should return the class times for a single session class",
+			class: Class{
+				StartDate: time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC),
+			},
+			date:          time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC),
+			expectedStart: time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "should return the daily session on the booked day of a class range",
+			class: Class{
+				StartDate: time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2025, 5, 20, 19, 0, 0, 0, time.UTC),
+			},
+			date:          time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC),
+			expectedStart: time.Date(2025, 5, 7, 18, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2025, 5, 7, 19, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "should end the next day when the session runs past midnight",
+			class: Class{
+				StartDate: time.Date(2025, 5, 1, 23, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2025, 5, 20, 1, 0, 0, 0, time.UTC),
+			},
+			date:          time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC),
+			expectedStart: time.Date(2025, 5, 7, 23, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2025, 5, 8, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := tt.class.Session(tt.date)
+
+			assert.Equal(t, tt.expectedStart, start)
+			assert.Equal(t, tt.expectedEnd, end)
+		})
+	}
+}
+
+func TestClass_NextSession(t *testing.T) {
+	daily := Class{
+		StartDate: time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 5, 20, 19, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name          string
+		class         Class
+		now           time.Time
+		expectedStart time.Time
+		expectedOK    bool
+	}{
+		{
+			name:          "should return the first session before the class starts",
+			class:         daily,
+			now:           time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC),
+			expectedStart: time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC),
+			expectedOK:    true,
+		},
+		{
+			name:          "should return today's session while it has not ended",
+			class:         daily,
+			now:           time.Date(2025, 5, 7, 18, 30, 0, 0, time.UTC),
+			expectedStart: time.Date(2025, 5, 7, 18, 0, 0, 0, time.UTC),
+			expectedOK:    true,
+		},
+		{
+			name:          "should return tomorrow's session once today's has ended",
+			class:         daily,
+			now:           time.Date(2025, 5, 7, 19, 0, 0, 0, time.UTC),
+			expectedStart: time.Date(2025, 5, 8, 18, 0, 0, 0, time.UTC),
+			expectedOK:    true,
+		},
+		{
+			name:       "should return nothing after the last session",
+			class:      daily,
+			now:        time.Date(2025, 5, 20, 20, 0, 0, 0, time.UTC),
+			expectedOK: false,
+		},
+		{
+			name: "should return nothing after a single session class",
+			class: Class{
+				StartDate: time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC),
+			},
+			now:        time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC),
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, _, ok := tt.class.NextSession(tt.now)
+
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedStart, start)
+		})
+	}
+}
+
+func TestClass_Sessions(t *testing.T) {
+	class := Class{
+		StartDate: time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 5, 4, 19, 0, 0, 0, time.UTC),
+	}
+	var starts []time.Time
+	for start, end := range class.Sessions(time.Date(2025, 5, 1, 18, 30, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		assert.Equal(t, time.Hour, end.Sub(start))
+		starts = append(starts, start)
+	}
+
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 2, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 3, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 4, 18, 0, 0, 0, time.UTC),
+	}, starts)
+
+	// Sessions starting at or after to are left out
+	count := 0
+	for range class.Sessions(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 2, 18, 0, 0, 0, time.UTC)) {
+		count++
+	}
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,227 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type EventType string
+
+const (
+	EventClassCreated     EventType = "class.created"
+	EventClassCancelled   EventType = "class.cancelled"
+	EventBookingCreated   EventType = "booking.created"
+	EventBookingCancelled EventType = "booking.cancelled"
+	EventWaitlistPromoted EventType = "waitlist.promoted"
+)
+
+// EventTypes lists every event type in the order they are documented.
+var EventTypes = []EventType{EventClassCreated, EventClassCancelled, EventBookingCreated, EventBookingCancelled, EventWaitlistPromoted}
+
+// EventPayload is the typed body of a domain event.
+type EventPayload interface {
+	EventType() EventType
+	// classID is the class the event belongs to. Events of one class are
+	// delivered in the order they were recorded.
+	classID() int
+}
+
+// ClassCreatedEvent is recorded when a class is added to the schedule.
+type ClassCreatedEvent struct {
+	Class *Class `json:"class"`
+}
+
+func (ClassCreatedEvent) EventType() EventType { return EventClassCreated }
+func (e ClassCreatedEvent) classID() int       { return e.Class.ID }
+
+// ClassCancelledEvent is recorded when the studio calls a class off. The
+// bookings it cancels are recorded as events of their own.
+type ClassCancelledEvent struct {
+	Class *Class `json:"class"`
+}
+
+func (ClassCancelledEvent) EventType() EventType { return EventClassCancelled }
+func (e ClassCancelledEvent) classID() int       { return e.Class.ID }
+
+// BookingCreatedEvent is recorded once a booking is confirmed, i.e. straight
+// away for bookings covered by a membership and after payment for paid
+// drop-ins, or put on the waitlist of a full session.
+type BookingCreatedEvent struct {
+	Booking *Booking `json:"booking"`
+}
+
+func (BookingCreatedEvent) EventType() EventType { return EventBookingCreated }
+func (e BookingCreatedEvent) classID() int       { return e.Booking.ClassID }
+
+func (e BookingCreatedEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Booking Booking `json:"booking"`
+	}{publicBooking(e.Booking)})
+}
+
+// BookingCancelledEvent is recorded when a member cancels a booking.
+type BookingCancelledEvent struct {
+	Booking *Booking `json:"booking"`
+}
+
+func (BookingCancelledEvent) EventType() EventType { return EventBookingCancelled }
+func (e BookingCancelledEvent) classID() int       { return e.Booking.ClassID }
+
+func (e BookingCancelledEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Booking Booking `json:"booking"`
+	}{publicBooking(e.Booking)})
+}
+
+// WaitlistPromotedEvent is recorded when a waitlisted booking is given a
+// spot that freed up, and so is confirmed.
+type WaitlistPromotedEvent struct {
+	Booking *Booking `json:"booking"`
+}
+
+func (WaitlistPromotedEvent) EventType() EventType { return EventWaitlistPromoted }
+func (e WaitlistPromotedEvent) classID() int       { return e.Booking.ClassID }
+
+func (e WaitlistPromotedEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Booking Booking `json:"booking"`
+	}{publicBooking(e.Booking)})
+}
+
+// publicBooking copies b without its check-in token, which only the member
+// should see.
+func publicBooking(b *Booking) Booking {
+	public := *b
+	public.CheckInToken = ""
+	return public
+}
+
+type EventStatus string
+
+const (
+	EventPending   EventStatus = "pending"
+	EventDelivered EventStatus = "delivered"
+	// EventFailed events ran out of delivery attempts and are not retried.
+	EventFailed EventStatus = "failed"
+)
+
+// Event is a domain event in the outbox. Repositories store events together
+// with the change they describe, so an event is recorded if and only if the
+// change is.
+type Event struct {
+	ID         int             `json:"id"`
+	Type       EventType       `json:"type"`
+	ClassID    int             `json:"class_id"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+
+	Status        EventStatus `json:"status"`
+	Attempts      int         `json:"attempts"`
+	NextAttemptAt time.Time   `json:"next_attempt_at,omitempty"`
+	LastError     string      `json:"last_error,omitempty"`
+	// DeliveredTo lists the subscribers that have acknowledged the event, so
+	// a retry only goes to the ones that failed.
+	DeliveredTo []string   `json:"delivered_to,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+
+	payload EventPayload
+}
+
+// NewEvent prepares an event to be stored along with a repository change.
+// The payload is encoded when it is stored, so it may point at the record
+// being added and will carry the ID assigned to it.
+func NewEvent(payload EventPayload, occurredAt time.Time) Event {
+	return Event{Type: payload.EventType(), OccurredAt: occurredAt, payload: payload}
+}
+
+// Decode unmarshals the payload into v, e.g. a *BookingCreatedEvent.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// In-memory storage of the outbox
+var Outbox []Event
+
+type EventRepository interface {
+	// PendingEvents returns up to limit undelivered events recorded after
+	// the event with ID after, in the order they were recorded.
+	PendingEvents(ctx context.Context, after, limit int) ([]Event, error)
+	UpdateEvent(ctx context.Context, e *Event) error
+}
+
+type EventEntity struct {
+	EventRepository
+}
+
+func (e *EventEntity) PendingEvents(ctx context.Context, after, limit int) ([]Event, error) {
+	_, span := telemetry.StartSpan(ctx, "EventEntity.PendingEvents", attribute.Int("event.after", after))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	var pending []Event
+	for _, ev := range Outbox {
+		if len(pending) == limit {
+			break
+		}
+		if ev.ID > after && ev.Status == EventPending {
+			ev.DeliveredTo = append([]string(nil), ev.DeliveredTo...)
+			pending = append(pending, ev)
+		}
+	}
+	span.SetAttributes(attribute.Int("events.pending", len(pending)))
+	telemetry.EndSpan(span, "found", nil)
+	return pending, nil
+}
+
+func (e *EventEntity) UpdateEvent(ctx context.Context, ev *Event) error {
+	_, span := telemetry.StartSpan(ctx, "EventEntity.UpdateEvent", attribute.Int("event.id", ev.ID))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range Outbox {
+		if Outbox[i].ID == ev.ID {
+			Outbox[i] = *ev
+			logWrite("outbox", Outbox, i)
+			telemetry.EndSpan(span, "stored", nil)
+			return nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return ErrNotFound
+}
+
+// encodeEvents encodes the payloads of events ahead of appendEvents, so that
+// a payload that cannot be encoded fails the change before anything is
+// stored. Callers must hold storeMu and have assigned IDs to new records.
+func encodeEvents(events []Event) ([]Event, error) {
+	encoded := make([]Event, len(events))
+	for i, ev := range events {
+		if ev.payload == nil {
+			return nil, fmt.Errorf("event %q has no payload", ev.Type)
+		}
+		payload, err := json.Marshal(ev.payload)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %s event: %w", ev.Type, err)
+		}
+		ev.Payload = payload
+		ev.ClassID = ev.payload.classID()
+		ev.Status = EventPending
+		ev.payload = nil
+		encoded[i] = ev
+	}
+	return encoded, nil
+}
+
+// appendEvents adds encoded events to the outbox. Callers must hold storeMu.
+func appendEvents(events []Event) {
+	for _, ev := range events {
+		ev.ID = len(Outbox) + 1
+		Outbox = append(Outbox, ev)
+		logWrite("outbox", Outbox, len(Outbox)-1)
+	}
+}
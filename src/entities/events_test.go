@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookingEntity_AddBooking_RecordsEvents(t *testing.T) {
+	Bookings = nil
+	Outbox = nil
+	entity := &BookingEntity{}
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	booking := &Booking{ClassID: 3, Name: "John Doe", Status: BookingConfirmed, CheckInToken: "secret"}
+
+	_, err := entity.AddBooking(context.Background(), booking, NewEvent(BookingCreatedEvent{Booking: booking}, now))
+	assert.NoError(t, err)
+
+	assert.Len(t, Outbox, 1)
+	event := Outbox[0]
+	assert.Equal(t, 1, event.ID)
+	assert.Equal(t, EventBookingCreated, event.Type)
+	assert.Equal(t, 3, event.ClassID)
+	assert.Equal(t, now, event.OccurredAt)
+	assert.Equal(t, EventPending, event.Status)
+
+	var payload BookingCreatedEvent
+	assert.NoError(t, event.Decode(&payload))
+	assert.Equal(t, 1, payload.Booking.ID)
+	assert.Equal(t, "John Doe", payload.Booking.Name)
+	assert.Empty(t, payload.Booking.CheckInToken)
+}
+
+func TestBookingEntity_UpdateBooking_RecordsNoEventsForUnknownBooking(t *testing.T) {
+	Bookings = nil
+	Outbox = nil
+	entity := &BookingEntity{}
+	booking := &Booking{ID: 7, ClassID: 3, Status: BookingCancelled}
+
+	err := entity.UpdateBooking(context.Background(), booking, NewEvent(BookingCancelledEvent{Booking: booking}, time.Now()))
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Empty(t, Outbox)
+}
+
+func TestClassEntity_AddClass_RecordsEvents(t *testing.T) {
+	Classes = nil
+	Outbox = nil
+	entity := ClassEntity{}
+	start := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	class := &Class{ClassName: "Yoga", StartDate: start, EndDate: start.Add(time.Hour), Capacity: 10}
+
+	_, err := entity.AddClass(context.Background(), class, NewEvent(ClassCreatedEvent{Class: class}, start))
+	assert.NoError(t, err)
+	// An overlapping class is rejected along with its event
+	_, err = entity.AddClass(context.Background(), class, NewEvent(ClassCreatedEvent{Class: class}, start))
+	assert.Error(t, err)
+
+	assert.Len(t, Outbox, 1)
+	assert.Equal(t, EventClassCreated, Outbox[0].Type)
+	assert.Equal(t, 1, Outbox[0].ClassID)
+}
+
+func TestEventEntity_PendingEvents(t *testing.T) {
+	Outbox = []Event{
+		{ID: 1, Status: EventDelivered},
+		{ID: 2, Status: EventPending},
+		{ID: 3, Status: EventFailed},
+		{ID: 4, Status: EventPending},
+		{ID: 5, Status: EventPending},
+	}
+	entity := &EventEntity{}
+
+	pending, err := entity.PendingEvents(context.Background(), 0, 2)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, 2, pending[0].ID)
+	assert.Equal(t, 4, pending[1].ID)
+
+	rest, err := entity.PendingEvents(context.Background(), 4, 2)
+	assert.NoError(t, err)
+	assert.Len(t, rest, 1)
+	assert.Equal(t, 5, rest[0].ID)
+
+	pending[0].Status = EventDelivered
+	assert.NoError(t, entity.UpdateEvent(context.Background(), &pending[0]))
+	assert.Equal(t, EventDelivered, Outbox[1].Status)
+	assert.ErrorIs(t, entity.UpdateEvent(context.Background(), &Event{ID: 9}), ErrNotFound)
+}
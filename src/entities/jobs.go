@@ -0,0 +1,169 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	// JobRunning jobs are leased to a worker until LeaseUntil. A job whose
+	// lease runs out, e.g. because the process stopped, is claimed again.
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	// JobFailed jobs ran out of attempts and are not retried.
+	JobFailed JobStatus = "failed"
+)
+
+// Job is a unit of background work run by the scheduler.
+type Job struct {
+	ID   int    `json:"id"`
+	Kind string `json:"kind"`
+	// Key deduplicates jobs: adding a job whose key matches an unfinished
+	// job returns the existing one.
+	Key string `json:"key,omitempty"`
+	// Schedule is the cron spec of a recurring job, empty for one-off jobs.
+	Schedule   string          `json:"schedule,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	RunAt      time.Time       `json:"run_at"`
+	Status     JobStatus       `json:"status"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"last_error,omitempty"`
+	LeaseUntil *time.Time      `json:"lease_until,omitempty"`
+}
+
+// Finished reports whether the job will not run again.
+func (j Job) Finished() bool {
+	return j.Status == JobDone || j.Status == JobFailed
+}
+
+// In-memory storage of jobs
+var Jobs []Job
+
+type JobRepository interface {
+	AddJob(ctx context.Context, j *Job) (*Job, error)
+	UpdateJob(ctx context.Context, j *Job) error
+	FindJob(ctx context.Context, id int) (*Job, error)
+	// ClaimDueJobs leases up to limit jobs due at now to the caller.
+	ClaimDueJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Job, error)
+}
+
+type JobEntity struct {
+	JobRepository
+}
+
+func (e *JobEntity) AddJob(ctx context.Context, j *Job) (*Job, error) {
+	_, span := telemetry.StartSpan(ctx, "JobEntity.AddJob", attribute.String("job.kind", j.Kind))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	if j.Key != "" {
+		for _, existing := range Jobs {
+			if existing.Key == j.Key && !existing.Finished() {
+				telemetry.EndSpan(span, "duplicate", nil)
+				return &existing, nil
+			}
+		}
+	}
+	j.ID = len(Jobs) + 1
+	Jobs = append(Jobs, *j)
+	logWrite("jobs", Jobs, len(Jobs)-1)
+	span.SetAttributes(attribute.Int("job.id", j.ID))
+	telemetry.EndSpan(span, "stored", nil)
+	return j, nil
+}
+
+func (e *JobEntity) UpdateJob(ctx context.Context, j *Job) error {
+	_, span := telemetry.StartSpan(ctx, "JobEntity.UpdateJob", attribute.Int("job.id", j.ID))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range Jobs {
+		if Jobs[i].ID == j.ID {
+			Jobs[i] = *j
+			logRun(i)
+			telemetry.EndSpan(span, "stored", nil)
+			return nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return ErrNotFound
+}
+
+func (e *JobEntity) FindJob(ctx context.Context, id int) (*Job, error) {
+	_, span := telemetry.StartSpan(ctx, "JobEntity.FindJob", attribute.Int("job.id", id))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, j := range Jobs {
+		if j.ID == id {
+			telemetry.EndSpan(span, "found", nil)
+			return &j, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
+
+// ClaimDueJobs leases pending jobs due at now, and running jobs whose lease
+// has expired, in RunAt order. Claiming happens under the store lock so a
+// job is never leased to two workers at once.
+func (e *JobEntity) ClaimDueJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Job, error) {
+	_, span := telemetry.StartSpan(ctx, "JobEntity.ClaimDueJobs")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	var due []int
+	for i, j := range Jobs {
+		if err := ctx.Err(); err != nil {
+			telemetry.EndSpan(span, "error", err)
+			return nil, err
+		}
+		pending := j.Status == JobPending && !j.RunAt.After(now)
+		abandoned := j.Status == JobRunning && j.LeaseUntil != nil && !j.LeaseUntil.After(now)
+		if pending || abandoned {
+			due = append(due, i)
+		}
+	}
+	sortByRunAt(due)
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leaseUntil := now.Add(lease)
+	claimed := make([]Job, 0, len(due))
+	for _, i := range due {
+		Jobs[i].Status = JobRunning
+		Jobs[i].Attempts++
+		until := leaseUntil
+		Jobs[i].LeaseUntil = &until
+		logRun(i)
+		claimed = append(claimed, Jobs[i])
+	}
+	span.SetAttributes(attribute.Int("jobs.claimed", len(claimed)))
+	telemetry.EndSpan(span, "claimed", nil)
+	return claimed, nil
+}
+
+// logRun records a write to Jobs[i], except for runs of recurring jobs:
+// those come round every second and the scheduler reschedules them on start.
+// Callers must hold storeMu.
+func logRun(i int) {
+	if Jobs[i].Schedule == "" {
+		logWrite("jobs", Jobs, i)
+	}
+}
+
+// sortByRunAt orders indexes into Jobs by RunAt. Callers must hold storeMu.
+func sortByRunAt(indexes []int) {
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return Jobs[indexes[a]].RunAt.Before(Jobs[indexes[b]].RunAt)
+	})
+}
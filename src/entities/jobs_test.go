@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobEntity_AddJob(t *testing.T) {
+	Jobs = nil
+	entity := &JobEntity{}
+	ctx := context.Background()
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+
+	first, err := entity.AddJob(ctx, &Job{Kind: "remind", Key: "remind-1", RunAt: now, Status: JobPending})
+	assert.NoError(t, err)
+	duplicate, err := entity.AddJob(ctx, &Job{Kind: "remind", Key: "remind-1", RunAt: now.Add(time.Hour), Status: JobPending})
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, duplicate.ID)
+	assert.Equal(t, now, duplicate.RunAt)
+	assert.Len(t, Jobs, 1)
+
+	first.Status = JobDone
+	assert.NoError(t, entity.UpdateJob(ctx, first))
+	again, err := entity.AddJob(ctx, &Job{Kind: "remind", Key: "remind-1", RunAt: now, Status: JobPending})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, again.ID)
+}
+
+func TestJobEntity_ClaimDueJobs(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Second)
+	leased := now.Add(time.Minute)
+	Jobs = []Job{
+		{ID: 1, Kind: "later", RunAt: now.Add(time.Minute), Status: JobPending},
+		{ID: 2, Kind: "due", RunAt: now, Status: JobPending},
+		{ID: 3, Kind: "overdue", RunAt: now.Add(-time.Hour), Status: JobPending},
+		{ID: 4, Kind: "abandoned", RunAt: now.Add(-time.Minute), Status: JobRunning, Attempts: 1, LeaseUntil: &expired},
+		{ID: 5, Kind: "leased", RunAt: now.Add(-time.Minute), Status: JobRunning, Attempts: 1, LeaseUntil: &leased},
+		{ID: 6, Kind: "done", RunAt: now.Add(-time.Minute), Status: JobDone},
+	}
+	entity := &JobEntity{}
+
+	claimed, err := entity.ClaimDueJobs(context.Background(), now, time.Minute, 2)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 2)
+	assert.Equal(t, 3, claimed[0].ID)
+	assert.Equal(t, 4, claimed[1].ID)
+	assert.Equal(t, 2, claimed[1].Attempts)
+	assert.Equal(t, JobRunning, Jobs[2].Status)
+	assert.Equal(t, now.Add(time.Minute), *Jobs[2].LeaseUntil)
+
+	claimed, err = entity.ClaimDueJobs(context.Background(), now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, 2, claimed[0].ID)
+
+	claimed, err = entity.ClaimDueJobs(context.Background(), now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+}
@@ -0,0 +1,177 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+)
+
+type MemberTier string
+
+const (
+	TierStandard MemberTier = "standard"
+	TierStudent  MemberTier = "student"
+	TierPremium  MemberTier = "premium"
+)
+
+type NotificationChannel string
+
+const (
+	ChannelEmail NotificationChannel = "email"
+	ChannelSMS   NotificationChannel = "sms"
+)
+
+// Member is a studio member, identified by the name used when booking.
+type Member struct {
+	Name    string     `json:"name"`
+	Tier    MemberTier `json:"tier"`
+	NoShows int        `json:"no_shows"`
+	Email   string     `json:"email,omitempty"`
+	// Phone is an E.164 number SMS notifications are sent to.
+	Phone string `json:"phone,omitempty"`
+	// NotificationChannels are the channels the member wants notifications
+	// on. When empty, email is used if the member has an address.
+	NotificationChannels []NotificationChannel `json:"notification_channels,omitempty"`
+	NotificationsOptOut  bool                  `json:"notifications_opt_out"`
+	// CalendarToken grants access to the member's calendar feed of
+	// bookings.
+	CalendarToken string `json:"calendar_token,omitempty"`
+}
+
+// Channels returns the channels the member is notified on: their chosen
+// channels, or email by default, restricted to those they have contact
+// details for. It is empty for members who opted out.
+func (m Member) Channels() []NotificationChannel {
+	if m.NotificationsOptOut {
+		return nil
+	}
+	wanted := m.NotificationChannels
+	if len(wanted) == 0 {
+		wanted = []NotificationChannel{ChannelEmail}
+	}
+	var channels []NotificationChannel
+	for _, c := range wanted {
+		if m.Address(c) != "" {
+			channels = append(channels, c)
+		}
+	}
+	return channels
+}
+
+// Address returns where notifications on channel are sent to.
+func (m Member) Address(channel NotificationChannel) string {
+	switch channel {
+	case ChannelEmail:
+		return m.Email
+	case ChannelSMS:
+		return m.Phone
+	}
+	return ""
+}
+
+// In-memory storage of members
+var Members []Member
+
+type MemberRepository interface {
+	AddMember(ctx context.Context, m *Member) (*Member, error)
+	// AddMembers stores all of members, or none of them if any name is
+	// taken.
+	AddMembers(ctx context.Context, members []Member) error
+	FindMember(ctx context.Context, name string) (*Member, error)
+	UpdateMember(ctx context.Context, m *Member) error
+}
+
+type MemberEntity struct {
+	MemberRepository
+}
+
+func (e *MemberEntity) AddMember(ctx context.Context, m *Member) (*Member, error) {
+	_, span := telemetry.StartSpan(ctx, "MemberEntity.AddMember")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for _, existing := range Members {
+		if existing.Name == m.Name {
+			err := errors.New("a member with that name already exists")
+			telemetry.EndSpan(span, "duplicate", err)
+			return nil, err
+		}
+	}
+	Members = append(Members, *m)
+	logWrite("members", Members, len(Members)-1)
+	telemetry.EndSpan(span, "stored", nil)
+	return m, nil
+}
+
+func (e *MemberEntity) AddMembers(ctx context.Context, members []Member) error {
+	_, span := telemetry.StartSpan(ctx, "MemberEntity.AddMembers")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	names := map[string]bool{}
+	for _, existing := range Members {
+		names[existing.Name] = true
+	}
+	for _, m := range members {
+		if names[m.Name] {
+			err := fmt.Errorf("a member named %q already exists", m.Name)
+			telemetry.EndSpan(span, "duplicate", err)
+			return err
+		}
+		names[m.Name] = true
+	}
+	n := len(Members)
+	Members = append(Members, members...)
+	logAppends("members", Members, n)
+	telemetry.EndSpan(span, "stored", nil)
+	return nil
+}
+
+func (e *MemberEntity) FindMember(ctx context.Context, name string) (*Member, error) {
+	_, span := telemetry.StartSpan(ctx, "MemberEntity.FindMember")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, m := range Members {
+		if m.Name == name {
+			telemetry.EndSpan(span, "found", nil)
+			return &m, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
+
+func (e *MemberEntity) UpdateMember(ctx context.Context, m *Member) error {
+	_, span := telemetry.StartSpan(ctx, "MemberEntity.UpdateMember")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range Members {
+		if Members[i].Name == m.Name {
+			Members[i] = *m
+			logWrite("members", Members, i)
+			telemetry.EndSpan(span, "stored", nil)
+			return nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return ErrNotFound
+}
+
+// recordNoShow increments the member's no-show counter, registering them as
+// a standard member if they booked without signing up. Callers must hold
+// storeMu.
+func recordNoShow(name string) {
+	for i := range Members {
+		if Members[i].Name == name {
+			Members[i].NoShows++
+			logWrite("members", Members, i)
+			return
+		}
+	}
+	Members = append(Members, Member{Name: name, Tier: TierStandard, NoShows: 1})
+	logWrite("members", Members, len(Members)-1)
+}
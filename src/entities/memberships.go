@@ -0,0 +1,161 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type PlanType string
+
+const (
+	// PlanUnlimitedMonthly allows any number of bookings for one month.
+	PlanUnlimitedMonthly PlanType = "unlimited_monthly"
+	// PlanClassPack holds a number of credits, one debited per booking.
+	PlanClassPack PlanType = "class_pack"
+	// PlanDropIn books a single class at a time, paid per class.
+	PlanDropIn PlanType = "drop_in"
+)
+
+// ClassPackCredits is the number of credits in a class pack.
+const ClassPackCredits = 10
+
+type Membership struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Plan      PlanType   `json:"plan"`
+	Credits   int        `json:"credits,omitempty"`
+	StartDate time.Time  `json:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+}
+
+// ActiveAt reports whether the membership covers a class starting at t.
+func (m Membership) ActiveAt(t time.Time) bool {
+	if t.Before(m.StartDate) {
+		return false
+	}
+	if m.EndDate != nil && !t.Before(*m.EndDate) {
+		return false
+	}
+	if m.Plan == PlanClassPack {
+		return m.Credits > 0
+	}
+	return true
+}
+
+// ErrNoCredits is returned when debiting a membership without credits left.
+var ErrNoCredits = errors.New("no credits left")
+
+// In-memory storage of memberships
+var Memberships []Membership
+
+type MembershipRepository interface {
+	AddMembership(ctx context.Context, m *Membership) (*Membership, error)
+	DebitCredit(ctx context.Context, id int) error
+	RefundCredit(ctx context.Context, id int) error
+	FindMembership(ctx context.Context, id int) (*Membership, error)
+	FindMemberships(ctx context.Context, name string) ([]Membership, error)
+}
+
+type MembershipEntity struct {
+	MembershipRepository
+}
+
+func (e *MembershipEntity) AddMembership(ctx context.Context, m *Membership) (*Membership, error) {
+	_, span := telemetry.StartSpan(ctx, "MembershipEntity.AddMembership")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	m.ID = len(Memberships) + 1
+	Memberships = append(Memberships, *m)
+	logWrite("memberships", Memberships, len(Memberships)-1)
+	span.SetAttributes(attribute.Int("membership.id", m.ID))
+	telemetry.EndSpan(span, "stored", nil)
+	return m, nil
+}
+
+// DebitCredit takes one credit from a class pack, failing with ErrNoCredits
+// when it is empty. Check and debit happen under one lock so concurrent
+// bookings cannot overdraw the pack.
+func (e *MembershipEntity) DebitCredit(ctx context.Context, id int) error {
+	_, span := telemetry.StartSpan(ctx, "MembershipEntity.DebitCredit", attribute.Int("membership.id", id))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	m := membershipByID(id)
+	if m == nil {
+		telemetry.EndSpan(span, "not_found", ErrNotFound)
+		return ErrNotFound
+	}
+	if m.Credits <= 0 {
+		telemetry.EndSpan(span, "no_credits", ErrNoCredits)
+		return ErrNoCredits
+	}
+	m.Credits--
+	logRecord("memberships", Memberships, m)
+	telemetry.EndSpan(span, "debited", nil)
+	return nil
+}
+
+func (e *MembershipEntity) RefundCredit(ctx context.Context, id int) error {
+	_, span := telemetry.StartSpan(ctx, "MembershipEntity.RefundCredit", attribute.Int("membership.id", id))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	m := membershipByID(id)
+	if m == nil {
+		telemetry.EndSpan(span, "not_found", ErrNotFound)
+		return ErrNotFound
+	}
+	m.Credits++
+	logRecord("memberships", Memberships, m)
+	telemetry.EndSpan(span, "refunded", nil)
+	return nil
+}
+
+func (e *MembershipEntity) FindMembership(ctx context.Context, id int) (*Membership, error) {
+	_, span := telemetry.StartSpan(ctx, "MembershipEntity.FindMembership", attribute.Int("membership.id", id))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, m := range Memberships {
+		if m.ID == id {
+			telemetry.EndSpan(span, "found", nil)
+			return &m, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
+
+func (e *MembershipEntity) FindMemberships(ctx context.Context, name string) ([]Membership, error) {
+	_, span := telemetry.StartSpan(ctx, "MembershipEntity.FindMemberships")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	var found []Membership
+	for _, m := range Memberships {
+		if err := ctx.Err(); err != nil {
+			telemetry.EndSpan(span, "error", err)
+			return nil, err
+		}
+		if m.Name == name {
+			found = append(found, m)
+		}
+	}
+	telemetry.EndSpan(span, "found", nil)
+	return found, nil
+}
+
+// membershipByID returns a pointer into the storage. Callers must hold storeMu.
+func membershipByID(id int) *Membership {
+	for i := range Memberships {
+		if Memberships[i].ID == id {
+			return &Memberships[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package entities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembership_ActiveAt(t *testing.T) {
+	start := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name       string
+		membership Membership
+		at         time.Time
+		expected   bool
+	}{
+		{
+			name:       "should be active during an unlimited month",
+			membership: Membership{Plan: PlanUnlimitedMonthly, StartDate: start, EndDate: &end},
+			at:         start.AddDate(0, 0, 10),
+			expected:   true,
+		},
+		{
+			name:       "should not be active before the membership starts",
+			membership: Membership{Plan: PlanUnlimitedMonthly, StartDate: start, EndDate: &end},
+			at:         start.AddDate(0, 0, -1),
+			expected:   false,
+		},
+		{
+			name:       "should not be active once the membership has ended",
+			membership: Membership{Plan: PlanUnlimitedMonthly, StartDate: start, EndDate: &end},
+			at:         end,
+			expected:   false,
+		},
+		{
+			name:       "should be active for a class pack with credits",
+			membership: Membership{Plan: PlanClassPack, Credits: 1, StartDate: start},
+			at:         start.AddDate(1, 0, 0),
+			expected:   true,
+		},
+		{
+			name:       "should not be active for an empty class pack",
+			membership: Membership{Plan: PlanClassPack, Credits: 0, StartDate: start},
+			at:         start.AddDate(0, 0, 1),
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.membership.ActiveAt(tt.at))
+		})
+	}
+}
+
+func TestMembershipEntity_Credits(t *testing.T) {
+	entity := &MembershipEntity{}
+	ctx := context.Background()
+	Memberships = []Membership{{ID: 1, Name: "John Doe", Plan: PlanClassPack, Credits: 1}}
+
+	assert.NoError(t, entity.DebitCredit(ctx, 1))
+	assert.Equal(t, 0, Memberships[0].Credits)
+
+	assert.ErrorIs(t, entity.DebitCredit(ctx, 1), ErrNoCredits)
+	assert.Equal(t, 0, Memberships[0].Credits)
+
+	assert.NoError(t, entity.RefundCredit(ctx, 1))
+	assert.Equal(t, 1, Memberships[0].Credits)
+
+	assert.ErrorIs(t, entity.DebitCredit(ctx, 2), ErrNotFound)
+}
+
+func TestMembershipEntity_FindMemberships(t *testing.T) {
+	entity := &MembershipEntity{}
+	Memberships = nil
+
+	_, err := entity.AddMembership(context.Background(), &Membership{Name: "John Doe", Plan: PlanDropIn})
+	assert.NoError(t, err)
+	_, err = entity.AddMembership(context.Background(), &Membership{Name: "Jane Doe", Plan: PlanClassPack})
+	assert.NoError(t, err)
+
+	found, err := entity.FindMemberships(context.Background(), "Jane Doe")
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, 2, found[0].ID)
+}
@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"context"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type NotificationKind string
+
+const (
+	NotificationBookingConfirmed NotificationKind = "booking_confirmed"
+	NotificationBookingCancelled NotificationKind = "booking_cancelled"
+	NotificationClassReminder    NotificationKind = "class_reminder"
+	NotificationClassCancelled   NotificationKind = "class_cancelled"
+	NotificationWaitlistPromoted NotificationKind = "waitlist_promoted"
+)
+
+// Notification records a message sent to a member.
+type Notification struct {
+	ID      int                 `json:"id"`
+	Name    string              `json:"name"`
+	Kind    NotificationKind    `json:"kind"`
+	Channel NotificationChannel `json:"channel"`
+	To      string              `json:"to"`
+	// Key identifies what the notification is about, e.g. the event that
+	// caused it, so that it is not sent twice.
+	Key    string    `json:"key"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// In-memory storage of sent notifications
+var Notifications []Notification
+
+type NotificationRepository interface {
+	AddNotification(ctx context.Context, n *Notification) (*Notification, error)
+	// NotificationSent reports whether a notification with key has been
+	// sent on channel.
+	NotificationSent(ctx context.Context, key string, channel NotificationChannel) (bool, error)
+}
+
+type NotificationEntity struct {
+	NotificationRepository
+}
+
+func (e *NotificationEntity) AddNotification(ctx context.Context, n *Notification) (*Notification, error) {
+	_, span := telemetry.StartSpan(ctx, "NotificationEntity.AddNotification", attribute.String("notification.kind", string(n.Kind)))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	n.ID = len(Notifications) + 1
+	Notifications = append(Notifications, *n)
+	logWrite("notifications", Notifications, len(Notifications)-1)
+	telemetry.EndSpan(span, "stored", nil)
+	return n, nil
+}
+
+func (e *NotificationEntity) NotificationSent(ctx context.Context, key string, channel NotificationChannel) (bool, error) {
+	_, span := telemetry.StartSpan(ctx, "NotificationEntity.NotificationSent")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, n := range Notifications {
+		if n.Key == key && n.Channel == channel {
+			telemetry.EndSpan(span, "found", nil)
+			return true, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return false, nil
+}
@@ -0,0 +1,99 @@
+package entities
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+// SortField names the order a list comes back in.
+type SortField string
+
+const (
+	// SortCreated lists records in the order they were stored, which is ID
+	// order.
+	SortCreated SortField = "created"
+	SortDate    SortField = "date"
+	SortName    SortField = "name"
+)
+
+// Sort orders a list by Field, ties broken by ID.
+type Sort struct {
+	Field SortField
+	Desc  bool
+}
+
+// Cursor marks the last record of a page: the next page starts after it in
+// the list's order. Key is the record's sort value as given by sortKey, and
+// empty when sorting by SortCreated. The zero Cursor starts at the
+// beginning.
+type Cursor struct {
+	Key string
+	ID  int
+}
+
+// sortable is a record that can be listed in any Sort.
+type sortable interface {
+	recordID() int
+	// sortKey returns the value of field as a string that compares in the
+	// same order as the value itself.
+	sortKey(field SortField) string
+}
+
+// sortTime formats t so that times compare in order as strings.
+func sortTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
+
+// CursorOf returns the cursor that continues a list in order s after r.
+func CursorOf[T sortable](r T, s Sort) Cursor {
+	c := Cursor{ID: r.recordID()}
+	if s.Field != SortCreated && s.Field != "" {
+		c.Key = r.sortKey(s.Field)
+	}
+	return c
+}
+
+// listPage returns up to limit records matching match, in order s and
+// starting after cursor. A zero limit returns every match. Callers must hold
+// storeMu.
+func listPage[T sortable](ctx context.Context, records []T, match func(T) bool, s Sort, after Cursor, limit int) ([]T, error) {
+	compare := func(key string, id int, c Cursor) int {
+		order := cmp.Or(cmp.Compare(key, c.Key), cmp.Compare(id, c.ID))
+		if s.Desc {
+			return -order
+		}
+		return order
+	}
+	byID := s.Field == SortCreated || s.Field == ""
+	keyOf := func(r T) string {
+		if byID {
+			return ""
+		}
+		return r.sortKey(s.Field)
+	}
+	started := after == Cursor{}
+
+	found := []T{}
+	for _, r := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !match(r) || !started && compare(keyOf(r), r.recordID(), after) <= 0 {
+			continue
+		}
+		found = append(found, r)
+		// Records are stored in ID order, so that order needs no sorting
+		if byID && !s.Desc && limit > 0 && len(found) == limit {
+			return found, nil
+		}
+	}
+	slices.SortStableFunc(found, func(a, b T) int {
+		return compare(keyOf(a), a.recordID(), Cursor{Key: keyOf(b), ID: b.recordID()})
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
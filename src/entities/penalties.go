@@ -0,0 +1,221 @@
+package entities
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type LateCancelAction string
+
+const (
+	// LateCancelNone refunds late cancellations like any other.
+	LateCancelNone LateCancelAction = "none"
+	// LateCancelForfeit keeps the class pack credit or drop-in payment.
+	LateCancelForfeit LateCancelAction = "forfeit"
+	// LateCancelFee refunds the booking but charges LateCancelFee through
+	// the payment gateway.
+	LateCancelFee LateCancelAction = "fee"
+)
+
+// PenaltyPolicy is a studio's rules for no-shows and late cancellations.
+type PenaltyPolicy struct {
+	Studio string `json:"studio"`
+	// NoShowLimit no-shows within NoShowWindowDays suspend booking for
+	// SuspensionDays. Zero disables suspensions.
+	NoShowLimit      int `json:"no_show_limit"`
+	NoShowWindowDays int `json:"no_show_window_days"`
+	SuspensionDays   int `json:"suspension_days"`
+	// LateCancelAction applies to cancellations inside the cancellation window.
+	LateCancelAction LateCancelAction `json:"late_cancel_action"`
+	// LateCancelFee is in minor units of Currency.
+	LateCancelFee int    `json:"late_cancel_fee,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+}
+
+// DefaultPenaltyPolicy applies to studios that have not set their own.
+var DefaultPenaltyPolicy = PenaltyPolicy{
+	NoShowLimit:      3,
+	NoShowWindowDays: 30,
+	SuspensionDays:   7,
+	LateCancelAction: LateCancelForfeit,
+}
+
+type PenaltyType string
+
+const (
+	PenaltySuspension        PenaltyType = "suspension"
+	PenaltyLateCancelForfeit PenaltyType = "late_cancel_forfeit"
+	PenaltyLateCancelFee     PenaltyType = "late_cancel_fee"
+)
+
+// Penalty records a policy being applied to a member, kept for staff to
+// review and override.
+type Penalty struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	Studio    string      `json:"studio"`
+	Type      PenaltyType `json:"type"`
+	Reason    string      `json:"reason"`
+	BookingID int         `json:"booking_id,omitempty"`
+	// Amount is the fee, in minor units of Currency.
+	Amount   int    `json:"amount,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	// PaymentID is the payment the fee was charged with. A fee without one
+	// was declined and is still owed.
+	PaymentID string     `json:"payment_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// OverriddenAt is set once staff have lifted or waived the penalty.
+	OverriddenAt   *time.Time `json:"overridden_at,omitempty"`
+	OverriddenBy   string     `json:"overridden_by,omitempty"`
+	OverrideReason string     `json:"override_reason,omitempty"`
+}
+
+// ActiveAt reports whether the penalty still applies at t.
+func (p Penalty) ActiveAt(t time.Time) bool {
+	return p.OverriddenAt == nil && (p.ExpiresAt == nil || t.Before(*p.ExpiresAt))
+}
+
+// PenaltyFilter narrows ListPenalties; zero fields match everything.
+type PenaltyFilter struct {
+	Name   string
+	Studio string
+	Type   PenaltyType
+	// Sort, After and Limit page through penalties, as in BookingFilter.
+	// Penalties are dated when they are issued.
+	Sort  Sort
+	After Cursor
+	Limit int
+}
+
+func (p Penalty) recordID() int { return p.ID }
+
+func (p Penalty) sortKey(field SortField) string {
+	if field == SortName {
+		return strings.ToLower(p.Name)
+	}
+	return sortTime(p.CreatedAt)
+}
+
+func (f PenaltyFilter) matches(p Penalty) bool {
+	return (f.Name == "" || p.Name == f.Name) &&
+		(f.Studio == "" || p.Studio == f.Studio) &&
+		(f.Type == "" || p.Type == f.Type)
+}
+
+// In-memory storage of penalty policies and the penalties they issued
+var (
+	PenaltyPolicies []PenaltyPolicy
+	Penalties       []Penalty
+)
+
+type PenaltyRepository interface {
+	SetPenaltyPolicy(ctx context.Context, p *PenaltyPolicy) (*PenaltyPolicy, error)
+	FindPenaltyPolicy(ctx context.Context, studio string) (*PenaltyPolicy, error)
+	AddPenalty(ctx context.Context, p *Penalty) (*Penalty, error)
+	UpdatePenalty(ctx context.Context, p *Penalty) error
+	FindPenalty(ctx context.Context, id int) (*Penalty, error)
+	ListPenalties(ctx context.Context, filter PenaltyFilter) ([]Penalty, error)
+}
+
+type PenaltyEntity struct {
+	PenaltyRepository
+}
+
+// SetPenaltyPolicy stores the studio's policy, replacing any previous one.
+func (e *PenaltyEntity) SetPenaltyPolicy(ctx context.Context, p *PenaltyPolicy) (*PenaltyPolicy, error) {
+	_, span := telemetry.StartSpan(ctx, "PenaltyEntity.SetPenaltyPolicy", attribute.String("studio", p.Studio))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range PenaltyPolicies {
+		if PenaltyPolicies[i].Studio == p.Studio {
+			PenaltyPolicies[i] = *p
+			logWrite("penalty_policies", PenaltyPolicies, i)
+			telemetry.EndSpan(span, "replaced", nil)
+			return p, nil
+		}
+	}
+	PenaltyPolicies = append(PenaltyPolicies, *p)
+	logWrite("penalty_policies", PenaltyPolicies, len(PenaltyPolicies)-1)
+	telemetry.EndSpan(span, "stored", nil)
+	return p, nil
+}
+
+func (e *PenaltyEntity) FindPenaltyPolicy(ctx context.Context, studio string) (*PenaltyPolicy, error) {
+	_, span := telemetry.StartSpan(ctx, "PenaltyEntity.FindPenaltyPolicy", attribute.String("studio", studio))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, p := range PenaltyPolicies {
+		if p.Studio == studio {
+			telemetry.EndSpan(span, "found", nil)
+			return &p, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
+
+func (e *PenaltyEntity) AddPenalty(ctx context.Context, p *Penalty) (*Penalty, error) {
+	_, span := telemetry.StartSpan(ctx, "PenaltyEntity.AddPenalty")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	p.ID = len(Penalties) + 1
+	Penalties = append(Penalties, *p)
+	logWrite("penalties", Penalties, len(Penalties)-1)
+	span.SetAttributes(attribute.Int("penalty.id", p.ID), attribute.String("penalty.type", string(p.Type)))
+	telemetry.EndSpan(span, "stored", nil)
+	return p, nil
+}
+
+func (e *PenaltyEntity) UpdatePenalty(ctx context.Context, p *Penalty) error {
+	_, span := telemetry.StartSpan(ctx, "PenaltyEntity.UpdatePenalty", attribute.Int("penalty.id", p.ID))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range Penalties {
+		if Penalties[i].ID == p.ID {
+			Penalties[i] = *p
+			logWrite("penalties", Penalties, i)
+			telemetry.EndSpan(span, "stored", nil)
+			return nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return ErrNotFound
+}
+
+func (e *PenaltyEntity) FindPenalty(ctx context.Context, id int) (*Penalty, error) {
+	_, span := telemetry.StartSpan(ctx, "PenaltyEntity.FindPenalty", attribute.Int("penalty.id", id))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, p := range Penalties {
+		if p.ID == id {
+			telemetry.EndSpan(span, "found", nil)
+			return &p, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
+
+func (e *PenaltyEntity) ListPenalties(ctx context.Context, filter PenaltyFilter) ([]Penalty, error) {
+	_, span := telemetry.StartSpan(ctx, "PenaltyEntity.ListPenalties")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	found, err := listPage(ctx, Penalties, filter.matches, filter.Sort, filter.After, filter.Limit)
+	if err != nil {
+		telemetry.EndSpan(span, "error", err)
+		return nil, err
+	}
+	telemetry.EndSpan(span, "found", nil)
+	return found, nil
+}
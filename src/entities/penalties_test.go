@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPenaltyEntity_SetPenaltyPolicy(t *testing.T) {
+	PenaltyPolicies = nil
+	entity := &PenaltyEntity{}
+	ctx := context.Background()
+
+	_, err := entity.SetPenaltyPolicy(ctx, &PenaltyPolicy{Studio: "main", NoShowLimit: 3, LateCancelAction: LateCancelForfeit})
+	assert.NoError(t, err)
+	_, err = entity.SetPenaltyPolicy(ctx, &PenaltyPolicy{Studio: "main", LateCancelAction: LateCancelNone})
+	assert.NoError(t, err)
+
+	policy, err := entity.FindPenaltyPolicy(ctx, "main")
+	assert.NoError(t, err)
+	assert.Equal(t, &PenaltyPolicy{Studio: "main", LateCancelAction: LateCancelNone}, policy)
+	assert.Len(t, PenaltyPolicies, 1)
+
+	_, err = entity.FindPenaltyPolicy(ctx, "riverside")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPenalty_ActiveAt(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		penalty  Penalty
+		expected bool
+	}{
+		{name: "should be active before it expires", penalty: Penalty{ExpiresAt: &later}, expected: true},
+		{name: "should be inactive once expired", penalty: Penalty{ExpiresAt: &earlier}, expected: false},
+		{name: "should stay active without an expiry", penalty: Penalty{}, expected: true},
+		{name: "should be inactive once overridden", penalty: Penalty{ExpiresAt: &later, OverriddenAt: &earlier}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.penalty.ActiveAt(now))
+		})
+	}
+}
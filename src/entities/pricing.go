@@ -0,0 +1,13 @@
+package entities
+
+// PriceBreakdown explains how the amount charged for a booking was reached.
+// All amounts are in minor units of Currency.
+type PriceBreakdown struct {
+	Currency      string `json:"currency"`
+	BasePrice     int    `json:"base_price"`
+	Tier          string `json:"tier"`
+	TierDiscount  int    `json:"tier_discount"`
+	PromoCode     string `json:"promo_code,omitempty"`
+	PromoDiscount int    `json:"promo_discount"`
+	Total         int    `json:"total"`
+}
@@ -0,0 +1,129 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+)
+
+var (
+	ErrPromoCodeExpired   = errors.New("promo code has expired")
+	ErrPromoCodeExhausted = errors.New("promo code has reached its usage limit")
+)
+
+type PromoCode struct {
+	Code       string    `json:"code"`
+	PercentOff int       `json:"percent_off"`
+	MaxUses    int       `json:"max_uses,omitempty"`
+	Uses       int       `json:"uses"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Redeemable reports why the code cannot be used at t, or nil if it can.
+func (p PromoCode) Redeemable(t time.Time) error {
+	if !t.Before(p.ExpiresAt) {
+		return ErrPromoCodeExpired
+	}
+	if p.MaxUses > 0 && p.Uses >= p.MaxUses {
+		return ErrPromoCodeExhausted
+	}
+	return nil
+}
+
+// In-memory storage of promo codes
+var PromoCodes []PromoCode
+
+type PromoCodeRepository interface {
+	AddPromoCode(ctx context.Context, p *PromoCode) (*PromoCode, error)
+	FindPromoCode(ctx context.Context, code string) (*PromoCode, error)
+	RedeemPromoCode(ctx context.Context, code string, at time.Time) error
+	ReleasePromoCode(ctx context.Context, code string) error
+}
+
+type PromoCodeEntity struct {
+	PromoCodeRepository
+}
+
+func (e *PromoCodeEntity) AddPromoCode(ctx context.Context, p *PromoCode) (*PromoCode, error) {
+	_, span := telemetry.StartSpan(ctx, "PromoCodeEntity.AddPromoCode")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	if promoCodeByCode(p.Code) != nil {
+		err := errors.New("promo code already exists")
+		telemetry.EndSpan(span, "duplicate", err)
+		return nil, err
+	}
+	PromoCodes = append(PromoCodes, *p)
+	logWrite("promo_codes", PromoCodes, len(PromoCodes)-1)
+	telemetry.EndSpan(span, "stored", nil)
+	return p, nil
+}
+
+func (e *PromoCodeEntity) FindPromoCode(ctx context.Context, code string) (*PromoCode, error) {
+	_, span := telemetry.StartSpan(ctx, "PromoCodeEntity.FindPromoCode")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	p := promoCodeByCode(code)
+	if p == nil {
+		telemetry.EndSpan(span, "not_found", nil)
+		return nil, ErrNotFound
+	}
+	found := *p
+	telemetry.EndSpan(span, "found", nil)
+	return &found, nil
+}
+
+// RedeemPromoCode records one use of the code, checking expiry and the usage
+// limit under the same lock so concurrent bookings cannot exceed it.
+func (e *PromoCodeEntity) RedeemPromoCode(ctx context.Context, code string, at time.Time) error {
+	_, span := telemetry.StartSpan(ctx, "PromoCodeEntity.RedeemPromoCode")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	p := promoCodeByCode(code)
+	if p == nil {
+		telemetry.EndSpan(span, "not_found", ErrNotFound)
+		return ErrNotFound
+	}
+	if err := p.Redeemable(at); err != nil {
+		telemetry.EndSpan(span, "rejected", err)
+		return err
+	}
+	p.Uses++
+	logRecord("promo_codes", PromoCodes, p)
+	telemetry.EndSpan(span, "redeemed", nil)
+	return nil
+}
+
+// ReleasePromoCode gives back a use taken by a booking that was rolled back.
+func (e *PromoCodeEntity) ReleasePromoCode(ctx context.Context, code string) error {
+	_, span := telemetry.StartSpan(ctx, "PromoCodeEntity.ReleasePromoCode")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	p := promoCodeByCode(code)
+	if p == nil {
+		telemetry.EndSpan(span, "not_found", ErrNotFound)
+		return ErrNotFound
+	}
+	if p.Uses > 0 {
+		p.Uses--
+		logRecord("promo_codes", PromoCodes, p)
+	}
+	telemetry.EndSpan(span, "released", nil)
+	return nil
+}
+
+// promoCodeByCode returns a pointer into the storage. Callers must hold storeMu.
+func promoCodeByCode(code string) *PromoCode {
+	for i := range PromoCodes {
+		if PromoCodes[i].Code == code {
+			return &PromoCodes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoCodeEntity_RedeemPromoCode(t *testing.T) {
+	entity := &PromoCodeEntity{}
+	now := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		promo        PromoCode
+		at           time.Time
+		expectedErr  error
+		expectedUses int
+	}{
+		{
+			name:         "should redeem a valid code",
+			promo:        PromoCode{Code: "SPRING", PercentOff: 10, MaxUses: 2, Uses: 1, ExpiresAt: now.AddDate(0, 1, 0)},
+			at:           now,
+			expectedUses: 2,
+		},
+		{
+			name:         "should reject a code that reached its usage limit",
+			promo:        PromoCode{Code: "SPRING", PercentOff: 10, MaxUses: 2, Uses: 2, ExpiresAt: now.AddDate(0, 1, 0)},
+			at:           now,
+			expectedErr:  ErrPromoCodeExhausted,
+			expectedUses: 2,
+		},
+		{
+			name:         "should allow unlimited uses when there is no limit",
+			promo:        PromoCode{Code: "SPRING", PercentOff: 10, Uses: 500, ExpiresAt: now.AddDate(0, 1, 0)},
+			at:           now,
+			expectedUses: 501,
+		},
+		{
+			name:         "should reject an expired code",
+			promo:        PromoCode{Code: "SPRING", PercentOff: 10, ExpiresAt: now},
+			at:           now,
+			expectedErr:  ErrPromoCodeExpired,
+			expectedUses: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			PromoCodes = []PromoCode{tt.promo}
+
+			err := entity.RedeemPromoCode(context.Background(), "SPRING", tt.at)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedUses, PromoCodes[0].Uses)
+		})
+	}
+}
+
+func TestPromoCodeEntity_ReleasePromoCode(t *testing.T) {
+	entity := &PromoCodeEntity{}
+	PromoCodes = []PromoCode{{Code: "SPRING", Uses: 1}}
+
+	assert.NoError(t, entity.ReleasePromoCode(context.Background(), "SPRING"))
+	assert.Equal(t, 0, PromoCodes[0].Uses)
+	assert.ErrorIs(t, entity.ReleasePromoCode(context.Background(), "OTHER"), ErrNotFound)
+}
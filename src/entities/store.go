@@ -0,0 +1,13 @@
+package entities
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned by repositories when a record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// storeMu guards the in-memory storage shared by every repository, so that a
+// change spanning several collections is applied as a whole.
+var storeMu sync.RWMutex
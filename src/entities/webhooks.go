@@ -0,0 +1,217 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Webhook is an endpoint that is sent the events it subscribes to.
+type Webhook struct {
+	ID         int         `json:"id"`
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"event_types"`
+	// Secret signs every delivery. It is only shown when the webhook is
+	// registered.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribes reports whether the webhook wants events of type t.
+func (w Webhook) Subscribes(t EventType) bool {
+	for _, et := range w.EventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed deliveries ran out of attempts. They are only
+	// sent again when redelivered by hand.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookAttempt is one try at sending a delivery.
+type WebhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// WebhookDelivery is an event on its way to one webhook, with the history
+// of every attempt at sending it.
+type WebhookDelivery struct {
+	ID        int                   `json:"id"`
+	WebhookID int                   `json:"webhook_id"`
+	EventID   int                   `json:"event_id"`
+	EventType EventType             `json:"event_type"`
+	Payload   json.RawMessage       `json:"payload"`
+	Status    WebhookDeliveryStatus `json:"status"`
+	Attempts  []WebhookAttempt      `json:"attempts"`
+	// NextAttemptAt is when a pending delivery is sent next.
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// In-memory storage of webhooks and their deliveries
+var (
+	Webhooks          []Webhook
+	WebhookDeliveries []WebhookDelivery
+)
+
+type WebhookRepository interface {
+	AddWebhook(ctx context.Context, w *Webhook) (*Webhook, error)
+	FindWebhook(ctx context.Context, id int) (*Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	// AddWebhookDelivery stores d unless the event has already been queued
+	// for the webhook, in which case the existing delivery is returned.
+	AddWebhookDelivery(ctx context.Context, d *WebhookDelivery) (*WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error
+	FindWebhookDelivery(ctx context.Context, id int) (*WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, webhookID int) ([]WebhookDelivery, error)
+	// DueWebhookDeliveries returns up to limit pending deliveries due at now,
+	// oldest first.
+	DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error)
+}
+
+type WebhookEntity struct {
+	WebhookRepository
+}
+
+func (e *WebhookEntity) AddWebhook(ctx context.Context, w *Webhook) (*Webhook, error) {
+	_, span := telemetry.StartSpan(ctx, "WebhookEntity.AddWebhook")
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	w.ID = len(Webhooks) + 1
+	Webhooks = append(Webhooks, *w)
+	logWrite("webhooks", Webhooks, len(Webhooks)-1)
+	span.SetAttributes(attribute.Int("webhook.id", w.ID))
+	telemetry.EndSpan(span, "stored", nil)
+	return w, nil
+}
+
+func (e *WebhookEntity) FindWebhook(ctx context.Context, id int) (*Webhook, error) {
+	_, span := telemetry.StartSpan(ctx, "WebhookEntity.FindWebhook", attribute.Int("webhook.id", id))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, w := range Webhooks {
+		if w.ID == id {
+			telemetry.EndSpan(span, "found", nil)
+			return &w, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
+
+func (e *WebhookEntity) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	_, span := telemetry.StartSpan(ctx, "WebhookEntity.ListWebhooks")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	webhooks := make([]Webhook, len(Webhooks))
+	copy(webhooks, Webhooks)
+	telemetry.EndSpan(span, "found", nil)
+	return webhooks, nil
+}
+
+func (e *WebhookEntity) AddWebhookDelivery(ctx context.Context, d *WebhookDelivery) (*WebhookDelivery, error) {
+	_, span := telemetry.StartSpan(ctx, "WebhookEntity.AddWebhookDelivery",
+		attribute.Int("webhook.id", d.WebhookID), attribute.Int("event.id", d.EventID))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for _, existing := range WebhookDeliveries {
+		if existing.WebhookID == d.WebhookID && existing.EventID == d.EventID {
+			telemetry.EndSpan(span, "duplicate", nil)
+			return &existing, nil
+		}
+	}
+	d.ID = len(WebhookDeliveries) + 1
+	WebhookDeliveries = append(WebhookDeliveries, *d)
+	logWrite("webhook_deliveries", WebhookDeliveries, len(WebhookDeliveries)-1)
+	telemetry.EndSpan(span, "stored", nil)
+	return d, nil
+}
+
+func (e *WebhookEntity) UpdateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	_, span := telemetry.StartSpan(ctx, "WebhookEntity.UpdateWebhookDelivery", attribute.Int("delivery.id", d.ID))
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for i := range WebhookDeliveries {
+		if WebhookDeliveries[i].ID == d.ID {
+			WebhookDeliveries[i] = *d
+			logWrite("webhook_deliveries", WebhookDeliveries, i)
+			telemetry.EndSpan(span, "stored", nil)
+			return nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", ErrNotFound)
+	return ErrNotFound
+}
+
+func (e *WebhookEntity) FindWebhookDelivery(ctx context.Context, id int) (*WebhookDelivery, error) {
+	_, span := telemetry.StartSpan(ctx, "WebhookEntity.FindWebhookDelivery", attribute.Int("delivery.id", id))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	for _, d := range WebhookDeliveries {
+		if d.ID == id {
+			d.Attempts = append([]WebhookAttempt(nil), d.Attempts...)
+			telemetry.EndSpan(span, "found", nil)
+			return &d, nil
+		}
+	}
+	telemetry.EndSpan(span, "not_found", nil)
+	return nil, ErrNotFound
+}
+
+func (e *WebhookEntity) ListWebhookDeliveries(ctx context.Context, webhookID int) ([]WebhookDelivery, error) {
+	_, span := telemetry.StartSpan(ctx, "WebhookEntity.ListWebhookDeliveries", attribute.Int("webhook.id", webhookID))
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	deliveries := []WebhookDelivery{}
+	for _, d := range WebhookDeliveries {
+		if d.WebhookID == webhookID {
+			d.Attempts = append([]WebhookAttempt(nil), d.Attempts...)
+			deliveries = append(deliveries, d)
+		}
+	}
+	telemetry.EndSpan(span, "found", nil)
+	return deliveries, nil
+}
+
+func (e *WebhookEntity) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error) {
+	_, span := telemetry.StartSpan(ctx, "WebhookEntity.DueWebhookDeliveries")
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	var due []WebhookDelivery
+	for _, d := range WebhookDeliveries {
+		if len(due) == limit {
+			break
+		}
+		if d.Status == WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			d.Attempts = append([]WebhookAttempt(nil), d.Attempts...)
+			due = append(due, d)
+		}
+	}
+	span.SetAttributes(attribute.Int("deliveries.due", len(due)))
+	telemetry.EndSpan(span, "found", nil)
+	return due, nil
+}
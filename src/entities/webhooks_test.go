@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookEntity_AddWebhookDelivery(t *testing.T) {
+	WebhookDeliveries = nil
+	entity := &WebhookEntity{}
+	ctx := context.Background()
+
+	first, err := entity.AddWebhookDelivery(ctx, &WebhookDelivery{WebhookID: 1, EventID: 4, Status: WebhookDeliveryPending})
+	assert.NoError(t, err)
+	duplicate, err := entity.AddWebhookDelivery(ctx, &WebhookDelivery{WebhookID: 1, EventID: 4, Status: WebhookDeliveryPending})
+	assert.NoError(t, err)
+	other, err := entity.AddWebhookDelivery(ctx, &WebhookDelivery{WebhookID: 2, EventID: 4, Status: WebhookDeliveryPending})
+	assert.NoError(t, err)
+
+	assert.Equal(t, first.ID, duplicate.ID)
+	assert.Equal(t, 2, other.ID)
+	assert.Len(t, WebhookDeliveries, 2)
+}
+
+func TestWebhookEntity_DueWebhookDeliveries(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	WebhookDeliveries = []WebhookDelivery{
+		{ID: 1, Status: WebhookDeliverySucceeded, NextAttemptAt: now},
+		{ID: 2, Status: WebhookDeliveryPending, NextAttemptAt: now},
+		{ID: 3, Status: WebhookDeliveryPending, NextAttemptAt: now.Add(time.Minute)},
+		{ID: 4, Status: WebhookDeliveryFailed, NextAttemptAt: now},
+		{ID: 5, Status: WebhookDeliveryPending, NextAttemptAt: now.Add(-time.Minute)},
+	}
+	entity := &WebhookEntity{}
+
+	due, err := entity.DueWebhookDeliveries(context.Background(), now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+	assert.Equal(t, 2, due[0].ID)
+	assert.Equal(t, 5, due[1].ID)
+}
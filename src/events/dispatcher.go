@@ -0,0 +1,273 @@
+// Package events delivers the domain events recorded in the outbox to
+// in-process subscribers and external sinks.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/retry"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Handler receives an event. Events are delivered at least once, so
+// handlers must tolerate duplicates, e.g. by remembering event IDs.
+type Handler func(ctx context.Context, event entities.Event) error
+
+// Sink delivers events to a system outside the process.
+type Sink interface {
+	Deliver(ctx context.Context, event entities.Event) error
+}
+
+type subscriber struct {
+	name    string
+	types   []entities.EventType
+	handler Handler
+}
+
+func (s subscriber) wants(t entities.EventType) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, t)
+}
+
+// awaits reports whether the subscriber is still to acknowledge event.
+func (s subscriber) awaits(event *entities.Event) bool {
+	return s.wants(event.Type) && !slices.Contains(event.DeliveredTo, s.name)
+}
+
+// blockKey identifies a subscriber's stream of the events of a class.
+type blockKey struct {
+	subscriber string
+	classID    int
+}
+
+// Dispatcher delivers pending outbox events to its subscribers. Each
+// subscriber gets the events of a class in the order they were recorded:
+// while an event waits to be retried for a subscriber, later events of the
+// same class wait behind it for that subscriber only.
+type Dispatcher struct {
+	entities.EventRepository
+	Clock clock.Clock
+	// Backoff spaces out the deliveries of an event. Once its attempts are
+	// used up, the event is marked failed and the events behind it move on.
+	retry.Backoff
+	// BatchSize caps the events tried per Dispatch. Events that only wait
+	// behind a retry do not count towards it.
+	BatchSize int
+
+	mu          sync.Mutex
+	subscribers []subscriber
+	// dispatching serialises Dispatch so that events are never delivered
+	// out of order by two overlapping calls.
+	dispatching sync.Mutex
+}
+
+func NewDispatcher(repo entities.EventRepository, clk clock.Clock) *Dispatcher {
+	return &Dispatcher{
+		EventRepository: repo,
+		Clock:           clk,
+		Backoff:         retry.Backoff{MaxAttempts: 10, RetryDelay: 5 * time.Second},
+		BatchSize:       100,
+	}
+}
+
+// Subscribe registers handler under name for events of the given types, or
+// of every type when none are given. Names identify subscribers across
+// retries and must be unique.
+func (d *Dispatcher) Subscribe(name string, handler Handler, types ...entities.EventType) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers = append(d.subscribers, subscriber{name: name, types: types, handler: handler})
+}
+
+// AddSink subscribes an external sink under name.
+func (d *Dispatcher) AddSink(name string, sink Sink, types ...entities.EventType) {
+	d.Subscribe(name, sink.Deliver, types...)
+}
+
+// Dispatch delivers the pending events that are due and returns how many
+// were fully delivered.
+func (d *Dispatcher) Dispatch(ctx context.Context) (delivered int, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "Dispatcher.Dispatch")
+	outcome := "dispatched"
+	defer func() { telemetry.EndSpan(span, outcome, err) }()
+
+	d.dispatching.Lock()
+	defer d.dispatching.Unlock()
+
+	d.mu.Lock()
+	subscribers := slices.Clone(d.subscribers)
+	d.mu.Unlock()
+
+	// Subscribers with an event of a class waiting to be retried
+	blocked := map[blockKey]bool{}
+	// Pages are read until the batch is full, so that events waiting behind
+	// retries do not crowd out the rest
+	tried, after := 0, 0
+	for tried < d.BatchSize {
+		pending, err := d.PendingEvents(ctx, after, d.BatchSize)
+		if err != nil {
+			outcome = "error"
+			return delivered, err
+		}
+		for _, event := range pending {
+			if tried == d.BatchSize {
+				break
+			}
+			after = event.ID
+			if err := ctx.Err(); err != nil {
+				outcome = "error"
+				return delivered, err
+			}
+			if event.NextAttemptAt.After(d.Clock.Now()) {
+				for _, s := range subscribers {
+					if s.awaits(&event) {
+						blocked[blockKey{s.name, event.ClassID}] = true
+					}
+				}
+				continue
+			}
+
+			if !d.deliver(ctx, &event, subscribers, blocked) {
+				continue
+			}
+			tried++
+			if event.Status == entities.EventDelivered {
+				delivered++
+			}
+			if err := d.UpdateEvent(context.WithoutCancel(ctx), &event); err != nil {
+				outcome = "error"
+				return delivered, err
+			}
+		}
+		if len(pending) < d.BatchSize {
+			break
+		}
+	}
+	span.SetAttributes(attribute.Int("events.delivered", delivered))
+	return delivered, nil
+}
+
+// deliver hands event to the subscribers that have not acknowledged it yet
+// and are not blocked on an earlier event of its class, and updates its
+// delivery state. Subscribers that fail are blocked in turn. It reports
+// whether the event changed, which it does not when every subscriber left
+// is blocked.
+func (d *Dispatcher) deliver(ctx context.Context, event *entities.Event, subscribers []subscriber, blocked map[blockKey]bool) bool {
+	logger := utils.Logger(ctx).With("event_id", event.ID, "type", event.Type, "class_id", event.ClassID)
+	var errs []error
+	var failed []blockKey
+	tried, waiting := false, false
+	for _, s := range subscribers {
+		if !s.awaits(event) {
+			continue
+		}
+		key := blockKey{s.name, event.ClassID}
+		if blocked[key] {
+			waiting = true
+			continue
+		}
+		tried = true
+		if err := call(ctx, s.handler, *event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			failed = append(failed, key)
+			blocked[key] = true
+			continue
+		}
+		event.DeliveredTo = append(event.DeliveredTo, s.name)
+	}
+
+	now := d.Clock.Now()
+	if len(errs) == 0 {
+		if waiting {
+			// The rest get it once the retries ahead of it are done
+			return tried
+		}
+		event.Attempts++
+		event.Status = entities.EventDelivered
+		event.DeliveredAt = &now
+		event.LastError = ""
+		return true
+	}
+
+	event.Attempts++
+	event.LastError = errors.Join(errs...).Error()
+	retryAt, ok := d.RetryAt(now, event.Attempts)
+	if !ok {
+		event.Status = entities.EventFailed
+		for _, key := range failed {
+			delete(blocked, key)
+		}
+		logger.Error("event delivery failed", "attempts", event.Attempts, "error", event.LastError)
+		return true
+	}
+	event.NextAttemptAt = retryAt
+	logger.Warn("event delivery failed, retrying", "error", event.LastError, "retry_at", event.NextAttemptAt)
+	return true
+}
+
+// call runs handler, turning a panic into an error.
+func call(ctx context.Context, handler Handler, event entities.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber panicked: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
+
+// DispatchJob is the scheduler handler that runs Dispatch.
+func (d *Dispatcher) DispatchJob(ctx context.Context, _ entities.Job) error {
+	_, err := d.Dispatch(ctx)
+	return err
+}
+
+// Message is the form events take outside the process.
+type Message struct {
+	ID         int                `json:"id"`
+	Type       entities.EventType `json:"type"`
+	ClassID    int                `json:"class_id"`
+	OccurredAt time.Time          `json:"occurred_at"`
+	Payload    json.RawMessage    `json:"payload"`
+}
+
+func NewMessage(event entities.Event) Message {
+	return Message{
+		ID:         event.ID,
+		Type:       event.Type,
+		ClassID:    event.ClassID,
+		OccurredAt: event.OccurredAt,
+		Payload:    event.Payload,
+	}
+}
+
+// WriterSink writes each event as a line of JSON, e.g. to stdout for a log
+// shipper to pick up.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Deliver(ctx context.Context, event entities.Event) error {
+	line, err := json.Marshal(NewMessage(event))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+
+// newTestDispatcher seeds the outbox with pending events for the given
+// classes, in order.
+func newTestDispatcher(classIDs ...int) (*Dispatcher, *clock.Fake) {
+	entities.Outbox = nil
+	for i, classID := range classIDs {
+		entities.Outbox = append(entities.Outbox, entities.Event{
+			ID:         i + 1,
+			Type:       entities.EventBookingCreated,
+			ClassID:    classID,
+			Payload:    json.RawMessage(`{}`),
+			OccurredAt: testNow,
+			Status:     entities.EventPending,
+		})
+	}
+	clk := clock.NewFake(testNow)
+	return NewDispatcher(&entities.EventEntity{}, clk), clk
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	d, _ := newTestDispatcher(1, 2, 1)
+	var received []int
+	d.Subscribe("audit", func(ctx context.Context, event entities.Event) error {
+		received = append(received, event.ID)
+		return nil
+	})
+	var cancelled int
+	d.Subscribe("cancellations", func(ctx context.Context, event entities.Event) error {
+		cancelled++
+		return nil
+	}, entities.EventBookingCancelled)
+
+	delivered, err := d.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+	assert.Equal(t, []int{1, 2, 3}, received)
+	assert.Equal(t, 0, cancelled)
+	assert.Equal(t, entities.EventDelivered, entities.Outbox[0].Status)
+	assert.Equal(t, testNow, *entities.Outbox[0].DeliveredAt)
+
+	delivered, err = d.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, received, 3)
+}
+
+func TestDispatcher_Dispatch_RetriesInOrderPerClass(t *testing.T) {
+	d, clk := newTestDispatcher(1, 2, 1)
+	var audited, mirrored []int
+	d.Subscribe("audit", func(ctx context.Context, event entities.Event) error {
+		audited = append(audited, event.ID)
+		return nil
+	})
+	failing := true
+	d.Subscribe("mirror", func(ctx context.Context, event entities.Event) error {
+		if event.ID == 1 && failing {
+			return errors.New("mirror unavailable")
+		}
+		mirrored = append(mirrored, event.ID)
+		return nil
+	})
+
+	delivered, err := d.Dispatch(context.Background())
+	assert.NoError(t, err)
+	// Class 1 waits behind its failed event for the mirror only; class 2
+	// carries on
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []int{1, 2, 3}, audited)
+	assert.Equal(t, []int{2}, mirrored)
+	first := entities.Outbox[0]
+	assert.Equal(t, entities.EventPending, first.Status)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, "mirror: mirror unavailable", first.LastError)
+	assert.Equal(t, []string{"audit"}, first.DeliveredTo)
+	assert.Equal(t, testNow.Add(d.RetryDelay), first.NextAttemptAt)
+	third := entities.Outbox[2]
+	assert.Equal(t, entities.EventPending, third.Status)
+	assert.Equal(t, 0, third.Attempts, "waiting is not a failed attempt")
+	assert.Equal(t, []string{"audit"}, third.DeliveredTo)
+
+	// Not due for a retry yet
+	delivered, _ = d.Dispatch(context.Background())
+	assert.Equal(t, 0, delivered)
+
+	failing = false
+	clk.Advance(d.RetryDelay)
+	delivered, err = d.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	// The subscriber that already had event 1 does not get it again
+	assert.Equal(t, []int{1, 2, 3}, audited)
+	assert.Equal(t, []int{2, 1, 3}, mirrored)
+}
+
+func TestDispatcher_Dispatch_FillsBatchPastBlockedEvents(t *testing.T) {
+	d, _ := newTestDispatcher(1, 1, 1, 2)
+	d.BatchSize = 2
+	var received []int
+	d.Subscribe("mirror", func(ctx context.Context, event entities.Event) error {
+		if event.ClassID == 1 {
+			return errors.New("mirror unavailable")
+		}
+		received = append(received, event.ID)
+		return nil
+	})
+
+	delivered, err := d.Dispatch(context.Background())
+	assert.NoError(t, err)
+	// Events 2 and 3 wait behind event 1 without taking up the batch
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []int{4}, received)
+	assert.Equal(t, 1, entities.Outbox[0].Attempts)
+	assert.Equal(t, 0, entities.Outbox[1].Attempts)
+}
+
+func TestDispatcher_Dispatch_GivesUpAfterMaxAttempts(t *testing.T) {
+	d, clk := newTestDispatcher(1, 1)
+	d.MaxAttempts = 2
+	var received []int
+	d.Subscribe("mirror", func(ctx context.Context, event entities.Event) error {
+		if event.ID == 1 {
+			panic("bad payload")
+		}
+		received = append(received, event.ID)
+		return nil
+	})
+
+	_, _ = d.Dispatch(context.Background())
+	clk.Advance(d.RetryDelay)
+	delivered, err := d.Dispatch(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []int{2}, received)
+	assert.Equal(t, entities.EventFailed, entities.Outbox[0].Status)
+	assert.Equal(t, "mirror: subscriber panicked: bad payload", entities.Outbox[0].LastError)
+}
+
+func TestWriterSink_Deliver(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	err := sink.Deliver(context.Background(), entities.Event{
+		ID:         4,
+		Type:       entities.EventClassCreated,
+		ClassID:    2,
+		Payload:    json.RawMessage(`{"class":{"id":2}}`),
+		OccurredAt: testNow,
+		Status:     entities.EventPending,
+		Attempts:   3,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":4,"type":"class.created","class_id":2,"occurred_at":"2025-05-20T12:00:00Z","payload":{"class":{"id":2}}}`+"\n", buf.String())
+}
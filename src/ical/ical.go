@@ -0,0 +1,153 @@
+// Package ical writes iCalendar (RFC 5545) feeds that calendar apps can
+// subscribe to.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of iCalendar feeds.
+const ContentType = "text/calendar; charset=utf-8"
+
+// prodID identifies the product that wrote a feed.
+const prodID = "-//Glofox//Glofox API//EN"
+
+// maxLineOctets is the length lines are folded at.
+const maxLineOctets = 75
+
+type Status string
+
+const (
+	StatusConfirmed Status = "CONFIRMED"
+	StatusCancelled Status = "CANCELLED"
+)
+
+// Calendar is a feed of events.
+type Calendar struct {
+	// Name is shown by calendar apps that support X-WR-CALNAME.
+	Name string
+	// RefreshInterval suggests how often subscribers fetch the feed again.
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is a VEVENT. Calendar apps match events across fetches by UID, and
+// apply a changed event only when its Sequence has gone up.
+type Event struct {
+	UID         string
+	Sequence    int
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Status      Status
+}
+
+// WriteTo writes the calendar in iCalendar format.
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	cw := &writer{w: bufio.NewWriter(w)}
+	cw.line("BEGIN", "VCALENDAR")
+	cw.line("VERSION", "2.0")
+	cw.line("PRODID", prodID)
+	cw.line("CALSCALE", "GREGORIAN")
+	cw.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		cw.line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		cw.line("REFRESH-INTERVAL;VALUE=DURATION", duration(c.RefreshInterval))
+		cw.line("X-PUBLISHED-TTL", duration(c.RefreshInterval))
+	}
+	for _, e := range c.Events {
+		cw.line("BEGIN", "VEVENT")
+		cw.line("UID", escape(e.UID))
+		cw.line("SEQUENCE", fmt.Sprint(e.Sequence))
+		cw.line("DTSTAMP", timestamp(e.Stamp))
+		cw.line("DTSTART", timestamp(e.Start))
+		cw.line("DTEND", timestamp(e.End))
+		cw.line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			cw.line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			cw.line("LOCATION", escape(e.Location))
+		}
+		if e.Status != "" {
+			cw.line("STATUS", string(e.Status))
+		}
+		cw.line("END", "VEVENT")
+	}
+	cw.line("END", "VCALENDAR")
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// writer writes content lines, keeping the first error.
+type writer struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// line writes "name:value", folded so that no line is longer than
+// maxLineOctets. Folds never split a UTF-8 sequence.
+func (cw *writer) line(name, value string) {
+	if cw.err != nil {
+		return
+	}
+	s := name + ":" + value
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		cw.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines start with the space
+		limit = maxLineOctets - 1
+	}
+	cw.write(s + "\r\n")
+}
+
+func (cw *writer) write(s string) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.WriteString(s)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// duration formats d as a DURATION value in whole minutes, e.g. PT1H30M.
+func duration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	s := "PT"
+	if h := d / time.Hour; h > 0 {
+		s += fmt.Sprintf("%dH", h)
+	}
+	if m := (d % time.Hour) / time.Minute; m > 0 || s == "PT" {
+		s += fmt.Sprintf("%dM", m)
+	}
+	return s
+}
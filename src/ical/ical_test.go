@@ -0,0 +1,77 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_WriteTo(t *testing.T) {
+	start := time.Date(2025, 5, 20, 9, 0, 0, 0, time.FixedZone("IST", 3600))
+	cal := &Calendar{
+		Name:            "Timetable",
+		RefreshInterval: 90 * time.Minute,
+		Events: []Event{{
+			UID:         "class-1-20250520@glofox",
+			Sequence:    1,
+			Stamp:       time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),
+			Start:       start,
+			End:         start.Add(time.Hour),
+			Summary:     "Yoga; Beginners, all levels",
+			Description: "Bring a mat\nand water",
+			Location:    "main",
+			Status:      StatusCancelled,
+		}},
+	}
+
+	var b strings.Builder
+	n, err := cal.WriteTo(&b)
+	require.NoError(t, err)
+	assert.Equal(t, int64(b.Len()), n)
+	assert.Equal(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Glofox//Glofox API//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Timetable",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H30M",
+		"X-PUBLISHED-TTL:PT1H30M",
+		"BEGIN:VEVENT",
+		"UID:class-1-20250520@glofox",
+		"SEQUENCE:1",
+		"DTSTAMP:20250501T120000Z",
+		"DTSTART:20250520T080000Z",
+		"DTEND:20250520T090000Z",
+		`SUMMARY:Yoga\; Beginners\, all levels`,
+		`DESCRIPTION:Bring a mat\nand water`,
+		"LOCATION:main",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"), b.String())
+}
+
+func TestCalendar_WriteTo_FoldsLongLines(t *testing.T) {
+	summary := strings.Repeat("é", 100)
+	cal := &Calendar{Events: []Event{{UID: "1", Summary: summary}}}
+
+	var b strings.Builder
+	_, err := cal.WriteTo(&b)
+	require.NoError(t, err)
+
+	var unfolded string
+	for _, line := range strings.Split(b.String(), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		if strings.HasPrefix(line, " ") {
+			unfolded += line[1:]
+		} else if strings.HasPrefix(line, "SUMMARY:") {
+			unfolded = line
+		}
+	}
+	assert.Equal(t, "SUMMARY:"+summary, unfolded)
+}
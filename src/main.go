@@ -1,30 +1,16 @@
 package main
 
 import (
-	"fmt"
-	"github.com/Vidyuallatha/glofox/src/controllers"
-	"log"
-	"net/http"
+	"context"
+	"github.com/Vidyuallatha/glofox/src/cli"
+	"os"
 )
 
 func main() {
-	port := ":9000"
-	serverURL := "http://localhost" + port
-	fmt.Println("==============================GLOFOX==============================")
-	fmt.Println("Server listening on", serverURL)
-
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain")
-		w.WriteHeader(http.StatusOK)
-		_, err := w.Write([]byte("Welcome to Glofox!"))
-		if err != nil {
-			return
-		}
-	})
-
-	http.HandleFunc("/classes", controllers.HandleClasses)
-	http.HandleFunc("/bookings", controllers.HandleBookings)
-
-	log.Println("Server running at", serverURL)
-	log.Fatal(http.ListenAndServe(port, nil))
+	// Without arguments the binary runs the server, as it always has
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	os.Exit(cli.Run(context.Background(), cli.DefaultEnv(), args))
 }
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+func init() {
+	// Event streams, calendar feeds and import and export files are
+	// documented as plain text or binary. Import rows are checked one by one
+	// by the import itself.
+	openapi3filter.RegisterBodyDecoder("text/event-stream", openapi3filter.PlainBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/calendar", openapi3filter.PlainBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/csv", openapi3filter.PlainBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.PlainBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", openapi3filter.FileBodyDecoder)
+}
+
+// OpenAPIValidator checks requests, and optionally responses, against an
+// OpenAPI document.
+type OpenAPIValidator struct {
+	router            routers.Router
+	validateResponses bool
+}
+
+// NewOpenAPIValidator loads and validates the given spec. Response
+// validation is meant for tests: responses are buffered and any response
+// that breaks the spec is replaced by a 500.
+func NewOpenAPIValidator(spec []byte, validateResponses bool) (*OpenAPIValidator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("loading openapi spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	// Match routes on path only so the validator works on any host and port
+	doc.Servers = nil
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAPIValidator{router: router, validateResponses: validateResponses}, nil
+}
+
+func (v *OpenAPIValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			// Undocumented routes and methods are left to the mux
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, nil, validationErrors(err))
+			return
+		}
+
+		if !v.validateResponses || streams(route) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Headers set further out, such as X-Request-ID, stay visible to the
+		// handler, which echoes the request ID in error bodies
+		buf := &bufferedResponse{header: w.Header().Clone()}
+		next.ServeHTTP(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+
+		respInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 buf.status,
+			Header:                 buf.header,
+			Body:                   io.NopCloser(bytes.NewReader(buf.body.Bytes())),
+			Options: &openapi3filter.Options{
+				MultiError:            true,
+				IncludeResponseStatus: true,
+			},
+		}
+		if err := openapi3filter.ValidateResponse(r.Context(), respInput); err != nil {
+			utils.Logger(r.Context()).Error("response does not match openapi spec", "error", err)
+			utils.WriteJSON(w, http.StatusInternalServerError, nil,
+				append([]error{errors.New("response does not match openapi spec")}, validationErrors(err)...))
+			return
+		}
+
+		for k, vals := range buf.header {
+			w.Header()[k] = vals
+		}
+		w.WriteHeader(buf.status)
+		_, _ = w.Write(buf.body.Bytes())
+	})
+}
+
+// streams reports whether the route answers with an event stream or is
+// marked x-streamed, as exports are. Those responses are not buffered until
+// they end.
+func streams(route *routers.Route) bool {
+	if streamed, _ := route.Operation.Extensions["x-streamed"].(bool); streamed {
+		return true
+	}
+	for _, resp := range route.Operation.Responses.Map() {
+		if resp.Value != nil && resp.Value.Content.Get("text/event-stream") != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validationErrors flattens kin-openapi errors into one error per violation.
+func validationErrors(err error) []error {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		var errs []error
+		for _, e := range multi {
+			errs = append(errs, validationErrors(e)...)
+		}
+		return errs
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if nested, ok := schemaErr.Origin.(openapi3.MultiError); ok {
+			return validationErrors(nested)
+		}
+		reason := schemaErr.Reason
+		if schemaErr.SchemaField == "format" && schemaErr.Schema != nil {
+			// The default reason embeds the whole format regex
+			reason = "must be a valid " + schemaErr.Schema.Format
+		}
+		field := strings.Join(schemaErr.JSONPointer(), ".")
+		if field == "" {
+			return []error{errors.New(reason)}
+		}
+		return []error{fmt.Errorf("%s: %s", field, reason)}
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) && reqErr.Err != nil {
+		return validationErrors(reqErr.Err)
+	}
+	var respErr *openapi3filter.ResponseError
+	if errors.As(err, &respErr) && respErr.Err != nil {
+		return validationErrors(respErr.Err)
+	}
+	return []error{err}
+}
+
+// bufferedResponse holds a response until it has been validated.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.status == 0 {
+		b.status = code
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vidyuallatha/glofox"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIValidator_Requests(t *testing.T) {
+	validator, err := NewOpenAPIValidator(glofox.OpenAPISpec, false)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+		expectedErrors []string
+	}{
+		{
+			name:           "should pass a valid class request through",
+			path:           "/classes",
+			body:           `{"class_name":"Yoga","start_date":"2025-05-03T10:00:00Z","end_date":"2025-05-03T11:00:00Z","capacity":20}`,
+			expectedStatus: http.StatusTeapot,
+		},
+		{
+			name:           "should reject a class request missing required fields",
+			path:           "/classes",
+			body:           `{"class_name":"Yoga","start_date":"2025-05-03T10:00:00Z","end_date":"2025-05-03T11:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: []string{`capacity: property \"capacity\" is missing`},
+		},
+		{
+			name:           "should reject a booking with a date that is not a date-time",
+			path:           "/bookings",
+			body:           `{"name":"John Doe","date":"2025-05-03"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: []string{"date: must be a valid date-time"},
+		},
+		{
+			name:           "should leave undocumented routes to the mux",
+			path:           "/unknown",
+			body:           `not json`,
+			expectedStatus: http.StatusTeapot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			for _, e := range tt.expectedErrors {
+				assert.Contains(t, rec.Body.String(), e)
+			}
+		})
+	}
+}
+
+func TestOpenAPIValidator_Responses(t *testing.T) {
+	validator, err := NewOpenAPIValidator(glofox.OpenAPISpec, true)
+	assert.NoError(t, err)
+
+	handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A 201 without the created class breaks the spec
+		utils.WriteJSON(w, http.StatusCreated, nil, nil)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(`{"name":"John Doe","date":"2025-05-03T10:00:00Z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "response does not match openapi spec")
+}
+
+func TestOpenAPIValidator_Responses_KeepHeaders(t *testing.T) {
+	validator, err := NewOpenAPIValidator(glofox.OpenAPISpec, true)
+	assert.NoError(t, err)
+
+	handler := RequestID(validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteJSON(w, http.StatusNotFound, nil, []error{errors.New("booking not found")})
+	})))
+
+	req := httptest.NewRequest(http.MethodDelete, "/bookings/1", nil)
+	req.Header.Set(utils.RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "req-123", rec.Header().Get(utils.RequestIDHeader))
+	assert.Contains(t, rec.Body.String(), `"request_id":"req-123"`)
+}
+
+func TestOpenAPIValidator_Responses_StreamsPassThrough(t *testing.T) {
+	validator, err := NewOpenAPIValidator(glofox.OpenAPISpec, true)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name, path, contentType, body string
+	}{
+		{name: "event stream", path: "/classes/1/availability/stream", contentType: "text/event-stream", body: "data: {}\n\n"},
+		{name: "export", path: "/exports/bookings", contentType: "text/csv", body: "id,name\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flushed := false
+			handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+				// Streamed responses must reach the client as they are written
+				flushed = http.NewResponseController(w).Flush() == nil
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.True(t, flushed)
+			assert.Equal(t, tt.body, rec.Body.String())
+		})
+	}
+}
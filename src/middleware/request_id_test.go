@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{
+			name:     "should reuse the request ID sent by the client",
+			incoming: "client-request_id.42",
+			reused:   true,
+		},
+		{
+			name:     "should generate a request ID when none is sent",
+			incoming: "",
+		},
+		{
+			name:     "should replace a request ID longer than 128 characters",
+			incoming: strings.Repeat("a", 129),
+		},
+		{
+			name:     "should replace a request ID with other characters",
+			incoming: "id\" request_id=forged",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = utils.RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(utils.RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(utils.RequestIDHeader)
+			assert.NotEmpty(t, echoed)
+			assert.Equal(t, echoed, fromContext)
+			if tt.reused {
+				assert.Equal(t, tt.incoming, echoed)
+			} else {
+				assert.NotEqual(t, tt.incoming, echoed)
+				assert.Len(t, echoed, 32)
+			}
+		})
+	}
+}
+
+func TestAccessLog_RecordsStatusAndBytes(t *testing.T) {
+	rec := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+
+	rec.WriteHeader(http.StatusCreated)
+	_, err := rec.Write([]byte("hello"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.status)
+	assert.Equal(t, 5, rec.bytes)
+}
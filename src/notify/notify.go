@@ -0,0 +1,67 @@
+// Package notify sends notifications to members over email and SMS. Email
+// goes out over SMTP; SMS has no gateway yet and is only written out by a
+// WriterNotifier, for development.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+)
+
+// Message is a rendered notification ready to send.
+type Message struct {
+	Channel entities.NotificationChannel
+	// To is an email address or phone number, depending on Channel.
+	To string
+	// Subject and HTML are only used for email.
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Notifier delivers messages on one or more channels.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// WriterNotifier writes messages to w in a readable form instead of sending
+// them. It stands in for real channels in development.
+type WriterNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+// NewFileNotifier appends messages to the file at path, creating it if
+// needed.
+func NewFileNotifier(path string) (*WriterNotifier, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterNotifier(f), nil
+}
+
+func (n *WriterNotifier) Send(ctx context.Context, msg Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s to %s\n", msg.Channel, msg.To)
+	if msg.Subject != "" {
+		fmt.Fprintf(&b, "Subject: %s\n\n", msg.Subject)
+	}
+	b.WriteString(strings.TrimRight(msg.Text, "\n"))
+	b.WriteString("\n\n")
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err := io.WriteString(n.w, b.String())
+	return err
+}
@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts mail on a local port and keeps what it receives.
+type fakeSMTPServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	from     []string
+	to       []string
+	messages []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTPServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250-localhost\r\n250 8BITMIME")
+		case "MAIL":
+			s.record(&s.from, arg)
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.record(&s.to, arg)
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			s.record(&s.messages, string(data))
+			_ = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) record(into *[]string, v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*into = append(*into, v)
+}
+
+func TestSMTPNotifier_Send(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	n := NewSMTPNotifier(server.ln.Addr().String(), "studio@example.com", "", "")
+
+	err := n.Send(context.Background(), Message{
+		Channel: entities.ChannelEmail,
+		To:      "jane@example.com",
+		Subject: "Booking confirmed: Yoga",
+		Text:    "You're booked in.",
+		HTML:    "<p>You're booked in.</p>",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, server.from, 1)
+	assert.True(t, strings.HasPrefix(server.from[0], "FROM:<studio@example.com>"))
+	assert.Equal(t, []string{"TO:<jane@example.com>"}, server.to)
+	msg, err := mail.ReadMessage(strings.NewReader(server.messages[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "Booking confirmed: Yoga", decodeHeader(t, msg.Header.Get("Subject")))
+	assert.Equal(t, "jane@example.com", msg.Header.Get("To"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, _ := io.ReadAll(part)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8: You're booked in.",
+		"text/html; charset=utf-8: <p>You're booked in.</p>",
+	}, bodies)
+}
+
+func TestSMTPNotifier_Send_RejectsSMS(t *testing.T) {
+	n := NewSMTPNotifier("127.0.0.1:1", "studio@example.com", "", "")
+	err := n.Send(context.Background(), Message{Channel: entities.ChannelSMS, To: "+353861234567", Text: "hi"})
+	assert.EqualError(t, err, "smtp: cannot send sms messages")
+}
+
+func decodeHeader(t *testing.T, v string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(v)
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestWriterNotifier_Send(t *testing.T) {
+	var b strings.Builder
+	n := NewWriterNotifier(&b)
+
+	assert.NoError(t, n.Send(context.Background(), Message{Channel: entities.ChannelEmail, To: "jane@example.com", Subject: "Hi", Text: "Hello\n"}))
+	assert.NoError(t, n.Send(context.Background(), Message{Channel: entities.ChannelSMS, To: "+353861234567", Text: "Hello"}))
+
+	assert.Equal(t, "--- email to jane@example.com\nSubject: Hi\n\nHello\n\n--- sms to +353861234567\nHello\n\n", b.String())
+}
+
+func TestTemplates_Render(t *testing.T) {
+	templates, err := LoadTemplates()
+	require.NoError(t, err)
+	start := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	data := TemplateData{
+		Member:  entities.Member{Name: "Jane <3", Email: "jane@example.com", Phone: "+353861234567"},
+		Booking: entities.Booking{ID: 7, CreditRefunded: true},
+		Class:   entities.Class{ClassName: "Yoga"},
+		Start:   start,
+		End:     start.Add(time.Hour),
+	}
+
+	email, err := templates.Render(entities.NotificationBookingConfirmed, entities.ChannelEmail, data)
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", email.To)
+	assert.Equal(t, "Booking confirmed: Yoga on Tuesday 20 May", email.Subject)
+	assert.Contains(t, email.Text, "You're booked into Yoga on Tuesday 20 May from 09:00 to 10:00.")
+	// HTML bodies are escaped
+	assert.Contains(t, email.HTML, "<p>Hi Jane &lt;3,</p>")
+
+	sms, err := templates.Render(entities.NotificationBookingCancelled, entities.ChannelSMS, data)
+	require.NoError(t, err)
+	assert.Equal(t, Message{
+		Channel: entities.ChannelSMS,
+		To:      "+353861234567",
+		Text:    "Cancelled: Yoga, Tuesday 20 May 09:00. Booking #7.",
+	}, sms)
+
+	cancelled, err := templates.Render(entities.NotificationBookingCancelled, entities.ChannelEmail, data)
+	require.NoError(t, err)
+	assert.Contains(t, cancelled.Text, "Your class pack credit has been refunded.")
+
+	for _, kind := range []entities.NotificationKind{entities.NotificationBookingConfirmed, entities.NotificationBookingCancelled, entities.NotificationClassReminder, entities.NotificationClassCancelled, entities.NotificationWaitlistPromoted} {
+		for _, channel := range []entities.NotificationChannel{entities.ChannelEmail, entities.ChannelSMS} {
+			msg, err := templates.Render(kind, channel, data)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, msg.Text, "%s over %s", kind, channel)
+		}
+	}
+
+	_, err = templates.Render("class_changed", entities.ChannelEmail, data)
+	assert.EqualError(t, err, "no template for class_changed notifications")
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+)
+
+// templateFS holds two files per notification kind: <kind>.txt defines the
+// "subject", "text" and "sms" templates, and <kind>.html is the HTML email
+// body.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// TemplateData is what notification templates are rendered with.
+type TemplateData struct {
+	Member  entities.Member
+	Booking entities.Booking
+	Class   entities.Class
+	// Start and End bound the booked session.
+	Start time.Time
+	End   time.Time
+}
+
+var funcs = map[string]any{
+	"day":  func(t time.Time) string { return t.Format("Monday 2 January") },
+	"time": func(t time.Time) string { return t.Format("15:04") },
+}
+
+// Templates renders notifications from the embedded templates.
+type Templates struct {
+	text map[entities.NotificationKind]*template.Template
+	html map[entities.NotificationKind]*htmltemplate.Template
+}
+
+func LoadTemplates() (*Templates, error) {
+	t := &Templates{
+		text: map[entities.NotificationKind]*template.Template{},
+		html: map[entities.NotificationKind]*htmltemplate.Template{},
+	}
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		src, err := templateFS.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		name, ext, _ := strings.Cut(entry.Name(), ".")
+		kind := entities.NotificationKind(name)
+		switch ext {
+		case "txt":
+			t.text[kind], err = template.New(name).Funcs(funcs).Parse(string(src))
+		case "html":
+			t.html[kind], err = htmltemplate.New(name).Funcs(funcs).Parse(string(src))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", entry.Name(), err)
+		}
+	}
+	return t, nil
+}
+
+// Render builds the message of kind for channel, addressed to data.Member.
+func (t *Templates) Render(kind entities.NotificationKind, channel entities.NotificationChannel, data TemplateData) (Message, error) {
+	msg := Message{Channel: channel, To: data.Member.Address(channel)}
+	text, ok := t.text[kind]
+	if !ok {
+		return msg, fmt.Errorf("no template for %s notifications", kind)
+	}
+	render := func(name string) (string, error) {
+		var b bytes.Buffer
+		err := text.ExecuteTemplate(&b, name, data)
+		return strings.TrimSpace(b.String()), err
+	}
+
+	var err error
+	if channel == entities.ChannelSMS {
+		msg.Text, err = render("sms")
+		return msg, err
+	}
+	if msg.Subject, err = render("subject"); err != nil {
+		return msg, err
+	}
+	if msg.Text, err = render("text"); err != nil {
+		return msg, err
+	}
+	if html, ok := t.html[kind]; ok {
+		var b bytes.Buffer
+		if err := html.Execute(&b, data); err != nil {
+			return msg, err
+		}
+		msg.HTML = b.String()
+	}
+	return msg, nil
+}
@@ -0,0 +1,110 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrDeclined             = errors.New("payment declined")
+	ErrUnknownAuthorization = errors.New("unknown authorization")
+)
+
+type AuthorizationStatus string
+
+const (
+	StatusAuthorized AuthorizationStatus = "authorized"
+	StatusCaptured   AuthorizationStatus = "captured"
+	StatusRefunded   AuthorizationStatus = "refunded"
+)
+
+type Authorization struct {
+	ID        string
+	Amount    int
+	Currency  string
+	Reference string
+	Status    AuthorizationStatus
+}
+
+// FakeGateway is an in-process gateway for local development and tests. It
+// approves every payment unless told to decline.
+type FakeGateway struct {
+	// DeclineAuthorize and DeclineCapture make the matching call fail.
+	DeclineAuthorize bool
+	DeclineCapture   bool
+
+	mu             sync.Mutex
+	authorizations map[string]*Authorization
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{authorizations: map[string]*Authorization{}}
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, amount int, currency, reference string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.DeclineAuthorize {
+		return "", ErrDeclined
+	}
+	if g.authorizations == nil {
+		g.authorizations = map[string]*Authorization{}
+	}
+	id := fmt.Sprintf("auth_%d", len(g.authorizations)+1)
+	g.authorizations[id] = &Authorization{
+		ID:        id,
+		Amount:    amount,
+		Currency:  currency,
+		Reference: reference,
+		Status:    StatusAuthorized,
+	}
+	return id, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, authorizationID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationID]
+	if !ok {
+		return ErrUnknownAuthorization
+	}
+	if g.DeclineCapture {
+		return ErrDeclined
+	}
+	if auth.Status != StatusAuthorized {
+		return fmt.Errorf("cannot capture %s authorization", auth.Status)
+	}
+	auth.Status = StatusCaptured
+	return nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, authorizationID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationID]
+	if !ok {
+		return ErrUnknownAuthorization
+	}
+	if auth.Status == StatusRefunded {
+		return errors.New("authorization already refunded")
+	}
+	auth.Status = StatusRefunded
+	return nil
+}
+
+// Authorization returns a copy of the authorization with the given ID.
+func (g *FakeGateway) Authorization(id string) (Authorization, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[id]
+	if !ok {
+		return Authorization{}, false
+	}
+	return *auth, true
+}
@@ -0,0 +1,110 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPGateway talks to a payment provider over a small JSON API:
+//
+//	POST {BaseURL}/authorizations              {"amount", "currency", "reference"} -> {"id"}
+//	POST {BaseURL}/authorizations/{id}/capture
+//	POST {BaseURL}/authorizations/{id}/refund
+type HTTPGateway struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+func NewHTTPGateway(baseURL, apiKey string) *HTTPGateway {
+	return &HTTPGateway{
+		BaseURL: baseURL,
+		APIKey:  apiKey,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type authorizeRequest struct {
+	Amount    int    `json:"amount"`
+	Currency  string `json:"currency"`
+	Reference string `json:"reference"`
+}
+
+type authorizeResponse struct {
+	ID string `json:"id"`
+}
+
+func (g *HTTPGateway) Authorize(ctx context.Context, amount int, currency, reference string) (string, error) {
+	var resp authorizeResponse
+	err := g.post(ctx, "/authorizations", authorizeRequest{
+		Amount:    amount,
+		Currency:  currency,
+		Reference: reference,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.ID == "" {
+		return "", fmt.Errorf("payment gateway returned no authorization id")
+	}
+	return resp.ID, nil
+}
+
+func (g *HTTPGateway) Capture(ctx context.Context, authorizationID string) error {
+	return g.post(ctx, "/authorizations/"+url.PathEscape(authorizationID)+"/capture", nil, nil)
+}
+
+func (g *HTTPGateway) Refund(ctx context.Context, authorizationID string) error {
+	return g.post(ctx, "/authorizations/"+url.PathEscape(authorizationID)+"/refund", nil, nil)
+}
+
+func (g *HTTPGateway) post(ctx context.Context, path string, body, out interface{}) error {
+	var payload io.Reader = http.NoBody
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.BaseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.APIKey)
+	}
+
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("payment gateway: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPaymentRequired:
+		return ErrDeclined
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrUnknownAuthorization
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("payment gateway: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,72 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubProvider serves the gateway API on top of a FakeGateway.
+func stubProvider(t *testing.T, fake *FakeGateway) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /authorizations", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var req authorizeRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		id, err := fake.Authorize(r.Context(), req.Amount, req.Currency, req.Reference)
+		if err != nil {
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(authorizeResponse{ID: id})
+	})
+	mux.HandleFunc("POST /authorizations/{id}/capture", func(w http.ResponseWriter, r *http.Request) {
+		if err := fake.Capture(r.Context(), r.PathValue("id")); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("POST /authorizations/{id}/refund", func(w http.ResponseWriter, r *http.Request) {
+		if err := fake.Refund(r.Context(), r.PathValue("id")); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestHTTPGateway(t *testing.T) {
+	fake := NewFakeGateway()
+	srv := stubProvider(t, fake)
+	defer srv.Close()
+
+	gateway := NewHTTPGateway(srv.URL, "secret")
+	ctx := context.Background()
+
+	id, err := gateway.Authorize(ctx, 1500, "EUR", "booking-1")
+	assert.NoError(t, err)
+	auth, _ := fake.Authorization(id)
+	assert.Equal(t, Authorization{ID: id, Amount: 1500, Currency: "EUR", Reference: "booking-1", Status: StatusAuthorized}, auth)
+
+	assert.NoError(t, gateway.Capture(ctx, id))
+	auth, _ = fake.Authorization(id)
+	assert.Equal(t, StatusCaptured, auth.Status)
+
+	assert.NoError(t, gateway.Refund(ctx, id))
+	auth, _ = fake.Authorization(id)
+	assert.Equal(t, StatusRefunded, auth.Status)
+
+	assert.ErrorIs(t, gateway.Capture(ctx, "auth_missing"), ErrUnknownAuthorization)
+}
+
+func TestHTTPGateway_Declined(t *testing.T) {
+	fake := NewFakeGateway()
+	fake.DeclineAuthorize = true
+	srv := stubProvider(t, fake)
+	defer srv.Close()
+
+	_, err := NewHTTPGateway(srv.URL, "secret").Authorize(context.Background(), 1500, "EUR", "booking-1")
+	assert.ErrorIs(t, err, ErrDeclined)
+}
@@ -0,0 +1,156 @@
+package records
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/xlsx"
+)
+
+// Encoder writes one row per struct of type T. CSV and XLSX files start with
+// a header row, which is written even when there are no rows.
+type Encoder[T any] struct {
+	format  Format
+	columns []column
+	csv     *csv.Writer
+	json    *json.Encoder
+	sheet   *xlsx.Writer
+}
+
+// NewEncoder returns an encoder writing to w in format. XLSX sheets are
+// called sheetName.
+func NewEncoder[T any](w io.Writer, format Format, sheetName string) (*Encoder[T], error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("records: cannot encode %s, expected a struct", t)
+	}
+	e := &Encoder[T]{format: format, columns: columns(t)}
+	names := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		names[i] = c.name
+	}
+
+	var err error
+	switch format {
+	case FormatCSV:
+		e.csv = csv.NewWriter(w)
+		err = e.csv.Write(names)
+	case FormatNDJSON:
+		e.json = json.NewEncoder(w)
+	case FormatXLSX:
+		if e.sheet, err = xlsx.NewWriter(w, sheetName); err == nil {
+			err = e.sheet.WriteHeader(names...)
+		}
+	default:
+		err = fmt.Errorf("records: unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Encode writes v as the next row. Rows may be buffered until Flush or
+// Close.
+func (e *Encoder[T]) Encode(v T) error {
+	if e.json != nil {
+		return e.json.Encode(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	if e.csv != nil {
+		record := make([]string, len(e.columns))
+		for i, c := range e.columns {
+			record[i] = formatField(rv.FieldByIndex(c.index))
+		}
+		return e.csv.Write(record)
+	}
+	cells := make([]any, len(e.columns))
+	for i, c := range e.columns {
+		cells[i] = cellValue(rv.FieldByIndex(c.index))
+	}
+	return e.sheet.WriteRow(cells...)
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (e *Encoder[T]) Flush() error {
+	switch {
+	case e.csv != nil:
+		e.csv.Flush()
+		return e.csv.Error()
+	case e.sheet != nil:
+		return e.sheet.Flush()
+	}
+	return nil
+}
+
+// Close flushes buffered rows and ends the file. It does not close the
+// underlying writer.
+func (e *Encoder[T]) Close() error {
+	if e.sheet != nil {
+		return e.sheet.Close()
+	}
+	return e.Flush()
+}
+
+// formatField formats f as a CSV cell, the way setField parses it.
+func formatField(f reflect.Value) string {
+	if f.Type() == timeType {
+		t := f.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	switch f.Kind() {
+	case reflect.Pointer:
+		if f.IsNil() {
+			return ""
+		}
+		return formatField(f.Elem())
+	case reflect.String:
+		return f.String()
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return strconv.FormatInt(f.Int(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(f.Bool())
+	case reflect.Slice:
+		items := make([]string, f.Len())
+		for i := range items {
+			items[i] = formatField(f.Index(i))
+		}
+		return strings.Join(items, ListSeparator)
+	}
+	return fmt.Sprint(f.Interface())
+}
+
+// cellValue returns f as an XLSX cell; see xlsx.Writer.WriteRow.
+func cellValue(f reflect.Value) any {
+	if f.Type() == timeType {
+		t := f.Interface().(time.Time)
+		if t.IsZero() {
+			return nil
+		}
+		return t
+	}
+
+	switch f.Kind() {
+	case reflect.Pointer:
+		if f.IsNil() {
+			return nil
+		}
+		return cellValue(f.Elem())
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return f.Int()
+	case reflect.Bool:
+		return f.Bool()
+	}
+	return formatField(f)
+}
@@ -0,0 +1,282 @@
+// Package records reads rows of structs from CSV and NDJSON files, as used
+// for bulk imports, and writes them as CSV, NDJSON or XLSX for exports.
+// Columns are named after the json tags of the struct fields they hold.
+package records
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/xlsx"
+)
+
+// Format is a file format rows are read from or written in.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	// FormatXLSX can only be written.
+	FormatXLSX Format = "xlsx"
+)
+
+// Formats lists every format, in order of preference.
+var Formats = []Format{FormatCSV, FormatNDJSON, FormatXLSX}
+
+// ContentType returns the media type of files in the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return xlsx.ContentType
+	}
+	return ""
+}
+
+// Readable reports whether a Decoder can read files in the format.
+func (f Format) Readable() bool {
+	return f == FormatCSV || f == FormatNDJSON
+}
+
+// ParseFormat parses a format name such as "csv".
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatNDJSON, FormatXLSX:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected csv, ndjson or xlsx", s)
+}
+
+// FormatOf returns the format of an uploaded file from its Content-Type,
+// which must name a readable format.
+func FormatOf(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q", contentType)
+	}
+	for _, f := range Formats {
+		if f.Readable() && mediaType == f.ContentType() {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported content type %q, expected text/csv or application/x-ndjson", mediaType)
+}
+
+// ListSeparator separates the items of list fields in a CSV cell.
+const ListSeparator = ";"
+
+// RowError is a row that could not be decoded. Decoding can carry on with
+// the next row.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder reads one struct per data row. Rows are numbered from 1, not
+// counting the CSV header or blank NDJSON lines.
+type Decoder struct {
+	format Format
+	csv    *csv.Reader
+	header []string
+	lines  *bufio.Scanner
+	row    int
+}
+
+// NewDecoder returns a decoder of r in format, which must be readable.
+func NewDecoder(r io.Reader, format Format) *Decoder {
+	d := &Decoder{format: format}
+	switch format {
+	case FormatCSV:
+		d.csv = csv.NewReader(r)
+		d.csv.FieldsPerRecord = -1
+		d.csv.TrimLeadingSpace = true
+	default:
+		d.lines = bufio.NewScanner(r)
+		d.lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	}
+	return d
+}
+
+// Decode reads the next row into v, a pointer to a struct. It returns
+// io.EOF after the last row and a *RowError for a row that is invalid.
+func (d *Decoder) Decode(v any) error {
+	if d.format == FormatCSV {
+		return d.decodeCSV(v)
+	}
+	for d.lines.Scan() {
+		line := bytes.TrimSpace(d.lines.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		d.row++
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(v); err != nil {
+			return &RowError{Row: d.row, Err: err}
+		}
+		return nil
+	}
+	if err := d.lines.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+func (d *Decoder) decodeCSV(v any) error {
+	if d.header == nil {
+		header, err := d.csv.Read()
+		if err == io.EOF {
+			return io.EOF
+		}
+		if err != nil {
+			return fmt.Errorf("reading header: %w", err)
+		}
+		for i := range header {
+			header[i] = strings.TrimSpace(header[i])
+		}
+		d.header = header
+	}
+
+	record, err := d.csv.Read()
+	if err == io.EOF {
+		return io.EOF
+	}
+	d.row++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &RowError{Row: d.row, Err: parseErr.Err}
+	}
+	if err != nil {
+		return err
+	}
+	if len(record) != len(d.header) {
+		return &RowError{Row: d.row, Err: fmt.Errorf("has %d columns, the header has %d", len(record), len(d.header))}
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	rv.SetZero()
+	fields := jsonFields(rv.Type())
+	var errs []error
+	for i, name := range d.header {
+		index, ok := fields[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown column %q", name))
+			continue
+		}
+		if record[i] == "" {
+			continue
+		}
+		if err := setField(rv.FieldByIndex(index), strings.TrimSpace(record[i])); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return &RowError{Row: d.row, Err: errors.Join(errs...)}
+	}
+	return nil
+}
+
+// column is a struct field held in a column.
+type column struct {
+	name  string
+	index []int
+}
+
+// columns lists the exported fields of t in order, named by their json tags.
+func columns(t reflect.Type) []column {
+	var cols []column
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		cols = append(cols, column{name: name, index: f.Index})
+	}
+	return cols
+}
+
+// jsonFields maps the json names of the exported fields of t to their
+// indexes.
+func jsonFields(t reflect.Type) map[string][]int {
+	fields := map[string][]int{}
+	for _, c := range columns(t) {
+		fields[c.name] = c.index
+	}
+	return fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// setField parses s into the field f.
+func setField(f reflect.Value, s string) error {
+	if f.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return errors.New("must be an RFC 3339 date-time")
+		}
+		f.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.Pointer:
+		v := reflect.New(f.Type().Elem())
+		if err := setField(v.Elem(), s); err != nil {
+			return err
+		}
+		f.Set(v)
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		f.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		f.SetBool(b)
+	case reflect.Slice:
+		items := strings.Split(s, ListSeparator)
+		slice := reflect.MakeSlice(f.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setField(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		f.Set(slice)
+	default:
+		return fmt.Errorf("cannot be set from CSV")
+	}
+	return nil
+}
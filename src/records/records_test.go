@@ -0,0 +1,161 @@
+package records
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type row struct {
+	Name     string    `json:"name"`
+	Date     time.Time `json:"date"`
+	Capacity int       `json:"capacity"`
+	Limit    *int      `json:"limit,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Active   bool      `json:"active"`
+	Internal string    `json:"-"`
+}
+
+func decodeAll(t *testing.T, d *Decoder) ([]row, []string) {
+	var rows []row
+	var errs []string
+	for {
+		var r row
+		err := d.Decode(&r)
+		if err == io.EOF {
+			return rows, errs
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			errs = append(errs, err.Error())
+			continue
+		}
+		require.NoError(t, err)
+		rows = append(rows, r)
+	}
+}
+
+func TestDecoder_CSV(t *testing.T) {
+	input := `name, date, capacity, limit, tags, active
+Yoga,2025-05-03T09:00:00Z,10,5,"morning; beginners",true
+Spin,2025-05-04T09:00:00Z,,,,
+Pilates,yesterday,ten,,,
+Boxing,2025-05-04T09:00:00Z
+`
+	rows, errs := decodeAll(t, NewDecoder(strings.NewReader(input), FormatCSV))
+
+	limit := 5
+	assert.Equal(t, []row{
+		{Name: "Yoga", Date: time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC), Capacity: 10, Limit: &limit, Tags: []string{"morning", "beginners"}, Active: true},
+		{Name: "Spin", Date: time.Date(2025, 5, 4, 9, 0, 0, 0, time.UTC)},
+	}, rows)
+	assert.Equal(t, []string{
+		"row 3: date: must be an RFC 3339 date-time\ncapacity: must be a whole number",
+		"row 4: has 2 columns, the header has 6",
+	}, errs)
+}
+
+func TestDecoder_CSV_UnknownColumn(t *testing.T) {
+	d := NewDecoder(strings.NewReader("name,colour\nYoga,red\n"), FormatCSV)
+	var r row
+	assert.EqualError(t, d.Decode(&r), `row 1: unknown column "colour"`)
+}
+
+func TestDecoder_NDJSON(t *testing.T) {
+	input := `{"name":"Yoga","capacity":10}
+
+{"name":"Spin","colour":"red"}
+{"name":
+`
+	rows, errs := decodeAll(t, NewDecoder(strings.NewReader(input), FormatNDJSON))
+
+	assert.Equal(t, []row{{Name: "Yoga", Capacity: 10}}, rows)
+	require.Len(t, errs, 2)
+	assert.Equal(t, `row 2: json: unknown field "colour"`, errs[0])
+	assert.Contains(t, errs[1], "row 3:")
+}
+
+func TestFormatOf(t *testing.T) {
+	f, err := FormatOf("text/csv; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, f)
+
+	f, err = FormatOf("application/x-ndjson")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatNDJSON, f)
+
+	_, err = FormatOf("application/json")
+	assert.EqualError(t, err, `unsupported content type "application/json", expected text/csv or application/x-ndjson`)
+}
+
+func TestEncoder_CSV(t *testing.T) {
+	var b strings.Builder
+	enc, err := NewEncoder[row](&b, FormatCSV, "Rows")
+	require.NoError(t, err)
+	limit := 5
+	require.NoError(t, enc.Encode(row{Name: "Yoga, Beginners", Date: time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC), Capacity: 10, Limit: &limit, Tags: []string{"morning", "beginners"}, Active: true}))
+	require.NoError(t, enc.Encode(row{Name: "Spin"}))
+	require.NoError(t, enc.Close())
+
+	assert.Equal(t, `name,date,capacity,limit,tags,active
+"Yoga, Beginners",2025-05-03T09:00:00Z,10,5,morning;beginners,true
+Spin,,0,,,false
+`, b.String())
+
+	// What is written can be read back
+	rows, errs := decodeAll(t, NewDecoder(strings.NewReader(b.String()), FormatCSV))
+	assert.Empty(t, errs)
+	assert.Equal(t, "Yoga, Beginners", rows[0].Name)
+	assert.Equal(t, &limit, rows[0].Limit)
+}
+
+func TestEncoder_EmptyCSV(t *testing.T) {
+	var b strings.Builder
+	enc, err := NewEncoder[row](&b, FormatCSV, "Rows")
+	require.NoError(t, err)
+	require.NoError(t, enc.Close())
+	assert.Equal(t, "name,date,capacity,limit,tags,active\n", b.String())
+}
+
+func TestEncoder_NDJSON(t *testing.T) {
+	var b strings.Builder
+	enc, err := NewEncoder[row](&b, FormatNDJSON, "Rows")
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(row{Name: "Yoga", Capacity: 10}))
+	require.NoError(t, enc.Close())
+	assert.Equal(t, `{"name":"Yoga","date":"0001-01-01T00:00:00Z","capacity":10,"active":false}`+"\n", b.String())
+}
+
+func TestEncoder_XLSX(t *testing.T) {
+	var b bytes.Buffer
+	enc, err := NewEncoder[row](&b, FormatXLSX, "Rows")
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(row{Name: "Yoga", Tags: []string{"a", "b"}}))
+	require.NoError(t, enc.Close())
+
+	r, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	require.NoError(t, err)
+	f, err := r.Open("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	sheet, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Contains(t, string(sheet), `<c r="E1" t="inlineStr" s="2"><is><t xml:space="preserve">tags</t></is></c>`)
+	assert.Contains(t, string(sheet), `<c r="C2"><v>0</v></c><c r="E2" t="inlineStr"><is><t xml:space="preserve">a;b</t></is></c>`)
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("XLSX")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatXLSX, f)
+	assert.False(t, f.Readable())
+
+	_, err = FormatOf(FormatXLSX.ContentType())
+	assert.Error(t, err)
+}
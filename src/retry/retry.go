@@ -0,0 +1,24 @@
+// Package retry spaces out the attempts at work that fails, such as jobs,
+// event deliveries and webhook calls.
+package retry
+
+import "time"
+
+// Backoff retries failed work with exponential backoff.
+type Backoff struct {
+	// MaxAttempts is how many times the work is tried before giving up.
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry, doubled for each
+	// further attempt.
+	RetryDelay time.Duration
+}
+
+// RetryAt returns when to try again after the given number of attempts, the
+// last of which failed at now. It reports false once the attempts are used
+// up.
+func (b Backoff) RetryAt(now time.Time, attempts int) (time.Time, bool) {
+	if attempts >= b.MaxAttempts {
+		return time.Time{}, false
+	}
+	return now.Add(b.RetryDelay << (attempts - 1)), true
+}
@@ -0,0 +1,28 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_RetryAt(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	b := Backoff{MaxAttempts: 3, RetryDelay: 10 * time.Second}
+
+	tests := []struct {
+		attempts int
+		expected time.Time
+		ok       bool
+	}{
+		{attempts: 1, expected: now.Add(10 * time.Second), ok: true},
+		{attempts: 2, expected: now.Add(20 * time.Second), ok: true},
+		{attempts: 3, ok: false},
+	}
+	for _, tt := range tests {
+		at, ok := b.RetryAt(now, tt.attempts)
+		assert.Equal(t, tt.ok, ok, "attempts %d", tt.attempts)
+		assert.Equal(t, tt.expected, at, "attempts %d", tt.attempts)
+	}
+}
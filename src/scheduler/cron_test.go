@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2025, 5, 20, 12, 34, 56, 0, time.UTC) // a Tuesday
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "@every 90s", expected: from.Add(90 * time.Second)},
+		{spec: "@hourly", expected: time.Date(2025, 5, 20, 13, 0, 0, 0, time.UTC)},
+		{spec: "@daily", expected: time.Date(2025, 5, 21, 0, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", expected: time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", expected: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "* * * * *", expected: time.Date(2025, 5, 20, 12, 35, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expected: time.Date(2025, 5, 20, 12, 45, 0, 0, time.UTC)},
+		{spec: "0 9-17/4 * * *", expected: time.Date(2025, 5, 20, 13, 0, 0, 0, time.UTC)},
+		{spec: "30 6 * * 1,3", expected: time.Date(2025, 5, 21, 6, 30, 0, 0, time.UTC)},
+		{spec: "0 0 1 1 *", expected: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 31 * 0", expected: time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, schedule.Next(from))
+		})
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "@yearly", "@every", "@every -1m", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := ParseSchedule(spec)
+			assert.Error(t, err)
+		})
+	}
+}
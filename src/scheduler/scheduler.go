@@ -0,0 +1,278 @@
+// Package scheduler runs background jobs: recurring ones on cron-like
+// schedules and one-off ones at a given time. Jobs are kept in a
+// JobRepository so that pending work outlives the process, and are run at
+// least once, so handlers must be idempotent.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/retry"
+	"github.com/Vidyuallatha/glofox/src/telemetry"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Handler runs one job. A job may be run more than once, e.g. when the
+// process stops mid-run, so handlers must be safe to repeat.
+type Handler func(ctx context.Context, job entities.Job) error
+
+var ErrNoHandler = errors.New("no handler registered for job kind")
+
+type Scheduler struct {
+	entities.JobRepository
+	Clock clock.Clock
+	// PollInterval is how often due jobs are looked for.
+	PollInterval time.Duration
+	// Lease is how long a job may run before it is considered abandoned and
+	// claimed again.
+	Lease time.Duration
+	// Backoff spaces out the runs of a failing job. Once its attempts are
+	// used up, recurring jobs skip to their next run and one-off jobs are
+	// marked failed.
+	retry.Backoff
+	// BatchSize caps the jobs claimed per poll.
+	BatchSize int
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	stop     context.CancelFunc
+	// cancelRuns interrupts running handlers when Stop runs out of time.
+	cancelRuns context.CancelFunc
+	loopDone   chan struct{}
+	running    sync.WaitGroup
+}
+
+func New(repo entities.JobRepository, clk clock.Clock) *Scheduler {
+	return &Scheduler{
+		JobRepository: repo,
+		Clock:         clk,
+		PollInterval:  time.Second,
+		Lease:         5 * time.Minute,
+		Backoff:       retry.Backoff{MaxAttempts: 5, RetryDelay: 10 * time.Second},
+		BatchSize:     10,
+		handlers:      map[string]Handler{},
+	}
+}
+
+// Handle registers the handler for jobs of kind.
+func (s *Scheduler) Handle(kind string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = h
+}
+
+// Every schedules kind to run on spec. The job is keyed by kind, so calling
+// Every again, e.g. after a restart, keeps the existing job and only updates
+// its schedule.
+func (s *Scheduler) Every(ctx context.Context, kind, spec string) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	next := schedule.Next(s.Clock.Now())
+	job, err := s.AddJob(ctx, &entities.Job{
+		Kind:     kind,
+		Key:      kind,
+		Schedule: spec,
+		RunAt:    next,
+		Status:   entities.JobPending,
+	})
+	if err != nil {
+		return err
+	}
+	if job.Schedule != spec {
+		job.Schedule = spec
+		if job.Status == entities.JobPending {
+			job.RunAt = next
+		}
+		return s.UpdateJob(ctx, job)
+	}
+	return nil
+}
+
+// At schedules a one-off job of kind to run at runAt with payload encoded as
+// JSON. A non-empty key makes scheduling idempotent: while a job with the
+// same key is unfinished, that job is returned instead.
+func (s *Scheduler) At(ctx context.Context, kind, key string, runAt time.Time, payload any) (*entities.Job, error) {
+	var raw json.RawMessage
+	if payload != nil {
+		var err error
+		if raw, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("encoding job payload: %w", err)
+		}
+	}
+	return s.AddJob(ctx, &entities.Job{
+		Kind:    kind,
+		Key:     key,
+		Payload: raw,
+		RunAt:   runAt,
+		Status:  entities.JobPending,
+	})
+}
+
+// After schedules a one-off job to run once delay has passed.
+func (s *Scheduler) After(ctx context.Context, kind, key string, delay time.Duration, payload any) (*entities.Job, error) {
+	return s.At(ctx, kind, key, s.Clock.Now().Add(delay), payload)
+}
+
+// Start polls for due jobs in the background until Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	loopCtx, stop := context.WithCancel(ctx)
+	// Handlers outlive the poll loop until Stop gives up waiting for them
+	runCtx, cancelRuns := context.WithCancel(context.WithoutCancel(ctx))
+	s.stop, s.cancelRuns = stop, cancelRuns
+	s.loopDone = make(chan struct{})
+
+	go func() {
+		defer close(s.loopDone)
+		ticker := s.Clock.NewTicker(s.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-loopCtx.Done():
+				return
+			case <-ticker.C():
+				s.runDue(loopCtx, runCtx, true)
+			}
+		}
+	}()
+}
+
+// Stop stops polling and waits for running jobs to finish. If ctx ends
+// first, the jobs are interrupted and ctx's error is returned; they are
+// claimed again once their lease runs out.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	stop, cancelRuns, loopDone := s.stop, s.cancelRuns, s.loopDone
+	s.stop = nil
+	s.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	stop()
+	<-loopDone
+	finished := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(finished)
+	}()
+	defer cancelRuns()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunDue claims the jobs due now and runs them, returning once they have
+// all finished. It returns how many jobs were run.
+func (s *Scheduler) RunDue(ctx context.Context) int {
+	return s.runDue(ctx, ctx, false)
+}
+
+func (s *Scheduler) runDue(claimCtx, runCtx context.Context, async bool) int {
+	jobs, err := s.ClaimDueJobs(claimCtx, s.Clock.Now(), s.Lease, s.BatchSize)
+	if err != nil {
+		if claimCtx.Err() == nil {
+			utils.Logger(claimCtx).Error("failed to claim jobs", "error", err)
+		}
+		return 0
+	}
+
+	for _, job := range jobs {
+		s.running.Add(1)
+		run := func(job entities.Job) {
+			defer s.running.Done()
+			s.run(runCtx, job)
+		}
+		if async {
+			go run(job)
+		} else {
+			run(job)
+		}
+	}
+	return len(jobs)
+}
+
+func (s *Scheduler) run(ctx context.Context, job entities.Job) {
+	ctx, span := telemetry.StartSpan(ctx, "Scheduler.run",
+		attribute.String("job.kind", job.Kind), attribute.Int("job.id", job.ID), attribute.Int("job.attempt", job.Attempts))
+	logger := utils.Logger(ctx).With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	err := s.call(ctx, job)
+	outcome := "done"
+	now := s.Clock.Now()
+	job.LeaseUntil = nil
+	retryAt, retrying := s.RetryAt(now, job.Attempts)
+	switch {
+	case err == nil:
+		job.LastError = ""
+		s.finish(&job, now)
+	case retrying && !errors.Is(err, ErrNoHandler):
+		outcome = "retry"
+		job.LastError = err.Error()
+		job.Status = entities.JobPending
+		job.RunAt = retryAt
+		logger.Warn("job failed, retrying", "error", err, "retry_at", job.RunAt)
+	default:
+		outcome = "failed"
+		job.LastError = err.Error()
+		s.finish(&job, now)
+		logger.Error("job failed", "error", err)
+	}
+
+	if err := s.UpdateJob(context.WithoutCancel(ctx), &job); err != nil {
+		logger.Error("failed to record job result", "error", err)
+	}
+	telemetry.EndSpan(span, outcome, err)
+}
+
+// finish moves a recurring job on to its next run, and ends a one-off job
+// as done or, when it last failed, failed.
+func (s *Scheduler) finish(job *entities.Job, now time.Time) {
+	if job.Schedule != "" {
+		if schedule, err := ParseSchedule(job.Schedule); err == nil {
+			job.Status = entities.JobPending
+			job.RunAt = schedule.Next(now)
+			job.Attempts = 0
+			return
+		}
+	}
+	job.Status = entities.JobDone
+	if job.LastError != "" {
+		job.Status = entities.JobFailed
+	}
+}
+
+// call runs the job's handler, turning a panic into an error.
+func (s *Scheduler) call(ctx context.Context, job entities.Job) (err error) {
+	s.mu.Lock()
+	handler, ok := s.handlers[job.Kind]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoHandler, job.Kind)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,215 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+
+func newTestScheduler() (*Scheduler, *clock.Fake) {
+	entities.Jobs = nil
+	clk := clock.NewFake(testNow)
+	return New(&entities.JobEntity{}, clk), clk
+}
+
+func TestScheduler_OneOffJob(t *testing.T) {
+	s, clk := newTestScheduler()
+	ctx := context.Background()
+	var got map[string]int
+	s.Handle("remind", func(ctx context.Context, job entities.Job) error {
+		return json.Unmarshal(job.Payload, &got)
+	})
+
+	job, err := s.After(ctx, "remind", "remind-7", time.Hour, map[string]int{"booking_id": 7})
+	assert.NoError(t, err)
+	duplicate, err := s.After(ctx, "remind", "remind-7", 2*time.Hour, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, job.ID, duplicate.ID)
+
+	assert.Equal(t, 0, s.RunDue(ctx))
+	clk.Advance(time.Hour)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	assert.Equal(t, map[string]int{"booking_id": 7}, got)
+
+	stored, _ := s.FindJob(ctx, job.ID)
+	assert.Equal(t, entities.JobDone, stored.Status)
+	assert.Nil(t, stored.LeaseUntil)
+	assert.Equal(t, 0, s.RunDue(ctx))
+}
+
+func TestScheduler_RecurringJob(t *testing.T) {
+	s, clk := newTestScheduler()
+	ctx := context.Background()
+	runs := 0
+	s.Handle("tick", func(ctx context.Context, job entities.Job) error {
+		runs++
+		return nil
+	})
+
+	assert.NoError(t, s.Every(ctx, "tick", "@every 1m"))
+	// Registering again, as on restart, keeps the one job
+	assert.NoError(t, s.Every(ctx, "tick", "@every 1m"))
+	assert.Len(t, entities.Jobs, 1)
+
+	clk.Advance(time.Minute)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	clk.Advance(time.Minute)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	assert.Equal(t, 2, runs)
+
+	stored, _ := s.FindJob(ctx, 1)
+	assert.Equal(t, entities.JobPending, stored.Status)
+	assert.Equal(t, testNow.Add(3*time.Minute), stored.RunAt)
+	assert.Equal(t, 0, stored.Attempts)
+
+	assert.NoError(t, s.Every(ctx, "tick", "@every 5m"))
+	stored, _ = s.FindJob(ctx, 1)
+	assert.Equal(t, "@every 5m", stored.Schedule)
+	assert.Equal(t, testNow.Add(7*time.Minute), stored.RunAt)
+
+	assert.Error(t, s.Every(ctx, "tick", "every minute"))
+}
+
+func TestScheduler_RetriesWithBackoff(t *testing.T) {
+	s, clk := newTestScheduler()
+	s.MaxAttempts = 3
+	ctx := context.Background()
+	s.Handle("flaky", func(ctx context.Context, job entities.Job) error {
+		return errors.New("gateway unavailable")
+	})
+	job, _ := s.At(ctx, "flaky", "", testNow, nil)
+
+	assert.Equal(t, 1, s.RunDue(ctx))
+	stored, _ := s.FindJob(ctx, job.ID)
+	assert.Equal(t, entities.JobPending, stored.Status)
+	assert.Equal(t, testNow.Add(s.RetryDelay), stored.RunAt)
+	assert.Equal(t, "gateway unavailable", stored.LastError)
+
+	clk.Advance(s.RetryDelay)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	stored, _ = s.FindJob(ctx, job.ID)
+	assert.Equal(t, clk.Now().Add(2*s.RetryDelay), stored.RunAt)
+
+	clk.Advance(2 * s.RetryDelay)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	stored, _ = s.FindJob(ctx, job.ID)
+	assert.Equal(t, entities.JobFailed, stored.Status)
+	assert.Equal(t, 3, stored.Attempts)
+
+	clk.Advance(time.Hour)
+	assert.Equal(t, 0, s.RunDue(ctx))
+}
+
+func TestScheduler_RecoversPanics(t *testing.T) {
+	s, _ := newTestScheduler()
+	s.MaxAttempts = 1
+	ctx := context.Background()
+	s.Handle("broken", func(ctx context.Context, job entities.Job) error {
+		panic("nil booking")
+	})
+	job, _ := s.At(ctx, "broken", "", testNow, nil)
+
+	assert.Equal(t, 1, s.RunDue(ctx))
+	stored, _ := s.FindJob(ctx, job.ID)
+	assert.Equal(t, entities.JobFailed, stored.Status)
+	assert.Equal(t, "job panicked: nil booking", stored.LastError)
+}
+
+func TestScheduler_FailsJobsWithoutHandler(t *testing.T) {
+	s, _ := newTestScheduler()
+	ctx := context.Background()
+	job, _ := s.At(ctx, "unknown", "", testNow, nil)
+
+	assert.Equal(t, 1, s.RunDue(ctx))
+	stored, _ := s.FindJob(ctx, job.ID)
+	assert.Equal(t, entities.JobFailed, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+}
+
+func TestScheduler_ReclaimsAbandonedJobs(t *testing.T) {
+	s, clk := newTestScheduler()
+	ctx := context.Background()
+	runs := 0
+	s.Handle("remind", func(ctx context.Context, job entities.Job) error {
+		runs++
+		return nil
+	})
+	job, _ := s.At(ctx, "remind", "", testNow, nil)
+
+	// A previous process claimed the job and stopped before finishing it
+	_, err := s.ClaimDueJobs(ctx, clk.Now(), s.Lease, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, s.RunDue(ctx))
+
+	clk.Advance(s.Lease)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	assert.Equal(t, 1, runs)
+	stored, _ := s.FindJob(ctx, job.ID)
+	assert.Equal(t, entities.JobDone, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+}
+
+func TestScheduler_StartStop(t *testing.T) {
+	s, clk := newTestScheduler()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s.Handle("slow", func(ctx context.Context, job entities.Job) error {
+		close(started)
+		<-release
+		return nil
+	})
+	job, _ := s.At(context.Background(), "slow", "", testNow, nil)
+
+	s.Start(context.Background())
+	clk.BlockUntil(1)
+	clk.Advance(s.PollInterval)
+	<-started
+
+	stopped := make(chan error)
+	go func() { stopped <- s.Stop(context.Background()) }()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned before the running job finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	assert.NoError(t, <-stopped)
+	assert.Equal(t, 0, clk.Waiters())
+
+	stored, _ := s.FindJob(context.Background(), job.ID)
+	assert.Equal(t, entities.JobDone, stored.Status)
+}
+
+func TestScheduler_StopTimesOut(t *testing.T) {
+	s, clk := newTestScheduler()
+	started := make(chan struct{})
+	s.Handle("stuck", func(ctx context.Context, job entities.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job, _ := s.At(context.Background(), "stuck", "", testNow, nil)
+
+	s.Start(context.Background())
+	clk.BlockUntil(1)
+	clk.Advance(s.PollInterval)
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.Canceled)
+
+	// The interrupted job is left to be retried
+	assert.Eventually(t, func() bool {
+		stored, _ := s.FindJob(context.Background(), job.ID)
+		return stored.Status == entities.JobPending
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,329 @@
+// Package search keeps an in-memory inverted index of the timetable, so that
+// members can find classes by the words that describe them and narrow the
+// results down by facets.
+package search
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+)
+
+// Facet is a property classes are grouped and filtered by.
+type Facet string
+
+const (
+	FacetCategory   Facet = "category"
+	FacetRoom       Facet = "room"
+	FacetInstructor Facet = "instructor"
+	// FacetTimeOfDay is morning, afternoon or evening, by when sessions
+	// start.
+	FacetTimeOfDay Facet = "time_of_day"
+	// FacetWeekday holds the lowercase names of the days sessions run on.
+	FacetWeekday Facet = "weekday"
+)
+
+// Facets lists every facet in the order they are documented.
+var Facets = []Facet{FacetCategory, FacetRoom, FacetInstructor, FacetTimeOfDay, FacetWeekday}
+
+// Words matching a class's name count for more than words matching its tags
+// or instructor, which count for more than its description. When sessions
+// run is searchable too, so that "evening yoga" finds evening classes.
+const (
+	nameWeight        = 4
+	tagWeight         = 3
+	instructorWeight  = 3
+	descriptionWeight = 1
+	whenWeight        = 1
+	// prefixFactor scales the weight of a word that only starts with a
+	// query word, so that "begin" finds "beginner" below "begin" itself.
+	prefixFactor = 0.5
+	// minPrefix is the shortest query word that also matches longer words.
+	minPrefix = 2
+)
+
+// Filters narrows a search to classes having the given value of each facet,
+// compared regardless of case.
+type Filters map[Facet]string
+
+// Hit is a class matching a search. Score is higher the better the class
+// matches the words searched for, and zero when none were given.
+type Hit struct {
+	Class entities.Class
+	Score float64
+}
+
+// FacetCount is how many of a search's hits have a value of a facet.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Index is an inverted index of classes, safe for concurrent use. Cancelled
+// classes are left out, as they no longer have sessions to book.
+type Index struct {
+	mu   sync.RWMutex
+	docs map[int]*document
+	// postings maps each word to the classes it describes and its weight
+	// in each.
+	postings map[string]map[int]float64
+	// words are the keys of postings in order, for prefix matching.
+	words []string
+}
+
+// document is what the index knows of one class.
+type document struct {
+	class  entities.Class
+	words  map[string]float64
+	facets map[Facet][]string
+}
+
+func NewIndex() *Index {
+	return &Index{docs: map[int]*document{}, postings: map[string]map[int]float64{}}
+}
+
+// Put adds class to the index or, when it is already there, replaces it.
+// Putting a cancelled class removes it.
+func (idx *Index) Put(class entities.Class) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(class.ID)
+	if class.CancelledAt == nil {
+		idx.add(class)
+	}
+}
+
+// Remove takes class id out of the index.
+func (idx *Index) Remove(id int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+// Replace empties the index and fills it with classes.
+func (idx *Index) Replace(classes []entities.Class) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.docs, idx.postings, idx.words = map[int]*document{}, map[string]map[int]float64{}, nil
+	for _, class := range classes {
+		if class.CancelledAt == nil {
+			idx.add(class)
+		}
+	}
+}
+
+// Len returns how many classes are indexed.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// add indexes class. idx.mu must be held.
+func (idx *Index) add(class entities.Class) {
+	doc := &document{class: class, words: map[string]float64{}, facets: facetsOf(class)}
+	addWords := func(text string, weight float64) {
+		for _, word := range Words(text) {
+			doc.words[word] += weight
+		}
+	}
+	addWords(class.ClassName, nameWeight)
+	for _, tag := range class.Tags {
+		addWords(tag, tagWeight)
+	}
+	addWords(class.Instructor, instructorWeight)
+	addWords(class.Description, descriptionWeight)
+	for _, when := range append(doc.facets[FacetTimeOfDay], doc.facets[FacetWeekday]...) {
+		addWords(when, whenWeight)
+	}
+
+	idx.docs[class.ID] = doc
+	for word, weight := range doc.words {
+		if idx.postings[word] == nil {
+			idx.postings[word] = map[int]float64{}
+			i, _ := slices.BinarySearch(idx.words, word)
+			idx.words = slices.Insert(idx.words, i, word)
+		}
+		idx.postings[word][class.ID] = weight
+	}
+}
+
+// remove drops class id from the index. idx.mu must be held.
+func (idx *Index) remove(id int) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	delete(idx.docs, id)
+	for word := range doc.words {
+		delete(idx.postings[word], id)
+		if len(idx.postings[word]) == 0 {
+			delete(idx.postings, word)
+			if i, ok := slices.BinarySearch(idx.words, word); ok {
+				idx.words = slices.Delete(idx.words, i, i+1)
+			}
+		}
+	}
+}
+
+// Search returns the classes matching every word of text and every filter,
+// best matches first and then by start date. Empty text matches every
+// class.
+func (idx *Index) Search(text string, filters Filters) []Hit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var scores map[int]float64
+	for _, word := range Words(text) {
+		matched := idx.match(word)
+		if scores == nil {
+			scores = matched
+			continue
+		}
+		for id, score := range scores {
+			if extra, ok := matched[id]; ok {
+				scores[id] = score + extra
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	if scores == nil {
+		scores = make(map[int]float64, len(idx.docs))
+		for id := range idx.docs {
+			scores[id] = 0
+		}
+	}
+
+	hits := []Hit{}
+	for id, score := range scores {
+		if doc := idx.docs[id]; doc.matches(filters) {
+			hits = append(hits, Hit{Class: doc.class, Score: score})
+		}
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			a.Class.StartDate.Compare(b.Class.StartDate),
+			cmp.Compare(a.Class.ID, b.Class.ID),
+		)
+	})
+	return hits
+}
+
+// match returns the weight of word in each class it describes, adding the
+// classes described by longer words starting with it. idx.mu must be held.
+func (idx *Index) match(word string) map[int]float64 {
+	scores := map[int]float64{}
+	for id, weight := range idx.postings[word] {
+		scores[id] = weight
+	}
+	if len(word) < minPrefix {
+		return scores
+	}
+	for i, _ := slices.BinarySearch(idx.words, word); i < len(idx.words) && strings.HasPrefix(idx.words[i], word); i++ {
+		if idx.words[i] == word {
+			continue
+		}
+		for id, weight := range idx.postings[idx.words[i]] {
+			scores[id] += weight * prefixFactor
+		}
+	}
+	return scores
+}
+
+func (doc *document) matches(filters Filters) bool {
+	for facet, value := range filters {
+		if !slices.ContainsFunc(doc.facets[facet], func(v string) bool { return strings.EqualFold(v, value) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func facetsOf(class entities.Class) map[Facet][]string {
+	facets := map[Facet][]string{
+		FacetTimeOfDay: {TimeOfDay(class.StartDate)},
+		FacetWeekday:   weekdays(class),
+	}
+	for facet, value := range map[Facet]string{
+		FacetCategory:   class.Category,
+		FacetRoom:       class.Room,
+		FacetInstructor: class.Instructor,
+	} {
+		if value != "" {
+			facets[facet] = []string{value}
+		}
+	}
+	return facets
+}
+
+// CountFacets counts the hits having each value of every facet, most common
+// values first.
+func CountFacets(hits []Hit) map[Facet][]FacetCount {
+	counts := map[Facet]map[string]int{}
+	for _, facet := range Facets {
+		counts[facet] = map[string]int{}
+	}
+	for _, hit := range hits {
+		for facet, values := range facetsOf(hit.Class) {
+			for _, value := range values {
+				counts[facet][value]++
+			}
+		}
+	}
+
+	facets := make(map[Facet][]FacetCount, len(counts))
+	for facet, byValue := range counts {
+		list := []FacetCount{}
+		for value, n := range byValue {
+			list = append(list, FacetCount{Value: value, Count: n})
+		}
+		slices.SortFunc(list, func(a, b FacetCount) int {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+		})
+		facets[facet] = list
+	}
+	return facets
+}
+
+// TimeOfDay returns morning for times before noon, afternoon for times
+// before 5pm and evening for the rest.
+func TimeOfDay(t time.Time) string {
+	switch {
+	case t.Hour() < 12:
+		return "morning"
+	case t.Hour() < 17:
+		return "afternoon"
+	default:
+		return "evening"
+	}
+}
+
+// weekdays returns the days of the week sessions of class run on, which is
+// every day of its date range.
+func weekdays(class entities.Class) []string {
+	loc := class.StartDate.Location()
+	y, m, d := class.StartDate.Date()
+	first := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	y, m, d = class.EndDate.In(loc).Date()
+	last := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	days := []string{strings.ToLower(first.Weekday().String())}
+	for day := first.AddDate(0, 0, 1); !day.After(last) && len(days) < 7; day = day.AddDate(0, 0, 1) {
+		days = append(days, strings.ToLower(day.Weekday().String()))
+	}
+	return days
+}
+
+// Words splits text into the lowercase words it is indexed and searched by.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
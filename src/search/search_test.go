@@ -0,0 +1,105 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+// monday is the first day of the timetable the tests search.
+var monday = time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)
+
+func timetable() []entities.Class {
+	return []entities.Class{
+		{
+			ID: 1, ClassName: "Vinyasa Yoga", StartDate: monday.Add(18 * time.Hour), EndDate: monday.Add(19 * time.Hour),
+			Instructor: "Ann Lee", Category: "yoga", Room: "Studio A", Tags: []string{"beginner", "flow"},
+			Description: "A gentle flow for newcomers.",
+		},
+		{
+			ID: 2, ClassName: "Power Yoga", StartDate: monday.Add(7 * time.Hour), EndDate: monday.AddDate(0, 0, 2).Add(8 * time.Hour),
+			Instructor: "Bob Ray", Category: "yoga", Room: "Studio B", Tags: []string{"advanced"},
+		},
+		{
+			ID: 3, ClassName: "Spin", StartDate: monday.Add(19 * time.Hour), EndDate: monday.Add(20 * time.Hour),
+			Instructor: "Ann Lee", Category: "cycling", Room: "Studio A",
+			Description: "Beginner friendly, bring water.",
+		},
+	}
+}
+
+func ids(hits []Hit) []int {
+	found := []int{}
+	for _, hit := range hits {
+		found = append(found, hit.Class.ID)
+	}
+	return found
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := NewIndex()
+	idx.Replace(timetable())
+
+	tests := []struct {
+		name     string
+		text     string
+		filters  Filters
+		expected []int
+	}{
+		{name: "should list every class in start order without words", expected: []int{2, 1, 3}},
+		{name: "should match every word of the query", text: "yoga beginner evening", expected: []int{1}},
+		{name: "should rank name matches above description matches", text: "beginner", expected: []int{1, 3}},
+		{name: "should match the start of longer words", text: "begin", expected: []int{1, 3}},
+		{name: "should match the instructor", text: "ann", expected: []int{1, 3}},
+		{name: "should match the weekdays sessions run on", text: "wednesday", expected: []int{2}},
+		{name: "should ignore case and punctuation", text: "POWER-yoga!", expected: []int{2}},
+		{name: "should find nothing for an unknown word", text: "yoga boxing", expected: []int{}},
+		{name: "should filter by facets regardless of case", filters: Filters{FacetRoom: "studio a", FacetTimeOfDay: "evening"}, expected: []int{1, 3}},
+		{name: "should combine words and filters", text: "yoga", filters: Filters{FacetWeekday: "tuesday"}, expected: []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ids(idx.Search(tt.text, tt.filters)))
+		})
+	}
+}
+
+func TestIndex_Put(t *testing.T) {
+	idx := NewIndex()
+	for _, class := range timetable() {
+		idx.Put(class)
+	}
+	assert.Equal(t, 3, idx.Len())
+
+	renamed := timetable()[2]
+	renamed.ClassName = "Indoor Cycling"
+	idx.Put(renamed)
+	assert.Empty(t, idx.Search("spin", nil), "words of the old version are dropped")
+	assert.Equal(t, []int{3}, ids(idx.Search("cycling", nil)))
+
+	cancelled := timetable()[0]
+	cancelledAt := monday
+	cancelled.CancelledAt = &cancelledAt
+	idx.Put(cancelled)
+	assert.Equal(t, []int{2}, ids(idx.Search("yoga", nil)), "cancelled classes are removed")
+	assert.Empty(t, idx.Search("vinyasa", nil))
+
+	idx.Remove(2)
+	assert.Equal(t, 1, idx.Len())
+}
+
+func TestCountFacets(t *testing.T) {
+	idx := NewIndex()
+	idx.Replace(timetable())
+
+	facets := CountFacets(idx.Search("", nil))
+
+	assert.Equal(t, []FacetCount{{Value: "yoga", Count: 2}, {Value: "cycling", Count: 1}}, facets[FacetCategory])
+	assert.Equal(t, []FacetCount{{Value: "Ann Lee", Count: 2}, {Value: "Bob Ray", Count: 1}}, facets[FacetInstructor])
+	assert.Equal(t, []FacetCount{{Value: "evening", Count: 2}, {Value: "morning", Count: 1}}, facets[FacetTimeOfDay])
+	assert.Equal(t, []FacetCount{{Value: "monday", Count: 3}, {Value: "tuesday", Count: 1}, {Value: "wednesday", Count: 1}}, facets[FacetWeekday])
+	assert.Equal(t, []FacetCount{}, CountFacets(nil)[FacetRoom])
+}
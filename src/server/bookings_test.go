@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/controllers"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelBooking_Access(t *testing.T) {
+	handler, err := NewHandler(Config{StaffAPIKey: contractStaffKey, Clock: clock.NewFake(contractNow)})
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	defer resetStore()
+
+	const token = "0123456789abcdef0123456789abcdef"
+	const otherToken = "fedcba9876543210fedcba9876543210"
+	tests := []struct {
+		name     string
+		query    string
+		staffKey string
+		expected int
+	}{
+		{name: "should refuse a request without a token", expected: http.StatusForbidden},
+		{name: "should refuse another member's token", query: "?token=" + otherToken, expected: http.StatusForbidden},
+		{name: "should refuse a wrong staff key", staffKey: "guess", expected: http.StatusForbidden},
+		{name: "should let the member cancel with their token", query: "?token=" + token, expected: http.StatusOK},
+		{name: "should let staff cancel with the staff key", staffKey: contractStaffKey, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStore()
+			seedClass()
+			seedMembership()
+			entities.Members = []entities.Member{
+				{Name: "aa", Tier: entities.TierStandard, CalendarToken: token},
+				{Name: "bb", Tier: entities.TierStandard, CalendarToken: otherToken},
+			}
+			entities.Bookings = []entities.Booking{{
+				ID: 1, ClassID: 1, Name: "aa", Date: contractTime,
+				Status: entities.BookingConfirmed, MembershipID: 1,
+			}}
+			req, err := http.NewRequest(http.MethodDelete, srv.URL+"/bookings/1"+tt.query, nil)
+			require.NoError(t, err)
+			if tt.staffKey != "" {
+				req.Header.Set(controllers.StaffKeyHeader, tt.staffKey)
+			}
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.expected, resp.StatusCode)
+			assert.Equal(t, tt.expected == http.StatusOK, entities.Bookings[0].Status == entities.BookingCancelled)
+		})
+	}
+}
@@ -0,0 +1,560 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox"
+	"github.com/Vidyuallatha/glofox/src/backup"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/controllers"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/utils"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contractTime is the value generated for every date-time field, so that
+// fixtures can seed data that valid payloads refer to.
+var contractTime = time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+// contractNow is when the server under test thinks it is: the day before
+// contractTime, so that generated dates are bookable.
+var contractNow = contractTime.AddDate(0, 0, -1)
+
+// contractStaffKey matches the example of the X-Staff-Key parameter, which is
+// sent on every operation that declares it.
+const contractStaffKey = "staff-key"
+
+// staffOnly is the description of the 403 response of every staff-only
+// operation, which is checked to refuse requests without the staff key.
+const staffOnly = "Missing or wrong staff key"
+
+// fixtures seed the state an operation needs for its valid request to
+// succeed and return the path parameters pointing at the seeded records.
+// Every operation in openapi.yaml must have an entry.
+var fixtures = map[string]func() map[string]string{
+	"createClass": noFixture,
+	"listClasses": func() map[string]string {
+		seedClass()
+		return nil
+	},
+	"searchClasses": func() map[string]string {
+		seedClass()
+		if err := controllers.SearchComponent().Rebuild(context.Background()); err != nil {
+			panic(err)
+		}
+		return nil
+	},
+	"cancelClass": func() map[string]string {
+		seedClass()
+		seedMembership()
+		entities.Bookings = []entities.Booking{{
+			ID: 1, ClassID: 1, Name: "aa", Date: contractTime,
+			Status: entities.BookingConfirmed, MembershipID: 1,
+		}}
+		return map[string]string{"id": "1"}
+	},
+	"getTimetableCalendar": func() map[string]string {
+		seedClass()
+		return nil
+	},
+	"streamClassAvailability": func() map[string]string {
+		seedClass()
+		return map[string]string{"id": "1"}
+	},
+	"createBooking": func() map[string]string {
+		seedClass()
+		seedMembership()
+		return nil
+	},
+	"listBookings": func() map[string]string {
+		seedClass()
+		entities.Bookings = []entities.Booking{{
+			ID: 1, ClassID: 1, Name: "jane", Date: contractTime, Status: entities.BookingConfirmed,
+			CheckInToken: "0123456789abcdef0123456789abcdef",
+		}}
+		return nil
+	},
+	"cancelBooking": func() map[string]string {
+		seedClass()
+		seedMembership()
+		entities.Bookings = []entities.Booking{{
+			ID: 1, ClassID: 1, Name: "aa", Date: contractTime,
+			Status: entities.BookingConfirmed, MembershipID: 1,
+		}}
+		entities.Members = []entities.Member{{Name: "aa", Tier: entities.TierStandard, CalendarToken: "0123456789abcdef0123456789abcdef"}}
+		return map[string]string{"id": "1"}
+	},
+	"checkInBooking": func() map[string]string {
+		// Check-in is only open around the session start
+		now := contractNow
+		entities.Classes = []entities.Class{{
+			ID: 1, ClassName: "Yoga", StartDate: now, EndDate: now.Add(time.Hour), Capacity: 10,
+		}}
+		entities.Bookings = []entities.Booking{{
+			ID: 1, ClassID: 1, Name: "aa", Date: now,
+			Status: entities.BookingConfirmed, CheckInToken: "0123456789abcdef0123456789abcdef",
+		}}
+		return map[string]string{"id": "1"}
+	},
+	"setPenaltyPolicy": func() map[string]string {
+		return map[string]string{"studio": entities.DefaultStudio}
+	},
+	"setBookingWindow": func() map[string]string {
+		return map[string]string{"studio": entities.DefaultStudio}
+	},
+	"listPenalties": func() map[string]string {
+		seedSuspension()
+		return nil
+	},
+	"overridePenalty": func() map[string]string {
+		seedSuspension()
+		return map[string]string{"id": "1"}
+	},
+	"takeBackup":      noFixture,
+	"listBackups":     noFixture,
+	"verifyBackups":   noFixture,
+	"registerWebhook": noFixture,
+	"listWebhooks": func() map[string]string {
+		seedWebhookDelivery()
+		return nil
+	},
+	"listWebhookDeliveries": func() map[string]string {
+		seedWebhookDelivery()
+		return map[string]string{"id": "1"}
+	},
+	"redeliverWebhookDelivery": func() map[string]string {
+		seedWebhookDelivery()
+		return map[string]string{"id": "1", "delivery_id": "1"}
+	},
+	"createMembership": noFixture,
+	"createMember":     noFixture,
+	"setNotificationPreferences": func() map[string]string {
+		entities.Members = []entities.Member{{
+			Name: "jane", Tier: entities.TierStandard, Email: "jane@example.com", Phone: "+353861234567",
+			CalendarToken: "0123456789abcdef0123456789abcdef",
+		}}
+		return map[string]string{"name": "jane"}
+	},
+	"rotateCalendarToken": func() map[string]string {
+		entities.Members = []entities.Member{{Name: "jane", Tier: entities.TierStandard}}
+		return map[string]string{"name": "jane"}
+	},
+	"getMemberCalendar": func() map[string]string {
+		seedClass()
+		entities.Members = []entities.Member{{
+			Name: "jane", Tier: entities.TierStandard, CalendarToken: "0123456789abcdef0123456789abcdef",
+		}}
+		entities.Bookings = []entities.Booking{{
+			ID: 1, ClassID: 1, Name: "jane", Date: contractTime, Status: entities.BookingConfirmed,
+		}}
+		return map[string]string{"name": "jane"}
+	},
+	"exportClasses": func() map[string]string {
+		seedClass()
+		return nil
+	},
+	"exportBookings": func() map[string]string {
+		seedClass()
+		entities.Bookings = []entities.Booking{{
+			ID: 1, ClassID: 1, Name: "jane", Date: contractTime, Status: entities.BookingConfirmed,
+		}}
+		return nil
+	},
+	"exportAttendance": func() map[string]string {
+		seedClass()
+		checkedIn := contractTime
+		entities.Bookings = []entities.Booking{{
+			ID: 1, ClassID: 1, Name: "jane", Date: contractTime, Status: entities.BookingAttended,
+			CheckedInAt: &checkedIn, CheckedInBy: "staff",
+		}}
+		return nil
+	},
+	"getUtilisationReport": seedReportBookings,
+	"getPeakTimesReport":   seedReportBookings,
+	"getRetentionReport":   seedReportBookings,
+	"importClasses":        noFixture,
+	"importMembers":        noFixture,
+	"createPromoCode":      noFixture,
+}
+
+func noFixture() map[string]string { return nil }
+
+func seedClass() {
+	entities.Classes = []entities.Class{{
+		ID:        1,
+		ClassName: "Yoga",
+		StartDate: contractTime.Add(-time.Hour),
+		EndDate:   contractTime.Add(time.Hour),
+		Capacity:  10,
+	}}
+}
+
+// seedReportBookings gives the class an attended booking and a no-show.
+func seedReportBookings() map[string]string {
+	seedClass()
+	checkedIn := contractTime
+	entities.Bookings = []entities.Booking{
+		{ID: 1, ClassID: 1, Name: "jane", Date: contractTime, Status: entities.BookingAttended, CheckedInAt: &checkedIn},
+		{ID: 2, ClassID: 1, Name: "john", Date: contractTime, Status: entities.BookingCancelled},
+	}
+	return nil
+}
+
+// seedMembership gives the generated member name an unlimited membership.
+func seedMembership() {
+	end := contractTime.AddDate(0, 1, 0)
+	entities.Memberships = []entities.Membership{{
+		ID:        1,
+		Name:      "aa",
+		Plan:      entities.PlanUnlimitedMonthly,
+		StartDate: contractTime.AddDate(0, -1, 0),
+		EndDate:   &end,
+	}}
+}
+
+func seedSuspension() {
+	expires := contractTime.AddDate(0, 0, 7)
+	entities.Penalties = []entities.Penalty{{
+		ID: 1, Name: "aa", Studio: entities.DefaultStudio, Type: entities.PenaltySuspension,
+		Reason: "3 no-shows in the last 30 days", CreatedAt: contractTime, ExpiresAt: &expires,
+	}}
+}
+
+// webhookReceiverURL is an endpoint that accepts every webhook delivery.
+var webhookReceiverURL string
+
+func seedWebhookDelivery() {
+	entities.Webhooks = []entities.Webhook{{
+		ID: 1, URL: webhookReceiverURL, EventTypes: []entities.EventType{entities.EventBookingCreated},
+		Secret: "contract-secret", CreatedAt: contractTime,
+	}}
+	entities.WebhookDeliveries = []entities.WebhookDelivery{{
+		ID: 1, WebhookID: 1, EventID: 1, EventType: entities.EventBookingCreated,
+		Payload: json.RawMessage(`{"id":1,"type":"booking.created"}`), Status: entities.WebhookDeliveryFailed,
+		Attempts:      []entities.WebhookAttempt{{At: contractTime, StatusCode: 500, Error: "unexpected response status 500"}},
+		NextAttemptAt: contractTime, CreatedAt: contractTime,
+	}}
+}
+
+// resetStore gives every case its own empty repositories.
+func resetStore() {
+	entities.Classes = nil
+	entities.Bookings = nil
+	entities.Memberships = nil
+	entities.Members = nil
+	entities.PromoCodes = nil
+	entities.PenaltyPolicies = nil
+	entities.Penalties = nil
+	entities.BookingWindows = nil
+	entities.Outbox = nil
+	entities.Webhooks = nil
+	entities.WebhookDeliveries = nil
+	entities.Notifications = nil
+}
+
+type contractCase struct {
+	name        string
+	contentType string
+	body        []byte
+	pathParams  map[string]string
+	// withoutStaffKey leaves the X-Staff-Key header out of the request
+	withoutStaffKey bool
+	expectedStatus  int
+}
+
+func TestContract(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData(glofox.OpenAPISpec)
+	require.NoError(t, err)
+	doc.Servers = nil
+	router, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	// The server checks its own responses too, as it does in development
+	handler, err := NewHandler(Config{StaffAPIKey: contractStaffKey, Clock: clock.NewFake(contractNow), ValidateResponses: true})
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+	webhookReceiverURL = receiver.URL
+	backups, err := backup.Open(t.TempDir())
+	require.NoError(t, err)
+	controllers.BackupsComponent().Dir = backups
+	defer func() { controllers.BackupsComponent().Dir = nil }()
+
+	for _, path := range doc.Paths.InMatchingOrder() {
+		for method, op := range doc.Paths.Find(path).Operations() {
+			fixture, ok := fixtures[op.OperationID]
+			if !assert.True(t, ok, "no fixture for operation %q", op.OperationID) {
+				continue
+			}
+
+			for _, tc := range contractCases(op) {
+				t.Run(fmt.Sprintf("%s %s %s", method, path, tc.name), func(t *testing.T) {
+					resetStore()
+					params := map[string]string{}
+					for k, v := range fixture() {
+						params[k] = v
+					}
+					for k, v := range tc.pathParams {
+						params[k] = v
+					}
+
+					req, err := http.NewRequest(method, srv.URL+expandPath(path, params), bytes.NewReader(tc.body))
+					require.NoError(t, err)
+					query := req.URL.Query()
+					for _, param := range op.Parameters {
+						if param.Value.In == openapi3.ParameterInQuery && param.Value.Example != nil {
+							query.Set(param.Value.Name, fmt.Sprint(param.Value.Example))
+						}
+					}
+					req.URL.RawQuery = query.Encode()
+					req.Header.Set("Content-Type", tc.contentType)
+					for _, param := range op.Parameters {
+						if tc.withoutStaffKey && param.Value.Name == controllers.StaffKeyHeader {
+							continue
+						}
+						if param.Value.In == openapi3.ParameterInHeader && param.Value.Example != nil {
+							req.Header.Set(param.Value.Name, fmt.Sprint(param.Value.Example))
+						}
+					}
+
+					resp, err := srv.Client().Do(req)
+					require.NoError(t, err)
+					defer resp.Body.Close()
+					var body []byte
+					if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+						// Streams stay open, so check up to the first event
+						body, err = readFirstEvent(resp.Body)
+					} else {
+						body, err = io.ReadAll(resp.Body)
+					}
+					require.NoError(t, err)
+
+					assert.Equal(t, tc.expectedStatus, resp.StatusCode, string(body))
+					assertResponseMatchesSpec(t, router, req, resp, body)
+					if resp.StatusCode >= 400 && strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+						// Failed calls can be matched to the server logs
+						var failed utils.APIResponse
+						require.NoError(t, json.Unmarshal(body, &failed))
+						assert.NotEmpty(t, failed.RequestID)
+						assert.Equal(t, resp.Header.Get(utils.RequestIDHeader), failed.RequestID)
+					}
+				})
+			}
+		}
+	}
+}
+
+// contractCases returns the cases of requestCases and, for staff-only
+// operations, the valid request sent without the staff key.
+func contractCases(op *openapi3.Operation) []contractCase {
+	cases := requestCases(op)
+	// Staff-only operations refuse the valid request without the staff key
+	if forbidden := op.Responses.Status(http.StatusForbidden); forbidden != nil && *forbidden.Value.Description == staffOnly {
+		withoutKey := cases[0]
+		withoutKey.name, withoutKey.withoutStaffKey, withoutKey.expectedStatus = "without staff key", true, http.StatusForbidden
+		cases = append(cases, withoutKey)
+	}
+	return cases
+}
+
+// requestCases derives the valid request and the invalid ones from the
+// operation's parameters and request body.
+func requestCases(op *openapi3.Operation) []contractCase {
+	cases := []contractCase{{name: "valid", contentType: "application/json", expectedStatus: successStatus(op)}}
+	for _, param := range op.Parameters {
+		// Any path segment is a valid string, so strings have no wrong type
+		if param.Value.In != openapi3.ParameterInPath || param.Value.Schema.Value.Type.Is(openapi3.TypeString) {
+			continue
+		}
+		cases = append(cases, contractCase{
+			name:           "wrong type for path " + param.Value.Name,
+			contentType:    "application/json",
+			pathParams:     map[string]string{param.Value.Name: fmt.Sprint(generateWrongType(param.Value.Schema.Value))},
+			expectedStatus: http.StatusBadRequest,
+		})
+	}
+	if op.RequestBody == nil {
+		return cases
+	}
+
+	content := op.RequestBody.Value.Content
+	if content.Get("application/json") == nil {
+		// Files such as CSV imports are sent as their example, with one
+		// invalid variant: a content type the operation does not take
+		for _, contentType := range sortedKeys(content) {
+			if example, ok := content[contentType].Example.(string); ok {
+				cases[0].contentType = contentType
+				cases[0].body = []byte(example)
+				break
+			}
+		}
+		return append(cases, contractCase{
+			name:           "unsupported content type",
+			contentType:    "application/json",
+			body:           []byte("{}"),
+			expectedStatus: http.StatusBadRequest,
+		})
+	}
+	schema := content.Get("application/json").Schema.Value
+	valid := generateValid(schema).(map[string]interface{})
+	cases[0].body = mustJSON(valid)
+	cases = append(cases, contractCase{name: "malformed json", contentType: "application/json", body: []byte("{"), expectedStatus: http.StatusBadRequest})
+
+	for _, field := range schema.Required {
+		payload := copyPayload(valid)
+		delete(payload, field)
+		cases = append(cases, contractCase{
+			name:           "missing " + field,
+			contentType:    "application/json",
+			body:           mustJSON(payload),
+			expectedStatus: http.StatusBadRequest,
+		})
+	}
+
+	for _, field := range sortedKeys(schema.Properties) {
+		payload := copyPayload(valid)
+		payload[field] = generateWrongType(schema.Properties[field].Value)
+		cases = append(cases, contractCase{
+			name:           "wrong type for " + field,
+			contentType:    "application/json",
+			body:           mustJSON(payload),
+			expectedStatus: http.StatusBadRequest,
+		})
+	}
+	return cases
+}
+
+func generateValid(schema *openapi3.Schema) interface{} {
+	if schema.Example != nil {
+		return schema.Example
+	}
+	switch {
+	case schema.Type.Is(openapi3.TypeObject):
+		obj := map[string]interface{}{}
+		for name, prop := range schema.Properties {
+			if prop.Value.ReadOnly {
+				continue
+			}
+			obj[name] = generateValid(prop.Value)
+		}
+		return obj
+	case schema.Type.Is(openapi3.TypeArray):
+		return []interface{}{generateValid(schema.Items.Value)}
+	case schema.Type.Is(openapi3.TypeInteger), schema.Type.Is(openapi3.TypeNumber):
+		if schema.Min != nil && *schema.Min > 1 {
+			return *schema.Min
+		}
+		return 1
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return true
+	case schema.Format == "date-time":
+		return contractTime.Format(time.RFC3339)
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	default:
+		return strings.Repeat("a", int(schema.MinLength)+1)
+	}
+}
+
+func generateWrongType(schema *openapi3.Schema) interface{} {
+	if schema.Type.Is(openapi3.TypeString) {
+		return 42
+	}
+	return "not-a-" + schema.Type.Slice()[0]
+}
+
+func expandPath(path string, params map[string]string) string {
+	for k, v := range params {
+		path = strings.ReplaceAll(path, "{"+k+"}", v)
+	}
+	return path
+}
+
+func successStatus(op *openapi3.Operation) int {
+	for code := http.StatusOK; code < http.StatusMultipleChoices; code++ {
+		if op.Responses.Status(code) != nil {
+			return code
+		}
+	}
+	return http.StatusOK
+}
+
+func assertResponseMatchesSpec(t *testing.T, router routers.Router, req *http.Request, resp *http.Response, body []byte) {
+	route, pathParams, err := router.FindRoute(req)
+	require.NoError(t, err)
+
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   io.NopCloser(bytes.NewReader(body)),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+		},
+	})
+	assert.NoError(t, err, "response does not match openapi.yaml")
+}
+
+// readFirstEvent reads a Server-Sent Events stream up to the end of its
+// first event carrying data.
+func readFirstEvent(r io.Reader) ([]byte, error) {
+	var event bytes.Buffer
+	lines := bufio.NewReader(r)
+	for {
+		line, err := lines.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		event.WriteString(line)
+		if line == "\n" && strings.Contains(event.String(), "\ndata:") {
+			return event.Bytes(), nil
+		}
+	}
+}
+
+func mustJSON(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func copyPayload(in map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/controllers"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateMember_Tier(t *testing.T) {
+	handler, err := NewHandler(Config{StaffAPIKey: contractStaffKey, Clock: clock.NewFake(contractNow)})
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	defer resetStore()
+
+	tests := []struct {
+		name     string
+		tier     entities.MemberTier
+		staffKey string
+		expected int
+	}{
+		{name: "should let anyone register a standard member", tier: entities.TierStandard, expected: http.StatusCreated},
+		{name: "should refuse a premium member without the staff key", tier: entities.TierPremium, expected: http.StatusForbidden},
+		{name: "should refuse a student member with a wrong staff key", tier: entities.TierStudent, staffKey: "guess", expected: http.StatusForbidden},
+		{name: "should let staff register a premium member", tier: entities.TierPremium, staffKey: contractStaffKey, expected: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStore()
+			req, err := http.NewRequest(http.MethodPost, srv.URL+"/members",
+				strings.NewReader(`{"name":"jane","tier":"`+string(tt.tier)+`"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.staffKey != "" {
+				req.Header.Set(controllers.StaffKeyHeader, tt.staffKey)
+			}
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.expected, resp.StatusCode)
+			if tt.expected == http.StatusForbidden {
+				assert.Empty(t, entities.Members, "the member is not created")
+			}
+		})
+	}
+}
+
+func TestSetNotificationPreferences_Access(t *testing.T) {
+	handler, err := NewHandler(Config{StaffAPIKey: contractStaffKey, Clock: clock.NewFake(contractNow)})
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	defer resetStore()
+
+	const token = "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name     string
+		query    string
+		staffKey string
+		expected int
+	}{
+		{name: "should refuse a request without a token", expected: http.StatusForbidden},
+		{name: "should refuse a wrong token", query: "?token=guess", expected: http.StatusForbidden},
+		{name: "should refuse a wrong staff key", staffKey: "guess", expected: http.StatusForbidden},
+		{name: "should let the member in with their token", query: "?token=" + token, expected: http.StatusOK},
+		{name: "should let staff in with the staff key", staffKey: contractStaffKey, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStore()
+			entities.Members = []entities.Member{{Name: "jane", Email: "jane@example.com", CalendarToken: token}}
+			req, err := http.NewRequest(http.MethodPut, srv.URL+"/members/jane/notification-preferences"+tt.query,
+				strings.NewReader(`{"channels":["email"],"opt_out":true}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.staffKey != "" {
+				req.Header.Set(controllers.StaffKeyHeader, tt.staffKey)
+			}
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.expected, resp.StatusCode)
+			assert.Equal(t, tt.expected == http.StatusOK, entities.Members[0].NotificationsOptOut)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPages(t *testing.T) {
+	handler, err := NewHandler(Config{StaffAPIKey: contractStaffKey, Clock: clock.NewFake(contractNow)})
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	resetStore()
+	defer resetStore()
+	for i, name := range []string{"Spin", "yoga", "Barre", "Pilates", "Boxing"} {
+		start := contractTime.AddDate(0, 0, 7*i)
+		entities.Classes = append(entities.Classes, entities.Class{
+			ID: i + 1, ClassName: name, StartDate: start, EndDate: start.Add(time.Hour), Capacity: 10,
+		})
+	}
+
+	get := func(t *testing.T, target string) (int, []map[string]any, string) {
+		resp, err := srv.Client().Get(srv.URL + target)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body struct {
+			Data []map[string]any `json:"data"`
+			Next string           `json:"next"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body.Data, body.Next
+	}
+
+	t.Run("should follow next links through every page", func(t *testing.T) {
+		var names []any
+		target := "/classes?sort=-name&limit=2&fields=class_name"
+		pages := 0
+		for target != "" {
+			code, data, next := get(t, target)
+			require.Equal(t, http.StatusOK, code)
+			for _, class := range data {
+				assert.Len(t, class, 1, "only the selected fields are returned")
+				names = append(names, class["class_name"])
+			}
+			target, pages = next, pages+1
+		}
+		assert.Equal(t, []any{"yoga", "Spin", "Pilates", "Boxing", "Barre"}, names)
+		assert.Equal(t, 3, pages)
+	})
+
+	t.Run("should leave out the next link on a page that ends the list", func(t *testing.T) {
+		code, data, next := get(t, "/classes?limit=5")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, data, 5)
+		assert.Empty(t, next)
+	})
+
+	t.Run("should refuse a cursor made for another sort", func(t *testing.T) {
+		_, _, next := get(t, "/classes?sort=date&limit=1")
+		require.NotEmpty(t, next)
+		link, err := url.Parse(next)
+		require.NoError(t, err)
+		query := link.Query()
+		query.Set("sort", "name")
+		code, _, _ := get(t, link.Path+"?"+query.Encode())
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	for _, query := range []string{"limit=201", "limit=0", "sort=capacity", "fields=colour", "cursor=nonsense"} {
+		t.Run("should refuse "+query, func(t *testing.T) {
+			code, _, _ := get(t, "/classes?"+query)
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
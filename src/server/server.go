@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Vidyuallatha/glofox"
+	"github.com/Vidyuallatha/glofox/src/clock"
+	"github.com/Vidyuallatha/glofox/src/components"
+	"github.com/Vidyuallatha/glofox/src/controllers"
+	"github.com/Vidyuallatha/glofox/src/entities"
+	"github.com/Vidyuallatha/glofox/src/middleware"
+	"github.com/Vidyuallatha/glofox/src/notify"
+)
+
+// Config controls how the handler stack is assembled.
+type Config struct {
+	// ValidateResponses checks every response against openapi.yaml.
+	ValidateResponses bool
+
+	// PaymentGateway charges drop-in bookings and late cancellation fees.
+	// The in-process fake gateway is used when nil.
+	PaymentGateway components.PaymentGateway
+
+	// StaffAPIKey authorizes staff-only requests, such as checking members
+	// in without a QR token or overriding penalties. Those requests are
+	// refused when it is empty.
+	StaffAPIKey string
+
+	// Clock is what time rules are checked against. The wall clock is used
+	// when nil.
+	Clock clock.Clock
+
+	// Notifiers send member notifications by channel. Channels without one
+	// are written to stdout.
+	Notifiers map[entities.NotificationChannel]notify.Notifier
+}
+
+// NewHandler builds the full HTTP stack: routes wrapped in request ID,
+// tracing, access log and OpenAPI validation middleware.
+func NewHandler(cfg Config) (http.Handler, error) {
+	if cfg.PaymentGateway != nil {
+		controllers.UsePaymentGateway(cfg.PaymentGateway)
+	}
+	controllers.UseStaffAPIKey(cfg.StaffAPIKey)
+	if cfg.Clock != nil {
+		controllers.UseClock(cfg.Clock)
+	}
+	for channel, notifier := range cfg.Notifiers {
+		controllers.UseNotifier(channel, notifier)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte("Welcome to Glofox!"))
+		if err != nil {
+			return
+		}
+	})
+
+	mux.HandleFunc("/classes", controllers.HandleClasses)
+	mux.HandleFunc("/classes/{id}", controllers.HandleClass)
+	mux.HandleFunc("/classes/import", controllers.HandleClassImport)
+	mux.HandleFunc("/classes/search", controllers.HandleClassSearch)
+	mux.HandleFunc("/classes.ics", controllers.HandleTimetableCalendar)
+	mux.HandleFunc("/classes/{id}/availability/stream", controllers.HandleAvailabilityStream)
+	mux.HandleFunc("/bookings", controllers.HandleBookings)
+	mux.HandleFunc("/bookings/{id}", controllers.HandleBooking)
+	mux.HandleFunc("/bookings/{id}/check-in", controllers.HandleCheckIn)
+	mux.HandleFunc("/memberships", controllers.HandleMemberships)
+	mux.HandleFunc("/members", controllers.HandleMembers)
+	mux.HandleFunc("/members/import", controllers.HandleMemberImport)
+	mux.HandleFunc("/members/{name}/notification-preferences", controllers.HandleNotificationPreferences)
+	mux.HandleFunc("/members/{name}/calendar-token", controllers.HandleCalendarToken)
+	mux.HandleFunc("/members/{name}/bookings.ics", controllers.HandleMemberCalendar)
+	mux.HandleFunc("/promo-codes", controllers.HandlePromoCodes)
+	mux.HandleFunc("/penalty-policies/{studio}", controllers.HandlePenaltyPolicy)
+	mux.HandleFunc("/booking-windows/{studio}", controllers.HandleBookingWindow)
+	mux.HandleFunc("/penalties", controllers.HandlePenalties)
+	mux.HandleFunc("/penalties/{id}/override", controllers.HandlePenaltyOverride)
+	mux.HandleFunc("/exports/classes", controllers.HandleClassExport)
+	mux.HandleFunc("/exports/bookings", controllers.HandleBookingExport)
+	mux.HandleFunc("/exports/attendance", controllers.HandleAttendanceExport)
+	mux.HandleFunc("/reports/utilisation", controllers.HandleUtilisationReport)
+	mux.HandleFunc("/reports/peak-times", controllers.HandlePeakTimesReport)
+	mux.HandleFunc("/reports/retention", controllers.HandleRetentionReport)
+	mux.HandleFunc("/backups", controllers.HandleBackups)
+	mux.HandleFunc("/backups/verification", controllers.HandleBackupVerification)
+	mux.HandleFunc("/webhooks", controllers.HandleWebhooks)
+	mux.HandleFunc("/webhooks/{id}/deliveries", controllers.HandleWebhookDeliveries)
+	mux.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/redeliver", controllers.HandleWebhookRedelivery)
+
+	validator, err := middleware.NewOpenAPIValidator(glofox.OpenAPISpec, cfg.ValidateResponses)
+	if err != nil {
+		return nil, err
+	}
+
+	return middleware.RequestID(middleware.Tracing(middleware.AccessLog(validator.Middleware(mux)))), nil
+}
@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewExporter(t *testing.T) {
+	tests := []struct {
+		name        string
+		exporter    string
+		expectNil   bool
+		expectedErr string
+	}{
+		{name: "should disable tracing when no exporter is set", exporter: "", expectNil: true},
+		{name: "should disable tracing for none", exporter: "none", expectNil: true},
+		{name: "should create a stdout exporter", exporter: "stdout"},
+		{name: "should create an otlp exporter", exporter: "otlp"},
+		{name: "should reject unknown exporters", exporter: "zipkin", expectNil: true, expectedErr: `unknown trace exporter "zipkin"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, err := newExporter(context.Background(), tt.exporter, &bytes.Buffer{})
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectNil {
+				assert.Nil(t, exp)
+			} else {
+				assert.NotNil(t, exp)
+			}
+		})
+	}
+}
+
+func TestEndSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	_, span := StartSpan(context.Background(), "Test.Fail")
+	EndSpan(span, "error", errors.New("boom"))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "Test.Fail", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("outcome", "error"))
+}
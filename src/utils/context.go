@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+)
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// RequestIDHeader is the header used to receive and echo request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// Logger returns the default logger annotated with the request ID from ctx.
+func Logger(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if id := RequestIDFromContext(ctx); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	return logger
+}
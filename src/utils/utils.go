@@ -9,6 +9,9 @@ type APIResponse struct {
 	Code   int         `json:"code"`
 	Data   interface{} `json:"data"`
 	Errors []string    `json:"errors"`
+	// Next links to the following page of a list, when there is one.
+	Next      string `json:"next,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func WriteJSON(w http.ResponseWriter, code int, data interface{}, errs []error) {
@@ -25,7 +28,23 @@ func WriteJSON(w http.ResponseWriter, code int, data interface{}, errs []error)
 		Data:   data,
 		Errors: errStrs,
 	}
+	if len(errStrs) > 0 {
+		// Echo the request ID so failed calls can be matched to server logs
+		resp.RequestID = w.Header().Get(RequestIDHeader)
+	}
+
+	encode(w, resp)
+}
+
+// WritePage writes one page of a list, linking to the next page when next is
+// not empty.
+func WritePage(w http.ResponseWriter, data interface{}, next string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	encode(w, APIResponse{Code: http.StatusOK, Data: data, Next: next})
+}
 
+func encode(w http.ResponseWriter, resp APIResponse) {
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		http.Error(w, "failed to encode response", http.StatusInternalServerError)
 	}
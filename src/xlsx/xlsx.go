@@ -0,0 +1,210 @@
+// Package xlsx writes Office Open XML spreadsheets one row at a time, so
+// that large sheets can be streamed without being held in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of .xlsx files.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxSheetName is the longest sheet name spreadsheet apps accept.
+const maxSheetName = 31
+
+// Cell styles, as indexes into cellXfs in styles.xml.
+const (
+	styleDefault = 0
+	styleDate    = 1
+	styleHeader  = 2
+)
+
+// epoch is day zero of spreadsheet date serial numbers.
+var epoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Writer writes a workbook with a single sheet. Rows are written to the
+// underlying writer as they come; Close must be called to finish the file.
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+	err   error
+}
+
+// NewWriter starts a workbook whose only sheet is called sheetName.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	if len(sheetName) > maxSheetName {
+		sheetName = sheetName[:maxSheetName]
+	}
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, escape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/styles.xml", styles},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// The sheet is the last part, so it can stay open while rows arrive
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sw := &Writer{zip: zw, sheet: bufio.NewWriter(f)}
+	sw.write(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return sw, sw.err
+}
+
+// WriteHeader writes a row of bold column names.
+func (w *Writer) WriteHeader(names ...string) error {
+	cells := make([]any, len(names))
+	for i, name := range names {
+		cells[i] = name
+	}
+	return w.writeRow(styleHeader, cells)
+}
+
+// WriteRow writes a row of cells. Cells may be strings, integers, floats,
+// bools or times, which are stored in UTC; nil leaves a cell empty.
+func (w *Writer) WriteRow(cells ...any) error {
+	return w.writeRow(styleDefault, cells)
+}
+
+func (w *Writer) writeRow(style int, cells []any) error {
+	w.row++
+	w.write(fmt.Sprintf(`<row r="%d">`, w.row))
+	for i, cell := range cells {
+		if cell == nil {
+			continue
+		}
+		ref := column(i) + strconv.Itoa(w.row)
+		switch v := cell.(type) {
+		case string:
+			w.write(fmt.Sprintf(`<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr(style), escape(v)))
+		case int:
+			w.write(fmt.Sprintf(`<c r="%s"%s><v>%d</v></c>`, ref, styleAttr(style), v))
+		case int64:
+			w.write(fmt.Sprintf(`<c r="%s"%s><v>%d</v></c>`, ref, styleAttr(style), v))
+		case float64:
+			w.write(fmt.Sprintf(`<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(style), strconv.FormatFloat(v, 'f', -1, 64)))
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			w.write(fmt.Sprintf(`<c r="%s" t="b"%s><v>%d</v></c>`, ref, styleAttr(style), b))
+		case time.Time:
+			days := float64(v.UTC().Sub(epoch)) / float64(24*time.Hour)
+			w.write(fmt.Sprintf(`<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(styleDate), strconv.FormatFloat(days, 'f', -1, 64)))
+		default:
+			return fmt.Errorf("xlsx: unsupported cell type %T", cell)
+		}
+	}
+	w.write(`</row>`)
+	return w.err
+}
+
+// Flush writes the rows buffered so far to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.err == nil {
+		w.err = w.sheet.Flush()
+	}
+	if w.err == nil {
+		w.err = w.zip.Flush()
+	}
+	return w.err
+}
+
+// Close ends the sheet and the file. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	w.write(`</sheetData></worksheet>`)
+	if w.err == nil {
+		w.err = w.sheet.Flush()
+	}
+	if w.err == nil {
+		w.err = w.zip.Close()
+	}
+	return w.err
+}
+
+func (w *Writer) write(s string) {
+	if w.err == nil {
+		_, w.err = w.sheet.WriteString(s)
+	}
+}
+
+func styleAttr(style int) string {
+	if style == styleDefault {
+		return ""
+	}
+	return fmt.Sprintf(` s="%d"`, style)
+}
+
+// column returns the letters naming the zero-based column i: A to Z, then
+// AA and on.
+func column(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	// EscapeText swaps characters XML cannot hold for U+FFFD
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const contentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const workbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// styles defines the cell styles: default, date-time and bold header.
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
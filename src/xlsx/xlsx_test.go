@@ -0,0 +1,57 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readPart(t *testing.T, file []byte, name string) string {
+	r, err := zip.NewReader(bytes.NewReader(file), int64(len(file)))
+	require.NoError(t, err)
+	f, err := r.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestWriter(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(&b, "Bookings & more")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteHeader("name", "date", "total", "refunded"))
+	require.NoError(t, w.WriteRow("Ann <3", time.Date(2025, 5, 3, 18, 0, 0, 0, time.UTC), 1500, true))
+	require.NoError(t, w.WriteRow("Bob", nil, int64(0), false))
+	require.NoError(t, w.Close())
+
+	assert.Contains(t, readPart(t, b.Bytes(), "xl/workbook.xml"), `<sheet name="Bookings &amp; more" sheetId="1" r:id="rId1"/>`)
+	sheet := readPart(t, b.Bytes(), "xl/worksheets/sheet1.xml")
+	assert.NoError(t, xml.Unmarshal([]byte(sheet), new(struct{})), "sheet is not well-formed XML")
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" t="inlineStr" s="2"><is><t xml:space="preserve">name</t></is></c>`)
+	assert.Contains(t, sheet, `<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve">Ann &lt;3</t></is></c>`+
+		`<c r="B2" s="1"><v>45780.75</v></c><c r="C2"><v>1500</v></c><c r="D2" t="b"><v>1</v></c></row>`)
+	assert.Contains(t, sheet, `<row r="3"><c r="A3" t="inlineStr"><is><t xml:space="preserve">Bob</t></is></c>`+
+		`<c r="C3"><v>0</v></c><c r="D3" t="b"><v>0</v></c></row>`)
+}
+
+func TestWriter_UnsupportedCell(t *testing.T) {
+	w, err := NewWriter(io.Discard, "Sheet")
+	require.NoError(t, err)
+	assert.EqualError(t, w.WriteRow(struct{}{}), "xlsx: unsupported cell type struct {}")
+}
+
+func TestColumn(t *testing.T) {
+	assert.Equal(t, "A", column(0))
+	assert.Equal(t, "Z", column(25))
+	assert.Equal(t, "AA", column(26))
+	assert.Equal(t, "AZ", column(51))
+	assert.Equal(t, "BA", column(52))
+}